@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"strconv"
@@ -196,3 +197,93 @@ func TestShutdownViaSignal(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestManifestDescribesTheWorld(t *testing.T) {
+	type MoveMsg struct {
+		X, Y uint32
+	}
+	type MoveResult struct {
+		Moved bool
+	}
+	type HealthRequest struct {
+		ID cardinal.EntityID
+	}
+	type HealthReply struct {
+		HP int
+	}
+	world := testutils.NewTestWorld(t)
+	assert.NilError(t, cardinal.RegisterComponent[Foo](world))
+	moveMsg := cardinal.NewMessageTypeWithEVMSupport[MoveMsg, MoveResult]("move")
+	assert.NilError(t, cardinal.RegisterMessages(world, moveMsg))
+	assert.NilError(t, cardinal.RegisterQuery[HealthRequest, HealthReply](world, "health",
+		func(cardinal.WorldContext, *HealthRequest) (*HealthReply, error) {
+			return &HealthReply{}, nil
+		}))
+	assert.NilError(t, cardinal.RegisterSystems(world, func(cardinal.WorldContext) error {
+		return nil
+	}))
+	assert.NilError(t, world.Instance().LoadGameState())
+
+	bz, err := world.Manifest()
+	assert.NilError(t, err)
+	var manifest cardinal.Manifest
+	assert.NilError(t, json.Unmarshal(bz, &manifest))
+	assert.Equal(t, manifest.Namespace, world.Instance().Namespace().String())
+	assert.Equal(t, manifest.Mode, cardinal.ModeDev)
+
+	var foo *cardinal.ComponentManifest
+	for i := range manifest.Components {
+		if manifest.Components[i].Name == "foo" {
+			foo = &manifest.Components[i]
+		}
+	}
+	assert.Check(t, foo != nil)
+	assert.Check(t, len(foo.Schema) > 0)
+
+	var move *cardinal.MessageManifest
+	for i := range manifest.Messages {
+		if manifest.Messages[i].Name == "move" {
+			move = &manifest.Messages[i]
+		}
+	}
+	assert.Check(t, move != nil)
+	assert.Check(t, move.EVMCompatible)
+	assert.Check(t, move.InputSchema != nil)
+	assert.Check(t, move.OutputSchema != nil)
+
+	assert.Equal(t, 1, len(manifest.Queries))
+	assert.Equal(t, "health", manifest.Queries[0].Name)
+	assert.Check(t, !manifest.Queries[0].EVMCompatible)
+	assert.Check(t, manifest.Queries[0].RequestSchema != nil)
+	assert.Equal(t, len(world.Instance().GetSystemNames()), len(manifest.Systems))
+}
+
+func TestLifecycleWebhookIsToldWhenTheWorldStartsAndShutsDown(t *testing.T) {
+	var mutex sync.Mutex
+	var received []cardinal.LifecycleEvent
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event cardinal.LifecycleEvent
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&event))
+		mutex.Lock()
+		received = append(received, event)
+		mutex.Unlock()
+	}))
+	defer webhook.Close()
+
+	world := testutils.NewTestWorld(t, cardinal.WithLifecycleWebhook(webhook.URL))
+	go func() {
+		assert.NilError(t, world.StartGame())
+	}()
+	for !world.IsGameRunning() {
+		time.Sleep(50 * time.Millisecond)
+	}
+	// ShutDown waits for the pending lifecycle events to be delivered.
+	assert.NilError(t, world.ShutDown())
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, len(received), 2)
+	assert.Equal(t, received[0].Event, cardinal.LifecycleWorldStarted)
+	assert.Equal(t, received[1].Event, cardinal.LifecycleWorldShuttingDown)
+	assert.Assert(t, received[1].Namespace != "")
+}
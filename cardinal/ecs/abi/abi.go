@@ -57,6 +57,18 @@ func getArgumentsForType(rt reflect.Type) ([]abi.ArgumentMarshaling, error) {
 			return arg, nil
 		}
 
+		// types with a registered codec are encoded as the solidity type of their codec.
+		if codec, ok := lookupCodec(field.Type); ok {
+			args = append(args, abi.ArgumentMarshaling{Name: fieldName, Type: codec.solidityType})
+			continue
+		}
+		if kind == reflect.Slice {
+			if codec, ok := lookupCodec(field.Type.Elem()); ok {
+				args = append(args, abi.ArgumentMarshaling{Name: fieldName, Type: codec.solidityType + "[]"})
+				continue
+			}
+		}
+
 		// handle the special case for slice of struct fields.
 		if kind == reflect.Slice {
 			if field.Type.Elem().Kind() == reflect.Struct {
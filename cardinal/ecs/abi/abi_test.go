@@ -1,6 +1,7 @@
 package abi_test
 
 import (
+	"errors"
 	"math/big"
 	"testing"
 
@@ -185,3 +186,91 @@ func TestGenerateABIType_SliceOfStructInStruct(t *testing.T) {
 
 	assert.IsEqual(t, underlyingFoo, foo)
 }
+
+func TestTypeCodecRoundTripsNestedStruct(t *testing.T) {
+	// OrderID is encoded as bytes32, which the default reflection can not produce.
+	type OrderID [32]byte
+	// Position is packed into a single uint64, like a contract that stores both coordinates in one word.
+	type Position struct {
+		X, Y uint32
+	}
+	type Item struct {
+		Pos     Position
+		Related []OrderID
+	}
+	type Order struct {
+		ID    OrderID
+		Item  Item
+		Items []Item
+		Qty   uint64
+	}
+
+	assert.NilError(t, abi.RegisterTypeCodec[OrderID]("bytes32",
+		func(id OrderID) (any, error) { return [32]byte(id), nil },
+		func(v any) (OrderID, error) {
+			bz, ok := v.([32]byte)
+			if !ok {
+				return OrderID{}, errors.New("not a bytes32")
+			}
+			return OrderID(bz), nil
+		},
+	))
+	assert.NilError(t, abi.RegisterTypeCodec[Position]("uint64",
+		func(p Position) (any, error) { return uint64(p.X)<<32 | uint64(p.Y), nil },
+		func(v any) (Position, error) {
+			packed, ok := v.(uint64)
+			if !ok {
+				return Position{}, errors.New("not a uint64")
+			}
+			return Position{X: uint32(packed >> 32), Y: uint32(packed)}, nil
+		},
+	))
+
+	at, err := abi.GenerateABIType(Order{})
+	assert.NilError(t, err)
+	assert.Equal(t, "(bytes32,(uint64,bytes32[]),(uint64,bytes32[])[],uint64)", at.String())
+	args := ethereumAbi.Arguments{{Type: *at}}
+
+	order := Order{
+		ID: OrderID{1, 2, 3},
+		Item: Item{
+			Pos:     Position{X: 7, Y: 9},
+			Related: []OrderID{{4}, {5, 6}},
+		},
+		Items: []Item{{Pos: Position{X: 1<<32 - 1, Y: 2}}},
+		Qty:   12,
+	}
+	in, err := abi.ToABIValue(order)
+	assert.NilError(t, err)
+	bz, err := args.Pack(in)
+	assert.NilError(t, err)
+
+	unpacked, err := args.Unpack(bz)
+	assert.NilError(t, err)
+	assert.Len(t, unpacked, 1)
+
+	got, err := abi.FromABIValue[Order](unpacked[0])
+	assert.NilError(t, err)
+	assert.DeepEqual(t, order, got)
+}
+
+func TestTypeCodecMustEncodeToTheGoTypeOfItsSolidityType(t *testing.T) {
+	type Score struct {
+		Value uint16
+	}
+	type Reply struct {
+		S Score
+	}
+	assert.NilError(t, abi.RegisterTypeCodec[Score]("uint256",
+		func(s Score) (any, error) { return uint64(s.Value), nil },
+		func(v any) (Score, error) { return Score{}, nil },
+	))
+	_, err := abi.ToABIValue(Reply{S: Score{Value: 3}})
+	assert.IsError(t, err)
+
+	assert.IsError(t, abi.RegisterTypeCodec[Score]("uint256", nil, nil))
+	assert.IsError(t, abi.RegisterTypeCodec[Score]("not-a-type",
+		func(s Score) (any, error) { return nil, nil },
+		func(v any) (Score, error) { return Score{}, nil },
+	))
+}
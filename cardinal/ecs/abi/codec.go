@@ -0,0 +1,254 @@
+package abi
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/rotisserie/eris"
+)
+
+// typeCodec converts values of a Go type to and from the value geth packs for solidityType.
+type typeCodec struct {
+	solidityType string
+	// abiGoType is the Go type geth uses for solidityType, e.g. [32]uint8 for bytes32.
+	abiGoType reflect.Type
+	encode    func(reflect.Value) (reflect.Value, error)
+	decode    func(reflect.Value) (reflect.Value, error)
+}
+
+var (
+	codecsMutex sync.RWMutex
+	codecs      = map[reflect.Type]typeCodec{}
+)
+
+// RegisterTypeCodec makes every field of type T be encoded as the given solidity type, for contracts that use an
+// encoding the default reflection does not produce (e.g. a [32]byte ID as bytes32, or a struct packed into bytes).
+// encode converts a T into the Go value geth packs for the solidity type (e.g. [32]byte for bytes32, *big.Int for
+// uint256, []byte for bytes), and decode converts such a value back into a T. To use a codec for a single field,
+// give the field a named type of its own.
+//
+// Codecs are used when the ABI type of a message or query is generated, so they must be registered before the
+// message or query is created with EVM support.
+func RegisterTypeCodec[T any](solidityType string, encode func(T) (any, error), decode func(any) (T, error)) error {
+	if encode == nil || decode == nil {
+		return eris.New("type codec must have both an encode and a decode function")
+	}
+	at, err := abi.NewType(solidityType, "", nil)
+	if err != nil {
+		return eris.Wrapf(err, "invalid solidity type %q", solidityType)
+	}
+	goType := reflect.TypeOf((*T)(nil)).Elem()
+	abiGoType := at.GetType()
+	codec := typeCodec{
+		solidityType: solidityType,
+		abiGoType:    abiGoType,
+		encode: func(v reflect.Value) (reflect.Value, error) {
+			t, _ := v.Interface().(T)
+			encoded, err := encode(t)
+			if err != nil {
+				return reflect.Value{}, eris.Wrapf(err, "failed to encode %s as %s", goType, solidityType)
+			}
+			rv := reflect.ValueOf(encoded)
+			if !rv.IsValid() || rv.Kind() != abiGoType.Kind() || !rv.Type().ConvertibleTo(abiGoType) {
+				return reflect.Value{}, eris.Errorf("codec of %s must encode %s as %s, got %T",
+					goType, solidityType, abiGoType, encoded)
+			}
+			return rv.Convert(abiGoType), nil
+		},
+		decode: func(v reflect.Value) (reflect.Value, error) {
+			decoded, err := decode(v.Interface())
+			if err != nil {
+				return reflect.Value{}, eris.Wrapf(err, "failed to decode %s into %s", solidityType, goType)
+			}
+			return reflect.ValueOf(&decoded).Elem(), nil
+		},
+	}
+
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+	codecs[goType] = codec
+	return nil
+}
+
+func lookupCodec(rt reflect.Type) (typeCodec, bool) {
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+	codec, ok := codecs[rt]
+	return codec, ok
+}
+
+// usesCodec reports if a value of the given type contains a value that is encoded with a registered codec.
+func usesCodec(rt reflect.Type) bool {
+	if _, ok := lookupCodec(rt); ok {
+		return true
+	}
+	switch rt.Kind() {
+	case reflect.Struct:
+		for i := 0; i < rt.NumField(); i++ {
+			if usesCodec(rt.Field(i).Type) {
+				return true
+			}
+		}
+	case reflect.Slice:
+		return usesCodec(rt.Elem())
+	default:
+	}
+	return false
+}
+
+// ToABIValue returns the value to pack for the given struct, whose ABI type was made with GenerateABIType. Structs
+// without fields of a type with a registered codec are returned as is.
+func ToABIValue(v any) (any, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || !usesCodec(rv.Type()) {
+		return v, nil
+	}
+	target, err := abiGoTypeFor(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+	out, err := toABI(rv, target)
+	if err != nil {
+		return nil, err
+	}
+	return out.Interface(), nil
+}
+
+// FromABIValue converts a value unpacked with an ABI type made by GenerateABIType into T. It is identical to
+// SerdeInto for types without fields of a type with a registered codec.
+func FromABIValue[T any](unpacked any) (T, error) {
+	var t T
+	target := reflect.TypeOf(&t).Elem()
+	if !usesCodec(target) {
+		return SerdeInto[T](unpacked)
+	}
+	out, err := fromABI(reflect.ValueOf(unpacked), target)
+	if err != nil {
+		return t, err
+	}
+	reflect.ValueOf(&t).Elem().Set(out)
+	return t, nil
+}
+
+// abiGoTypeFor returns the type geth packs and unpacks for values of the given type. Types with a registered codec
+// are replaced by the Go type of their solidity type.
+func abiGoTypeFor(rt reflect.Type) (reflect.Type, error) {
+	if codec, ok := lookupCodec(rt); ok {
+		return codec.abiGoType, nil
+	}
+	switch rt.Kind() {
+	case reflect.Struct:
+		if !usesCodec(rt) {
+			return rt, nil
+		}
+		fields := make([]reflect.StructField, 0, rt.NumField())
+		for i := 0; i < rt.NumField(); i++ {
+			field := rt.Field(i)
+			if !field.IsExported() {
+				return nil, eris.Errorf("field %s of %s must be exported to be ABI encoded", field.Name, rt)
+			}
+			fieldType, err := abiGoTypeFor(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, reflect.StructField{Name: field.Name, Type: fieldType, Tag: field.Tag})
+		}
+		return reflect.StructOf(fields), nil
+	case reflect.Slice:
+		elem, err := abiGoTypeFor(rt.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(elem), nil
+	default:
+		return rt, nil
+	}
+}
+
+func toABI(v reflect.Value, target reflect.Type) (reflect.Value, error) {
+	if codec, ok := lookupCodec(v.Type()); ok {
+		return codec.encode(v)
+	}
+	if v.Type() == target {
+		return v, nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		out := reflect.New(target).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			field, err := toABI(v.Field(i), target.Field(i).Type)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			out.Field(i).Set(field)
+		}
+		return out, nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(target), nil
+		}
+		out := reflect.MakeSlice(target, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, err := toABI(v.Index(i), target.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			out.Index(i).Set(elem)
+		}
+		return out, nil
+	default:
+		return reflect.Value{}, eris.Errorf("cannot convert %s to %s", v.Type(), target)
+	}
+}
+
+func fromABI(v reflect.Value, target reflect.Type) (reflect.Value, error) {
+	if codec, ok := lookupCodec(target); ok {
+		return codec.decode(v)
+	}
+	if v.Type().AssignableTo(target) {
+		return v, nil
+	}
+	switch target.Kind() {
+	case reflect.Struct:
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, eris.Errorf("cannot convert %s to %s", v.Type(), target)
+		}
+		out := reflect.New(target).Elem()
+		for i := 0; i < target.NumField(); i++ {
+			field := target.Field(i)
+			if !field.IsExported() {
+				return reflect.Value{}, eris.Errorf("field %s of %s must be exported to be ABI decoded", field.Name, target)
+			}
+			// geth names the fields of unpacked tuples after the camel cased argument names.
+			src := v.FieldByName(abi.ToCamelCase(field.Name))
+			if !src.IsValid() {
+				return reflect.Value{}, eris.Errorf("unpacked value has no field for %s.%s", target, field.Name)
+			}
+			value, err := fromABI(src, field.Type)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			out.Field(i).Set(value)
+		}
+		return out, nil
+	case reflect.Slice:
+		if v.Kind() != reflect.Slice {
+			return reflect.Value{}, eris.Errorf("cannot convert %s to %s", v.Type(), target)
+		}
+		out := reflect.MakeSlice(target, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, err := fromABI(v.Index(i), target.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			out.Index(i).Set(elem)
+		}
+		return out, nil
+	default:
+		if v.Type().ConvertibleTo(target) && v.Kind() == target.Kind() {
+			return v.Convert(target), nil
+		}
+		return reflect.Value{}, eris.Errorf("cannot convert %s to %s", v.Type(), target)
+	}
+}
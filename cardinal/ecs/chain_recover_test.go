@@ -3,8 +3,11 @@ package ecs_test
 import (
 	"context"
 	"encoding/binary"
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
+	"time"
 
 	"pkg.world.dev/world-engine/cardinal"
 	"pkg.world.dev/world-engine/cardinal/testutils"
@@ -26,6 +29,8 @@ var _ shard.Adapter = &DummyAdapter{}
 
 type DummyAdapter struct {
 	txs map[uint64][]*types.Transaction
+	// compress gzips the bodies of submitted transactions and marks them Compressed.
+	compress bool
 }
 
 func (d *DummyAdapter) Submit(_ context.Context, p *sign.Transaction, txID, tick uint64) error {
@@ -36,6 +41,13 @@ func (d *DummyAdapter) Submit(_ context.Context, p *sign.Transaction, txID, tick
 		Signature:  p.Signature,
 		Body:       p.Body,
 	}
+	if d.compress {
+		var err error
+		if sp.Body, err = shard.CompressBody(sp.Body); err != nil {
+			return err
+		}
+		sp.Compressed = true
+	}
 	bz, err := proto.Marshal(sp)
 	if err != nil {
 		return err
@@ -157,3 +169,119 @@ func TestWorld_RecoverShouldErrorIfTickExists(t *testing.T) {
 	err := w.RecoverFromChain(ctx)
 	assert.ErrorContains(t, err, "world recovery should not occur in a world with existing state")
 }
+
+func TestOnRecoveryCompleteIsCalledWithTheRecoveryResult(t *testing.T) {
+	ctx := context.Background()
+	adapter := &DummyAdapter{txs: make(map[uint64][]*types.Transaction, 0)}
+	w := testutils.NewTestWorld(t, cardinal.WithAdapter(adapter)).Instance()
+	assert.NilError(t, w.LoadGameState())
+
+	done := make(chan error, 1)
+	w.OnRecoveryComplete(func(err error) {
+		assert.Check(t, !w.IsRecovering())
+		done <- err
+	})
+	go func() {
+		_ = w.RecoverFromChain(ctx)
+	}()
+	select {
+	case err := <-done:
+		assert.NilError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for recovery to complete")
+	}
+
+	// A failed recovery is reported to the callback as well.
+	assert.NilError(t, w.Tick(ctx))
+	assert.Check(t, w.RecoverFromChain(ctx) != nil)
+	assert.ErrorContains(t, <-done, "world recovery should not occur in a world with existing state")
+}
+
+func TestSystemsCanBeSkippedOrOnlyRunDuringRecovery(t *testing.T) {
+	ctx := context.Background()
+	adapter := &DummyAdapter{txs: make(map[uint64][]*types.Transaction, 0)}
+	w := testutils.NewTestWorld(t, cardinal.WithAdapter(adapter)).Instance()
+	sendEnergyTx := ecs.NewMessageType[SendEnergyMsg, SendEnergyResult]("send_energy")
+	assert.NilError(t, w.RegisterMessages(sendEnergyTx))
+
+	liveRuns, recoveryRuns, alwaysRuns := 0, 0, 0
+	w.RegisterSystemWithName(func(ecs.WorldContext) error {
+		liveRuns++
+		return nil
+	}, "live", ecs.WithSkipDuringRecovery())
+	w.RegisterSystemWithName(func(ecs.WorldContext) error {
+		recoveryRuns++
+		return nil
+	}, "recovery", ecs.WithRecoveryOnly())
+	w.RegisterSystemWithName(func(ecs.WorldContext) error {
+		alwaysRuns++
+		return nil
+	}, "always")
+
+	for i := 0; i < 3; i++ {
+		payload := generateRandomTransaction(t, "game1", sendEnergyTx)
+		assert.NilError(t, adapter.Submit(ctx, payload, uint64(sendEnergyTx.ID()), uint64(i)))
+	}
+	assert.NilError(t, w.LoadGameState())
+	assert.NilError(t, w.RecoverFromChain(ctx))
+	recoveredTicks := int(w.CurrentTick())
+	assert.Equal(t, 0, liveRuns)
+	assert.Equal(t, recoveredTicks, recoveryRuns)
+	assert.Equal(t, recoveredTicks, alwaysRuns)
+
+	assert.NilError(t, w.Tick(ctx))
+	assert.Equal(t, 1, liveRuns)
+	assert.Equal(t, recoveredTicks, recoveryRuns)
+	assert.Equal(t, recoveredTicks+1, alwaysRuns)
+}
+
+func TestRecoveryDecompressesCompressedTransactionBodies(t *testing.T) {
+	ctx := context.Background()
+	adapter := &DummyAdapter{txs: make(map[uint64][]*types.Transaction, 0)}
+	w := testutils.NewTestWorld(t, cardinal.WithAdapter(adapter)).Instance()
+	sendEnergyTx := ecs.NewMessageType[SendEnergyMsg, SendEnergyResult]("send_energy")
+	assert.NilError(t, w.RegisterMessages(sendEnergyTx))
+	var recovered []SendEnergyMsg
+	w.RegisterSystem(func(wCtx ecs.WorldContext) error {
+		for _, tx := range sendEnergyTx.In(wCtx) {
+			recovered = append(recovered, tx.Msg)
+		}
+		return nil
+	})
+
+	var want []SendEnergyMsg
+	for i := 0; i < 4; i++ {
+		payload := generateRandomTransaction(t, "game1", sendEnergyTx)
+		msg, err := sendEnergyTx.Decode(payload.Body)
+		assert.NilError(t, err)
+		want = append(want, msg.(SendEnergyMsg))
+		// Half of the transactions were submitted before compression was enabled.
+		adapter.compress = i%2 == 0
+		assert.NilError(t, adapter.Submit(ctx, payload, uint64(sendEnergyTx.ID()), uint64(i)))
+	}
+	assert.NilError(t, w.LoadGameState())
+	assert.NilError(t, w.RecoverFromChain(ctx))
+	assert.DeepEqual(t, want, recovered)
+}
+
+func TestRecoveredTransactionsAreNotLoggedAgain(t *testing.T) {
+	ctx := context.Background()
+	adapter := &DummyAdapter{txs: make(map[uint64][]*types.Transaction, 0)}
+	logPath := filepath.Join(t.TempDir(), "txs.log")
+	w := testutils.NewTestWorld(t, cardinal.WithAdapter(adapter), cardinal.WithTransactionLog(logPath)).Instance()
+	sendEnergyTx := ecs.NewMessageType[SendEnergyMsg, SendEnergyResult]("send_energy")
+	assert.NilError(t, w.RegisterMessages(sendEnergyTx))
+	for i := 0; i < 3; i++ {
+		payload := generateRandomTransaction(t, "game1", sendEnergyTx)
+		assert.NilError(t, adapter.Submit(ctx, payload, uint64(sendEnergyTx.ID()), uint64(i)))
+	}
+
+	assert.NilError(t, w.LoadGameState())
+	assert.NilError(t, w.RecoverFromChain(ctx))
+	w.Shutdown()
+
+	// The recovered transactions were logged when they were first committed, so the log stays empty.
+	bz, err := os.ReadFile(logPath)
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(bz))
+}
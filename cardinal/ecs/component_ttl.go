@@ -0,0 +1,132 @@
+package ecs
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rotisserie/eris"
+	"pkg.world.dev/world-engine/cardinal/ecs/storage"
+	"pkg.world.dev/world-engine/cardinal/events"
+	"pkg.world.dev/world-engine/cardinal/types/component"
+	"pkg.world.dev/world-engine/cardinal/types/entity"
+)
+
+// componentExpiry records the tick in which a component registered with component.WithTTL must be removed from an
+// entity. Expiries are stored as entities, so they are saved and recovered along with the rest of the game state.
+type componentExpiry struct {
+	EntityID  entity.ID
+	Component string
+	ExpiresAt uint64
+}
+
+func (componentExpiry) Name() string {
+	return "ComponentExpiry"
+}
+
+// trackComponentExpiries records an expiry for each of the given entities and each of the given components that was
+// registered with a TTL.
+func trackComponentExpiries(wCtx WorldContext, ids []entity.ID, comps []component.ComponentMetadata) error {
+	for _, c := range comps {
+		ttl := c.TTL()
+		if ttl == 0 {
+			continue
+		}
+		expiries := make([]componentExpiry, 0, len(ids))
+		for _, id := range ids {
+			expiries = append(expiries, componentExpiry{
+				EntityID:  id,
+				Component: c.Name(),
+				ExpiresAt: wCtx.CurrentTick() + ttl,
+			})
+		}
+		if _, err := createManyWith(wCtx, expiries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type entityComponent struct {
+	id   entity.ID
+	name string
+}
+
+// removeExpiredComponents removes every component whose TTL has run out by the current tick and emits an event for
+// each removal. A component that expires while it is the last component on its entity removes the entity. If a
+// component was removed and added again, only the expiry of the most recent addition is honored.
+func (w *World) removeExpiredComponents(wCtx WorldContext) error {
+	search, err := w.newInternalSearch(Exact(componentExpiry{}))
+	if err != nil {
+		return err
+	}
+	latest := map[entityComponent]uint64{}
+	due := map[entityComponent]bool{}
+	var dueRecords []entity.ID
+	var eachErr error
+	err = search.Each(wCtx, func(id entity.ID) bool {
+		expiry, err := GetComponent[componentExpiry](wCtx, id)
+		if err != nil {
+			eachErr = err
+			return false
+		}
+		key := entityComponent{id: expiry.EntityID, name: expiry.Component}
+		latest[key] = max(latest[key], expiry.ExpiresAt)
+		if expiry.ExpiresAt <= w.CurrentTick() {
+			due[key] = true
+			dueRecords = append(dueRecords, id)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if eachErr != nil {
+		return eachErr
+	}
+	for _, id := range dueRecords {
+		if err = w.Remove(id); err != nil {
+			return err
+		}
+	}
+
+	expired := make([]entityComponent, 0, len(due))
+	for key := range due {
+		if latest[key] <= w.CurrentTick() {
+			expired = append(expired, key)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		if expired[i].id != expired[j].id {
+			return expired[i].id < expired[j].id
+		}
+		return expired[i].name < expired[j].name
+	})
+	for _, key := range expired {
+		if err = w.removeExpiredComponent(key.id, key.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *World) removeExpiredComponent(id entity.ID, name string) error {
+	c, err := w.GetComponentByName(name)
+	if err != nil {
+		return err
+	}
+	err = w.StoreManager().RemoveComponentFromEntity(c, id)
+	switch {
+	case eris.Is(eris.Cause(err), storage.ErrEntityMustHaveAtLeastOneComponent):
+		if err = w.Remove(id); err != nil {
+			return err
+		}
+	case eris.Is(eris.Cause(err), storage.ErrComponentNotOnEntity), eris.Is(eris.Cause(err), redis.Nil):
+		// The component or the whole entity was already removed by the game.
+		return nil
+	case err != nil:
+		return err
+	}
+	w.EmitEvent(&events.Event{Message: fmt.Sprintf("component %s expired on entity %d", name, id)})
+	return nil
+}
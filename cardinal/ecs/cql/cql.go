@@ -231,22 +231,66 @@ func termToComponentFilter(
 func Parse(
 	cqlText string, stringToComponent func(string) (component.ComponentMetadata, error),
 ) (filter.ComponentFilter, error) {
-	term, err := internalCQLParser.ParseString("", cqlText)
+	compiled, err := Compile(cqlText)
 	if err != nil {
-		return nil, eris.Wrap(err, "")
+		return nil, err
 	}
-	resultFilter, err := termToComponentFilter(term, stringToComponent)
+	return compiled.ToComponentFilter(stringToComponent)
+}
+
+// CompiledQuery is a parsed CQL string. Compile a CQL string that is run often once and reuse the CompiledQuery to
+// avoid parsing it again every time it is run.
+type CompiledQuery struct {
+	text string
+	term *cqlTerm
+}
+
+// Compile parses the given CQL string. Component names are not resolved until ToComponentFilter is called, so a
+// CQL string can be compiled before its components are registered.
+func Compile(cqlText string) (CompiledQuery, error) {
+	term, err := internalCQLParser.ParseString("", cqlText)
 	if err != nil {
-		return nil, err
+		return CompiledQuery{}, eris.Wrap(err, "")
+	}
+	return CompiledQuery{text: cqlText, term: term}, nil
+}
+
+// String returns the CQL string the query was compiled from.
+func (q CompiledQuery) String() string {
+	return q.text
+}
+
+// ToComponentFilter converts the compiled query to a filter that can be used to search for entities.
+func (q CompiledQuery) ToComponentFilter(stringToComponent func(string) (component.ComponentMetadata, error)) (
+	filter.ComponentFilter, error,
+) {
+	if q.term == nil {
+		return nil, eris.New("CQL query has not been compiled")
 	}
-	return resultFilter, nil
+	return termToComponentFilter(q.term, stringToComponent)
 }
 
 type QueryRequest struct {
-	CQL string
+	CQL string `json:"CQL,omitempty"`
+	// Name optionally runs a CQL query that was registered by the game under this name instead of CQL.
+	Name string `json:"name,omitempty"`
+	// Include optionally lists the names of the components whose data should be returned for each matched entity.
+	// If it is empty, the data of all the entity's components is returned in QueryResponse.Data.
+	Include []string `json:"include,omitempty"`
+	// Limit and Offset optionally page through the matched entities. A Limit of 0 returns all remaining entities.
+	Limit  int `json:"limit,omitempty"`
+	Offset int `json:"offset,omitempty"`
+	// IncludeArchetype adds the names of all the components each matched entity has to QueryResponse.Archetype.
+	IncludeArchetype bool `json:"includeArchetype,omitempty"`
 }
 
 type QueryResponse struct {
 	ID   entity.ID         `json:"id"`
 	Data []json.RawMessage `json:"data"`
+	// Components maps component names to component data. It is only set when QueryRequest.Include is used, in which
+	// case it holds the included components that the entity has, and Data is empty.
+	Components map[string]json.RawMessage `json:"components,omitempty"`
+	// Archetype lists the names of all the components the entity has. It is only set when
+	// QueryRequest.IncludeArchetype is used.
+	Archetype []string `json:"archetype,omitempty"`
 }
@@ -112,3 +112,31 @@ func TestParser(t *testing.T) {
 		)
 	assert.Assert(t, reflect.DeepEqual(testResult2, result))
 }
+
+func TestCompiledQueryCanBeReused(t *testing.T) {
+	_, err := Compile("CONTAINS(a) &")
+	assert.Check(t, err != nil)
+
+	query := "EXACT(a) | CONTAINS(b)"
+	compiled, err := Compile(query)
+	assert.NilError(t, err)
+	assert.Equal(t, query, compiled.String())
+
+	emptyComponent, err := component.NewComponentMetadata[EmptyComponent]()
+	assert.NilError(t, err)
+	lookups := 0
+	stringToComponent := func(_ string) (component.ComponentMetadata, error) {
+		lookups++
+		return emptyComponent, nil
+	}
+	want := filter.Or(filter.Exact(emptyComponent), filter.Contains(emptyComponent))
+	for i := 0; i < 2; i++ {
+		got, err := compiled.ToComponentFilter(stringToComponent)
+		assert.NilError(t, err)
+		assert.Assert(t, reflect.DeepEqual(want, got))
+	}
+	assert.Equal(t, 4, lookups)
+
+	_, err = CompiledQuery{}.ToComponentFilter(stringToComponent)
+	assert.Check(t, err != nil)
+}
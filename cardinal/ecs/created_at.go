@@ -0,0 +1,71 @@
+package ecs
+
+import (
+	"errors"
+
+	"github.com/rotisserie/eris"
+	"pkg.world.dev/world-engine/cardinal/types/component"
+	"pkg.world.dev/world-engine/cardinal/types/entity"
+)
+
+var ErrCreationTicksNotRecorded = errors.New("the world does not record the creation tick of entities")
+
+// CreatedAt holds the tick in which an entity was created. Worlds created with WithEntityCreationTicks add it to every
+// entity created with Create, CreateMany or CreateManyWith.
+type CreatedAt struct {
+	Tick uint64 `json:"tick"`
+}
+
+func (CreatedAt) Name() string {
+	return "CreatedAt"
+}
+
+// withCreatedAt adds CreatedAt to the given components if the world records creation ticks and the components do not
+// already contain it.
+func withCreatedAt(wCtx WorldContext, components []component.Component) []component.Component {
+	if !wCtx.GetWorld().recordCreationTicks {
+		return components
+	}
+	for _, comp := range components {
+		if _, ok := comp.(CreatedAt); ok {
+			return components
+		}
+	}
+	stamped := make([]component.Component, 0, len(components)+1)
+	stamped = append(stamped, components...)
+	return append(stamped, CreatedAt{Tick: wCtx.CurrentTick()})
+}
+
+// EntitiesCreatedBetween returns the entities that were created from tick start up to, but not including, tick end.
+// ErrCreationTicksNotRecorded is returned unless the world was created with WithEntityCreationTicks.
+func (w *World) EntitiesCreatedBetween(start, end uint64) ([]entity.ID, error) {
+	return EntitiesCreatedBetweenInContext(NewReadOnlyWorldContext(w), start, end)
+}
+
+// EntitiesCreatedBetweenInContext is identical to World.EntitiesCreatedBetween, but reads from the given WorldContext.
+func EntitiesCreatedBetweenInContext(wCtx WorldContext, start, end uint64) ([]entity.ID, error) {
+	if !wCtx.GetWorld().recordCreationTicks {
+		return nil, eris.Wrap(ErrCreationTicksNotRecorded, "")
+	}
+	search, err := wCtx.NewSearch(Contains(CreatedAt{}))
+	if err != nil {
+		return nil, err
+	}
+	created := []entity.ID{}
+	var eachErr error
+	err = search.Each(wCtx, func(id entity.ID) bool {
+		createdAt, err := GetComponent[CreatedAt](wCtx, id)
+		if err != nil {
+			eachErr = err
+			return false
+		}
+		if createdAt.Tick >= start && createdAt.Tick < end {
+			created = append(created, id)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, eachErr
+}
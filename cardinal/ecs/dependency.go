@@ -0,0 +1,38 @@
+package ecs
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/rotisserie/eris"
+)
+
+var ErrDependencyNotFound = errors.New("dependency not found")
+
+// WithDependency makes the given instance (e.g. the client of a payment service) available to systems and queries via
+// GetDependency. Dependencies are looked up by the type T, so T can be an interface to allow injecting fakes in tests.
+// Giving a second dependency of the same type replaces the first one.
+func WithDependency[T any](instance T) Option {
+	return func(w *World) {
+		if w.dependencies == nil {
+			w.dependencies = map[reflect.Type]any{}
+		}
+		w.dependencies[dependencyType[T]()] = instance
+	}
+}
+
+// GetDependency returns the dependency of type T that was given to the world with WithDependency.
+// ErrDependencyNotFound is returned if there is none.
+func GetDependency[T any](wCtx WorldContext) (T, error) {
+	t := dependencyType[T]()
+	instance, ok := wCtx.GetWorld().dependencies[t].(T)
+	if !ok {
+		var zero T
+		return zero, eris.Wrapf(ErrDependencyNotFound, "no dependency of type %s", t)
+	}
+	return instance, nil
+}
+
+func dependencyType[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
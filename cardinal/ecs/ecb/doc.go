@@ -65,6 +65,22 @@ value:  JSON serialized bytes that can be deserialized to a list of transactions
 processed in the last started tick. This data is only relevant when the START-TICK number does not match the END-TICK
 number.
 
+# Component shards
+
+The values of a component can be stored in a dedicated redis instance with WithComponentShard. The component value keys
+are identical, but live in the shard instead of the main redis instance. Since redis cannot run a single multi/exec
+transaction across instances, the shard changes of a commit are first staged on each shard, then the main redis
+instance is committed, and finally the staged changes are applied to each shard.
+
+key:	"ECB:SHARD-COMMIT-ID" (main redis instance)
+value:	An integer that represents the ID of the last commit that included changes to sharded components. It is updated
+in the same atomic transaction as all other state changes of the commit.
+
+key:	"ECB:SHARD-COMMIT" (shard redis instance)
+value:	JSON serialized bytes of component changes that have been staged on the shard, along with the ID of their commit.
+If the ID is not larger than ECB:SHARD-COMMIT-ID, the main redis instance was committed and the changes are applied to
+the shard. Otherwise, the changes are discarded. This happens when components are registered and before every commit.
+
 # In-memory storage model
 
 The in-memory data model roughly matches the model that is stored in redis, but there are some differences:
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/rotisserie/eris"
 	"pkg.world.dev/world-engine/cardinal/ecs/codec"
@@ -31,10 +32,18 @@ type Manager struct {
 
 	activeEntities map[archetype.ID]activeEntities
 
-	// Fields that track the next valid entity ID that can be assigned
+	// Fields that track the next valid entity ID that can be assigned. nextEntityIDSaved and pendingEntityIDs count
+	// allocated IDs; the IDs themselves are spread out by entityIDStart and entityIDStride. See WithEntityIDRange.
 	nextEntityIDSaved uint64
 	pendingEntityIDs  uint64
 	isEntityIDLoaded  bool
+	entityIDStart     uint64
+	entityIDStride    uint64
+
+	// Fields that track the number of live entities
+	entityCountSaved    int
+	pendingEntityCount  int
+	isEntityCountLoaded bool
 
 	// Archetype ID management.
 	entityIDToArchID       map[entity.ID]archetype.ID
@@ -43,17 +52,31 @@ type Manager struct {
 	archIDToComps  map[archetype.ID][]component.ComponentMetadata
 	pendingArchIDs []archetype.ID
 
+	// Component shards. shardClients cannot be set until RegisterComponents is called.
+	shardClientsByName  map[string]*redis.Client
+	shardClients        map[component.TypeID]*redis.Client
+	shardsNeedResolving bool
+
+	// readCache is nil unless WithReadCache is used.
+	readCache *componentReadCache
+
+	// redisTTLsRefreshedAt is when the keys of each component type with a redis TTL were last handed to the refresher.
+	// redisTTLRefreshes is nil unless a registered component has a redis TTL. See scheduleRedisTTLRefreshes.
+	redisTTLsRefreshedAt map[component.TypeID]time.Time
+	redisTTLRefreshes    chan []redisTTLRefresh
+
 	logger *ecslog.Logger
 }
 
 var (
 	ErrArchetypeNotFound    = errors.New("archetype for components not found")
+	ErrInvalidEntityIDRange = errors.New("entity ID stride must be greater than zero and the start below the stride")
 	doesNotExistArchetypeID = archetype.ID(-1)
 )
 
 // NewManager creates a new command buffer manager that is able to queue up a series of states changes and
 // atomically commit them to the underlying redis storage layer.
-func NewManager(client *redis.Client) (*Manager, error) {
+func NewManager(client *redis.Client, opts ...Option) (*Manager, error) {
 	m := &Manager{
 		client:             client,
 		compValues:         map[compKey]any{},
@@ -62,16 +85,28 @@ func NewManager(client *redis.Client) (*Manager, error) {
 		activeEntities: map[archetype.ID]activeEntities{},
 		archIDToComps:  map[archetype.ID][]component.ComponentMetadata{},
 
+		redisTTLsRefreshedAt: map[component.TypeID]time.Time{},
+
 		entityIDToArchID:       map[entity.ID]archetype.ID{},
 		entityIDToOriginArchID: map[entity.ID]archetype.ID{},
 
 		// This field cannot be set until RegisterComponents is called
 		typeToComponent: nil,
 
+		shardClientsByName: map[string]*redis.Client{},
+
+		entityIDStride: 1,
+
 		logger: &ecslog.Logger{
 			&log.Logger,
 		},
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.entityIDStride == 0 || m.entityIDStart >= m.entityIDStride {
+		return nil, eris.Wrapf(ErrInvalidEntityIDRange, "start %d, stride %d", m.entityIDStart, m.entityIDStride)
+	}
 
 	return m, nil
 }
@@ -81,6 +116,10 @@ func (m *Manager) RegisterComponents(comps []component.ComponentMetadata) error
 	for _, comp := range comps {
 		m.typeToComponent[comp.ID()] = comp
 	}
+	if err := m.registerShards(); err != nil {
+		return err
+	}
+	m.startRedisTTLRefresher()
 
 	return m.loadArchIDs()
 }
@@ -89,24 +128,28 @@ func (m *Manager) RegisterComponents(comps []component.ComponentMetadata) error
 // to the underlying DB.
 func (m *Manager) CommitPending() error {
 	ctx := context.Background()
-	pipe, err := m.makePipeOfRedisCommands(ctx)
+	pipe, shardChanges, err := m.makePipeOfRedisCommands(ctx)
 	if err != nil {
 		return err
 	}
-	_, err = pipe.Exec(ctx)
+	err = m.execPipe(ctx, pipe, shardChanges)
+	m.invalidateReadCache()
 	if err != nil {
-		return eris.Wrap(err, "")
+		return err
 	}
 
 	m.pendingArchIDs = nil
+	m.scheduleRedisTTLRefreshes()
 
 	// All changes were just successfully committed to redis, so stop tracking them locally
 	m.DiscardPending()
 	return nil
 }
 
-// DiscardPending discards any pending state changes.
+// DiscardPending discards any pending state changes. Values in the read cache are dropped as well, since the state in
+// redis may have changed since they were read (see World.RefreshReadReplica).
 func (m *Manager) DiscardPending() {
+	m.invalidateReadCache()
 	clear(m.compValues)
 
 	// Any entity archetypes movements need to be undone
@@ -119,6 +162,9 @@ func (m *Manager) DiscardPending() {
 	m.isEntityIDLoaded = false
 	m.pendingEntityIDs = 0
 
+	m.isEntityCountLoaded = false
+	m.pendingEntityCount = 0
+
 	for _, archID := range m.pendingArchIDs {
 		delete(m.archIDToComps, archID)
 	}
@@ -141,6 +187,7 @@ func (m *Manager) RemoveEntity(idToRemove entity.ID) error {
 	}
 
 	m.setActiveEntities(archID, active)
+	m.pendingEntityCount--
 	if _, ok := m.entityIDToOriginArchID[idToRemove]; !ok {
 		m.entityIDToOriginArchID[idToRemove] = archID
 	}
@@ -190,6 +237,7 @@ func (m *Manager) CreateManyEntities(num int, comps ...component.ComponentMetada
 		m.logger.LogEntity(zerolog.DebugLevel, currID, archID, comps)
 	}
 	m.setActiveEntities(archID, active)
+	m.pendingEntityCount += num
 	return ids, nil
 }
 
@@ -227,8 +275,13 @@ func (m *Manager) GetComponentForEntity(cType component.ComponentMetadata, id en
 	// Fetch the value from redis
 	redisKey := redisComponentKey(cType.ID(), id)
 	ctx := context.Background()
+	if m.shardsNeedResolving {
+		if err = m.resolveShardCommits(ctx); err != nil {
+			return nil, err
+		}
+	}
 
-	bz, err := m.client.Get(ctx, redisKey).Bytes()
+	bz, err := m.clientForComponent(cType.ID()).Get(ctx, redisKey).Bytes()
 	if err != nil {
 		if !errors.Is(err, redis.Nil) {
 			return nil, err
@@ -381,6 +434,28 @@ func (m *Manager) ArchetypeCount() int {
 	return len(m.archIDToComps)
 }
 
+// EntityCount returns the number of live entities, including any pending creations and removals.
+func (m *Manager) EntityCount() (int, error) {
+	if err := m.loadEntityCount(); err != nil {
+		return 0, err
+	}
+	return m.entityCountSaved + m.pendingEntityCount, nil
+}
+
+// loadEntityCount loads the number of live entities that have been committed to storage.
+func (m *Manager) loadEntityCount() error {
+	if m.isEntityCountLoaded {
+		return nil
+	}
+	count, err := getEntityCountFromRedis(m.client, len(m.archIDToComps))
+	if err != nil {
+		return err
+	}
+	m.entityCountSaved = count
+	m.isEntityCountLoaded = true
+	return nil
+}
+
 // InjectLogger sets the logger for the manager.
 func (m *Manager) InjectLogger(logger *ecslog.Logger) {
 	m.logger = logger
@@ -388,13 +463,19 @@ func (m *Manager) InjectLogger(logger *ecslog.Logger) {
 
 // Close closes the manager.
 func (m *Manager) Close() error {
-	err := eris.Wrap(m.client.Close(), "")
-	if eris.Is(eris.Cause(err), redis.ErrClosed) {
-		// if redis is already closed that means another shutdown pathway got to it first.
-		// There are multiple modules that will try to shutdown redis, if it is already shutdown it is not an error.
-		return nil
+	m.stopRedisTTLRefresher()
+	clients := append([]*redis.Client{m.client}, m.shards()...)
+	var errs []error
+	for _, client := range clients {
+		err := eris.Wrap(client.Close(), "")
+		if eris.Is(eris.Cause(err), redis.ErrClosed) {
+			// if redis is already closed that means another shutdown pathway got to it first.
+			// There are multiple modules that will try to shutdown redis, if it is already shutdown it is not an error.
+			continue
+		}
+		errs = append(errs, err)
 	}
-	return err
+	return errors.Join(errs...)
 }
 
 // getArchetypeForEntity returns the archetype ID for the given entity ID.
@@ -432,7 +513,7 @@ func (m *Manager) nextEntityID() (entity.ID, error) {
 		m.isEntityIDLoaded = true
 	}
 
-	id := m.nextEntityIDSaved + m.pendingEntityIDs
+	id := m.entityIDStart + (m.nextEntityIDSaved+m.pendingEntityIDs)*m.entityIDStride
 	m.pendingEntityIDs++
 	return entity.ID(id), nil
 }
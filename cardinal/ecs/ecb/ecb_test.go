@@ -156,6 +156,50 @@ func TestDiscardedEntityIDsWillBeAssignedAgain(t *testing.T) {
 	assert.NilError(t, manager.CommitPending())
 }
 
+func TestEntityIDRangeAllocatesDisjointIDs(t *testing.T) {
+	newManager := func(client *redis.Client, start uint64) *ecb.Manager {
+		manager, err := ecb.NewManager(client, ecb.WithEntityIDRange(start, 2))
+		assert.NilError(t, err)
+		assert.NilError(t, manager.RegisterComponents(allComponents))
+		return manager
+	}
+	_, evenClient := newCmdBufferAndRedisClientForTest(t, nil)
+	_, oddClient := newCmdBufferAndRedisClientForTest(t, nil)
+	even, odd := newManager(evenClient, 0), newManager(oddClient, 1)
+
+	ids, err := even.CreateManyEntities(3, fooComp)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ids, []entity.ID{0, 2, 4})
+	assert.NilError(t, even.CommitPending())
+	ids, err = odd.CreateManyEntities(3, fooComp)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ids, []entity.ID{1, 3, 5})
+	assert.NilError(t, odd.CommitPending())
+
+	// The range continues where it left off after a restart.
+	even = newManager(evenClient, 0)
+	id, err := even.CreateEntity(fooComp)
+	assert.NilError(t, err)
+	assert.Equal(t, id, entity.ID(6))
+}
+
+func TestEntityIDRangeMustHaveAStride(t *testing.T) {
+	_, client := newCmdBufferAndRedisClientForTest(t, nil)
+	_, err := ecb.NewManager(client, ecb.WithEntityIDRange(1, 0))
+	assert.ErrorIs(t, err, ecb.ErrInvalidEntityIDRange)
+}
+
+func TestEntityIDRangeMustStartBelowTheStride(t *testing.T) {
+	_, client := newCmdBufferAndRedisClientForTest(t, nil)
+	// A start of 3 with a stride of 2 would hand out the IDs of the shard that starts at 1.
+	_, err := ecb.NewManager(client, ecb.WithEntityIDRange(3, 2))
+	assert.ErrorIs(t, err, ecb.ErrInvalidEntityIDRange)
+	_, err = ecb.NewManager(client, ecb.WithEntityIDRange(2, 2))
+	assert.ErrorIs(t, err, ecb.ErrInvalidEntityIDRange)
+	_, err = ecb.NewManager(client, ecb.WithEntityIDRange(1, 2))
+	assert.NilError(t, err)
+}
+
 func TestCanGetComponentsForEntity(t *testing.T) {
 	manager := newCmdBufferForTest(t)
 	id, err := manager.CreateEntity(fooComp)
@@ -468,6 +512,40 @@ func TestCanGetArchetypeCount(t *testing.T) {
 	assert.Equal(t, 3, manager.ArchetypeCount())
 }
 
+func TestCanGetEntityCount(t *testing.T) {
+	manager, client := newCmdBufferAndRedisClientForTest(t, nil)
+	count, err := manager.EntityCount()
+	assert.NilError(t, err)
+	assert.Equal(t, 0, count)
+
+	ids, err := manager.CreateManyEntities(3, fooComp)
+	assert.NilError(t, err)
+	_, err = manager.CreateEntity(barComp)
+	assert.NilError(t, err)
+	assert.NilError(t, manager.RemoveEntity(ids[0]))
+	count, err = manager.EntityCount()
+	assert.NilError(t, err)
+	assert.Equal(t, 3, count)
+	assert.NilError(t, manager.CommitPending())
+
+	// Discarded changes do not affect the count
+	_, err = manager.CreateManyEntities(10, fooComp)
+	assert.NilError(t, err)
+	manager.DiscardPending()
+	count, err = manager.EntityCount()
+	assert.NilError(t, err)
+	assert.Equal(t, 3, count)
+
+	// The count survives a restart
+	manager, _ = newCmdBufferAndRedisClientForTest(t, client)
+	count, err = manager.EntityCount()
+	assert.NilError(t, err)
+	assert.Equal(t, 3, count)
+	count, err = manager.ToReadOnly().EntityCount()
+	assert.NilError(t, err)
+	assert.Equal(t, 3, count)
+}
+
 func TestClearComponentWhenAnEntityMovesAwayFromAnArchetypeThenBackToTheArchetype(t *testing.T) {
 	manager := newCmdBufferForTest(t)
 	id, err := manager.CreateEntity(fooComp, barComp)
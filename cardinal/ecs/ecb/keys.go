@@ -19,6 +19,11 @@ func redisNextEntityIDKey() string {
 	return "ECB:NEXT-ENTITY-ID"
 }
 
+// redisEntityCountKey is the key that stores the number of live entities.
+func redisEntityCountKey() string {
+	return "ECB:ENTITY-COUNT"
+}
+
 // redisArchetypeIDForEntityID is the key that maps a specific entity ID to its archetype ID.
 // Note, this key and redisActiveEntityIDKey represent the same information.
 // This maps entity.ID -> archetype.ID.
@@ -52,3 +57,15 @@ func redisEndTickKey() string {
 func redisPendingTransactionKey() string {
 	return "ECB:PENDING-TRANSACTIONS"
 }
+
+// redisShardCommitIDKey is the key in the main redis instance that stores the ID of the last commit that included
+// changes to sharded components.
+func redisShardCommitIDKey() string {
+	return "ECB:SHARD-COMMIT-ID"
+}
+
+// redisShardCommitKey is the key in a shard's redis instance that stores component changes that have been staged, but
+// not yet applied to the shard.
+func redisShardCommitKey() string {
+	return "ECB:SHARD-COMMIT"
+}
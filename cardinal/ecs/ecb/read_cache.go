@@ -0,0 +1,63 @@
+package ecb
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// componentReadCache holds the committed values of components that were read through the read only Manager, so
+// repeated reads of the same component between two commits do not go to redis. Every commit invalidates the whole
+// cache. See WithReadCache.
+type componentReadCache struct {
+	mutex sync.Mutex
+	// generation is incremented by every commit. A value read from redis is only cached if no commit happened while it
+	// was being read, so a value from before a commit is never cached after the commit.
+	generation uint64
+	values     map[compKey]json.RawMessage
+}
+
+func newComponentReadCache() *componentReadCache {
+	return &componentReadCache{values: map[compKey]json.RawMessage{}}
+}
+
+// get returns the cached value of the given component. The returned generation must be passed to put when the value
+// is not cached.
+func (c *componentReadCache) get(key compKey) (value json.RawMessage, generation uint64, ok bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	value, ok = c.values[key]
+	return value, c.generation, ok
+}
+
+func (c *componentReadCache) put(key compKey, generation uint64, value json.RawMessage) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if generation != c.generation {
+		return
+	}
+	c.values[key] = value
+}
+
+// invalidate must be called after committed state changes.
+func (c *componentReadCache) invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.generation++
+	clear(c.values)
+}
+
+// WithReadCache caches the component values read through the read only Manager (see Manager.ToReadOnly), e.g. by
+// queries, until the next commit. This saves redis round trips when the same components are read many times within a
+// tick. Reads never see a value from before the last commit.
+func WithReadCache() Option {
+	return func(m *Manager) {
+		m.readCache = newComponentReadCache()
+	}
+}
+
+// invalidateReadCache must be called after committed state changes.
+func (m *Manager) invalidateReadCache() {
+	if m.readCache != nil {
+		m.readCache.invalidate()
+	}
+}
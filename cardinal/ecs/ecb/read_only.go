@@ -24,14 +24,18 @@ var (
 
 type readOnlyManager struct {
 	client          *redis.Client
+	shardClients    map[component.TypeID]*redis.Client
 	typeToComponent map[component.TypeID]component.ComponentMetadata
 	archIDToComps   map[archetype.ID][]component.ComponentMetadata
+	readCache       *componentReadCache
 }
 
 func (m *Manager) ToReadOnly() store.Reader {
 	return &readOnlyManager{
 		client:          m.client,
+		shardClients:    m.shardClients,
 		typeToComponent: m.typeToComponent,
+		readCache:       m.readCache,
 	}
 }
 
@@ -62,10 +66,28 @@ func (r *readOnlyManager) GetComponentForEntity(
 func (r *readOnlyManager) GetComponentForEntityInRawJSON(
 	cType component.ComponentMetadata, id entity.ID,
 ) (json.RawMessage, error) {
+	var generation uint64
+	if r.readCache != nil {
+		value, gen, ok := r.readCache.get(compKey{cType.ID(), id})
+		if ok {
+			return value, nil
+		}
+		generation = gen
+	}
 	ctx := context.Background()
 	key := redisComponentKey(cType.ID(), id)
-	res, err := r.client.Get(ctx, key).Bytes()
-	return res, eris.Wrap(err, "")
+	client := r.client
+	if shardClient, ok := r.shardClients[cType.ID()]; ok {
+		client = shardClient
+	}
+	res, err := client.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, eris.Wrap(err, "")
+	}
+	if r.readCache != nil {
+		r.readCache.put(compKey{cType.ID(), id}, generation, res)
+	}
+	return res, nil
 }
 
 func (r *readOnlyManager) getComponentsForArchID(archID archetype.ID) ([]component.ComponentMetadata, error) {
@@ -159,6 +181,10 @@ func (r *readOnlyManager) SearchFrom(filter filter.ComponentFilter, start int) *
 	return itr
 }
 
+func (r *readOnlyManager) EntityCount() (int, error) {
+	return getEntityCountFromRedis(r.client, r.ArchetypeCount())
+}
+
 func (r *readOnlyManager) ArchetypeCount() int {
 	if err := r.refreshArchIDToCompTypes(); err != nil {
 		return 0
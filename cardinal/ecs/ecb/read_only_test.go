@@ -1,13 +1,18 @@
 package ecb_test
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
 	"pkg.world.dev/world-engine/cardinal"
 	"pkg.world.dev/world-engine/cardinal/testutils"
 
 	"pkg.world.dev/world-engine/assert"
 	"pkg.world.dev/world-engine/cardinal/ecs"
+	"pkg.world.dev/world-engine/cardinal/ecs/ecb"
 	"pkg.world.dev/world-engine/cardinal/types/component"
 )
 
@@ -32,6 +37,37 @@ func TestReadOnly_CanGetComponent(t *testing.T) {
 	assert.NilError(t, err)
 }
 
+func TestReadOnly_ReadCacheIsInvalidatedByCommits(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	manager, err := ecb.NewManager(client, ecb.WithReadCache())
+	assert.NilError(t, err)
+	assert.NilError(t, manager.RegisterComponents(allComponents))
+
+	id, err := manager.CreateEntity(fooComp)
+	assert.NilError(t, err)
+	assert.NilError(t, manager.SetComponentForEntity(fooComp, id, Foo{Value: 1}))
+	assert.NilError(t, manager.CommitPending())
+
+	roStore := manager.ToReadOnly()
+	got, err := roStore.GetComponentForEntity(fooComp, id)
+	assert.NilError(t, err)
+	assert.Equal(t, got.(Foo).Value, 1)
+
+	// Change the value behind the cache's back. The cached value is served until the next commit.
+	key := fmt.Sprintf("ECB:COMPONENT-VALUE:TYPE-ID-%d:ENTITY-ID-%d", fooComp.ID(), id)
+	assert.NilError(t, s.Set(key, `{"Value":2}`))
+	got, err = roStore.GetComponentForEntity(fooComp, id)
+	assert.NilError(t, err)
+	assert.Equal(t, got.(Foo).Value, 1)
+
+	assert.NilError(t, manager.SetComponentForEntity(fooComp, id, Foo{Value: 3}))
+	assert.NilError(t, manager.CommitPending())
+	got, err = roStore.GetComponentForEntity(fooComp, id)
+	assert.NilError(t, err)
+	assert.Equal(t, got.(Foo).Value, 3)
+}
+
 func TestReadOnly_CanGetComponentTypesForEntityAndArchID(t *testing.T) {
 	manager := newCmdBufferForTest(t)
 
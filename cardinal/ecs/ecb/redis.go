@@ -9,35 +9,43 @@ import (
 	"pkg.world.dev/world-engine/cardinal/ecs/storage"
 	"pkg.world.dev/world-engine/cardinal/types/archetype"
 	"pkg.world.dev/world-engine/cardinal/types/component"
+	"pkg.world.dev/world-engine/cardinal/types/entity"
 )
 
 // pipeFlushToRedis return a pipeliner with all pending state changes to redis ready to be committed in an atomic
-// transaction. If an error is returned, no redis changes will have been made.
-func (m *Manager) makePipeOfRedisCommands(ctx context.Context) (redis.Pipeliner, error) {
+// transaction, along with the pending changes to components that are stored in a shard. Use execPipe to commit both.
+// If an error is returned, no redis changes will have been made.
+func (m *Manager) makePipeOfRedisCommands(ctx context.Context) (
+	redis.Pipeliner, map[*redis.Client]*shardCommit, error,
+) {
 	pipe := m.client.TxPipeline()
 
 	if m.typeToComponent == nil {
 		// component.TypeID -> ComponentMetadata mappings are required to serialized data for the DB
-		return nil, eris.New("must call RegisterComponents before flushing to DB")
+		return nil, nil, eris.New("must call RegisterComponents before flushing to DB")
 	}
 
-	if err := m.addComponentChangesToPipe(ctx, pipe); err != nil {
-		return nil, eris.Wrap(err, "failed to add component changes to pipe")
+	shardChanges, err := m.addComponentChangesToPipe(ctx, pipe)
+	if err != nil {
+		return nil, nil, eris.Wrap(err, "failed to add component changes to pipe")
 	}
 	if err := m.addNextEntityIDToPipe(ctx, pipe); err != nil {
-		return nil, eris.Wrap(err, "failed to add entity id changes to pipe")
+		return nil, nil, eris.Wrap(err, "failed to add entity id changes to pipe")
+	}
+	if err := m.addEntityCountToPipe(ctx, pipe); err != nil {
+		return nil, nil, eris.Wrap(err, "failed to add entity count changes to pipe")
 	}
 	if err := m.addPendingArchIDsToPipe(ctx, pipe); err != nil {
-		return nil, eris.Wrap(err, "failed to add archID to component type map to pipe")
+		return nil, nil, eris.Wrap(err, "failed to add archID to component type map to pipe")
 	}
 	if err := m.addEntityIDToArchIDToPipe(ctx, pipe); err != nil {
-		return nil, eris.Wrap(err, "failed to add entity ID to archID mapping to pipe")
+		return nil, nil, eris.Wrap(err, "failed to add entity ID to archID mapping to pipe")
 	}
 	if err := m.addActiveEntityIDsToPipe(ctx, pipe); err != nil {
-		return nil, eris.Wrap(err, "failed to add changes to active entity ids to pipe")
+		return nil, nil, eris.Wrap(err, "failed to add changes to active entity ids to pipe")
 	}
 
-	return pipe, nil
+	return pipe, shardChanges, nil
 }
 
 // addEntityIDToArchIDToPipe adds the information related to mapping an entity ID to its assigned archetype ID.
@@ -78,15 +86,73 @@ func (m *Manager) addNextEntityIDToPipe(ctx context.Context, pipe redis.Pipeline
 	return eris.Wrap(pipe.Set(ctx, key, nextID, 0).Err(), "")
 }
 
-// addComponentChangesToPipe adds updated component values for entities to the redis pipe.
-func (m *Manager) addComponentChangesToPipe(ctx context.Context, pipe redis.Pipeliner) error {
+// addEntityCountToPipe adds any changes to the number of live entities to the given redis pipe.
+func (m *Manager) addEntityCountToPipe(ctx context.Context, pipe redis.Pipeliner) error {
+	// No entities have been created or removed, so there's nothing to commit
+	if m.pendingEntityCount == 0 {
+		return nil
+	}
+	if err := m.loadEntityCount(); err != nil {
+		return err
+	}
+	key := redisEntityCountKey()
+	count := m.entityCountSaved + m.pendingEntityCount
+	return eris.Wrap(pipe.Set(ctx, key, count, 0).Err(), "")
+}
+
+// getEntityCountFromRedis returns the number of live entities that have been committed to storage. Storage written
+// before the entity count was tracked has no count saved, in which case the active entities of every archetype are
+// counted instead.
+func getEntityCountFromRedis(client *redis.Client, archetypeCount int) (int, error) {
+	ctx := context.Background()
+	count, err := client.Get(ctx, redisEntityCountKey()).Int()
+	err = eris.Wrap(err, "")
+	if err == nil {
+		return count, nil
+	}
+	if !eris.Is(eris.Cause(err), redis.Nil) {
+		return 0, err
+	}
+	count = 0
+	for archID := archetype.ID(0); int(archID) < archetypeCount; archID++ {
+		bz, err := client.Get(ctx, redisActiveEntityIDKey(archID)).Bytes()
+		err = eris.Wrap(err, "")
+		if err != nil {
+			if eris.Is(eris.Cause(err), redis.Nil) {
+				continue
+			}
+			return 0, err
+		}
+		ids, err := codec.Decode[[]entity.ID](bz)
+		if err != nil {
+			return 0, err
+		}
+		count += len(ids)
+	}
+	return count, nil
+}
+
+// addComponentChangesToPipe adds updated component values for entities to the redis pipe. Changes to components that
+// are stored in a shard are returned instead, grouped by shard.
+func (m *Manager) addComponentChangesToPipe(ctx context.Context, pipe redis.Pipeliner) (
+	map[*redis.Client]*shardCommit, error,
+) {
+	shardChanges := map[*redis.Client]*shardCommit{}
+	shardChangesFor := func(typeID component.TypeID) *shardCommit {
+		return m.shardChangesFor(shardChanges, typeID)
+	}
+
 	for key, isMarkedForDeletion := range m.compValuesToDelete {
 		if !isMarkedForDeletion {
 			continue
 		}
 		redisKey := redisComponentKey(key.typeID, key.entityID)
+		if changes := shardChangesFor(key.typeID); changes != nil {
+			changes.Dels = append(changes.Dels, redisKey)
+			continue
+		}
 		if err := pipe.Del(ctx, redisKey).Err(); err != nil {
-			return eris.Wrap(err, "")
+			return nil, eris.Wrap(err, "")
 		}
 	}
 
@@ -94,15 +160,35 @@ func (m *Manager) addComponentChangesToPipe(ctx context.Context, pipe redis.Pipe
 		cType := m.typeToComponent[key.typeID]
 		bz, err := cType.Encode(value)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		redisKey := redisComponentKey(key.typeID, key.entityID)
-		if err = pipe.Set(ctx, redisKey, bz, 0).Err(); err != nil {
-			return eris.Wrap(err, "")
+		if changes := shardChangesFor(key.typeID); changes != nil {
+			changes.Sets[redisKey] = bz
+			if ttl := cType.RedisTTL(); ttl > 0 {
+				changes.TTLs[redisKey] = ttl
+			}
+			continue
+		}
+		if err = pipe.Set(ctx, redisKey, bz, cType.RedisTTL()).Err(); err != nil {
+			return nil, eris.Wrap(err, "")
 		}
 	}
-	return nil
+	return shardChanges, nil
+}
+
+// shardChangesFor returns the changes for the shard that stores the given component type, or nil if the component
+// type is stored in the main redis instance.
+func (m *Manager) shardChangesFor(shardChanges map[*redis.Client]*shardCommit, typeID component.TypeID) *shardCommit {
+	client, ok := m.shardClients[typeID]
+	if !ok {
+		return nil
+	}
+	if shardChanges[client] == nil {
+		shardChanges[client] = newShardCommit()
+	}
+	return shardChanges[client]
 }
 
 // preloadArchIDs loads the mapping of archetypes IDs to sets of IComponentTypes from storage.
@@ -7,6 +7,7 @@ package ecb
 import (
 	"context"
 	"testing"
+	"time"
 
 	"pkg.world.dev/world-engine/assert"
 
@@ -73,3 +74,126 @@ func TestComponentValuesAreDeletedFromRedis(t *testing.T) {
 	err = client.Get(ctx, key).Err()
 	assert.ErrorIs(t, err, redis.Nil)
 }
+
+func TestComponentsWithARedisTTLExpireWhenNotWritten(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+
+	alphaComp, err := component.NewComponentMetadata[Alpha](component.WithRedisTTL[Alpha](time.Minute))
+	assert.NilError(t, err)
+	betaComp, err := component.NewComponentMetadata[Beta]()
+	assert.NilError(t, err)
+	assert.NilError(t, alphaComp.SetID(77))
+	assert.NilError(t, betaComp.SetID(88))
+
+	manager, err := NewManager(client)
+	assert.NilError(t, err)
+	assert.NilError(t, manager.RegisterComponents([]component.ComponentMetadata{alphaComp, betaComp}))
+	id, err := manager.CreateEntity(alphaComp, betaComp)
+	assert.NilError(t, err)
+	assert.NilError(t, manager.SetComponentForEntity(alphaComp, id, Alpha{99}))
+	assert.NilError(t, manager.SetComponentForEntity(betaComp, id, Beta{100}))
+	assert.NilError(t, manager.CommitPending())
+
+	alphaKey := redisComponentKey(alphaComp.ID(), id)
+	betaKey := redisComponentKey(betaComp.ID(), id)
+	assert.Equal(t, time.Minute, s.TTL(alphaKey))
+	assert.Equal(t, time.Duration(0), s.TTL(betaKey))
+
+	s.FastForward(2 * time.Minute)
+	assert.Check(t, !s.Exists(alphaKey))
+	assert.Check(t, s.Exists(betaKey))
+}
+
+func TestRedisTTLsAreRefreshedWhileTheWorldCommits(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+
+	alphaComp, err := component.NewComponentMetadata[Alpha](component.WithRedisTTL[Alpha](time.Minute))
+	assert.NilError(t, err)
+	betaComp, err := component.NewComponentMetadata[Beta]()
+	assert.NilError(t, err)
+	assert.NilError(t, alphaComp.SetID(77))
+	assert.NilError(t, betaComp.SetID(88))
+
+	manager, err := NewManager(client)
+	assert.NilError(t, err)
+	assert.NilError(t, manager.RegisterComponents([]component.ComponentMetadata{alphaComp, betaComp}))
+	id, err := manager.CreateEntity(alphaComp, betaComp)
+	assert.NilError(t, err)
+	assert.NilError(t, manager.SetComponentForEntity(alphaComp, id, Alpha{99}))
+	assert.NilError(t, manager.CommitPending())
+	alphaKey := redisComponentKey(alphaComp.ID(), id)
+
+	// Alpha is never written again, but the commits keep its key alive. Only beta changes in these commits.
+	for i := 0; i < 4; i++ {
+		s.FastForward(40 * time.Second)
+		// FastForward only moves the clock of miniredis, so the last refresh is moved back by hand.
+		manager.redisTTLsRefreshedAt[alphaComp.ID()] = time.Now().Add(-time.Minute)
+		assert.NilError(t, manager.SetComponentForEntity(betaComp, id, Beta{i}))
+		assert.NilError(t, manager.CommitPending())
+		// The TTLs are refreshed in the background, after the commit.
+		waitForTTL(t, s, alphaKey, time.Minute)
+	}
+
+	// Once the commits stop, the key expires.
+	s.FastForward(2 * time.Minute)
+	assert.Check(t, !s.Exists(alphaKey))
+}
+
+func waitForTTL(t *testing.T, s *miniredis.Miniredis, key string, ttl time.Duration) {
+	for i := 0; i < 500 && s.TTL(key) != ttl; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, ttl, s.TTL(key))
+}
+
+func TestEntitiesThatLostARedisTTLComponentAreRemoved(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+
+	alphaComp, err := component.NewComponentMetadata[Alpha](component.WithRedisTTL[Alpha](time.Minute))
+	assert.NilError(t, err)
+	betaComp, err := component.NewComponentMetadata[Beta]()
+	assert.NilError(t, err)
+	assert.NilError(t, alphaComp.SetID(77))
+	assert.NilError(t, betaComp.SetID(88))
+	comps := []component.ComponentMetadata{alphaComp, betaComp}
+
+	manager, err := NewManager(client)
+	assert.NilError(t, err)
+	assert.NilError(t, manager.RegisterComponents(comps))
+	abandoned, err := manager.CreateEntity(alphaComp, betaComp)
+	assert.NilError(t, err)
+	assert.NilError(t, manager.SetComponentForEntity(alphaComp, abandoned, Alpha{99}))
+	assert.NilError(t, manager.SetComponentForEntity(betaComp, abandoned, Beta{100}))
+	kept, err := manager.CreateEntity(betaComp)
+	assert.NilError(t, err)
+	assert.NilError(t, manager.SetComponentForEntity(betaComp, kept, Beta{200}))
+	assert.NilError(t, manager.CommitPending())
+
+	// Nothing expired yet, so nothing is removed.
+	removed, err := manager.RemoveExpiredEntities()
+	assert.NilError(t, err)
+	assert.Equal(t, 0, removed)
+
+	// The world stops running for longer than the TTL.
+	s.FastForward(2 * time.Minute)
+	manager, err = NewManager(client)
+	assert.NilError(t, err)
+	assert.NilError(t, manager.RegisterComponents(comps))
+	removed, err = manager.RemoveExpiredEntities()
+	assert.NilError(t, err)
+	assert.Equal(t, 1, removed)
+
+	// The whole entity is gone, rather than coming back with a zero valued alpha.
+	_, err = manager.GetComponentTypesForEntity(abandoned)
+	assert.Check(t, err != nil)
+	assert.Check(t, !s.Exists(redisComponentKey(betaComp.ID(), abandoned)))
+	count, err := manager.EntityCount()
+	assert.NilError(t, err)
+	assert.Equal(t, 1, count)
+	beta, err := manager.GetComponentForEntity(betaComp, kept)
+	assert.NilError(t, err)
+	assert.Equal(t, Beta{200}, beta)
+}
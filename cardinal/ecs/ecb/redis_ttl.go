@@ -0,0 +1,203 @@
+package ecb
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rotisserie/eris"
+	ecslog "pkg.world.dev/world-engine/cardinal/ecs/log"
+	"pkg.world.dev/world-engine/cardinal/types/archetype"
+	"pkg.world.dev/world-engine/cardinal/types/component"
+	"pkg.world.dev/world-engine/cardinal/types/entity"
+)
+
+const (
+	// pendingRedisTTLRefreshes is the number of refreshes that may wait for the refresher before further ones are
+	// skipped. A skipped refresh is tried again after the next commit.
+	pendingRedisTTLRefreshes = 4
+	// redisTTLRefreshBatchSize is the number of keys whose expiry is refreshed in a single round trip to redis.
+	redisTTLRefreshBatchSize = 1000
+)
+
+// redisTTLRefresh is a set of keys of a single redis instance whose expiry must be reset to the given TTL.
+type redisTTLRefresh struct {
+	client *redis.Client
+	ttl    time.Duration
+	keys   []string
+}
+
+// startRedisTTLRefresher starts the goroutine that refreshes the expiry of components with a redis TTL if any of the
+// registered components has one. The refresher runs until the Manager is closed.
+func (m *Manager) startRedisTTLRefresher() {
+	if m.redisTTLRefreshes != nil {
+		return
+	}
+	for _, comp := range m.typeToComponent {
+		if comp.RedisTTL() > 0 {
+			m.redisTTLRefreshes = make(chan []redisTTLRefresh, pendingRedisTTLRefreshes)
+			go runRedisTTLRefresher(m.redisTTLRefreshes, m.logger)
+			return
+		}
+	}
+}
+
+// runRedisTTLRefresher refreshes the expiry of the keys it is sent, in batches of redisTTLRefreshBatchSize keys, until
+// the channel is closed. The refreshes are not part of any commit, so they never hold up a tick.
+func runRedisTTLRefresher(refreshes <-chan []redisTTLRefresh, logger *ecslog.Logger) {
+	ctx := context.Background()
+	for batch := range refreshes {
+		for _, refresh := range batch {
+			for start := 0; start < len(refresh.keys); start += redisTTLRefreshBatchSize {
+				pipe := refresh.client.Pipeline()
+				for _, key := range refresh.keys[start:min(start+redisTTLRefreshBatchSize, len(refresh.keys))] {
+					pipe.Expire(ctx, key, refresh.ttl)
+				}
+				if _, err := pipe.Exec(ctx); err != nil {
+					logger.Warn().Err(err).Msg("failed to refresh the redis TTL of components")
+				}
+			}
+		}
+	}
+}
+
+// scheduleRedisTTLRefreshes hands the keys of the committed components of every live entity whose component type has a
+// redis TTL to the refresher, so the components only expire once the world stops committing. To keep the work
+// bounded, the keys of a component type are only refreshed once half of its TTL has passed since they were last
+// refreshed. It must be called after a successful commit, before the pending state is discarded. See
+// component.WithRedisTTL.
+func (m *Manager) scheduleRedisTTLRefreshes() {
+	if m.redisTTLRefreshes == nil {
+		return
+	}
+	now := time.Now()
+	due := map[component.TypeID]time.Duration{}
+	for typeID, comp := range m.typeToComponent {
+		ttl := comp.RedisTTL()
+		if ttl <= 0 || now.Sub(m.redisTTLsRefreshedAt[typeID]) < ttl/2 {
+			continue
+		}
+		due[typeID] = ttl
+	}
+	if len(due) == 0 {
+		return
+	}
+	keys := map[component.TypeID][]string{}
+	for archID, comps := range m.archIDToComps {
+		var expiring []component.TypeID
+		for _, comp := range comps {
+			if _, ok := due[comp.ID()]; ok {
+				expiring = append(expiring, comp.ID())
+			}
+		}
+		if len(expiring) == 0 {
+			continue
+		}
+		active, err := m.getActiveEntities(archID)
+		if err != nil {
+			m.logger.Warn().Err(err).Msg("failed to load entities whose redis TTL must be refreshed")
+			return
+		}
+		for _, id := range active.ids {
+			for _, typeID := range expiring {
+				keys[typeID] = append(keys[typeID], redisComponentKey(typeID, id))
+			}
+		}
+	}
+	batch := make([]redisTTLRefresh, 0, len(keys))
+	for typeID, typeKeys := range keys {
+		batch = append(batch, redisTTLRefresh{client: m.clientForComponent(typeID), ttl: due[typeID], keys: typeKeys})
+	}
+	select {
+	case m.redisTTLRefreshes <- batch:
+		for typeID := range due {
+			m.redisTTLsRefreshedAt[typeID] = now
+		}
+	default:
+		m.logger.Warn().Msg("redis TTL refresher is behind, skipped a refresh")
+	}
+}
+
+// stopRedisTTLRefresher stops the refresher started by startRedisTTLRefresher.
+func (m *Manager) stopRedisTTLRefresher() {
+	if m.redisTTLRefreshes != nil {
+		close(m.redisTTLRefreshes)
+		m.redisTTLRefreshes = nil
+	}
+}
+
+// RemoveExpiredEntities removes every entity that lost a component registered with component.WithRedisTTL because
+// redis expired it, e.g. because the world was not running for longer than the TTL. Otherwise, the rest of the entity
+// would stay behind and the expired component would read as its default value. The removals are committed right
+// away, and the number of removed entities is returned.
+func (m *Manager) RemoveExpiredEntities() (int, error) {
+	ctx := context.Background()
+	var expired []entity.ID
+	for archID, comps := range m.archIDToComps {
+		var expiring []component.TypeID
+		for _, comp := range comps {
+			if comp.RedisTTL() > 0 {
+				expiring = append(expiring, comp.ID())
+			}
+		}
+		if len(expiring) == 0 {
+			continue
+		}
+		ids, err := m.entitiesMissingComponents(ctx, archID, expiring)
+		if err != nil {
+			return 0, err
+		}
+		expired = append(expired, ids...)
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+	for _, id := range expired {
+		if err := m.RemoveEntity(id); err != nil {
+			m.DiscardPending()
+			return 0, err
+		}
+	}
+	if err := m.CommitPending(); err != nil {
+		m.DiscardPending()
+		return 0, err
+	}
+	return len(expired), nil
+}
+
+// entitiesMissingComponents returns the entities of the given archetype for which any of the given components has no
+// value in redis.
+func (m *Manager) entitiesMissingComponents(ctx context.Context, archID archetype.ID, typeIDs []component.TypeID) (
+	[]entity.ID, error,
+) {
+	active, err := m.getActiveEntities(archID)
+	if err != nil {
+		return nil, err
+	}
+	pipes := map[*redis.Client]redis.Pipeliner{}
+	exists := make([][]*redis.IntCmd, len(active.ids))
+	for i, id := range active.ids {
+		for _, typeID := range typeIDs {
+			client := m.clientForComponent(typeID)
+			if pipes[client] == nil {
+				pipes[client] = client.Pipeline()
+			}
+			exists[i] = append(exists[i], pipes[client].Exists(ctx, redisComponentKey(typeID, id)))
+		}
+	}
+	for _, pipe := range pipes {
+		if _, err = pipe.Exec(ctx); err != nil {
+			return nil, eris.Wrap(err, "")
+		}
+	}
+	var missing []entity.ID
+	for i, id := range active.ids {
+		for _, cmd := range exists[i] {
+			if cmd.Val() == 0 {
+				missing = append(missing, id)
+				break
+			}
+		}
+	}
+	return missing, nil
+}
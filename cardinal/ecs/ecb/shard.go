@@ -0,0 +1,212 @@
+package ecb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rotisserie/eris"
+	"pkg.world.dev/world-engine/cardinal/ecs/codec"
+	"pkg.world.dev/world-engine/cardinal/types/component"
+)
+
+var ErrShardedComponentNotRegistered = errors.New("sharded component has not been registered")
+
+type Option func(m *Manager)
+
+// WithComponentShard stores the values of the component with the given name in the redis instance behind the given
+// client instead of the main redis instance. All other state (archetypes, entity IDs, ticks) stays in the main redis
+// instance. Reads and writes through the Manager are routed to the correct instance, so the sharding is not visible
+// to callers.
+func WithComponentShard(componentName string, client *redis.Client) Option {
+	return func(m *Manager) {
+		m.shardClientsByName[componentName] = client
+	}
+}
+
+// WithEntityIDRange makes the Manager hand out the entity IDs start, start+stride, start+2*stride and so on, instead of
+// 0, 1, 2 and so on. Shards of the same game that use the same stride and different starts below the stride allocate
+// disjoint IDs, so entity IDs are unique across the shards without a central allocator. The range of a world must not
+// change once entities have been created. NewManager returns ErrInvalidEntityIDRange unless start is below stride,
+// since a start of stride or more would overlap with the range of the shard that starts at start modulo stride.
+func WithEntityIDRange(start, stride uint64) Option {
+	return func(m *Manager) {
+		m.entityIDStart = start
+		m.entityIDStride = stride
+	}
+}
+
+// shardCommit holds the component changes for a single shard that belong to one commit of the main redis instance.
+// A commit is staged on each shard before the main redis instance is committed, and applied to the shard afterward.
+// CommitID ties the staged changes to the commit of the main redis instance so that, after a crash, the staged changes
+// can be applied if the main commit went through, or discarded if it did not.
+type shardCommit struct {
+	CommitID uint64
+	Sets     map[string][]byte
+	// TTLs holds the expiration of the keys in Sets that expire. See component.WithRedisTTL.
+	TTLs map[string]time.Duration
+	Dels []string
+}
+
+func newShardCommit() *shardCommit {
+	return &shardCommit{
+		Sets: map[string][]byte{},
+		TTLs: map[string]time.Duration{},
+	}
+}
+
+// registerShards maps each sharded component name to its component type ID and finishes any commit that was
+// interrupted while the shards were being updated.
+func (m *Manager) registerShards() error {
+	m.shardClients = map[component.TypeID]*redis.Client{}
+	for name, client := range m.shardClientsByName {
+		found := false
+		for id, comp := range m.typeToComponent {
+			if comp.Name() == name {
+				m.shardClients[id] = client
+				found = true
+				break
+			}
+		}
+		if !found {
+			return eris.Wrapf(ErrShardedComponentNotRegistered, "component %q", name)
+		}
+	}
+	return m.resolveShardCommits(context.Background())
+}
+
+// clientForComponent returns the redis client that stores the values of the given component type.
+func (m *Manager) clientForComponent(typeID component.TypeID) *redis.Client {
+	if client, ok := m.shardClients[typeID]; ok {
+		return client
+	}
+	return m.client
+}
+
+// shards returns each distinct shard client.
+func (m *Manager) shards() []*redis.Client {
+	seen := map[*redis.Client]bool{}
+	var clients []*redis.Client
+	for _, client := range m.shardClientsByName {
+		if seen[client] {
+			continue
+		}
+		seen[client] = true
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// getCommitID returns the ID of the last commit to the main redis instance that included sharded component changes.
+func (m *Manager) getCommitID(ctx context.Context) (uint64, error) {
+	id, err := m.client.Get(ctx, redisShardCommitIDKey()).Uint64()
+	err = eris.Wrap(err, "")
+	if eris.Is(eris.Cause(err), redis.Nil) {
+		return 0, nil
+	}
+	return id, err
+}
+
+// resolveShardCommits applies the changes staged on each shard if the main redis instance committed them, and
+// discards them if it did not. Afterward, no shard has staged changes.
+func (m *Manager) resolveShardCommits(ctx context.Context) error {
+	if len(m.shardClientsByName) == 0 {
+		return nil
+	}
+	committedID, err := m.getCommitID(ctx)
+	if err != nil {
+		return err
+	}
+	for _, client := range m.shards() {
+		bz, err := client.Get(ctx, redisShardCommitKey()).Bytes()
+		err = eris.Wrap(err, "")
+		if eris.Is(eris.Cause(err), redis.Nil) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		staged, err := codec.Decode[shardCommit](bz)
+		if err != nil {
+			return err
+		}
+		if staged.CommitID > committedID {
+			// The main redis instance was never committed, so these changes must not be applied.
+			if err = client.Del(ctx, redisShardCommitKey()).Err(); err != nil {
+				return eris.Wrap(err, "")
+			}
+			continue
+		}
+		if err = applyShardCommit(ctx, client, staged); err != nil {
+			return err
+		}
+		m.invalidateReadCache()
+	}
+	m.shardsNeedResolving = false
+	return nil
+}
+
+// applyShardCommit atomically applies the given changes to a shard and removes them from the shard's staging key.
+func applyShardCommit(ctx context.Context, client *redis.Client, changes shardCommit) error {
+	pipe := client.TxPipeline()
+	for _, key := range changes.Dels {
+		if err := pipe.Del(ctx, key).Err(); err != nil {
+			return eris.Wrap(err, "")
+		}
+	}
+	for key, bz := range changes.Sets {
+		if err := pipe.Set(ctx, key, bz, changes.TTLs[key]).Err(); err != nil {
+			return eris.Wrap(err, "")
+		}
+	}
+	if err := pipe.Del(ctx, redisShardCommitKey()).Err(); err != nil {
+		return eris.Wrap(err, "")
+	}
+	_, err := pipe.Exec(ctx)
+	return eris.Wrap(err, "")
+}
+
+// execPipe executes the given pipe of commands for the main redis instance along with the given component changes for
+// each shard. The pipe is the commit point: shard changes are staged before the pipe is executed and only applied
+// once it succeeds, so a failure at any point leaves the main redis instance and all shards at the same commit.
+func (m *Manager) execPipe(ctx context.Context, pipe redis.Pipeliner, shardChanges map[*redis.Client]*shardCommit,
+) error {
+	if len(shardChanges) == 0 {
+		_, err := pipe.Exec(ctx)
+		return eris.Wrap(err, "")
+	}
+	// A previous commit may have left staged changes behind. They must be resolved before they are overwritten.
+	if err := m.resolveShardCommits(ctx); err != nil {
+		return err
+	}
+	commitID, err := m.getCommitID(ctx)
+	if err != nil {
+		return err
+	}
+	commitID++
+	for client, changes := range shardChanges {
+		changes.CommitID = commitID
+		bz, err := codec.Encode(changes)
+		if err != nil {
+			return err
+		}
+		if err = client.Set(ctx, redisShardCommitKey(), bz, 0).Err(); err != nil {
+			return eris.Wrap(err, "")
+		}
+	}
+	if err = pipe.Set(ctx, redisShardCommitIDKey(), commitID, 0).Err(); err != nil {
+		return eris.Wrap(err, "")
+	}
+	if _, err = pipe.Exec(ctx); err != nil {
+		return eris.Wrap(err, "")
+	}
+	for client, changes := range shardChanges {
+		if err = applyShardCommit(ctx, client, *changes); err != nil {
+			// The commit already succeeded, so this must not be reported as a failure. The staged changes are applied
+			// before the shard is read from or written to again.
+			m.shardsNeedResolving = true
+			m.logger.Error().Err(err).Msg("failed to apply committed changes to component shard")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package ecb_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"pkg.world.dev/world-engine/assert"
+	"pkg.world.dev/world-engine/cardinal/ecs/ecb"
+	"pkg.world.dev/world-engine/cardinal/types/entity"
+)
+
+func newShardedManagerForTest(t *testing.T, mainRedis, shardRedis *miniredis.Miniredis) *ecb.Manager {
+	mainClient := redis.NewClient(&redis.Options{Addr: mainRedis.Addr()})
+	shardClient := redis.NewClient(&redis.Options{Addr: shardRedis.Addr()})
+	manager, err := ecb.NewManager(mainClient, ecb.WithComponentShard(fooComp.Name(), shardClient))
+	assert.NilError(t, err)
+	assert.NilError(t, manager.RegisterComponents(allComponents))
+	return manager
+}
+
+func fooKey(id entity.ID) string {
+	return fmt.Sprintf("ECB:COMPONENT-VALUE:TYPE-ID-%d:ENTITY-ID-%d", fooComp.ID(), id)
+}
+
+func stageShardCommit(t *testing.T, shardRedis *miniredis.Miniredis, commitID uint64, key string, value Foo) {
+	bz, err := json.Marshal(value)
+	assert.NilError(t, err)
+	staged, err := json.Marshal(struct {
+		CommitID uint64
+		Sets     map[string][]byte
+	}{
+		CommitID: commitID,
+		Sets:     map[string][]byte{key: bz},
+	})
+	assert.NilError(t, err)
+	assert.NilError(t, shardRedis.Set("ECB:SHARD-COMMIT", string(staged)))
+}
+
+func TestShardedComponentsAreStoredInTheShard(t *testing.T) {
+	mainRedis, shardRedis := miniredis.RunT(t), miniredis.RunT(t)
+	manager := newShardedManagerForTest(t, mainRedis, shardRedis)
+
+	id, err := manager.CreateEntity(fooComp, barComp)
+	assert.NilError(t, err)
+	assert.NilError(t, manager.SetComponentForEntity(fooComp, id, Foo{1}))
+	assert.NilError(t, manager.SetComponentForEntity(barComp, id, Bar{2}))
+	assert.NilError(t, manager.CommitPending())
+
+	assert.Check(t, shardRedis.Exists(fooKey(id)))
+	assert.Check(t, !mainRedis.Exists(fooKey(id)))
+	assert.Check(t, !shardRedis.Exists("ECB:SHARD-COMMIT"))
+
+	// A fresh manager finds the values in the shard.
+	manager = newShardedManagerForTest(t, mainRedis, shardRedis)
+	foo, err := manager.GetComponentForEntity(fooComp, id)
+	assert.NilError(t, err)
+	assert.Equal(t, Foo{1}, foo)
+	bar, err := manager.GetComponentForEntity(barComp, id)
+	assert.NilError(t, err)
+	assert.Equal(t, Bar{2}, bar)
+	foo, err = manager.ToReadOnly().GetComponentForEntity(fooComp, id)
+	assert.NilError(t, err)
+	assert.Equal(t, Foo{1}, foo)
+
+	// Removing the entity removes the value from the shard.
+	assert.NilError(t, manager.RemoveEntity(id))
+	assert.NilError(t, manager.CommitPending())
+	assert.Check(t, !shardRedis.Exists(fooKey(id)))
+}
+
+func TestStagedShardChangesAreOnlyAppliedIfTheMainCommitSucceeded(t *testing.T) {
+	mainRedis, shardRedis := miniredis.RunT(t), miniredis.RunT(t)
+	manager := newShardedManagerForTest(t, mainRedis, shardRedis)
+	id, err := manager.CreateEntity(fooComp)
+	assert.NilError(t, err)
+	assert.NilError(t, manager.SetComponentForEntity(fooComp, id, Foo{1}))
+	assert.NilError(t, manager.CommitPending())
+
+	// Simulate a crash after the shard changes were staged, but before the main redis instance was committed.
+	stageShardCommit(t, shardRedis, 2, fooKey(id), Foo{99})
+	manager = newShardedManagerForTest(t, mainRedis, shardRedis)
+	foo, err := manager.GetComponentForEntity(fooComp, id)
+	assert.NilError(t, err)
+	assert.Equal(t, Foo{1}, foo)
+	assert.Check(t, !shardRedis.Exists("ECB:SHARD-COMMIT"))
+
+	// Simulate a crash after the main redis instance was committed, but before the shard changes were applied.
+	stageShardCommit(t, shardRedis, 1, fooKey(id), Foo{50})
+	manager = newShardedManagerForTest(t, mainRedis, shardRedis)
+	foo, err = manager.GetComponentForEntity(fooComp, id)
+	assert.NilError(t, err)
+	assert.Equal(t, Foo{50}, foo)
+	assert.Check(t, !shardRedis.Exists("ECB:SHARD-COMMIT"))
+}
+
+func TestCannotShardAnUnregisteredComponent(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	shardClient := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	manager, err := ecb.NewManager(client, ecb.WithComponentShard("not-a-component", shardClient))
+	assert.NilError(t, err)
+	err = manager.RegisterComponents(allComponents)
+	assert.ErrorIs(t, err, ecb.ErrShardedComponentNotRegistered)
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/redis/go-redis/v9"
 	"pkg.world.dev/world-engine/cardinal/ecs/codec"
+	redisstorage "pkg.world.dev/world-engine/cardinal/ecs/storage/redis"
 	"pkg.world.dev/world-engine/cardinal/ecs/store"
 	"pkg.world.dev/world-engine/sign"
 )
@@ -43,13 +44,19 @@ func (m *Manager) GetTickNumbers() (start, end uint64, err error) {
 }
 
 // StartNextTick saves the given transactions to the DB and sets the tick trackers to indicate we are in the middle
-// of a tick. While transactions are saved to the DB, no state changes take place at this time.
-func (m *Manager) StartNextTick(txs []message.Message, queue *txpool.TxQueue) error {
+// of a tick. While transactions are saved to the DB, no state changes take place at this time. The transactions with
+// the given IDs are removed from the durable tx queue in the same redis transaction, so each transaction is either
+// in the durable tx queue or in the pending transactions of the tick, but never in both.
+func (m *Manager) StartNextTick(txs []message.Message, queue *txpool.TxQueue, durableTxIDs ...string) error {
 	ctx := context.Background()
 	pipe := m.client.TxPipeline()
 	if err := addPendingTransactionToPipe(ctx, pipe, txs, queue); err != nil {
 		return err
 	}
+	txQueueStorage := redisstorage.NewTxQueueStorage(m.client)
+	if err := txQueueStorage.RemoveInPipe(ctx, pipe, durableTxIDs...); err != nil {
+		return err
+	}
 
 	if err := pipe.Incr(ctx, redisStartTickKey()).Err(); err != nil {
 		return eris.Wrap(err, "")
@@ -64,7 +71,7 @@ func (m *Manager) StartNextTick(txs []message.Message, queue *txpool.TxQueue) er
 func (m *Manager) FinalizeTick(event *zerolog.Event) error {
 	ctx := context.Background()
 	startRedisPipe := time.Now()
-	pipe, err := m.makePipeOfRedisCommands(ctx)
+	pipe, shardChanges, err := m.makePipeOfRedisCommands(ctx)
 	if err != nil {
 		return err
 	}
@@ -73,9 +80,13 @@ func (m *Manager) FinalizeTick(event *zerolog.Event) error {
 		return eris.Wrap(err, "")
 	}
 	flushStartTime := time.Now()
-	_, err = pipe.Exec(ctx)
+	err = m.execPipe(ctx, pipe, shardChanges)
+	m.invalidateReadCache()
 	event.Int("exec_pipe_time_ms", int(time.Since(flushStartTime).Milliseconds()))
-	return eris.Wrap(err, "")
+	if err == nil {
+		m.scheduleRedisTTLRefreshes()
+	}
+	return err
 }
 
 // Recover fetches the pending transactions for an incomplete tick. This should only be called if GetTickNumbers
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/rotisserie/eris"
 	"pkg.world.dev/world-engine/cardinal/testutils"
 
 	"pkg.world.dev/world-engine/assert"
@@ -227,6 +228,64 @@ func TestCanSetDefaultValue(t *testing.T) {
 	assert.Equal(t, newOwner.MyName, "Bob")
 }
 
+func TestRegisteredDefaultValueIsUsedForNewComponents(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[Owner](world, component.WithDefault(Owner{"Jeff"})))
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](world))
+	assert.NilError(t, world.LoadGameState())
+	wCtx := ecs.NewWorldContext(world)
+
+	// A zero value passed to Create falls back to the default.
+	id, err := ecs.Create(wCtx, Owner{})
+	assert.NilError(t, err)
+	owner, err := ecs.GetComponent[Owner](wCtx, id)
+	assert.NilError(t, err)
+	assert.Equal(t, "Jeff", owner.MyName)
+
+	// An explicit value wins over the default.
+	id, err = ecs.Create(wCtx, Owner{"Bob"})
+	assert.NilError(t, err)
+	owner, err = ecs.GetComponent[Owner](wCtx, id)
+	assert.NilError(t, err)
+	assert.Equal(t, "Bob", owner.MyName)
+
+	// Adding the component to an existing entity also starts with the default, even if it was set and removed before.
+	id, err = ecs.Create(wCtx, EnergyComponent{})
+	assert.NilError(t, err)
+	assert.NilError(t, ecs.AddComponentTo[Owner](wCtx, id))
+	owner, err = ecs.GetComponent[Owner](wCtx, id)
+	assert.NilError(t, err)
+	assert.Equal(t, "Jeff", owner.MyName)
+	assert.NilError(t, ecs.SetComponent[Owner](wCtx, id, &Owner{"Alice"}))
+	assert.NilError(t, ecs.RemoveComponentFrom[Owner](wCtx, id))
+	assert.NilError(t, ecs.AddComponentTo[Owner](wCtx, id))
+	owner, err = ecs.GetComponent[Owner](wCtx, id)
+	assert.NilError(t, err)
+	assert.Equal(t, "Jeff", owner.MyName)
+}
+
+func TestCreatedZeroValueCanBeSetAfterCreation(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[Owner](world, component.WithDefault(Owner{"Jeff"})))
+	assert.NilError(t, world.LoadGameState())
+	wCtx := ecs.NewWorldContext(world)
+
+	// Every zero value passed to CreateMany is replaced with the default.
+	ids, err := ecs.CreateMany(wCtx, 3, Owner{})
+	assert.NilError(t, err)
+	for _, id := range ids {
+		owner, err := ecs.GetComponent[Owner](wCtx, id)
+		assert.NilError(t, err)
+		assert.Equal(t, "Jeff", owner.MyName)
+	}
+
+	// An explicit zero value has to be set after the entity is created.
+	assert.NilError(t, ecs.SetComponent[Owner](wCtx, ids[0], &Owner{}))
+	owner, err := ecs.GetComponent[Owner](wCtx, ids[0])
+	assert.NilError(t, err)
+	assert.Equal(t, "", owner.MyName)
+}
+
 type Tuple struct {
 	A, B int
 }
@@ -381,6 +440,245 @@ func TestAddingAComponentThatAlreadyExistsIsError(t *testing.T) {
 	assert.ErrorIs(t, ecs.AddComponentTo[EnergyComponent](wCtx, ent), storage.ErrComponentAlreadyOnEntity)
 }
 
+func TestAddComponentToIfAbsent(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](world))
+	assert.NilError(t, ecs.RegisterComponent[ReactorEnergy](world))
+	assert.NilError(t, world.LoadGameState())
+
+	wCtx := ecs.NewWorldContext(world)
+	ent, err := ecs.Create(wCtx, EnergyComponent{})
+	assert.NilError(t, err)
+
+	added, err := ecs.AddComponentToIfAbsent[EnergyComponent](wCtx, ent)
+	assert.NilError(t, err)
+	assert.Check(t, !added)
+
+	added, err = ecs.AddComponentToIfAbsent[ReactorEnergy](wCtx, ent)
+	assert.NilError(t, err)
+	assert.Check(t, added)
+
+	added, err = ecs.AddComponentToIfAbsent[ReactorEnergy](wCtx, ent)
+	assert.NilError(t, err)
+	assert.Check(t, !added)
+
+	comps, err := wCtx.StoreReader().GetComponentTypesForEntity(ent)
+	assert.NilError(t, err)
+	assert.Equal(t, len(comps), 2)
+}
+
+func TestCreateManyWith(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](world))
+	assert.NilError(t, ecs.RegisterComponent[Owner](world))
+	assert.NilError(t, world.LoadGameState())
+
+	wCtx := ecs.NewWorldContext(world)
+	values := []EnergyComponent{{Amt: 1, Cap: 10}, {Amt: 2, Cap: 20}, {Amt: 3, Cap: 30}}
+	ids, err := ecs.CreateManyWith(wCtx, values, Owner{MyName: "spawner"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(ids), len(values))
+
+	for i, id := range ids {
+		energy, err := ecs.GetComponent[EnergyComponent](wCtx, id)
+		assert.NilError(t, err)
+		assert.Equal(t, *energy, values[i])
+		owner, err := ecs.GetComponent[Owner](wCtx, id)
+		assert.NilError(t, err)
+		assert.Equal(t, owner.MyName, "spawner")
+	}
+
+	ids, err = ecs.CreateManyWith(wCtx, []EnergyComponent{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(ids), 0)
+}
+
+func TestIncrementField(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](world))
+	assert.NilError(t, ecs.RegisterComponent[Owner](world))
+	assert.NilError(t, world.LoadGameState())
+
+	wCtx := ecs.NewWorldContext(world)
+	ent, err := ecs.Create(wCtx, EnergyComponent{Amt: 10, Cap: 100}, Owner{MyName: "foo"})
+	assert.NilError(t, err)
+
+	assert.NilError(t, ecs.IncrementField[EnergyComponent](wCtx, ent, "Amt", 5))
+	assert.NilError(t, ecs.IncrementField[EnergyComponent](wCtx, ent, "Amt", -2))
+	energy, err := ecs.GetComponent[EnergyComponent](wCtx, ent)
+	assert.NilError(t, err)
+	assert.Equal(t, energy.Amt, int64(13))
+	assert.Equal(t, energy.Cap, int64(100))
+
+	err = ecs.IncrementField[EnergyComponent](wCtx, ent, "Missing", 1)
+	assert.ErrorIs(t, err, ecs.ErrFieldNotFound)
+	err = ecs.IncrementField[Owner](wCtx, ent, "MyName", 1)
+	assert.ErrorIs(t, err, ecs.ErrFieldNotNumeric)
+
+	readOnlyCtx := ecs.NewReadOnlyWorldContext(world)
+	err = ecs.IncrementField[EnergyComponent](readOnlyCtx, ent, "Amt", 1)
+	assert.ErrorIs(t, err, ecs.ErrCannotModifyStateWithReadOnlyContext)
+}
+
+type Counters struct {
+	Hits  uint32
+	Level int8
+	Ratio float64
+}
+
+func (Counters) Name() string {
+	return "Counters"
+}
+
+func TestIncrementFieldDoesNotWrapOrTruncate(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[Counters](world))
+	assert.NilError(t, world.LoadGameState())
+
+	wCtx := ecs.NewWorldContext(world)
+	ent, err := ecs.Create(wCtx, Counters{Hits: 3, Level: 120, Ratio: 1})
+	assert.NilError(t, err)
+
+	assert.NilError(t, ecs.IncrementField[Counters](wCtx, ent, "Hits", -3))
+	assert.ErrorIs(t, ecs.IncrementField[Counters](wCtx, ent, "Hits", -1), ecs.ErrFieldOutOfRange)
+	assert.ErrorIs(t, ecs.IncrementField[Counters](wCtx, ent, "Level", 8), ecs.ErrFieldOutOfRange)
+	assert.ErrorIs(t, ecs.IncrementField[Counters](wCtx, ent, "Hits", 1.5), ecs.ErrFractionalDelta)
+	// Whole floats can still be added to integer fields, and fractions to float fields.
+	assert.NilError(t, ecs.IncrementField[Counters](wCtx, ent, "Level", -20.0))
+	assert.NilError(t, ecs.IncrementField[Counters](wCtx, ent, "Ratio", 0.5))
+
+	counters, err := ecs.GetComponent[Counters](wCtx, ent)
+	assert.NilError(t, err)
+	assert.Equal(t, *counters, Counters{Hits: 0, Level: 100, Ratio: 1.5})
+}
+
+func TestSwapComponent(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](world))
+	assert.NilError(t, ecs.RegisterComponent[Owner](world))
+	assert.NilError(t, world.LoadGameState())
+
+	wCtx := ecs.NewWorldContext(world)
+	alpha, err := ecs.Create(wCtx, EnergyComponent{Amt: 1, Cap: 10}, Owner{MyName: "alpha"})
+	assert.NilError(t, err)
+	beta, err := ecs.Create(wCtx, EnergyComponent{Amt: 2, Cap: 20})
+	assert.NilError(t, err)
+
+	assert.NilError(t, ecs.SwapComponent[EnergyComponent](wCtx, alpha, beta))
+	energy, err := ecs.GetComponent[EnergyComponent](wCtx, alpha)
+	assert.NilError(t, err)
+	assert.Equal(t, *energy, EnergyComponent{Amt: 2, Cap: 20})
+	energy, err = ecs.GetComponent[EnergyComponent](wCtx, beta)
+	assert.NilError(t, err)
+	assert.Equal(t, *energy, EnergyComponent{Amt: 1, Cap: 10})
+
+	// beta has no Owner, so nothing is swapped.
+	err = ecs.SwapComponent[Owner](wCtx, alpha, beta)
+	assert.ErrorIs(t, err, storage.ErrComponentNotOnEntity)
+	owner, err := ecs.GetComponent[Owner](wCtx, alpha)
+	assert.NilError(t, err)
+	assert.Equal(t, owner.MyName, "alpha")
+
+	readOnlyCtx := ecs.NewReadOnlyWorldContext(world)
+	err = ecs.SwapComponent[EnergyComponent](readOnlyCtx, alpha, beta)
+	assert.ErrorIs(t, err, ecs.ErrCannotModifyStateWithReadOnlyContext)
+}
+
+func TestEveryMutationFailsInReadOnlyContexts(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](world))
+	assert.NilError(t, ecs.RegisterComponent[Owner](world))
+	assert.NilError(t, world.LoadGameState())
+	id, err := ecs.Create(ecs.NewWorldContext(world), EnergyComponent{Amt: 1, Cap: 10})
+	assert.NilError(t, err)
+	other, err := ecs.Create(ecs.NewWorldContext(world), EnergyComponent{Amt: 2, Cap: 20})
+	assert.NilError(t, err)
+	// Read only contexts read the committed state.
+	assert.NilError(t, world.Tick(context.Background()))
+
+	mutations := map[string]func(ecs.WorldContext) error{
+		"Create": func(wCtx ecs.WorldContext) error {
+			_, err := ecs.Create(wCtx, EnergyComponent{})
+			return err
+		},
+		"CreateManyWith": func(wCtx ecs.WorldContext) error {
+			_, err := ecs.CreateManyWith(wCtx, []EnergyComponent{{Amt: 1}})
+			return err
+		},
+		"SetComponent": func(wCtx ecs.WorldContext) error {
+			return ecs.SetComponent[EnergyComponent](wCtx, id, &EnergyComponent{Amt: 99})
+		},
+		"UpdateComponent": func(wCtx ecs.WorldContext) error {
+			return ecs.UpdateComponent[EnergyComponent](wCtx, id, func(e *EnergyComponent) *EnergyComponent {
+				e.Amt = 99
+				return e
+			})
+		},
+		"AddComponentTo": func(wCtx ecs.WorldContext) error {
+			return ecs.AddComponentTo[Owner](wCtx, id)
+		},
+		"RemoveComponentFrom": func(wCtx ecs.WorldContext) error {
+			return ecs.RemoveComponentFrom[EnergyComponent](wCtx, id)
+		},
+		"IncrementField": func(wCtx ecs.WorldContext) error {
+			return ecs.IncrementField[EnergyComponent](wCtx, id, "Amt", 1)
+		},
+		"SwapComponent": func(wCtx ecs.WorldContext) error {
+			return ecs.SwapComponent[EnergyComponent](wCtx, id, other)
+		},
+		"Remove": func(wCtx ecs.WorldContext) error {
+			return ecs.Remove(wCtx, id)
+		},
+		"SetExternalKey": func(wCtx ecs.WorldContext) error {
+			return wCtx.SetExternalKey(id, "key")
+		},
+	}
+	for name, mutate := range mutations {
+		err = mutate(ecs.NewReadOnlyWorldContext(world))
+		assert.Check(t, errors.Is(eris.Cause(err), ecs.ErrCannotModifyStateWithReadOnlyContext), name)
+	}
+
+	// None of the mutations changed the world.
+	energy, err := ecs.GetComponent[EnergyComponent](ecs.NewReadOnlyWorldContext(world), id)
+	assert.NilError(t, err)
+	assert.Equal(t, *energy, EnergyComponent{Amt: 1, Cap: 10})
+	count, err := world.EntityCount()
+	assert.NilError(t, err)
+	assert.Equal(t, count, 2)
+}
+
+func TestReadOnlyFailsIfAChangeWasAttempted(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](world))
+	assert.NilError(t, world.LoadGameState())
+	wCtx := ecs.NewWorldContext(world)
+	id, err := ecs.Create(wCtx, EnergyComponent{Amt: 1, Cap: 10})
+	assert.NilError(t, err)
+
+	// Reads are allowed, and see the changes of the given context that have not been committed yet.
+	var amt int64
+	err = ecs.ReadOnly(wCtx, func(view ecs.WorldContext) error {
+		energy, err := ecs.GetComponent[EnergyComponent](view, id)
+		if err != nil {
+			return err
+		}
+		amt = energy.Amt
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, amt, int64(1))
+
+	// A change fails even though the writable context was given, and even if the error is ignored.
+	err = ecs.ReadOnly(wCtx, func(view ecs.WorldContext) error {
+		_ = ecs.SetComponent[EnergyComponent](view, id, &EnergyComponent{Amt: 99})
+		return nil
+	})
+	assert.ErrorIs(t, err, ecs.ErrCannotModifyStateWithReadOnlyContext)
+	energy, err := ecs.GetComponent[EnergyComponent](wCtx, id)
+	assert.NilError(t, err)
+	assert.Equal(t, energy.Amt, int64(1))
+}
+
 type ReactorEnergy struct {
 	Amt int64
 	Cap int64
@@ -751,3 +1049,111 @@ func TestEntityCreationAndSetting(t *testing.T) {
 		assert.Equal(t, y.Val, 999)
 	}
 }
+
+type ShieldComponent struct {
+	Strength int
+}
+
+func (ShieldComponent) Name() string {
+	return "shield"
+}
+
+func TestComponentsWithTTLExpireEvenAfterARestart(t *testing.T) {
+	rs := miniredis.RunT(t)
+	ctx := context.Background()
+	newWorld := func() *ecs.World {
+		world := testutils.NewTestWorldWithCustomRedis(t, rs).Instance()
+		assert.NilError(t, ecs.RegisterComponent[ShieldComponent](world, component.WithTTL[ShieldComponent](3)))
+		assert.NilError(t, ecs.RegisterComponent[EnergyComponent](world))
+		assert.NilError(t, world.LoadGameState())
+		return world
+	}
+
+	world := newWorld()
+	wCtx := ecs.NewWorldContext(world)
+	shieldedID, err := ecs.Create(wCtx, EnergyComponent{}, ShieldComponent{Strength: 10})
+	assert.NilError(t, err)
+	shieldOnlyID, err := ecs.Create(wCtx, ShieldComponent{Strength: 5})
+	assert.NilError(t, err)
+	assert.NilError(t, world.Tick(ctx))
+	// The expiries are tracked with entities that the game does not see.
+	search, err := world.NewSearch(ecs.All())
+	assert.NilError(t, err)
+	count, err := search.Count(ecs.NewReadOnlyWorldContext(world))
+	assert.NilError(t, err)
+	assert.Equal(t, 2, count)
+
+	// The pending expiries were saved with the game state, so a restarted world still removes the shields.
+	world = newWorld()
+	wCtx = ecs.NewWorldContext(world)
+	hasShield := func(id entity.ID) bool {
+		_, err := ecs.GetComponent[ShieldComponent](wCtx, id)
+		return err == nil
+	}
+	assert.NilError(t, world.Tick(ctx))
+	assert.NilError(t, world.Tick(ctx))
+	assert.Check(t, hasShield(shieldedID))
+
+	assert.NilError(t, world.Tick(ctx))
+	assert.Check(t, !hasShield(shieldedID))
+	_, err = ecs.GetComponent[EnergyComponent](wCtx, shieldedID)
+	assert.NilError(t, err)
+	// The shield was the only component on this entity, so the whole entity is removed.
+	_, err = wCtx.StoreReader().GetComponentTypesForEntity(shieldOnlyID)
+	assert.Check(t, err != nil)
+}
+
+func TestExternalKeysSurviveARestart(t *testing.T) {
+	rs := miniredis.RunT(t)
+	ctx := context.Background()
+	newWorld := func() *ecs.World {
+		world := testutils.NewTestWorldWithCustomRedis(t, rs).Instance()
+		assert.NilError(t, ecs.RegisterComponent[EnergyComponent](world))
+		assert.NilError(t, world.LoadGameState())
+		return world
+	}
+
+	world := newWorld()
+	wCtx := ecs.NewWorldContext(world)
+	swordID, err := ecs.Create(wCtx, EnergyComponent{})
+	assert.NilError(t, err)
+	shieldID, err := ecs.Create(wCtx, EnergyComponent{})
+	assert.NilError(t, err)
+	assert.NilError(t, wCtx.SetExternalKey(swordID, "item:sword"))
+	assert.NilError(t, wCtx.SetExternalKey(shieldID, "item:shield"))
+	assert.ErrorIs(t, wCtx.SetExternalKey(shieldID, "item:sword"), ecs.ErrExternalKeyInUse)
+	assert.NilError(t, world.Tick(ctx))
+
+	world = newWorld()
+	wCtx = ecs.NewWorldContext(world)
+	id, ok := wCtx.LookupByExternalKey("item:sword")
+	assert.Check(t, ok)
+	assert.Equal(t, swordID, id)
+	_, ok = wCtx.LookupByExternalKey("item:axe")
+	assert.Check(t, !ok)
+
+	// Tagged entities keep their archetype, so searches for their components are unaffected.
+	search, err := world.NewSearch(ecs.Exact(EnergyComponent{}))
+	assert.NilError(t, err)
+	count, err := search.Count(wCtx)
+	assert.NilError(t, err)
+	assert.Equal(t, 2, count)
+	// The key records themselves are hidden from the game.
+	search, err = world.NewSearch(ecs.All())
+	assert.NilError(t, err)
+	count, err = search.Count(wCtx)
+	assert.NilError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Giving an entity a new key releases its old one, and keys of removed entities can be reused.
+	assert.NilError(t, wCtx.SetExternalKey(shieldID, "item:shield-v2"))
+	_, ok = wCtx.LookupByExternalKey("item:shield")
+	assert.Check(t, !ok)
+	assert.NilError(t, world.Remove(swordID))
+	_, ok = wCtx.LookupByExternalKey("item:sword")
+	assert.Check(t, !ok)
+	assert.NilError(t, wCtx.SetExternalKey(shieldID, "item:sword"))
+	id, ok = wCtx.LookupByExternalKey("item:sword")
+	assert.Check(t, ok)
+	assert.Equal(t, shieldID, id)
+}
@@ -1,13 +1,37 @@
 package ecs
 
 import (
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
 	"strconv"
 
 	"github.com/rotisserie/eris"
+	"pkg.world.dev/world-engine/cardinal/ecs/storage"
 	"pkg.world.dev/world-engine/cardinal/types/component"
 	"pkg.world.dev/world-engine/cardinal/types/entity"
 )
 
+var (
+	ErrFieldNotFound   = errors.New("component does not have an exported field with the given name")
+	ErrFieldNotNumeric = errors.New("component field is not numeric")
+	// ErrFieldOutOfRange is returned by IncrementField when the incremented value does not fit in the field, e.g. when
+	// an unsigned field would drop below zero.
+	ErrFieldOutOfRange = errors.New("incremented value does not fit in the component field")
+	// ErrFractionalDelta is returned by IncrementField when a delta with a fractional part is added to an integer field.
+	ErrFractionalDelta = errors.New("fractional delta can not be added to an integer field")
+)
+
+// Number is the set of types that can be used as the delta in IncrementField.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// Create creates a single entity with the given components. Zero valued components start with their registered
+// default, as in CreateMany.
 func Create(wCtx WorldContext, components ...component.Component) (entity.ID, error) {
 	entities, err := CreateMany(wCtx, 1, components...)
 	if err != nil {
@@ -16,10 +40,19 @@ func Create(wCtx WorldContext, components ...component.Component) (entity.ID, er
 	return entities[0], nil
 }
 
+// CreateMany creates num entities with the given components. Components passed as their zero value start with the
+// default value they were registered with, if any, so a component with a default can not be created as its zero value.
+// Use SetComponent after creating the entity to store an explicit zero value.
 func CreateMany(wCtx WorldContext, num int, components ...component.Component) ([]entity.ID, error) {
-	if wCtx.IsReadOnly() {
-		return nil, eris.Wrap(ErrCannotModifyStateWithReadOnlyContext, "")
+	if err := checkWritable(wCtx); err != nil {
+		return nil, err
 	}
+	return createManyEntities(wCtx, num, withCreatedAt(wCtx, components)...)
+}
+
+// createManyEntities is identical to CreateMany, but does not add CreatedAt to the entities. It is used for the
+// entities the world creates for its own bookkeeping.
+func createManyEntities(wCtx WorldContext, num int, components ...component.Component) ([]entity.ID, error) {
 	world := wCtx.GetWorld()
 	acc := make([]component.ComponentMetadata, 0, len(components))
 	for _, comp := range components {
@@ -33,27 +66,84 @@ func CreateMany(wCtx WorldContext, num int, components ...component.Component) (
 	if err != nil {
 		return nil, err
 	}
+	values := make([]any, len(components))
+	for i, comp := range components {
+		values[i] = comp
+		// A zero value is treated as "no value given", so the component starts with its registered default.
+		if reflect.ValueOf(comp).IsZero() {
+			if values[i], err = newDefaultComponentValue(acc[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
 	for _, id := range entityIds {
-		for _, comp := range components {
+		for i, comp := range components {
 			var c component.ComponentMetadata
 			c, err = world.GetComponentByName(comp.Name())
 			if err != nil {
 				return nil, eris.Wrap(err, "must register component before creating an entity")
 			}
-			err = world.StoreManager().SetComponentForEntity(c, id, comp)
+			err = world.StoreManager().SetComponentForEntity(c, id, values[i])
 			if err != nil {
 				return nil, err
 			}
 		}
 	}
+	if err = trackComponentExpiries(wCtx, entityIds, acc); err != nil {
+		return nil, err
+	}
 	wCtx.GetWorld().SetEntitiesCreated(true)
 	return entityIds, nil
 }
 
+// CreateManyWith creates one entity per value in values, setting each entity's component of type T to its
+// corresponding value. Any extra components are added to every entity with the same value, as in CreateMany. The
+// returned IDs are in the same order as values.
+func CreateManyWith[T component.Component](wCtx WorldContext, values []T, components ...component.Component) (
+	[]entity.ID, error,
+) {
+	if err := checkWritable(wCtx); err != nil {
+		return nil, err
+	}
+	return createManyWith(wCtx, values, withCreatedAt(wCtx, components)...)
+}
+
+// createManyWith is identical to CreateManyWith, but does not add CreatedAt to the entities.
+func createManyWith[T component.Component](wCtx WorldContext, values []T, components ...component.Component) (
+	[]entity.ID, error,
+) {
+	if len(values) == 0 {
+		return []entity.ID{}, nil
+	}
+	var t T
+	ids, err := createManyEntities(wCtx, len(values), append([]component.Component{t}, components...)...)
+	if err != nil {
+		return nil, err
+	}
+	c, err := wCtx.GetWorld().GetComponentByName(t.Name())
+	if err != nil {
+		return nil, eris.Wrap(err, "must register component")
+	}
+	for i, id := range ids {
+		if err = wCtx.StoreManager().SetComponentForEntity(c, id, values[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// Remove removes the given entity from the world.
+func Remove(wCtx WorldContext, id entity.ID) error {
+	if err := checkWritable(wCtx); err != nil {
+		return err
+	}
+	return wCtx.GetWorld().Remove(id)
+}
+
 // RemoveComponentFrom removes a component from an entity.
 func RemoveComponentFrom[T component.Component](wCtx WorldContext, id entity.ID) error {
-	if wCtx.IsReadOnly() {
-		return eris.Wrap(ErrCannotModifyStateWithReadOnlyContext, "")
+	if err := checkWritable(wCtx); err != nil {
+		return err
 	}
 	w := wCtx.GetWorld()
 	var t T
@@ -65,9 +155,11 @@ func RemoveComponentFrom[T component.Component](wCtx WorldContext, id entity.ID)
 	return w.StoreManager().RemoveComponentFromEntity(c, id)
 }
 
+// AddComponentTo adds a component to an entity. The component starts with the default value it was registered with,
+// or the zero value if it has none.
 func AddComponentTo[T component.Component](wCtx WorldContext, id entity.ID) error {
-	if wCtx.IsReadOnly() {
-		return eris.Wrap(ErrCannotModifyStateWithReadOnlyContext, "")
+	if err := checkWritable(wCtx); err != nil {
+		return err
 	}
 	w := wCtx.GetWorld()
 	var t T
@@ -76,7 +168,40 @@ func AddComponentTo[T component.Component](wCtx WorldContext, id entity.ID) erro
 	if err != nil {
 		return eris.Wrap(err, "must register component")
 	}
-	return w.StoreManager().AddComponentToEntity(c, id)
+	if err = w.StoreManager().AddComponentToEntity(c, id); err != nil {
+		return err
+	}
+	// Set the default explicitly so a value left behind by a previous removal of this component is never read back.
+	defaultVal, err := newDefaultComponentValue(c)
+	if err != nil {
+		return err
+	}
+	if err = w.StoreManager().SetComponentForEntity(c, id, defaultVal); err != nil {
+		return err
+	}
+	return trackComponentExpiries(wCtx, []entity.ID{id}, []component.ComponentMetadata{c})
+}
+
+// newDefaultComponentValue returns the default value of the given component type.
+func newDefaultComponentValue(c component.ComponentMetadata) (any, error) {
+	bz, err := c.New()
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(bz)
+}
+
+// AddComponentToIfAbsent adds a component to an entity if the entity does not already have it. added is false
+// if the component was already on the entity, in which case the entity is left unchanged.
+func AddComponentToIfAbsent[T component.Component](wCtx WorldContext, id entity.ID) (added bool, err error) {
+	err = AddComponentTo[T](wCtx, id)
+	if eris.Is(eris.Cause(err), storage.ErrComponentAlreadyOnEntity) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // GetComponent returns component data from the entity.
@@ -106,8 +231,8 @@ func GetComponent[T component.Component](wCtx WorldContext, id entity.ID) (comp
 
 // SetComponent sets component data to the entity.
 func SetComponent[T component.Component](wCtx WorldContext, id entity.ID, component *T) error {
-	if wCtx.IsReadOnly() {
-		return eris.Wrap(ErrCannotModifyStateWithReadOnlyContext, "")
+	if err := checkWritable(wCtx); err != nil {
+		return err
 	}
 	var t T
 	name := t.Name()
@@ -128,8 +253,8 @@ func SetComponent[T component.Component](wCtx WorldContext, id entity.ID, compon
 }
 
 func UpdateComponent[T component.Component](wCtx WorldContext, id entity.ID, fn func(*T) *T) error {
-	if wCtx.IsReadOnly() {
-		return eris.Wrap(ErrCannotModifyStateWithReadOnlyContext, "")
+	if err := checkWritable(wCtx); err != nil {
+		return err
 	}
 	val, err := GetComponent[T](wCtx, id)
 	if err != nil {
@@ -138,3 +263,96 @@ func UpdateComponent[T component.Component](wCtx WorldContext, id entity.ID, fn
 	updatedVal := fn(val)
 	return SetComponent[T](wCtx, id, updatedVal)
 }
+
+// SwapComponent exchanges the values of the component of type T between the two given entities, e.g. to let two players
+// trade positions. An error is returned, and neither entity is changed, if either entity lacks the component.
+func SwapComponent[T component.Component](wCtx WorldContext, a, b entity.ID) error {
+	if err := checkWritable(wCtx); err != nil {
+		return err
+	}
+	compA, err := GetComponent[T](wCtx, a)
+	if err != nil {
+		return err
+	}
+	compB, err := GetComponent[T](wCtx, b)
+	if err != nil {
+		return err
+	}
+	valueA, valueB := *compA, *compB
+	if err = SetComponent[T](wCtx, a, &valueB); err != nil {
+		return err
+	}
+	if err = SetComponent[T](wCtx, b, &valueA); err != nil {
+		// Put the first value back so a failed swap leaves both entities unchanged.
+		if restoreErr := SetComponent[T](wCtx, a, &valueA); restoreErr != nil {
+			return errors.Join(err, restoreErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// IncrementField adds delta to the numeric field called fieldName on the entity's component of type T. This is a
+// shorthand for the common get-modify-set pattern on counters (health, gold, score, etc.). ErrFieldNotNumeric is
+// returned if the field is not an integer or float. For integer fields, ErrFractionalDelta is returned if delta has a
+// fractional part, and ErrFieldOutOfRange if the result does not fit in the field instead of wrapping around.
+func IncrementField[T component.Component, N Number](wCtx WorldContext, id entity.ID, fieldName string, delta N) error {
+	if err := checkWritable(wCtx); err != nil {
+		return err
+	}
+	comp, err := GetComponent[T](wCtx, id)
+	if err != nil {
+		return err
+	}
+	field := reflect.ValueOf(comp).Elem().FieldByName(fieldName)
+	if !field.IsValid() || !field.CanSet() {
+		return eris.Wrapf(ErrFieldNotFound, "field %q on component %q", fieldName, (*comp).Name())
+	}
+	switch field.Kind() { //nolint:exhaustive // all other kinds are not numeric
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		sum, err := integerDelta(delta)
+		if err != nil {
+			return eris.Wrapf(err, "field %q on component %q", fieldName, (*comp).Name())
+		}
+		sum.Add(sum, big.NewInt(field.Int()))
+		if !sum.IsInt64() || field.OverflowInt(sum.Int64()) {
+			return eris.Wrapf(ErrFieldOutOfRange, "field %q on component %q", fieldName, (*comp).Name())
+		}
+		field.SetInt(sum.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		sum, err := integerDelta(delta)
+		if err != nil {
+			return eris.Wrapf(err, "field %q on component %q", fieldName, (*comp).Name())
+		}
+		sum.Add(sum, new(big.Int).SetUint64(field.Uint()))
+		if !sum.IsUint64() || field.OverflowUint(sum.Uint64()) {
+			return eris.Wrapf(ErrFieldOutOfRange, "field %q on component %q", fieldName, (*comp).Name())
+		}
+		field.SetUint(sum.Uint64())
+	case reflect.Float32, reflect.Float64:
+		field.SetFloat(field.Float() + float64(delta))
+	default:
+		return eris.Wrapf(ErrFieldNotNumeric, "field %q on component %q has kind %s",
+			fieldName, (*comp).Name(), field.Kind())
+	}
+	return SetComponent[T](wCtx, id, comp)
+}
+
+// integerDelta returns the given delta as an integer, so it can be added to integer fields of any size and sign
+// without wrapping around.
+func integerDelta[N Number](delta N) (*big.Int, error) {
+	v := reflect.ValueOf(delta)
+	switch v.Kind() { //nolint:exhaustive // Number only has integer and float kinds
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) || f != math.Trunc(f) {
+			return nil, eris.Wrapf(ErrFractionalDelta, "delta %v", f)
+		}
+		i, _ := big.NewFloat(f).Int(nil)
+		return i, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(v.Uint()), nil
+	default:
+		return big.NewInt(v.Int()), nil
+	}
+}
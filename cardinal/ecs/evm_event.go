@@ -0,0 +1,120 @@
+package ecs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// evmEventPendingTicks is the number of committed ticks whose EVM events may wait for the handler. The events of
+	// further ticks are dropped while the handler is behind, so a slow handler never holds up the game loop.
+	evmEventPendingTicks = 64
+	// evmEventShutdownTimeout is how long Shutdown waits for the handler to finish the queued events.
+	evmEventShutdownTimeout = 5 * time.Second
+)
+
+// EVMEvent is an event emitted by a system with EmitEVMEvent.
+type EVMEvent struct {
+	Tick  uint64
+	Topic string
+	Data  []byte
+}
+
+// EVMEventHandler receives the EVM events of a tick once the tick has been committed. See WithEVMEventHandler.
+type EVMEventHandler func(events []EVMEvent)
+
+// evmEventDispatcher calls the EVM event handler from a goroutine of its own, in the order the ticks were committed.
+type evmEventDispatcher struct {
+	handler EVMEventHandler
+	events  chan []EVMEvent
+	done    chan struct{}
+	mutex   sync.Mutex
+	closed  bool
+}
+
+func newEVMEventDispatcher(handler EVMEventHandler) *evmEventDispatcher {
+	d := &evmEventDispatcher{
+		handler: handler,
+		events:  make(chan []EVMEvent, evmEventPendingTicks),
+		done:    make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+func (d *evmEventDispatcher) run() {
+	defer close(d.done)
+	for events := range d.events {
+		d.handler(events)
+	}
+}
+
+// send queues the events of a tick for the handler. It never blocks.
+func (d *evmEventDispatcher) send(events []EVMEvent) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.closed {
+		return
+	}
+	select {
+	case d.events <- events:
+	default:
+		log.Warn().Uint64("tick", events[0].Tick).Int("events", len(events)).
+			Msg("EVM event handler is not keeping up, dropping the events of the tick")
+	}
+}
+
+// close stops accepting events and waits up to evmEventShutdownTimeout for the handler to finish the queued ones.
+func (d *evmEventDispatcher) close() {
+	d.mutex.Lock()
+	if d.closed {
+		d.mutex.Unlock()
+		return
+	}
+	d.closed = true
+	close(d.events)
+	d.mutex.Unlock()
+	select {
+	case <-d.done:
+	case <-time.After(evmEventShutdownTimeout):
+		log.Warn().Msg("EVM event handler did not finish the queued events before shutdown")
+	}
+}
+
+// EmitEVMEvent queues an event that is handed to the handler given with WithEVMEventHandler once the current tick has
+// been committed. The world does not submit the event to the chain itself; that is up to the handler. Events of a tick
+// that fails are discarded, and events are not handed out again while the world is recovering, so every event is
+// delivered at most once. Without a handler, events are dropped.
+func (w *worldContext) EmitEVMEvent(topic string, data []byte) error {
+	if err := checkWritable(w); err != nil {
+		return err
+	}
+	if w.world.evmEvents == nil {
+		return nil
+	}
+	w.world.pendingEVMEvents = append(w.world.pendingEVMEvents, EVMEvent{
+		Tick:  w.CurrentTick(),
+		Topic: topic,
+		Data:  append([]byte(nil), data...),
+	})
+	return nil
+}
+
+// deliverEVMEvents queues the EVM events of the tick that was just committed for the EVM event handler.
+func (w *World) deliverEVMEvents() {
+	events := w.pendingEVMEvents
+	w.pendingEVMEvents = nil
+	if len(events) == 0 || w.evmEvents == nil || w.IsRecovering() {
+		return
+	}
+	w.evmEvents.send(events)
+}
+
+// closeEVMEvents waits for the EVM event handler to finish the events of the committed ticks.
+func (w *World) closeEVMEvents() {
+	if w.evmEvents != nil {
+		w.evmEvents.close()
+	}
+}
@@ -0,0 +1,168 @@
+package ecs
+
+import (
+	"errors"
+
+	"github.com/rotisserie/eris"
+	"pkg.world.dev/world-engine/cardinal/types/entity"
+)
+
+var ErrExternalKeyInUse = errors.New("external key is already in use by another entity")
+
+// externalKey maps a stable, game defined key (e.g. the catalog ID of an item) to an entity. Keys are stored as
+// entities of their own, so they are saved and recovered along with the rest of the game state without changing the
+// archetype of the tagged entity.
+type externalKey struct {
+	Key      string
+	EntityID entity.ID
+}
+
+func (externalKey) Name() string {
+	return "ExternalKey"
+}
+
+// externalKeyIndex maps keys, and the entities tagged with them, to the entity of their externalKey record. It is
+// built from the stored records the first time a key is used, and dropped whenever uncommitted state is discarded.
+// An entry may point at a record that is not visible to the reader of a context, so every entry is checked against
+// the stored record before it is used.
+type externalKeyIndex struct {
+	byKey    map[string]entity.ID
+	byEntity map[entity.ID]entity.ID
+}
+
+// SetExternalKey tags the given entity with the given key, so it can be found with LookupByExternalKey. An entity has
+// at most one key: setting a new key replaces the old one. An error is returned if the key is already used by another
+// entity that still exists.
+func (w *worldContext) SetExternalKey(id entity.ID, key string) error {
+	if err := checkWritable(w); err != nil {
+		return err
+	}
+	if _, err := w.StoreReader().GetComponentTypesForEntity(id); err != nil {
+		return err
+	}
+	world := w.world
+	world.externalKeyMutex.Lock()
+	defer world.externalKeyMutex.Unlock()
+	index, err := world.getExternalKeyIndex(w)
+	if err != nil {
+		return err
+	}
+	if recordID, ok := index.byKey[key]; ok {
+		if record, ok := w.getExternalKeyRecord(recordID); ok && record.Key == key {
+			if record.EntityID == id {
+				return nil
+			}
+			if w.entityExists(record.EntityID) {
+				return eris.Wrapf(ErrExternalKeyInUse, "key %q is used by entity %d", key, record.EntityID)
+			}
+		}
+		// The key belonged to an entity that has been removed.
+		if err = w.removeExternalKeyRecord(index, recordID); err != nil {
+			return err
+		}
+		delete(index.byKey, key)
+	}
+	if recordID, ok := index.byEntity[id]; ok {
+		// Drop the old key of the entity.
+		if err = w.removeExternalKeyRecord(index, recordID); err != nil {
+			return err
+		}
+		delete(index.byEntity, id)
+	}
+	recordID, err := create(w, externalKey{Key: key, EntityID: id})
+	if err != nil {
+		return err
+	}
+	index.byKey[key] = recordID
+	index.byEntity[id] = recordID
+	return nil
+}
+
+// LookupByExternalKey returns the entity that was tagged with the given key by SetExternalKey. False is returned if
+// no entity has the key, or if the entity has been removed.
+func (w *worldContext) LookupByExternalKey(key string) (entity.ID, bool) {
+	world := w.world
+	world.externalKeyMutex.Lock()
+	defer world.externalKeyMutex.Unlock()
+	index, err := world.getExternalKeyIndex(w)
+	if err != nil {
+		w.Logger().Error().Err(err).Msgf("failed to look up external key %q", key)
+		return 0, false
+	}
+	recordID, ok := index.byKey[key]
+	if !ok {
+		return 0, false
+	}
+	record, ok := w.getExternalKeyRecord(recordID)
+	if !ok || record.Key != key || !w.entityExists(record.EntityID) {
+		return 0, false
+	}
+	return record.EntityID, true
+}
+
+func (w *worldContext) entityExists(id entity.ID) bool {
+	_, err := w.StoreReader().GetComponentTypesForEntity(id)
+	return err == nil
+}
+
+// getExternalKeyRecord returns the externalKey record stored on the given entity, if it is visible to this context.
+func (w *worldContext) getExternalKeyRecord(recordID entity.ID) (*externalKey, bool) {
+	record, err := GetComponent[externalKey](w, recordID)
+	if err != nil {
+		return nil, false
+	}
+	return record, true
+}
+
+// removeExternalKeyRecord removes the given record, if it still exists, and drops it from the index.
+func (w *worldContext) removeExternalKeyRecord(index *externalKeyIndex, recordID entity.ID) error {
+	record, ok := w.getExternalKeyRecord(recordID)
+	if !ok {
+		return nil
+	}
+	if err := w.world.Remove(recordID); err != nil {
+		return err
+	}
+	if index.byKey[record.Key] == recordID {
+		delete(index.byKey, record.Key)
+	}
+	if index.byEntity[record.EntityID] == recordID {
+		delete(index.byEntity, record.EntityID)
+	}
+	return nil
+}
+
+// getExternalKeyIndex returns the index of the external keys, building it from the stored records if needed. The
+// caller must hold externalKeyMutex.
+func (w *World) getExternalKeyIndex(wCtx WorldContext) (*externalKeyIndex, error) {
+	if w.externalKeys != nil {
+		return w.externalKeys, nil
+	}
+	search, err := w.newInternalSearch(Exact(externalKey{}))
+	if err != nil {
+		return nil, err
+	}
+	index := &externalKeyIndex{
+		byKey:    map[string]entity.ID{},
+		byEntity: map[entity.ID]entity.ID{},
+	}
+	var eachErr error
+	err = search.Each(wCtx, func(id entity.ID) bool {
+		record, err := GetComponent[externalKey](wCtx, id)
+		if err != nil {
+			eachErr = err
+			return false
+		}
+		index.byKey[record.Key] = id
+		index.byEntity[record.EntityID] = id
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if eachErr != nil {
+		return nil, eachErr
+	}
+	w.externalKeys = index
+	return index, nil
+}
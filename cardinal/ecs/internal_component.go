@@ -0,0 +1,65 @@
+package ecs
+
+import (
+	"pkg.world.dev/world-engine/cardinal/types/component"
+)
+
+// The components the world registers for its own features have fixed IDs far above the IDs of the game's components.
+// Component IDs are persisted, so registering these components in NewWorld, or enabling a feature on an existing
+// world, must never change the IDs of the game's components. New internal components must be appended to the end of
+// this list, and existing ones must never be reordered or removed.
+const internalComponentIDBase component.TypeID = 1 << 30
+
+const (
+	scheduledMessageComponentID = internalComponentIDBase + iota
+	componentExpiryComponentID
+	externalKeyComponentID
+	personaDisplayNameComponentID
+	createdAtComponentID
+)
+
+// registerInternalComponent registers a component the world uses for its own bookkeeping with the given fixed ID.
+// Entities with an internal component are hidden from the searches, the manifest and the component list of the game.
+func registerInternalComponent[T component.Component](world *World, id component.TypeID) error {
+	registered, err := registerComponent[T](world, id)
+	if err != nil || !registered {
+		return err
+	}
+	if world.internalComponents == nil {
+		world.internalComponents = map[component.TypeID]bool{}
+	}
+	world.internalComponents[id] = true
+	return nil
+}
+
+// hasInternalComponent reports whether any of the given components is an internal component.
+func (w *World) hasInternalComponent(components []component.ComponentMetadata) bool {
+	for _, c := range components {
+		if w.internalComponents[c.ID()] {
+			return true
+		}
+	}
+	return false
+}
+
+// newInternalSearch is identical to NewSearch, but the search also finds entities with internal components.
+func (w *World) newInternalSearch(filter Filterable) (*Search, error) {
+	search, err := w.NewSearch(filter)
+	if err != nil {
+		return nil, err
+	}
+	search.includeInternal = true
+	return search, nil
+}
+
+// dropRecordIndexes makes the next use of the in-memory indexes of internal records rebuild them from the stored
+// records. It must be called whenever uncommitted state is discarded, because the indexes may refer to records that
+// no longer exist, or be missing records that exist again.
+func (w *World) dropRecordIndexes() {
+	w.externalKeyMutex.Lock()
+	w.externalKeys = nil
+	w.externalKeyMutex.Unlock()
+	w.displayNameMutex.Lock()
+	w.displayNames = nil
+	w.displayNameMutex.Unlock()
+}
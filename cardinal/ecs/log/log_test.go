@@ -8,11 +8,13 @@ import (
 	"testing"
 	"time"
 
+	"pkg.world.dev/world-engine/cardinal"
 	"pkg.world.dev/world-engine/cardinal/testutils"
 
 	"pkg.world.dev/world-engine/assert"
 
 	"github.com/rs/zerolog"
+	zerologlog "github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/require"
 	"pkg.world.dev/world-engine/cardinal/ecs"
 	"pkg.world.dev/world-engine/cardinal/ecs/log"
@@ -284,3 +286,12 @@ func TestWorldLogger(t *testing.T) {
 			}`, entityCreationStrings[1],
 	)
 }
+
+func TestPrettyLogCanBeTurnedOffInDevelopmentMode(t *testing.T) {
+	t.Setenv("CARDINAL_MODE", cardinal.ModeDev)
+	prettyWorld := testutils.NewTestWorld(t).Instance()
+	assert.Check(t, prettyWorld.Logger.Logger != &zerologlog.Logger)
+
+	jsonWorld := testutils.NewTestWorld(t, cardinal.WithPrettyLog(false)).Instance()
+	assert.Check(t, jsonWorld.Logger.Logger == &zerologlog.Logger)
+}
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/invopop/jsonschema"
 	"github.com/rotisserie/eris"
 	"pkg.world.dev/world-engine/cardinal/types/message"
 
 	ethereumAbi "github.com/ethereum/go-ethereum/accounts/abi"
 	"pkg.world.dev/world-engine/cardinal/ecs/abi"
 	"pkg.world.dev/world-engine/cardinal/ecs/codec"
+	"pkg.world.dev/world-engine/cardinal/ecs/receipt"
 	"pkg.world.dev/world-engine/sign"
 )
 
@@ -19,6 +21,8 @@ var (
 )
 
 var _ message.Message = &MessageType[struct{}, struct{}]{}
+var _ message.SchemaProvider = &MessageType[struct{}, struct{}]{}
+var _ message.Validator = &MessageType[struct{}, struct{}]{}
 
 // MessageType manages a user defined state transition message struct.
 type MessageType[In, Out any] struct {
@@ -27,6 +31,7 @@ type MessageType[In, Out any] struct {
 	name       string
 	inEVMType  *ethereumAbi.Type
 	outEVMType *ethereumAbi.Type
+	validator  func(In) error
 }
 
 func WithMsgEVMSupport[In, Out any]() func(messageType *MessageType[In, Out]) {
@@ -46,6 +51,16 @@ func WithMsgEVMSupport[In, Out any]() func(messageType *MessageType[In, Out]) {
 	}
 }
 
+// WithMsgValidator makes the HTTP server and the EVM server run the given function on each decoded message input
+// before the transaction is queued. Transactions whose input is rejected by the validator are never queued.
+func WithMsgValidator[In, Out any](validate func(In) error) func() func(*MessageType[In, Out]) {
+	return func() func(*MessageType[In, Out]) {
+		return func(msg *MessageType[In, Out]) {
+			msg.validator = validate
+		}
+	}
+}
+
 // NewMessageType creates a new message type. It accepts two generic type parameters: the first for the message input,
 // which defines the data needed to make a state transition, and the second for the message output, commonly used
 // for the results of a state transition.
@@ -92,6 +107,24 @@ func (t *MessageType[In, Out]) IsEVMCompatible() bool {
 	return t.inEVMType != nil && t.outEVMType != nil
 }
 
+// Validate runs the validator given with WithMsgValidator on the given message input. Nil is returned if the message
+// has no validator.
+func (t *MessageType[In, Out]) Validate(v any) error {
+	if t.validator == nil {
+		return nil
+	}
+	in, ok := v.(In)
+	if !ok {
+		return eris.Errorf("cannot cast %T to this message input type %T", v, new(In))
+	}
+	return t.validator(in)
+}
+
+// Schema returns the json schema of the message's input and output types. See message.SchemaProvider.
+func (t *MessageType[In, Out]) Schema() (in, out *jsonschema.Schema) {
+	return jsonschema.Reflect(new(In)), jsonschema.Reflect(new(Out))
+}
+
 func (t *MessageType[In, Out]) ID() message.TypeID {
 	if !t.isIDSet {
 		panic(fmt.Sprintf("id on msg %q is not set", t.name))
@@ -141,6 +174,40 @@ func (t *MessageType[In, Out]) SetResult(wCtx WorldContext, hash message.TxHash,
 	wCtx.GetWorld().SetMessageResult(hash, result)
 }
 
+// AddResult appends a result to the receipt of the given hash, for messages that have more than one outcome (e.g. an
+// attack that hits several targets). Unlike SetResult, previous results are kept. Use GetReceiptResults to read them.
+func (t *MessageType[In, Out]) AddResult(wCtx WorldContext, hash message.TxHash, result Out) {
+	wCtx.GetWorld().AddMessageResult(hash, result)
+}
+
+// GetReceiptResults returns all results of the given hash. This includes results added with AddResult as well as a
+// result set with SetResult.
+func (t *MessageType[In, Out]) GetReceiptResults(wCtx WorldContext, hash message.TxHash) (
+	results []Out, errs []error, ok bool,
+) {
+	iface, errs, ok := wCtx.GetWorld().GetTransactionReceipt(hash)
+	if !ok {
+		return nil, nil, false
+	}
+	var ifaces []any
+	switch v := iface.(type) {
+	case nil:
+	case receipt.Results:
+		ifaces = v
+	default:
+		ifaces = []any{v}
+	}
+	results = make([]Out, 0, len(ifaces))
+	for _, r := range ifaces {
+		value, isOut := r.(Out)
+		if !isOut {
+			return nil, nil, false
+		}
+		results = append(results, value)
+	}
+	return results, errs, true
+}
+
 func (t *MessageType[In, Out]) GetReceipt(wCtx WorldContext, hash message.TxHash) (
 	v Out, errs []error, ok bool,
 ) {
@@ -180,6 +247,7 @@ func (t *MessageType[In, Out]) Each(wCtx WorldContext, fn func(TxData[In]) (Out,
 // In extracts all the TxData in the tx queue that match this MessageType's ID.
 func (t *MessageType[In, Out]) In(wCtx WorldContext) []TxData[In] {
 	tq := wCtx.GetTxQueue()
+	tq.MarkConsumed(t.ID())
 	var txs []TxData[In]
 	for _, txData := range tq.ForID(t.ID()) {
 		if val, ok := txData.Msg.(In); ok {
@@ -222,6 +290,10 @@ func (t *MessageType[In, Out]) ABIEncode(v any) ([]byte, error) {
 		return nil, eris.Errorf("expected input to be of type %T or %T, got %T", new(In), new(Out), v)
 	}
 
+	input, err := abi.ToABIValue(input)
+	if err != nil {
+		return nil, err
+	}
 	return args.Pack(input)
 }
 
@@ -239,7 +311,7 @@ func (t *MessageType[In, Out]) DecodeEVMBytes(bz []byte) (any, error) {
 	if len(unpacked) < 1 {
 		return nil, eris.Errorf("error decoding EVM bytes: no values could be unpacked into the abi type")
 	}
-	input, err := abi.SerdeInto[In](unpacked[0])
+	input, err := abi.FromABIValue[In](unpacked[0])
 	if err != nil {
 		return nil, err
 	}
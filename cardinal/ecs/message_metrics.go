@@ -0,0 +1,133 @@
+package ecs
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/rotisserie/eris"
+
+	"pkg.world.dev/world-engine/cardinal/txpool"
+)
+
+// ErrInvalidMetricLabel is returned by NewWorld when a label given to WithMessageMetrics is not a valid Prometheus
+// label.
+var ErrInvalidMetricLabel = errors.New("invalid metric label")
+
+// metricLabelName matches the label names Prometheus accepts.
+var metricLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// MessageMetrics holds the counters of a single message type. See WithMessageMetrics.
+type MessageMetrics struct {
+	MessageName string
+	// Processed is the number of transactions of this message type that were processed by a tick.
+	Processed uint64
+	// Errors is the number of processed transactions whose receipt had at least one error.
+	Errors uint64
+	// Results is the number of processed transactions whose receipt had a result.
+	Results uint64
+	// ProcessedLastTick is the number of transactions of this message type that were processed by the last tick.
+	ProcessedLastTick uint64
+}
+
+type messageMetrics struct {
+	mutex sync.Mutex
+	// labels are added to every metric. See WithMessageMetrics.
+	labels map[string]string
+	byName map[string]*MessageMetrics
+}
+
+func newMessageMetrics(labels map[string]string) *messageMetrics {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return &messageMetrics{
+		labels: copied,
+		byName: map[string]*MessageMetrics{},
+	}
+}
+
+// validateLabels checks that the configured labels are valid Prometheus labels that do not clash with the labels the
+// metrics set themselves.
+func (m *messageMetrics) validateLabels() error {
+	for name, value := range m.labels {
+		switch {
+		case !metricLabelName.MatchString(name):
+			return eris.Wrapf(ErrInvalidMetricLabel, "label name %q is not a valid Prometheus label name", name)
+		case strings.HasPrefix(name, "__"):
+			return eris.Wrapf(ErrInvalidMetricLabel, "label names starting with __ are reserved, got %q", name)
+		case name == "message" || name == "query":
+			return eris.Wrapf(ErrInvalidMetricLabel, "label name %q is set by the metrics themselves", name)
+		case !utf8.ValidString(value):
+			return eris.Wrapf(ErrInvalidMetricLabel, "value of label %q is not valid UTF-8", name)
+		}
+	}
+	return nil
+}
+
+// recordMessageMetrics updates the per message counters with the transactions processed by the tick that just
+// finished. Errors and results are read from the receipts of the tick, so it must be called before the receipt history
+// moves on to the next tick.
+func (w *World) recordMessageMetrics(txQueue *txpool.TxQueue) {
+	if w.messageMetrics == nil {
+		return
+	}
+	w.messageMetrics.mutex.Lock()
+	defer w.messageMetrics.mutex.Unlock()
+	for _, m := range w.messageMetrics.byName {
+		m.ProcessedLastTick = 0
+	}
+	for _, msg := range w.registeredMessages {
+		txs := txQueue.ForID(msg.ID())
+		if len(txs) == 0 {
+			continue
+		}
+		m, ok := w.messageMetrics.byName[msg.Name()]
+		if !ok {
+			m = &MessageMetrics{MessageName: msg.Name()}
+			w.messageMetrics.byName[msg.Name()] = m
+		}
+		m.Processed += uint64(len(txs))
+		m.ProcessedLastTick = uint64(len(txs))
+		for _, tx := range txs {
+			rec, found := w.receiptHistory.GetReceipt(tx.TxHash)
+			if !found {
+				continue
+			}
+			if len(rec.Errs) > 0 {
+				m.Errors++
+			}
+			if rec.Result != nil {
+				m.Results++
+			}
+		}
+	}
+}
+
+// MessageMetricsEnabled reports whether the world was created with WithMessageMetrics.
+func (w *World) MessageMetricsEnabled() bool {
+	return w.messageMetrics != nil
+}
+
+// MessageMetrics returns the counters of every message type that has been processed at least once, sorted by message
+// name, along with the labels that were given to WithMessageMetrics. Nil is returned if WithMessageMetrics was not
+// used.
+func (w *World) MessageMetrics() ([]MessageMetrics, map[string]string) {
+	if w.messageMetrics == nil {
+		return nil, nil
+	}
+	w.messageMetrics.mutex.Lock()
+	defer w.messageMetrics.mutex.Unlock()
+	metrics := make([]MessageMetrics, 0, len(w.messageMetrics.byName))
+	for _, m := range w.messageMetrics.byName {
+		metrics = append(metrics, *m)
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].MessageName < metrics[j].MessageName
+	})
+	return metrics, w.messageMetrics.labels
+}
@@ -7,6 +7,7 @@ import (
 
 	"pkg.world.dev/world-engine/assert"
 
+	"pkg.world.dev/world-engine/cardinal"
 	"pkg.world.dev/world-engine/cardinal/ecs"
 	"pkg.world.dev/world-engine/cardinal/ecs/internal/testutil"
 	"pkg.world.dev/world-engine/cardinal/testutils"
@@ -64,3 +65,55 @@ func TestForEachTransaction(t *testing.T) {
 		}
 	}
 }
+
+func TestUnhandledMessagesGetAReceipt(t *testing.T) {
+	world := testutils.NewTestWorld(t, cardinal.WithUnhandledMessageReceipts()).Instance()
+	type Msg struct{}
+	handledMsg := ecs.NewMessageType[Msg, Msg]("handled")
+	unhandledMsg := ecs.NewMessageType[Msg, Msg]("unhandled")
+	assert.NilError(t, world.RegisterMessages(handledMsg, unhandledMsg))
+	world.RegisterSystem(func(wCtx ecs.WorldContext) error {
+		handledMsg.Each(wCtx, func(ecs.TxData[Msg]) (Msg, error) {
+			return Msg{}, nil
+		})
+		return nil
+	})
+	assert.NilError(t, world.LoadGameState())
+
+	handledHash := handledMsg.AddToQueue(world, Msg{}, testutil.UniqueSignature(t))
+	unhandledHash := unhandledMsg.AddToQueue(world, Msg{}, testutil.UniqueSignature(t))
+	assert.NilError(t, world.Tick(context.Background()))
+
+	receipts, err := world.GetTransactionReceiptsForTick(world.CurrentTick() - 1)
+	assert.NilError(t, err)
+	assert.Equal(t, 2, len(receipts))
+	for _, receipt := range receipts {
+		switch receipt.TxHash {
+		case handledHash:
+			assert.Equal(t, 0, len(receipt.Errs))
+		case unhandledHash:
+			assert.Equal(t, 1, len(receipt.Errs))
+			assert.ErrorIs(t, receipt.Errs[0], ecs.ErrMessageNotHandled)
+		default:
+			t.Fatalf("unexpected receipt for tx %q", receipt.TxHash)
+		}
+	}
+}
+
+func TestMessageEndpoint(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	type AttackMsg struct{}
+	attack := ecs.NewMessageType[AttackMsg, AttackMsg]("attack")
+	assert.NilError(t, world.RegisterMessages(attack))
+
+	endpoint, err := world.MessageEndpoint("attack")
+	assert.NilError(t, err)
+	assert.Equal(t, "/tx/game/attack", endpoint)
+
+	endpoint, err = world.MessageEndpoint(ecs.CreatePersonaMsg.Name())
+	assert.NilError(t, err)
+	assert.Equal(t, "/tx/persona/create-persona", endpoint)
+
+	_, err = world.MessageEndpoint("missing")
+	assert.ErrorIs(t, err, ecs.ErrMessageNotFound)
+}
@@ -2,6 +2,7 @@ package ecs
 
 import (
 	"os"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -26,8 +27,161 @@ func WithReceiptHistorySize(size int) Option {
 	}
 }
 
-func WithPrettyLog() Option {
+// WithRequiredAdapter makes on-chain persistence mandatory. LoadGameState returns ErrAdapterRequired if no adapter was
+// given with WithAdapter.
+func WithRequiredAdapter() Option {
+	return func(w *World) {
+		w.adapterRequired = true
+	}
+}
+
+// WithUnhandledMessageReceipts guarantees every transaction gets a receipt. At the end of each tick, any transaction
+// whose message type was not read by a system (via In or Each) gets a receipt with the error ErrMessageNotHandled.
+func WithUnhandledMessageReceipts() Option {
+	return func(w *World) {
+		w.unhandledMessageReceipts = true
+	}
+}
+
+// WithEVMEventHandler hands the events emitted by systems with EmitEVMEvent to the given handler after each committed
+// tick. The handler runs on a goroutine of its own, and is responsible for forwarding the events to the chain.
+func WithEVMEventHandler(handler EVMEventHandler) Option {
+	return func(w *World) {
+		w.evmEvents = newEVMEventDispatcher(handler)
+	}
+}
+
+// WithTransactionLog appends every transaction of each committed tick to the file at the given path, one JSON encoded
+// TransactionLogEntry per line, to keep an audit trail that does not depend on redis or the chain. The file is written
+// in the background and flushed once per tick, so a crash can lose the entries of the last few committed ticks. Ticks
+// only wait on the file when the writer falls far behind. Use an external tool such as logrotate to rotate the file.
+func WithTransactionLog(path string) Option {
+	return func(w *World) {
+		w.txLogPath = path
+	}
+}
+
+// WithRedisHealthCheckInterval sets how long the result of a redis health check is reused before redis is sent another
+// PING. See World.IsRedisHealthy.
+func WithRedisHealthCheckInterval(interval time.Duration) Option {
+	return func(w *World) {
+		w.redisHealthCheckInterval = interval
+	}
+}
+
+// WithMaxPersonasPerSigner caps the number of persona tags a single signer address can create. CreatePersona
+// transactions over the cap fail with ErrTooManyPersonas. A cap of 0, the default, means unlimited.
+func WithMaxPersonasPerSigner(maxPersonas int) Option {
+	return func(w *World) {
+		w.maxPersonasPerSigner = maxPersonas
+	}
+}
+
+// WithTickProgressEvents makes ticks that have been running for longer than the given threshold broadcast a
+// TickProgress event on the event hub before each system runs, so e.g. an admin UI can show which system a long tick
+// is stuck in. Progress events are sent right away with the events.TickProgressTopic topic, rather than with the
+// events of the tick, and only to the subscribers that ask for the topic, e.g. with /events?topics=tick-progress. A
+// threshold of 0 reports the progress of every tick.
+func WithTickProgressEvents(threshold time.Duration) Option {
+	return func(w *World) {
+		w.tickProgressEvents = true
+		w.tickProgressThreshold = threshold
+	}
+}
+
+// WithIdempotentComponentRegistration makes registering a component type that is already registered a no-op instead of
+// an ErrComponentAlreadyRegistered error, so packages can register the components they share without coordinating.
+// The options of the repeated registration are ignored. Registering a different type under the name of a registered
+// component is still an ErrDuplicateComponentName error.
+func WithIdempotentComponentRegistration() Option {
+	return func(w *World) {
+		w.idempotentComponentRegistration = true
+	}
+}
+
+// WithEntityCreationTicks adds a CreatedAt component with the current tick to every entity the game creates, so the
+// entities created within a range of ticks can be found with EntitiesCreatedBetween.
+func WithEntityCreationTicks() Option {
+	return func(w *World) {
+		w.recordCreationTicks = true
+	}
+}
+
+// WithPersonaDisplayNames registers SetDisplayNameMsg and the system that handles it, so personas can set a display
+// name that is shown to other players instead of their persona tag. See GetDisplayName.
+func WithPersonaDisplayNames() Option {
+	return func(w *World) {
+		w.personaDisplayNames = true
+	}
+}
+
+// WithReadReplica makes the world a read replica of another world that shares the same redis instance. A read replica
+// never runs systems or accepts transactions; its game loop only refreshes its view to the last tick the primary
+// world committed. This moves query traffic off of the world that is ticking.
+func WithReadReplica() Option {
+	return func(w *World) {
+		w.isReadReplica = true
+	}
+}
+
+// WithDurableTxQueue writes every transaction added to the tx queue to redis, and removes it once a tick has started
+// processing it. When the world is restarted, for example after a crash, the transactions that were accepted but never
+// processed are added back to the tx queue before the first tick.
+func WithDurableTxQueue() Option {
+	return func(w *World) {
+		w.durableTxQueue = true
+	}
+}
+
+// WithNonceRetryWindow lets clients safely resubmit a transaction, e.g. after a network failure, for the given amount
+// of time after it was first accepted. The resubmitted transaction may reuse the nonce only if it is byte-identical to
+// the accepted one (same persona tag, namespace, nonce and body); it is then not queued again, and gets the tx hash and
+// tick of the original submission. A different transaction with the same nonce is still rejected.
+func WithNonceRetryWindow(window time.Duration) Option {
+	return func(w *World) {
+		w.nonceRetryWindow = window
+	}
+}
+
+// WithConsistentQueries makes every query that runs outside a tick (e.g. from the HTTP server or the EVM) read the
+// state of a single, fully committed tick. Without it, a query that reads many components while a tick is being
+// committed may see some components from one tick and some from the next. Committing a tick waits for running queries
+// to finish, so long-running queries delay the game loop.
+func WithConsistentQueries() Option {
+	return func(w *World) {
+		w.consistentQueries = true
+	}
+}
+
+// WithMessageMetrics counts the transactions each tick processes, along with how many of them ended up with an error or
+// a result, for each message type. The counters can be read with World.MessageMetrics and are served in the Prometheus
+// text format on the /metrics endpoint, which only exists with this option or server.WithSlowQueryThreshold. The given
+// labels (e.g. the name of the shard) are added to every metric. NewWorld returns ErrInvalidMetricLabel if a label is
+// not a valid Prometheus label, or is called message or query.
+func WithMessageMetrics(labels map[string]string) Option {
+	return func(w *World) {
+		w.messageMetrics = newMessageMetrics(labels)
+	}
+}
+
+// WithTickRetry makes the game loop retry a failed tick up to maxRetries times, waiting delay between attempts, before
+// giving up. Retries help with transient errors (e.g. a redis blip). If a tick still fails after all retries, the
+// game loop stops ticking and the world reports the tick circuit as open instead of panicking. The loop keeps
+// running, so WaitForNextTick returns false and Shutdown still shuts the world down.
+func WithTickRetry(maxRetries int, delay time.Duration) Option {
+	return func(w *World) {
+		w.tickRetries = maxRetries
+		w.tickRetryDelay = delay
+	}
+}
+
+// WithPrettyLog makes the world log human readable lines instead of JSON, or JSON if pretty is false.
+func WithPrettyLog(pretty bool) Option {
 	return func(world *World) {
+		if !pretty {
+			world.Logger.Logger = &log.Logger
+			return
+		}
 		prettyLogger := log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 		world.Logger.Logger = &prettyLogger
 	}
@@ -1,6 +1,7 @@
 package ecs
 
 import (
+	"encoding/json"
 	"errors"
 	"regexp"
 	"strconv"
@@ -9,6 +10,8 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/rotisserie/eris"
+	"pkg.world.dev/world-engine/cardinal/ecs/filter"
+	"pkg.world.dev/world-engine/cardinal/ecs/receipt"
 	"pkg.world.dev/world-engine/cardinal/types/component"
 	"pkg.world.dev/world-engine/cardinal/types/entity"
 )
@@ -31,21 +34,29 @@ var CreatePersonaMsg = NewMessageType[CreatePersona, CreatePersonaResult](
 
 var regexpObj = regexp.MustCompile("^[a-zA-Z0-9_]+$")
 
+// AuthorizePersonaAddress authorizes EVM addresses to act for the persona that signs the transaction. Address is
+// kept for single address clients; Addresses allows linking several addresses in one transaction.
 type AuthorizePersonaAddress struct {
-	Address string `json:"address"`
+	Address   string   `json:"address"`
+	Addresses []string `json:"addresses,omitempty"`
 }
 
+// AuthorizePersonaAddressResult reports what happened to each of the given addresses. Addresses are lower cased.
 type AuthorizePersonaAddressResult struct {
-	Success bool `json:"success"`
+	Success           bool     `json:"success"`
+	Added             []string `json:"added,omitempty"`
+	AlreadyAuthorized []string `json:"alreadyAuthorized,omitempty"`
+	Invalid           []string `json:"invalid,omitempty"`
 }
 
 var AuthorizePersonaAddressMsg = NewMessageType[AuthorizePersonaAddress, AuthorizePersonaAddressResult](
 	"authorize-persona-address",
 )
 
-// AuthorizePersonaAddressSystem enables users to authorize an address to a persona tag. This is mostly used so that
+// AuthorizePersonaAddressSystem enables users to authorize addresses to a persona tag. This is mostly used so that
 // users who want to interact with the game via smart contract can link their EVM address to their persona tag, enabling
-// them to mutate their owned state from the context of the EVM.
+// them to mutate their owned state from the context of the EVM. Invalid addresses are skipped and reported in the
+// result, but the transaction fails if none of its addresses is valid.
 func AuthorizePersonaAddressSystem(wCtx WorldContext) error {
 	personaTagToAddress, err := buildPersonaTagMapping(wCtx)
 	if err != nil {
@@ -64,27 +75,44 @@ func AuthorizePersonaAddressSystem(wCtx WorldContext) error {
 				return result, eris.Errorf("persona %s does not exist", tx.PersonaTag)
 			}
 
-			// Check that the ETH Address is valid
-			msg.Address = strings.ToLower(msg.Address)
-			msg.Address = strings.ReplaceAll(msg.Address, " ", "")
-			valid := common.IsHexAddress(msg.Address)
-			if !valid {
-				return result, eris.Errorf("eth address %s is invalid", msg.Address)
+			// Check that the ETH Addresses are valid
+			var valid []string
+			seen := map[string]bool{}
+			for _, addr := range msg.addresses() {
+				addr = strings.ReplaceAll(strings.ToLower(addr), " ", "")
+				if seen[addr] {
+					continue
+				}
+				seen[addr] = true
+				if !common.IsHexAddress(addr) {
+					result.Invalid = append(result.Invalid, addr)
+					continue
+				}
+				valid = append(valid, addr)
+			}
+			if len(valid) == 0 {
+				return result, eris.Errorf("eth address %s is invalid", strings.Join(result.Invalid, ", "))
 			}
 
 			err = updateComponent[SignerComponent](
 				wCtx, data.EntityID, func(s *SignerComponent) *SignerComponent {
+					authorized := map[string]bool{}
 					for _, addr := range s.AuthorizedAddresses {
-						if addr == msg.Address {
-							return s
+						authorized[addr] = true
+					}
+					for _, addr := range valid {
+						if authorized[addr] {
+							result.AlreadyAuthorized = append(result.AlreadyAuthorized, addr)
+							continue
 						}
+						s.AuthorizedAddresses = append(s.AuthorizedAddresses, addr)
+						result.Added = append(result.Added, addr)
 					}
-					s.AuthorizedAddresses = append(s.AuthorizedAddresses, msg.Address)
 					return s
 				},
 			)
 			if err != nil {
-				return result, eris.Wrap(err, "unable to update signer component with address")
+				return AuthorizePersonaAddressResult{}, eris.Wrap(err, "unable to update signer component with address")
 			}
 			result.Success = true
 			return result, nil
@@ -93,6 +121,14 @@ func AuthorizePersonaAddressSystem(wCtx WorldContext) error {
 	return nil
 }
 
+// addresses returns Address followed by Addresses.
+func (a AuthorizePersonaAddress) addresses() []string {
+	if a.Address == "" {
+		return a.Addresses
+	}
+	return append([]string{a.Address}, a.Addresses...)
+}
+
 type SignerComponent struct {
 	PersonaTag          string
 	SignerAddress       string
@@ -111,7 +147,7 @@ type personaTagComponentData struct {
 func buildPersonaTagMapping(wCtx WorldContext) (map[string]personaTagComponentData, error) {
 	personaTagToAddress := map[string]personaTagComponentData{}
 	var errs []error
-	q, err := wCtx.NewSearch(Exact(SignerComponent{}))
+	q, err := wCtx.NewSearch(Contains(SignerComponent{}))
 	if err != nil {
 		return nil, err
 	}
@@ -146,22 +182,30 @@ func RegisterPersonaSystem(wCtx WorldContext) error {
 	if err != nil {
 		return err
 	}
+	maxPersonas := wCtx.GetWorld().maxPersonasPerSigner
+	personasPerSigner := map[string]int{}
+	for _, data := range personaTagToAddress {
+		personasPerSigner[strings.ToLower(data.SignerAddress)]++
+	}
 
 	CreatePersonaMsg.Each(wCtx, func(txData TxData[CreatePersona]) (result CreatePersonaResult, err error) {
 		msg := txData.Msg
 		result.Success = false
 
 		if !isAlphanumericWithUnderscore(msg.PersonaTag) {
-			err = eris.Errorf("persona tag %s is not valid: must only contain alphanumerics and underscores", msg.PersonaTag)
-			return result, err
+			return result, eris.Wrapf(ErrPersonaTagInvalid, "persona tag %s", msg.PersonaTag)
 		}
 
 		// Temporarily convert tag to lowercase to check against mapping of lowercase tags
 		lowerPersona := strings.ToLower(msg.PersonaTag)
 		if _, ok := personaTagToAddress[lowerPersona]; ok {
 			// This PersonaTag has already been registered. Don't do anything
-			err = eris.Errorf("persona tag %s has already been registered", msg.PersonaTag)
-			return result, err
+			return result, eris.Wrapf(ErrPersonaTagTaken, "persona tag %s", msg.PersonaTag)
+		}
+		lowerSigner := strings.ToLower(msg.SignerAddress)
+		if maxPersonas > 0 && personasPerSigner[lowerSigner] >= maxPersonas {
+			return result, eris.Wrapf(ErrTooManyPersonas, "signer %s already has %d persona tags",
+				msg.SignerAddress, personasPerSigner[lowerSigner])
 		}
 		id, err := create(wCtx, SignerComponent{})
 		if err != nil {
@@ -179,6 +223,7 @@ func RegisterPersonaSystem(wCtx WorldContext) error {
 			SignerAddress: msg.SignerAddress,
 			EntityID:      id,
 		}
+		personasPerSigner[lowerSigner]++
 		result.Success = true
 		return result, nil
 	})
@@ -192,8 +237,19 @@ func isAlphanumericWithUnderscore(s string) bool {
 }
 
 var (
-	ErrPersonaTagHasNoSigner        = errors.New("persona tag does not have a signer")
-	ErrCreatePersonaTxsNotProcessed = errors.New("create persona txs have not been processed for the given tick")
+	ErrPersonaTagHasNoSigner         = errors.New("persona tag does not have a signer")
+	ErrCreatePersonaTxsNotProcessed  = errors.New("create persona txs have not been processed for the given tick")
+	ErrPersonaTagNotFound            = errors.New("persona tag has not been registered")
+	ErrOwnerComponentHasNoPersonaTag = errors.New("owner component does not have a PersonaTag field")
+
+	// ErrPersonaTagInvalid, ErrPersonaTagTaken and ErrTooManyPersonas are the receipt errors of a rejected
+	// CreatePersona transaction. They carry codes, so clients can tell why the persona tag was rejected without
+	// parsing the message of the error.
+	ErrPersonaTagInvalid = receipt.NewCodedError("PERSONA_TAG_INVALID",
+		"persona tag is not valid: must only contain alphanumerics and underscores")
+	ErrPersonaTagTaken = receipt.NewCodedError("PERSONA_TAG_TAKEN", "persona tag has already been registered")
+	ErrTooManyPersonas = receipt.NewCodedError("TOO_MANY_PERSONAS",
+		"signer address already owns the maximum number of persona tags")
 )
 
 // GetSignerForPersonaTag returns the signer address that has been registered for the given persona tag after the
@@ -204,7 +260,7 @@ func (w *World) GetSignerForPersonaTag(personaTag string, tick uint64) (addr str
 		return "", ErrCreatePersonaTxsNotProcessed
 	}
 	var errs []error
-	q, err := w.NewSearch(Exact(SignerComponent{}))
+	q, err := w.NewSearch(Contains(SignerComponent{}))
 	if err != nil {
 		return "", err
 	}
@@ -229,6 +285,106 @@ func (w *World) GetSignerForPersonaTag(personaTag string, tick uint64) (addr str
 	return addr, errors.Join(errs...)
 }
 
+// GetEntityForPersona returns the entity that holds the SignerComponent for the given persona tag. Persona tags are
+// matched case-insensitively, the same way RegisterPersonaSystem enforces uniqueness. False is returned if the persona
+// tag has not been registered.
+func (w *World) GetEntityForPersona(personaTag string) (entity.ID, bool) {
+	return getEntityForPersona(NewReadOnlyWorldContext(w), personaTag)
+}
+
+// GetEntityForPersonaInContext is identical to World.GetEntityForPersona, but reads from the given WorldContext. Use
+// this inside of systems so persona tags registered earlier in the same tick are visible.
+func GetEntityForPersonaInContext(wCtx WorldContext, personaTag string) (entity.ID, bool) {
+	return getEntityForPersona(wCtx, personaTag)
+}
+
+func getEntityForPersona(wCtx WorldContext, personaTag string) (entity.ID, bool) {
+	q, err := wCtx.NewSearch(Contains(SignerComponent{}))
+	if err != nil {
+		return 0, false
+	}
+	var (
+		found bool
+		id    entity.ID
+	)
+	err = q.Each(
+		wCtx, func(currID entity.ID) bool {
+			sc, err := getComponent[SignerComponent](wCtx, currID)
+			if err != nil {
+				return true
+			}
+			if strings.EqualFold(sc.PersonaTag, personaTag) {
+				id, found = currID, true
+				return false
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return 0, false
+	}
+	return id, found
+}
+
+// EntitiesOwnedBy returns all entities whose owner component (the registered component named ownerComponentName) is
+// set to the given persona tag. See EntitiesOwnedByInContext for the expected owner component convention.
+func (w *World) EntitiesOwnedBy(personaTag string, ownerComponentName string) ([]entity.ID, error) {
+	return EntitiesOwnedByInContext(NewReadOnlyWorldContext(w), personaTag, ownerComponentName)
+}
+
+// EntitiesOwnedByInContext is identical to World.EntitiesOwnedBy, but reads from the given WorldContext.
+//
+// The owner component must have a string field named PersonaTag (the JSON key is matched case-insensitively, so a
+// `json:"personaTag"` tag works as well), e.g.:
+//
+//	type Owner struct {
+//		PersonaTag string `json:"personaTag"`
+//	}
+//
+// Persona tags are compared case-insensitively. ErrOwnerComponentHasNoPersonaTag is returned if the owner component
+// does not follow this convention.
+func EntitiesOwnedByInContext(wCtx WorldContext, personaTag string, ownerComponentName string) ([]entity.ID, error) {
+	ownerComponent, err := wCtx.GetWorld().GetComponentByName(ownerComponentName)
+	if err != nil {
+		return nil, err
+	}
+	search := NewSearch(filter.Contains(ownerComponent))
+	reader := wCtx.StoreReader()
+	owned := []entity.ID{}
+	var eachErr error
+	err = search.Each(
+		wCtx, func(id entity.ID) bool {
+			raw, err := reader.GetComponentForEntityInRawJSON(ownerComponent, id)
+			if err != nil {
+				eachErr = err
+				return false
+			}
+			var owner struct {
+				PersonaTag *string
+			}
+			if err = json.Unmarshal(raw, &owner); err != nil {
+				eachErr = eris.Wrapf(err, "unable to decode owner component %q", ownerComponentName)
+				return false
+			}
+			if owner.PersonaTag == nil {
+				eachErr = eris.Wrapf(ErrOwnerComponentHasNoPersonaTag, "component %q", ownerComponentName)
+				return false
+			}
+			if strings.EqualFold(*owner.PersonaTag, personaTag) {
+				owned = append(owned, id)
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if eachErr != nil {
+		return nil, eachErr
+	}
+	return owned, nil
+}
+
 // TODO private component function used to temporarily remove circular dependency until we replace components.
 // TODO this function is intended only for use with persona.go and is to be removed with persona when we replace with
 // plugins.
@@ -264,8 +420,8 @@ func getComponent[T component.Component](wCtx WorldContext, id entity.ID) (comp
 // TODO this function is intended only for use with persona.go and is to be removed with persona when we replace with
 // plugins.
 func setComponent[T component.Component](wCtx WorldContext, id entity.ID, component *T) error {
-	if wCtx.IsReadOnly() {
-		return eris.Wrap(ErrCannotModifyStateWithReadOnlyContext, "")
+	if err := checkWritable(wCtx); err != nil {
+		return err
 	}
 	var t T
 	name := t.Name()
@@ -290,8 +446,8 @@ func setComponent[T component.Component](wCtx WorldContext, id entity.ID, compon
 // plugins.
 // https://linear.app/arguslabs/issue/WORLD-423/ecs-plugin-feature
 func updateComponent[T component.Component](wCtx WorldContext, id entity.ID, fn func(*T) *T) error {
-	if wCtx.IsReadOnly() {
-		return eris.Wrap(ErrCannotModifyStateWithReadOnlyContext, "")
+	if err := checkWritable(wCtx); err != nil {
+		return err
 	}
 	val, err := getComponent[T](wCtx, id)
 	if err != nil {
@@ -306,8 +462,8 @@ func updateComponent[T component.Component](wCtx WorldContext, id entity.ID, fn
 // plugins.
 // https://linear.app/arguslabs/issue/WORLD-423/ecs-plugin-feature
 func createMany(wCtx WorldContext, num int, components ...component.Component) ([]entity.ID, error) {
-	if wCtx.IsReadOnly() {
-		return nil, eris.Wrap(ErrCannotModifyStateWithReadOnlyContext, "")
+	if err := checkWritable(wCtx); err != nil {
+		return nil, err
 	}
 	world := wCtx.GetWorld()
 	acc := make([]component.ComponentMetadata, 0, len(components))
@@ -0,0 +1,167 @@
+package ecs
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rotisserie/eris"
+	"pkg.world.dev/world-engine/cardinal/types/entity"
+)
+
+const maxDisplayNameLength = 32
+
+var ErrDisplayNameInvalid = errors.New("display name is not valid: must be at most 32 printable characters")
+
+// SetDisplayName changes the display name of the persona that signs the transaction. The display name is shown to
+// other players instead of the persona tag, which never changes. An empty display name removes it. The message is
+// only registered when the world is created with WithPersonaDisplayNames.
+type SetDisplayName struct {
+	DisplayName string `json:"displayName"`
+}
+
+type SetDisplayNameResult struct {
+	Success bool `json:"success"`
+}
+
+var SetDisplayNameMsg = NewMessageType[SetDisplayName, SetDisplayNameResult]("set-display-name")
+
+// personaDisplayName holds the display name of a persona. Display names are stored as entities of their own, so the
+// archetype of the persona's signer entity, and the schema of SignerComponent, stay unchanged.
+type personaDisplayName struct {
+	PersonaTag  string
+	DisplayName string
+}
+
+func (personaDisplayName) Name() string {
+	return "PersonaDisplayName"
+}
+
+// SetDisplayNameSystem is an ecs.System that sets the display name of the persona that signed each SetDisplayName
+// transaction. It is registered by WithPersonaDisplayNames.
+func SetDisplayNameSystem(wCtx WorldContext) error {
+	if len(SetDisplayNameMsg.In(wCtx)) == 0 {
+		return nil
+	}
+	personaTagToAddress, err := buildPersonaTagMapping(wCtx)
+	if err != nil {
+		return err
+	}
+	world := wCtx.GetWorld()
+	world.displayNameMutex.Lock()
+	defer world.displayNameMutex.Unlock()
+	displayNames, err := world.getDisplayNameIndex(wCtx)
+	if err != nil {
+		return err
+	}
+
+	SetDisplayNameMsg.Each(wCtx, func(txData TxData[SetDisplayName]) (result SetDisplayNameResult, err error) {
+		lowerPersona := strings.ToLower(txData.Tx.PersonaTag)
+		if _, ok := personaTagToAddress[lowerPersona]; !ok {
+			return result, eris.Wrapf(ErrPersonaTagNotFound, "persona tag %s", txData.Tx.PersonaTag)
+		}
+		displayName := strings.TrimSpace(txData.Msg.DisplayName)
+		if !isValidDisplayName(displayName) {
+			return result, eris.Wrapf(ErrDisplayNameInvalid, "display name %q", displayName)
+		}
+
+		recordID, hasRecord := displayNames[lowerPersona]
+		record := personaDisplayName{PersonaTag: txData.Tx.PersonaTag, DisplayName: displayName}
+		if displayName == "" {
+			if hasRecord {
+				if err = world.Remove(recordID); err != nil {
+					return result, err
+				}
+				delete(displayNames, lowerPersona)
+			}
+		} else if hasRecord {
+			if err = setComponent[personaDisplayName](wCtx, recordID, &record); err != nil {
+				return result, err
+			}
+		} else {
+			if recordID, err = create(wCtx, record); err != nil {
+				return result, err
+			}
+			displayNames[lowerPersona] = recordID
+		}
+		result.Success = true
+		return result, nil
+	})
+	return nil
+}
+
+func isValidDisplayName(displayName string) bool {
+	if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+		return false
+	}
+	for _, r := range displayName {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetDisplayName returns the display name set by the given persona with SetDisplayNameMsg. False is returned if the
+// persona has no display name, or if the world was not created with WithPersonaDisplayNames.
+func (w *World) GetDisplayName(personaTag string) (string, bool) {
+	return GetDisplayNameInContext(NewReadOnlyWorldContext(w), personaTag)
+}
+
+// GetDisplayNameInContext is identical to World.GetDisplayName, but reads from the given WorldContext.
+func GetDisplayNameInContext(wCtx WorldContext, personaTag string) (string, bool) {
+	world := wCtx.GetWorld()
+	if !world.personaDisplayNames {
+		return "", false
+	}
+	world.displayNameMutex.Lock()
+	defer world.displayNameMutex.Unlock()
+	displayNames, err := world.getDisplayNameIndex(wCtx)
+	if err != nil {
+		wCtx.Logger().Error().Err(err).Msgf("failed to look up the display name of persona %q", personaTag)
+		return "", false
+	}
+	id, ok := displayNames[strings.ToLower(personaTag)]
+	if !ok {
+		return "", false
+	}
+	// The record may not be visible to the given context, e.g. if it was created in a tick that has not been committed.
+	record, err := getComponent[personaDisplayName](wCtx, id)
+	if err != nil || !strings.EqualFold(record.PersonaTag, personaTag) {
+		return "", false
+	}
+	return record.DisplayName, true
+}
+
+// getDisplayNameIndex returns the entity of each display name record, keyed by the lower case persona tag. The index
+// is built from the stored records the first time it is used, and dropped whenever uncommitted state is discarded.
+// The caller must hold displayNameMutex.
+func (w *World) getDisplayNameIndex(wCtx WorldContext) (map[string]entity.ID, error) {
+	if w.displayNames != nil {
+		return w.displayNames, nil
+	}
+	search, err := w.newInternalSearch(Exact(personaDisplayName{}))
+	if err != nil {
+		return nil, err
+	}
+	records := map[string]entity.ID{}
+	var eachErr error
+	err = search.Each(wCtx, func(id entity.ID) bool {
+		record, err := getComponent[personaDisplayName](wCtx, id)
+		if err != nil {
+			eachErr = err
+			return false
+		}
+		records[strings.ToLower(record.PersonaTag)] = id
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if eachErr != nil {
+		return nil, eachErr
+	}
+	w.displayNames = records
+	return records, nil
+}
@@ -3,8 +3,10 @@ package ecs_test
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
+	"pkg.world.dev/world-engine/cardinal"
 	"pkg.world.dev/world-engine/cardinal/testutils"
 
 	"pkg.world.dev/world-engine/cardinal/types/entity"
@@ -13,6 +15,7 @@ import (
 	"pkg.world.dev/world-engine/assert"
 
 	"pkg.world.dev/world-engine/cardinal/ecs"
+	"pkg.world.dev/world-engine/cardinal/ecs/receipt"
 )
 
 func TestCreatePersonaTransactionAutomaticallyCreated(t *testing.T) {
@@ -48,6 +51,57 @@ func TestCreatePersonaTransactionAutomaticallyCreated(t *testing.T) {
 	assert.Equal(t, 1, count)
 }
 
+func TestRejectedCreatePersonaReceiptsHaveTypedErrors(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, world.LoadGameState())
+	ctx := context.Background()
+
+	getOnlyReceiptErr := func(tick uint64) error {
+		receipts, err := world.GetTransactionReceiptsForTick(tick)
+		assert.NilError(t, err)
+		assert.Equal(t, 1, len(receipts))
+		assert.Equal(t, 1, len(receipts[0].Errs))
+		return receipts[0].Errs[0]
+	}
+
+	ecs.CreatePersonaMsg.AddToQueue(world, ecs.CreatePersona{PersonaTag: "not a valid tag!", SignerAddress: "xyzzy"})
+	assert.NilError(t, world.Tick(ctx))
+	err := getOnlyReceiptErr(world.CurrentTick() - 1)
+	assert.ErrorIs(t, err, ecs.ErrPersonaTagInvalid)
+	assert.Equal(t, "PERSONA_TAG_INVALID", receipt.ErrorCode(err))
+
+	ecs.CreatePersonaMsg.AddToQueue(world, ecs.CreatePersona{PersonaTag: "jeff", SignerAddress: "xyzzy"})
+	assert.NilError(t, world.Tick(ctx))
+
+	// Persona tags are unique regardless of case.
+	ecs.CreatePersonaMsg.AddToQueue(world, ecs.CreatePersona{PersonaTag: "JEFF", SignerAddress: "other_address"})
+	assert.NilError(t, world.Tick(ctx))
+	err = getOnlyReceiptErr(world.CurrentTick() - 1)
+	assert.ErrorIs(t, err, ecs.ErrPersonaTagTaken)
+	assert.Equal(t, "PERSONA_TAG_TAKEN", receipt.ErrorCode(err))
+}
+
+func TestSignersCanOnlyCreateTheMaximumNumberOfPersonas(t *testing.T) {
+	world := testutils.NewTestWorld(t, cardinal.WithMaxPersonasPerSigner(2)).Instance()
+	assert.NilError(t, world.LoadGameState())
+	ctx := context.Background()
+
+	ecs.CreatePersonaMsg.AddToQueue(world, ecs.CreatePersona{PersonaTag: "first", SignerAddress: "0xabc"})
+	assert.NilError(t, world.Tick(ctx))
+	// The cap also applies to personas created in the same tick, and signer addresses are not case-sensitive.
+	ecs.CreatePersonaMsg.AddToQueue(world, ecs.CreatePersona{PersonaTag: "second", SignerAddress: "0xABC"})
+	txHash := ecs.CreatePersonaMsg.AddToQueue(world, ecs.CreatePersona{PersonaTag: "third", SignerAddress: "0xabc"})
+	// Other signers are not affected.
+	ecs.CreatePersonaMsg.AddToQueue(world, ecs.CreatePersona{PersonaTag: "other", SignerAddress: "0xdef"})
+	assert.NilError(t, world.Tick(ctx))
+
+	_, errs, ok := world.GetTransactionReceipt(txHash)
+	assert.Check(t, ok)
+	assert.Equal(t, 1, len(errs))
+	assert.ErrorIs(t, errs[0], ecs.ErrTooManyPersonas)
+	assert.Equal(t, 3, len(getSigners(t, world)))
+}
+
 func TestGetSignerForPersonaTagReturnsErrorWhenNotRegistered(t *testing.T) {
 	world := testutils.NewTestWorld(t).Instance()
 	assert.NilError(t, world.LoadGameState())
@@ -209,6 +263,99 @@ func TestCanAuthorizeAddress(t *testing.T) {
 	assert.Equal(t, count, 1)
 }
 
+func TestCanAuthorizeSeveralAddressesAtOnce(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, world.LoadGameState())
+	ctx := context.Background()
+
+	personaTag := "CoolMage"
+	ecs.CreatePersonaMsg.AddToQueue(world, ecs.CreatePersona{PersonaTag: personaTag, SignerAddress: "123_456"})
+	first := "0xd5e099c71b797516c10ed0f0d895f429c2781142"
+	ecs.AuthorizePersonaAddressMsg.AddToQueue(world, ecs.AuthorizePersonaAddress{Address: first},
+		&sign.Transaction{PersonaTag: personaTag, Nonce: 1})
+	assert.NilError(t, world.Tick(ctx))
+
+	second := "0x1111111111111111111111111111111111111111"
+	third := "0x2222222222222222222222222222222222222222"
+	txHash := ecs.AuthorizePersonaAddressMsg.AddToQueue(world, ecs.AuthorizePersonaAddress{
+		Addresses: []string{strings.ToUpper(first), second, "not an address", third, second},
+	}, &sign.Transaction{PersonaTag: personaTag, Nonce: 2})
+	assert.NilError(t, world.Tick(ctx))
+
+	result, errs, ok := world.GetTransactionReceipt(txHash)
+	assert.Check(t, ok)
+	assert.Equal(t, 0, len(errs))
+	assert.DeepEqual(t, ecs.AuthorizePersonaAddressResult{
+		Success:           true,
+		Added:             []string{second, third},
+		AlreadyAuthorized: []string{first},
+		Invalid:           []string{"notanaddress"},
+	}, result)
+	assert.DeepEqual(t, []string{first, second, third}, getSigners(t, world)[0].AuthorizedAddresses)
+
+	// A transaction without a single valid address fails.
+	txHash = ecs.AuthorizePersonaAddressMsg.AddToQueue(world, ecs.AuthorizePersonaAddress{
+		Addresses: []string{"0x12", "nope"},
+	}, &sign.Transaction{PersonaTag: personaTag, Nonce: 3})
+	assert.NilError(t, world.Tick(ctx))
+	_, errs, _ = world.GetTransactionReceipt(txHash)
+	assert.Equal(t, 1, len(errs))
+}
+
+func TestCanSetDisplayName(t *testing.T) {
+	world := testutils.NewTestWorld(t, cardinal.WithPersonaDisplayNames()).Instance()
+	assert.NilError(t, world.LoadGameState())
+	ctx := context.Background()
+
+	ecs.CreatePersonaMsg.AddToQueue(world, ecs.CreatePersona{PersonaTag: "CoolMage", SignerAddress: "123_456"})
+	assert.NilError(t, world.Tick(ctx))
+
+	setDisplayName := func(personaTag, displayName string, nonce uint64) []error {
+		txHash := ecs.SetDisplayNameMsg.AddToQueue(world, ecs.SetDisplayName{DisplayName: displayName},
+			&sign.Transaction{PersonaTag: personaTag, Nonce: nonce})
+		assert.NilError(t, world.Tick(ctx))
+		_, errs, ok := world.GetTransactionReceipt(txHash)
+		assert.Check(t, ok)
+		return errs
+	}
+
+	assert.Equal(t, 0, len(setDisplayName("CoolMage", " The Cool Mage ", 1)))
+	displayName, ok := world.GetDisplayName("coolmage")
+	assert.Check(t, ok)
+	assert.Equal(t, "The Cool Mage", displayName)
+
+	// Changing the display name leaves the persona tag as it was.
+	assert.Equal(t, 0, len(setDisplayName("CoolMage", "Mage of Cool", 2)))
+	displayName, _ = world.GetDisplayName("CoolMage")
+	assert.Equal(t, "Mage of Cool", displayName)
+	assert.Equal(t, "CoolMage", getSigners(t, world)[0].PersonaTag)
+
+	errs := setDisplayName("CoolMage", strings.Repeat("x", 33), 3)
+	assert.Equal(t, 1, len(errs))
+	assert.ErrorIs(t, errs[0], ecs.ErrDisplayNameInvalid)
+	errs = setDisplayName("NotAPersona", "Ghost", 4)
+	assert.Equal(t, 1, len(errs))
+	assert.ErrorIs(t, errs[0], ecs.ErrPersonaTagNotFound)
+
+	// An empty display name removes it.
+	assert.Equal(t, 0, len(setDisplayName("CoolMage", "", 5)))
+	_, ok = world.GetDisplayName("CoolMage")
+	assert.Check(t, !ok)
+}
+
+func TestDisplayNamesAreOptIn(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, world.LoadGameState())
+
+	msgs, err := world.ListMessages()
+	assert.NilError(t, err)
+	for _, msg := range msgs {
+		assert.Check(t, msg.Name() != ecs.SetDisplayNameMsg.Name())
+	}
+	_, ok := world.GetDisplayName("CoolMage")
+	assert.Check(t, !ok)
+}
+
 func TestAuthorizeAddressFailsOnInvalidAddress(t *testing.T) {
 	// Verify that the CreatePersona is automatically created and registered with a world.
 	world := testutils.NewTestWorld(t).Instance()
@@ -244,6 +391,130 @@ func TestAuthorizeAddressFailsOnInvalidAddress(t *testing.T) {
 	assert.Equal(t, count, 1)
 }
 
+func TestGetEntityForPersona(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, world.LoadGameState())
+
+	_, ok := world.GetEntityForPersona("CoolMage")
+	assert.Check(t, !ok)
+
+	ecs.CreatePersonaMsg.AddToQueue(
+		world, ecs.CreatePersona{
+			PersonaTag:    "CoolMage",
+			SignerAddress: "123_456",
+		},
+	)
+	assert.NilError(t, world.Tick(context.Background()))
+
+	// Persona tags are matched case-insensitively.
+	id, ok := world.GetEntityForPersona("coolmage")
+	assert.Check(t, ok)
+
+	wCtx := ecs.NewReadOnlyWorldContext(world)
+	sc, err := ecs.GetComponent[ecs.SignerComponent](wCtx, id)
+	assert.NilError(t, err)
+	assert.Equal(t, sc.PersonaTag, "CoolMage")
+	assert.Equal(t, sc.SignerAddress, "123_456")
+
+	_, ok = world.GetEntityForPersona("missing_persona")
+	assert.Check(t, !ok)
+}
+
+func TestPersonaIsFoundAfterGameAttachesComponent(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[UnownedComponent](world))
+	assert.NilError(t, world.LoadGameState())
+
+	ecs.CreatePersonaMsg.AddToQueue(
+		world, ecs.CreatePersona{
+			PersonaTag:    "CoolMage",
+			SignerAddress: "123_456",
+		},
+	)
+	assert.NilError(t, world.Tick(context.Background()))
+
+	id, ok := world.GetEntityForPersona("CoolMage")
+	assert.Check(t, ok)
+	assert.NilError(t, ecs.AddComponentTo[UnownedComponent](ecs.NewWorldContext(world), id))
+	assert.NilError(t, world.Tick(context.Background()))
+
+	gotID, ok := world.GetEntityForPersona("CoolMage")
+	assert.Check(t, ok)
+	assert.Equal(t, id, gotID)
+	addr, err := world.GetSignerForPersonaTag("CoolMage", 0)
+	assert.NilError(t, err)
+	assert.Equal(t, addr, "123_456")
+
+	// The persona tag must still be taken, so it cannot be claimed again.
+	ecs.CreatePersonaMsg.AddToQueue(
+		world, ecs.CreatePersona{
+			PersonaTag:    "coolmage",
+			SignerAddress: "789",
+		},
+	)
+	assert.NilError(t, world.Tick(context.Background()))
+	addr, err = world.GetSignerForPersonaTag("CoolMage", 0)
+	assert.NilError(t, err)
+	assert.Equal(t, addr, "123_456")
+	gotID, ok = world.GetEntityForPersona("coolmage")
+	assert.Check(t, ok)
+	assert.Equal(t, id, gotID)
+}
+
+type OwnerComponent struct {
+	PersonaTag string `json:"personaTag"`
+}
+
+func (OwnerComponent) Name() string {
+	return "persona_owner"
+}
+
+type UnownedComponent struct {
+	Value int
+}
+
+func (UnownedComponent) Name() string {
+	return "unowned"
+}
+
+func TestEntitiesOwnedBy(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[OwnerComponent](world))
+	assert.NilError(t, ecs.RegisterComponent[UnownedComponent](world))
+	assert.NilError(t, world.LoadGameState())
+
+	wCtx := ecs.NewWorldContext(world)
+	alphaIDs, err := ecs.CreateMany(wCtx, 2, OwnerComponent{})
+	assert.NilError(t, err)
+	for _, id := range alphaIDs {
+		assert.NilError(t, ecs.SetComponent[OwnerComponent](wCtx, id, &OwnerComponent{PersonaTag: "Alpha"}))
+	}
+	betaID, err := ecs.Create(wCtx, OwnerComponent{PersonaTag: "beta"}, UnownedComponent{})
+	assert.NilError(t, err)
+	_, err = ecs.Create(wCtx, UnownedComponent{})
+	assert.NilError(t, err)
+	assert.NilError(t, world.Tick(context.Background()))
+
+	// Persona tags are matched case-insensitively.
+	owned, err := world.EntitiesOwnedBy("alpha", OwnerComponent{}.Name())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, alphaIDs, owned)
+
+	owned, err = world.EntitiesOwnedBy("Beta", OwnerComponent{}.Name())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []entity.ID{betaID}, owned)
+
+	owned, err = world.EntitiesOwnedBy("gamma", OwnerComponent{}.Name())
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(owned))
+
+	_, err = world.EntitiesOwnedBy("alpha", "not_a_component")
+	assert.Check(t, err != nil)
+
+	_, err = world.EntitiesOwnedBy("alpha", UnownedComponent{}.Name())
+	assert.ErrorIs(t, err, ecs.ErrOwnerComponentHasNoPersonaTag)
+}
+
 func getSigners(t *testing.T, world *ecs.World) []*ecs.SignerComponent {
 	wCtx := ecs.NewWorldContext(world)
 	var signers = make([]*ecs.SignerComponent, 0)
@@ -0,0 +1,124 @@
+package ecs
+
+import (
+	"github.com/rotisserie/eris"
+	"pkg.world.dev/world-engine/cardinal/ecs/cql"
+	ecslog "pkg.world.dev/world-engine/cardinal/ecs/log"
+	"pkg.world.dev/world-engine/cardinal/types/component"
+	"pkg.world.dev/world-engine/cardinal/types/message"
+)
+
+// Plugin bundles the components, messages, queries and systems of a feature, e.g. an inventory, so the feature can be
+// added to a world with a single AddPlugin call.
+type Plugin interface {
+	// Register registers everything the plugin needs with the world, in dependency order: components first, then the
+	// messages, queries and systems that use them.
+	Register(w *World) error
+}
+
+// AddPlugin registers the given plugin with the world. Plugins must be added before the game state is loaded, and
+// plugins that register messages must be added before the messages of the game are registered. The messages of
+// plugins are registered after the messages of the game, so adding a plugin does not change the IDs of the game's
+// messages.
+//
+// Component IDs are handed out in registration order, so plugins that register components must be added after all
+// components of the game are registered. Otherwise adding a plugin would change the IDs of the game's components.
+// RegisterComponent returns ErrComponentRegisteredAfterPlugin for game components registered too late.
+//
+// Adding a plugin is atomic: if Register returns an error, everything the plugin registered is removed from the world
+// again. The schemas of its components may still have been written to redis.
+func (w *World) AddPlugin(plugin Plugin) error {
+	if w.stateIsLoaded {
+		panic("cannot add plugins after loading game state")
+	}
+	snapshot := w.snapshotRegistrations()
+	w.isAddingPlugin = true
+	err := plugin.Register(w)
+	w.isAddingPlugin = false
+	if err != nil {
+		w.restoreRegistrations(snapshot)
+		return eris.Wrap(err, "failed to add plugin")
+	}
+	return nil
+}
+
+// registrationSnapshot records what was registered with the world before a plugin was added.
+type registrationSnapshot struct {
+	numComponents          int
+	nextComponentID        component.TypeID
+	isComponentsRegistered bool
+	numQueries             int
+	cqlQueries             map[string]cql.CompiledQuery
+	numSystems             int
+	systemDependencies     map[int][]string
+	initSystem             System
+	initSystemLogger       *ecslog.Logger
+	numPerPersonaTickHooks int
+	numPluginMessages      int
+	hasPluginComponents    bool
+}
+
+func (w *World) snapshotRegistrations() registrationSnapshot {
+	cqlQueries := make(map[string]cql.CompiledQuery, len(w.nameToCQLQuery))
+	for name, q := range w.nameToCQLQuery {
+		cqlQueries[name] = q
+	}
+	var systemDependencies map[int][]string
+	if w.systemDependencies != nil {
+		systemDependencies = make(map[int][]string, len(w.systemDependencies))
+		for i, afterNames := range w.systemDependencies {
+			systemDependencies[i] = afterNames
+		}
+	}
+	return registrationSnapshot{
+		numComponents:          len(w.registeredComponents),
+		nextComponentID:        w.nextComponentID,
+		isComponentsRegistered: w.isComponentsRegistered,
+		numQueries:             len(w.registeredQueries),
+		cqlQueries:             cqlQueries,
+		numSystems:             len(w.systems),
+		systemDependencies:     systemDependencies,
+		initSystem:             w.initSystem,
+		initSystemLogger:       w.initSystemLogger,
+		numPerPersonaTickHooks: len(w.perPersonaTickHooks),
+		numPluginMessages:      len(w.pluginMessages),
+		hasPluginComponents:    w.hasPluginComponents,
+	}
+}
+
+// restoreRegistrations removes everything that was registered after the given snapshot was taken.
+func (w *World) restoreRegistrations(s registrationSnapshot) {
+	for _, c := range w.registeredComponents[s.numComponents:] {
+		delete(w.nameToComponent, c.Name())
+	}
+	w.registeredComponents = w.registeredComponents[:s.numComponents]
+	w.nextComponentID = s.nextComponentID
+	w.isComponentsRegistered = s.isComponentsRegistered
+	w.hasPluginComponents = s.hasPluginComponents
+
+	for _, q := range w.registeredQueries[s.numQueries:] {
+		delete(w.nameToQuery, q.Name())
+	}
+	w.registeredQueries = w.registeredQueries[:s.numQueries]
+	w.nameToCQLQuery = s.cqlQueries
+
+	w.systems = w.systems[:s.numSystems]
+	w.systemNames = w.systemNames[:s.numSystems]
+	w.systemLoggers = w.systemLoggers[:s.numSystems]
+	w.systemRunConfigs = w.systemRunConfigs[:s.numSystems]
+	w.systemDependencies = s.systemDependencies
+	w.initSystem = s.initSystem
+	w.initSystemLogger = s.initSystemLogger
+	w.perPersonaTickHooks = w.perPersonaTickHooks[:s.numPerPersonaTickHooks]
+	w.pluginMessages = w.pluginMessages[:s.numPluginMessages]
+}
+
+// addPluginMessages holds on to the messages a plugin registers until the messages of the game are registered.
+func (w *World) addPluginMessages(txs []message.Message) error {
+	if w.isMessagesRegistered {
+		return eris.Wrap(ErrMessageRegistrationMustHappenOnce,
+			"plugins with messages must be added before the messages of the game are registered")
+	}
+	w.pluginMessages = append(w.pluginMessages, txs...)
+	return nil
+}
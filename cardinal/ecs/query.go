@@ -2,7 +2,9 @@ package ecs
 
 import (
 	"encoding/json"
+	"errors"
 	"reflect"
+	"time"
 
 	ethereumAbi "github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/invopop/jsonschema"
@@ -10,13 +12,36 @@ import (
 	"pkg.world.dev/world-engine/cardinal/ecs/abi"
 )
 
+var (
+	// ErrQueryReplyNotSerializable is returned when a query's reply type can not be marshalled to JSON.
+	ErrQueryReplyNotSerializable = errors.New("query reply is not JSON serializable")
+	// ErrQueryReplyIsNil is returned by queries registered with NilReplyAsError when the handler returns a nil reply.
+	ErrQueryReplyIsNil = errors.New("query handler returned a nil reply")
+)
+
+// NilReplyPolicy decides what HandleQueryRaw returns when a query handler returns a nil reply and a nil error. See
+// WithNilReplyPolicy.
+type NilReplyPolicy int
+
+const (
+	// NilReplyAsNull encodes the nil reply as the JSON value null. This is the default.
+	NilReplyAsNull NilReplyPolicy = iota
+	// NilReplyAsEmptyObject encodes the nil reply as the empty JSON object {}.
+	NilReplyAsEmptyObject
+	// NilReplyAsNoContent returns an empty reply, which the HTTP server answers with 204 No Content.
+	NilReplyAsNoContent
+	// NilReplyAsError returns ErrQueryReplyIsNil.
+	NilReplyAsError
+)
+
 type Query interface {
 	// Name returns the name of the query.
 	Name() string
 	// HandleQuery handles queries with concrete types, rather than encoded bytes.
 	HandleQuery(WorldContext, any) (any, error)
 	// HandleQueryRaw is given a reference to the world, json encoded bytes that represent a query request
-	// and is expected to return a json encoded response struct.
+	// and is expected to return a json encoded response struct. The response is empty if the handler returned a nil
+	// reply and the query was registered with NilReplyAsNoContent.
 	HandleQueryRaw(WorldContext, []byte) ([]byte, error)
 	// Schema returns the json schema of the query request.
 	Schema() (request, reply *jsonschema.Schema)
@@ -30,13 +55,25 @@ type Query interface {
 	EncodeAsABI(any) ([]byte, error)
 	// IsEVMCompatible reports if the query is able to be sent from the EVM.
 	IsEVMCompatible() bool
+	// Deprecation returns the deprecation metadata of the query. The bool is false if the query is not deprecated.
+	Deprecation() (QueryDeprecation, bool)
+}
+
+// QueryDeprecation describes a query that clients should migrate away from.
+type QueryDeprecation struct {
+	// Message tells clients what to use instead, e.g. "use foo/v2".
+	Message string
+	// Sunset is when the query is expected to be removed. It is the zero time if no removal date is planned.
+	Sunset time.Time
 }
 
 type QueryType[Request any, Reply any] struct {
-	name       string
-	handler    func(wCtx WorldContext, req *Request) (*Reply, error)
-	requestABI *ethereumAbi.Type
-	replyABI   *ethereumAbi.Type
+	name        string
+	handler     func(wCtx WorldContext, req *Request) (*Reply, error)
+	requestABI  *ethereumAbi.Type
+	replyABI    *ethereumAbi.Type
+	deprecation *QueryDeprecation
+	nilReply    NilReplyPolicy
 }
 
 func WithQueryEVMSupport[Request, Reply any]() func(transactionType *QueryType[Request, Reply]) {
@@ -48,6 +85,27 @@ func WithQueryEVMSupport[Request, Reply any]() func(transactionType *QueryType[R
 	}
 }
 
+// WithQueryDeprecation marks the query as deprecated. The query keeps working, but clients are told to migrate with
+// the given message, and optionally the time after which the query may be removed (use the zero time for none).
+func WithQueryDeprecation[Request, Reply any](message string, sunset time.Time,
+) func() func(queryType *QueryType[Request, Reply]) {
+	return func() func(queryType *QueryType[Request, Reply]) {
+		return func(query *QueryType[Request, Reply]) {
+			query.deprecation = &QueryDeprecation{Message: message, Sunset: sunset}
+		}
+	}
+}
+
+// WithNilReplyPolicy sets what HandleQueryRaw returns when the query handler returns a nil reply and a nil error,
+// e.g. for queries that legitimately have no result. The default is NilReplyAsNull.
+func WithNilReplyPolicy[Request, Reply any](policy NilReplyPolicy) func() func(queryType *QueryType[Request, Reply]) {
+	return func() func(queryType *QueryType[Request, Reply]) {
+		return func(query *QueryType[Request, Reply]) {
+			query.nilReply = policy
+		}
+	}
+}
+
 var _ Query = &QueryType[struct{}, struct{}]{}
 
 func NewQueryType[Request any, Reply any](
@@ -91,6 +149,13 @@ func (r *QueryType[Request, Reply]) generateABIBindings() error {
 	return nil
 }
 
+func (r *QueryType[Request, Reply]) Deprecation() (QueryDeprecation, bool) {
+	if r.deprecation == nil {
+		return QueryDeprecation{}, false
+	}
+	return *r.deprecation, true
+}
+
 func (r *QueryType[req, rep]) Name() string {
 	return r.name
 }
@@ -104,6 +169,7 @@ func (r *QueryType[req, rep]) HandleQuery(wCtx WorldContext, a any) (any, error)
 	if !ok {
 		return nil, eris.Errorf("cannot cast %T to this query request type %T", a, new(req))
 	}
+	defer PinTickSnapshot(wCtx)()
 	reply, err := r.handler(wCtx, &request)
 	return reply, err
 }
@@ -114,13 +180,26 @@ func (r *QueryType[req, rep]) HandleQueryRaw(wCtx WorldContext, bz []byte) ([]by
 	if err != nil {
 		return nil, eris.Wrapf(err, "unable to unmarshal query request into type %T", *request)
 	}
+	release := PinTickSnapshot(wCtx)
 	res, err := r.handler(wCtx, request)
+	release()
 	if err != nil {
 		return nil, err
 	}
+	if res == nil {
+		switch r.nilReply {
+		case NilReplyAsNull:
+		case NilReplyAsEmptyObject:
+			return []byte("{}"), nil
+		case NilReplyAsNoContent:
+			return []byte{}, nil
+		case NilReplyAsError:
+			return nil, eris.Wrapf(ErrQueryReplyIsNil, "query %q", r.name)
+		}
+	}
 	bz, err = json.Marshal(res)
 	if err != nil {
-		return nil, eris.Wrapf(err, "unable to marshal response %T", res)
+		return nil, eris.Wrapf(errors.Join(ErrQueryReplyNotSerializable, err), "unable to marshal response %T", res)
 	}
 	return bz, nil
 }
@@ -137,7 +216,7 @@ func (r *QueryType[req, rep]) DecodeEVMRequest(bz []byte) (any, error) {
 	if len(unpacked) < 1 {
 		return nil, eris.New("error decoding EVM bytes: no values could be unpacked")
 	}
-	request, err := abi.SerdeInto[req](unpacked[0])
+	request, err := abi.FromABIValue[req](unpacked[0])
 	if err != nil {
 		return nil, err
 	}
@@ -156,7 +235,7 @@ func (r *QueryType[req, rep]) DecodeEVMReply(bz []byte) (any, error) {
 	if len(unpacked) < 1 {
 		return nil, eris.New("error decoding EVM bytes: no values could be unpacked")
 	}
-	reply, err := abi.SerdeInto[rep](unpacked[0])
+	reply, err := abi.FromABIValue[rep](unpacked[0])
 	if err != nil {
 		return nil, err
 	}
@@ -168,7 +247,11 @@ func (r *QueryType[req, rep]) EncodeEVMReply(a any) ([]byte, error) {
 		return nil, eris.Wrap(ErrEVMTypeNotSet, "")
 	}
 	args := ethereumAbi.Arguments{{Type: *r.replyABI}}
-	bz, err := args.Pack(a)
+	reply, err := abi.ToABIValue(a)
+	if err != nil {
+		return nil, err
+	}
+	bz, err := args.Pack(reply)
 	return bz, eris.Wrap(err, "")
 }
 
@@ -192,6 +275,10 @@ func (r *QueryType[Request, Reply]) EncodeAsABI(input any) ([]byte, error) {
 			new(Request), new(Reply), input)
 	}
 
+	in, err := abi.ToABIValue(in)
+	if err != nil {
+		return nil, err
+	}
 	bz, err := args.Pack(in)
 	if err != nil {
 		return nil, eris.Wrap(err, "")
@@ -233,5 +320,14 @@ func validateQuery[Request any, Reply any](
 			name,
 		)
 	}
+	// Catch replies that contain fields json can't handle (e.g. channels or functions) at registration time rather
+	// than on the first call to the query.
+	if _, err := json.Marshal(new(Reply)); err != nil {
+		return eris.Wrapf(
+			errors.Join(ErrQueryReplyNotSerializable, err),
+			"invalid query: %s: reply type %T can not be marshalled to json",
+			name, rep,
+		)
+	}
 	return nil
 }
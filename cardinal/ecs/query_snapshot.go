@@ -0,0 +1,40 @@
+package ecs
+
+import "github.com/rs/zerolog"
+
+// PinTickSnapshot keeps the committed game state from changing until the returned release function is called, so
+// everything read through the given read only context in between belongs to the same tick. It does nothing unless
+// WithConsistentQueries was used. Queries that run outside a tick pin the snapshot themselves.
+//
+// The snapshot is pinned at most once per context, and views made with ReadOnly share the pin of their context. A
+// query that runs other queries therefore does not pin the snapshot again, which would deadlock with a tick that is
+// waiting to commit.
+func PinTickSnapshot(wCtx WorldContext) (release func()) {
+	world := wCtx.GetWorld()
+	if !world.consistentQueries || !wCtx.IsReadOnly() {
+		return func() {}
+	}
+	c, ok := wCtx.(*worldContext)
+	if !ok || c.snapshotPinned == nil {
+		world.tickSnapshotLock.RLock()
+		return world.tickSnapshotLock.RUnlock
+	}
+	if !c.snapshotPinned.CompareAndSwap(false, true) {
+		return func() {}
+	}
+	world.tickSnapshotLock.RLock()
+	return func() {
+		c.snapshotPinned.Store(false)
+		world.tickSnapshotLock.RUnlock()
+	}
+}
+
+// finalizeTick commits the state changes of the current tick. With WithConsistentQueries, the commit waits for
+// queries that pinned the previous tick's state to finish, and new queries wait for the commit to finish.
+func (w *World) finalizeTick(event *zerolog.Event) error {
+	if w.consistentQueries {
+		w.tickSnapshotLock.Lock()
+		defer w.tickSnapshotLock.Unlock()
+	}
+	return w.TickStore().FinalizeTick(event)
+}
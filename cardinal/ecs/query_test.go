@@ -3,9 +3,12 @@ package ecs_test
 import (
 	"context"
 	"testing"
+	"time"
 
+	"pkg.world.dev/world-engine/cardinal"
 	"pkg.world.dev/world-engine/cardinal/ecs"
 	"pkg.world.dev/world-engine/cardinal/testutils"
+	"pkg.world.dev/world-engine/cardinal/types/entity"
 
 	"pkg.world.dev/world-engine/cardinal/evm"
 
@@ -26,6 +29,54 @@ func TestQueryTypeNotStructs(t *testing.T) {
 	assert.ErrorContains(t, err, "the Request and Reply generics must be both structs")
 }
 
+func TestQueryReplyMustBeSerializable(t *testing.T) {
+	type BadReply struct {
+		Callback func()
+	}
+	err := ecs.RegisterQuery[struct{}, BadReply](
+		testutils.NewTestWorld(t).Instance(),
+		"foo",
+		func(wCtx ecs.WorldContext, req *struct{}) (*BadReply, error) {
+			return &BadReply{}, nil
+		},
+	)
+	assert.ErrorIs(t, err, ecs.ErrQueryReplyNotSerializable)
+}
+
+func TestNilQueryReplyFollowsThePolicyOfTheQuery(t *testing.T) {
+	type EmptyReply struct {
+		Value int
+	}
+	testCases := []struct {
+		policy  ecs.NilReplyPolicy
+		want    string
+		wantErr error
+	}{
+		{policy: ecs.NilReplyAsNull, want: "null"},
+		{policy: ecs.NilReplyAsEmptyObject, want: "{}"},
+		{policy: ecs.NilReplyAsNoContent, want: ""},
+		{policy: ecs.NilReplyAsError, wantErr: ecs.ErrQueryReplyIsNil},
+	}
+	world := testutils.NewTestWorld(t).Instance()
+	for _, tc := range testCases {
+		query, err := ecs.NewQueryType[struct{}, EmptyReply](
+			"empty",
+			func(wCtx ecs.WorldContext, req *struct{}) (*EmptyReply, error) {
+				return nil, nil //nolint:nilnil // the nil reply is what is being tested
+			},
+			ecs.WithNilReplyPolicy[struct{}, EmptyReply](tc.policy),
+		)
+		assert.NilError(t, err)
+		bz, err := query.HandleQueryRaw(ecs.NewReadOnlyWorldContext(world), []byte("{}"))
+		if tc.wantErr != nil {
+			assert.ErrorIs(t, err, tc.wantErr)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, string(bz), tc.want)
+	}
+}
+
 func TestQueryEVM(t *testing.T) {
 	// --- TEST SETUP ---
 	type FooRequest struct {
@@ -116,3 +167,116 @@ func TestErrOnNoNameOrHandler(t *testing.T) {
 		})
 	}
 }
+
+type SnapshotCounter struct {
+	N int
+}
+
+func (SnapshotCounter) Name() string { return "snapshot_counter" }
+
+func TestConsistentQueriesDoNotSeeATickBeingCommitted(t *testing.T) {
+	w := testutils.NewTestWorld(t, cardinal.WithConsistentQueries()).Instance()
+	assert.NilError(t, ecs.RegisterComponent[SnapshotCounter](w))
+	started, proceed := make(chan struct{}), make(chan struct{})
+	type Reply struct {
+		First, Second int
+	}
+	var counterID entity.ID
+	readCounter := func(wCtx ecs.WorldContext) int {
+		counter, err := ecs.GetComponent[SnapshotCounter](wCtx, counterID)
+		assert.NilError(t, err)
+		return counter.N
+	}
+	assert.NilError(t, ecs.RegisterQuery[struct{}, Reply](w, "counter",
+		func(wCtx ecs.WorldContext, _ *struct{}) (*Reply, error) {
+			first := readCounter(wCtx)
+			close(started)
+			<-proceed
+			return &Reply{First: first, Second: readCounter(wCtx)}, nil
+		}))
+	w.RegisterSystem(func(wCtx ecs.WorldContext) error {
+		return ecs.UpdateComponent[SnapshotCounter](wCtx, counterID, func(c *SnapshotCounter) *SnapshotCounter {
+			c.N++
+			return c
+		})
+	})
+	assert.NilError(t, w.LoadGameState())
+	var err error
+	counterID, err = ecs.Create(ecs.NewWorldContext(w), SnapshotCounter{})
+	assert.NilError(t, err)
+	assert.NilError(t, w.Tick(context.Background()))
+
+	query, err := w.GetQueryByName("counter")
+	assert.NilError(t, err)
+	replies := make(chan any)
+	go func() {
+		reply, err := query.HandleQuery(ecs.NewReadOnlyWorldContext(w), struct{}{})
+		assert.NilError(t, err)
+		replies <- reply
+	}()
+	<-started
+
+	tickDone := make(chan struct{})
+	go func() {
+		assert.NilError(t, w.Tick(context.Background()))
+		close(tickDone)
+	}()
+	select {
+	case <-tickDone:
+		t.Fatal("tick was committed while a query was running")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(proceed)
+	reply, ok := (<-replies).(*Reply)
+	assert.Check(t, ok)
+	assert.Equal(t, reply.First, reply.Second)
+	<-tickDone
+}
+
+func TestNestedConsistentQueriesDoNotDeadlockWithAWaitingTick(t *testing.T) {
+	w := testutils.NewTestWorld(t, cardinal.WithConsistentQueries()).Instance()
+	assert.NilError(t, ecs.RegisterQuery[struct{}, struct{}](w, "inner",
+		func(ecs.WorldContext, *struct{}) (*struct{}, error) {
+			return &struct{}{}, nil
+		}))
+	started, proceed := make(chan struct{}), make(chan struct{})
+	assert.NilError(t, ecs.RegisterQuery[struct{}, struct{}](w, "outer",
+		func(wCtx ecs.WorldContext, _ *struct{}) (*struct{}, error) {
+			close(started)
+			<-proceed
+			inner, err := wCtx.GetWorld().GetQueryByName("inner")
+			if err != nil {
+				return nil, err
+			}
+			if _, err = inner.HandleQuery(wCtx, struct{}{}); err != nil {
+				return nil, err
+			}
+			return &struct{}{}, nil
+		}))
+	assert.NilError(t, w.LoadGameState())
+
+	outer, err := w.GetQueryByName("outer")
+	assert.NilError(t, err)
+	queryDone := make(chan error)
+	go func() {
+		_, err := outer.HandleQuery(ecs.NewReadOnlyWorldContext(w), struct{}{})
+		queryDone <- err
+	}()
+	<-started
+
+	tickDone := make(chan struct{})
+	go func() {
+		assert.NilError(t, w.Tick(context.Background()))
+		close(tickDone)
+	}()
+	// Give the tick time to start waiting for the outer query before the inner query runs.
+	time.Sleep(50 * time.Millisecond)
+	close(proceed)
+	select {
+	case err = <-queryDone:
+		assert.NilError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("nested query deadlocked with the tick waiting to commit")
+	}
+	<-tickDone
+}
@@ -0,0 +1,48 @@
+package ecs
+
+import (
+	"sync/atomic"
+
+	"github.com/rotisserie/eris"
+)
+
+// checkWritable returns ErrCannotModifyStateWithReadOnlyContext if the given WorldContext is read only. Every function
+// that changes the state of the world must call it first, so the attempt is also recorded for ReadOnly.
+func checkWritable(wCtx WorldContext) error {
+	if !wCtx.IsReadOnly() {
+		return nil
+	}
+	if w, ok := wCtx.(*worldContext); ok && w.mutationAttempts != nil {
+		w.mutationAttempts.Add(1)
+	}
+	return eris.Wrap(ErrCannotModifyStateWithReadOnlyContext, "")
+}
+
+// ReadOnly runs fn with a read only view of the given WorldContext. Every function that changes the state of the world
+// fails when it is given the view, and ReadOnly returns ErrCannotModifyStateWithReadOnlyContext if fn attempted any
+// change, even if fn ignored the error. Query handlers can use it to guarantee they never change state, e.g. when they
+// call helpers that are shared with systems.
+func ReadOnly(wCtx WorldContext, fn func(WorldContext) error) error {
+	view := &worldContext{
+		world:    wCtx.GetWorld(),
+		txQueue:  wCtx.GetTxQueue(),
+		readOnly: true,
+		ctx:      wCtx.Context(),
+		// The view reads what the given context reads, so e.g. changes made earlier in the same tick are visible.
+		reader:           wCtx.StoreReader(),
+		mutationAttempts: &atomic.Int64{},
+		snapshotPinned:   &atomic.Bool{},
+	}
+	if w, ok := wCtx.(*worldContext); ok {
+		view.logger = w.logger
+		if w.snapshotPinned != nil {
+			view.snapshotPinned = w.snapshotPinned
+		}
+	}
+	err := fn(view)
+	if attempts := view.mutationAttempts.Load(); attempts > 0 {
+		return eris.Wrapf(ErrCannotModifyStateWithReadOnlyContext, "%d changes were attempted in a read only block",
+			attempts)
+	}
+	return err
+}
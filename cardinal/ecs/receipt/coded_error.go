@@ -0,0 +1,31 @@
+package receipt
+
+import (
+	"errors"
+
+	"github.com/rotisserie/eris"
+)
+
+// CodedError is an error that carries a machine readable code (e.g. "INSUFFICIENT_FUNDS"), so clients can branch on
+// the code of a receipt error instead of parsing its message.
+type CodedError struct {
+	Code    string
+	Message string
+}
+
+func NewCodedError(code, message string) *CodedError {
+	return &CodedError{Code: code, Message: message}
+}
+
+func (e *CodedError) Error() string {
+	return e.Message
+}
+
+// ErrorCode returns the code of the first CodedError in the chain of err, or an empty string if there is none.
+func ErrorCode(err error) string {
+	var coded *CodedError
+	if errors.As(err, &coded) || errors.As(eris.Cause(err), &coded) {
+		return coded.Code
+	}
+	return ""
+}
@@ -24,13 +24,18 @@ type History struct {
 	history []map[message.TxHash]Receipt
 }
 
-// Receipt contains a transaction hash, an arbitrary result, and a list of errors.
+// Receipt contains a transaction hash, an arbitrary result, and a list of errors. TraceID is only set when the
+// transaction was tagged with a trace ID.
 type Receipt struct {
-	TxHash message.TxHash `json:"txHash"`
-	Result any            `json:"result"`
-	Errs   []error        `json:"errs"`
+	TxHash  message.TxHash `json:"txHash"`
+	Result  any            `json:"result"`
+	Errs    []error        `json:"errs"`
+	TraceID string         `json:"traceId,omitempty"`
 }
 
+// Results is the Result of a receipt whose results were added with AddResult. It is serialized as a list.
+type Results []any
+
 // NewHistory creates a object that can track transaction receipts over a number of ticks.
 func NewHistory(currentTick uint64, ticksToStore int) *History {
 	// Add an extra tick for the "current" tick.
@@ -84,6 +89,39 @@ func (h *History) SetResult(hash message.TxHash, result any) {
 	h.history[tick][hash] = rec
 }
 
+// AddResult appends the given result to the list of results of the given transaction hash, for messages with more than
+// one outcome. The receipt's Result is then of type Results. If a result was set with SetResult before, it becomes the
+// first item of the list.
+func (h *History) AddResult(hash message.TxHash, result any) {
+	tick := int(h.currTick.Load() % h.ticksToStore)
+	rec := h.history[tick][hash]
+	rec.TxHash = hash
+	switch existing := rec.Result.(type) {
+	case nil:
+		rec.Result = Results{result}
+	case Results:
+		rec.Result = append(existing, result)
+	default:
+		rec.Result = Results{existing, result}
+	}
+	h.history[tick][hash] = rec
+}
+
+// SetTraceID associates the given trace ID with the given transaction hash in the current tick.
+func (h *History) SetTraceID(hash message.TxHash, traceID string) {
+	tick := int(h.currTick.Load() % h.ticksToStore)
+	rec := h.history[tick][hash]
+	rec.TxHash = hash
+	rec.TraceID = traceID
+	h.history[tick][hash] = rec
+}
+
+// ClearCurrentTick discards all receipts for the current tick. This is used when a failed tick is retried.
+func (h *History) ClearCurrentTick() {
+	tick := int(h.currTick.Load() % h.ticksToStore)
+	h.history[tick] = map[message.TxHash]Receipt{}
+}
+
 // GetReceipt gets the receipt (the transaction result and the list of errors) for the given transaction hash in the
 // current tick. To get receipts from previous ticks use GetReceiptsForTick.
 func (h *History) GetReceipt(hash message.TxHash) (Receipt, bool) {
@@ -90,6 +90,24 @@ func TestCanReplaceResult(t *testing.T) {
 	assert.Equal(t, want, got)
 }
 
+func TestCanAddManyResults(t *testing.T) {
+	rh := NewHistory(99, 5)
+	hash := txHash(t)
+	rh.AddResult(hash, "a")
+	rh.AddResult(hash, "b")
+	rec, ok := rh.GetReceipt(hash)
+	assert.Check(t, ok)
+	assert.DeepEqual(t, Results{"a", "b"}, rec.Result)
+
+	// A result that was set with SetResult is kept as the first result.
+	otherHash := txHash(t)
+	rh.SetResult(otherHash, "a")
+	rh.AddResult(otherHash, "b")
+	rec, ok = rh.GetReceipt(otherHash)
+	assert.Check(t, ok)
+	assert.DeepEqual(t, Results{"a", "b"}, rec.Result)
+}
+
 func TestMissingHashReturnsNotOK(t *testing.T) {
 	rh := NewHistory(99, 5)
 	hash := txHash(t)
@@ -147,3 +165,9 @@ func TestOldTicksAreDiscarded(t *testing.T) {
 	_, err := rh.GetReceiptsForTick(tickToGet)
 	assert.ErrorIs(t, ErrOldTickHasBeenDiscarded, eris.Cause(err))
 }
+
+func TestErrorCodeIsFoundInWrappedErrors(t *testing.T) {
+	err := eris.Wrap(NewCodedError("INSUFFICIENT_FUNDS", "not enough gold"), "")
+	assert.Equal(t, "INSUFFICIENT_FUNDS", ErrorCode(err))
+	assert.Equal(t, "", ErrorCode(errors.New("plain error")))
+}
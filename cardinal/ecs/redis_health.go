@@ -0,0 +1,41 @@
+package ecs
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// DefaultRedisHealthCheckInterval is how long the result of a redis health check is reused when
+	// WithRedisHealthCheckInterval is not used.
+	DefaultRedisHealthCheckInterval = 5 * time.Second
+	redisPingTimeout                = time.Second
+)
+
+// IsRedisHealthy reports whether redis answered a PING. The result is reused for the interval given with
+// WithRedisHealthCheckInterval, so frequent health checks do not each send a PING to redis. While a PING is on its
+// way, other callers get the previous result instead of waiting for it.
+func (w *World) IsRedisHealthy() bool {
+	w.redisHealthMutex.Lock()
+	checked := !w.redisCheckedAt.IsZero()
+	if checked && (w.isCheckingRedis || time.Since(w.redisCheckedAt) < w.redisHealthCheckInterval) {
+		defer w.redisHealthMutex.Unlock()
+		return w.isRedisHealthy
+	}
+	w.isCheckingRedis = true
+	w.redisHealthMutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisPingTimeout)
+	defer cancel()
+	err := w.redisStorage.Client.Ping(ctx).Err()
+	if err != nil {
+		w.Logger.Error().Err(err).Msg("redis health check failed")
+	}
+
+	w.redisHealthMutex.Lock()
+	defer w.redisHealthMutex.Unlock()
+	w.isCheckingRedis = false
+	w.isRedisHealthy = err == nil
+	w.redisCheckedAt = time.Now()
+	return w.isRedisHealthy
+}
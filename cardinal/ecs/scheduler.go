@@ -0,0 +1,194 @@
+package ecs
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/rotisserie/eris"
+	"pkg.world.dev/world-engine/cardinal/txpool"
+	"pkg.world.dev/world-engine/cardinal/types/entity"
+	"pkg.world.dev/world-engine/cardinal/types/message"
+	"pkg.world.dev/world-engine/sign"
+)
+
+var (
+	ErrScheduledTickNotInFuture = errors.New("messages can only be scheduled for a future tick")
+	ErrMessageNotRegistered     = errors.New("message has not been registered")
+	ErrScheduledMessageNotFound = errors.New("scheduled message not found")
+)
+
+// ScheduledMessage describes a message that was scheduled with ScheduleMessage and has not been processed yet.
+type ScheduledMessage struct {
+	TxHash      message.TxHash
+	AtTick      uint64
+	MessageName string
+	Body        json.RawMessage
+}
+
+// scheduledMessage holds a message that will be added to the transaction queue of a future tick. Scheduled messages
+// are stored as entities, so they are saved and recovered along with the rest of the game state.
+type scheduledMessage struct {
+	AtTick    uint64
+	MessageID message.TypeID
+	Tx        *sign.Transaction
+}
+
+func (scheduledMessage) Name() string {
+	return "ScheduledMessage"
+}
+
+// ScheduleMessage stores the given message body so that it is processed as a system transaction in the given tick.
+// The returned hash can be used to look up the receipt of the message once that tick has run.
+func (w *worldContext) ScheduleMessage(msg message.Message, body any, atTick uint64) (message.TxHash, error) {
+	if err := checkWritable(w); err != nil {
+		return "", err
+	}
+	if atTick <= w.CurrentTick() {
+		return "", eris.Wrapf(ErrScheduledTickNotInFuture, "tick %d is not after tick %d", atTick, w.CurrentTick())
+	}
+	if !w.world.isMessageRegistered(msg) {
+		return "", eris.Wrapf(ErrMessageNotRegistered, "message %q", msg.Name())
+	}
+	bz, err := msg.Encode(body)
+	if err != nil {
+		return "", err
+	}
+	id, err := create(w, scheduledMessage{})
+	if err != nil {
+		return "", err
+	}
+	// The entity ID is used as the nonce so every scheduled message gets a unique, deterministic tx hash.
+	tx := &sign.Transaction{
+		PersonaTag: sign.SystemPersonaTag,
+		Namespace:  w.world.Namespace().String(),
+		Nonce:      uint64(id),
+		Body:       bz,
+	}
+	err = SetComponent[scheduledMessage](w, id, &scheduledMessage{
+		AtTick:    atTick,
+		MessageID: msg.ID(),
+		Tx:        tx,
+	})
+	if err != nil {
+		return "", err
+	}
+	return message.TxHash(tx.HashHex()), nil
+}
+
+// ScheduledMessages returns every message that has been scheduled but not processed yet, in the order they were
+// scheduled.
+func (w *worldContext) ScheduledMessages() ([]ScheduledMessage, error) {
+	ids, scheduled, err := w.world.getScheduledMessages(w)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]ScheduledMessage, 0, len(ids))
+	for _, id := range ids {
+		sm := scheduled[id]
+		name := ""
+		if msg := w.world.getMessage(sm.MessageID); msg != nil {
+			name = msg.Name()
+		}
+		result = append(result, ScheduledMessage{
+			TxHash:      message.TxHash(sm.Tx.HashHex()),
+			AtTick:      sm.AtTick,
+			MessageName: name,
+			Body:        sm.Tx.Body,
+		})
+	}
+	return result, nil
+}
+
+// CancelScheduled removes the scheduled message with the given hash (as returned by ScheduleMessage) so it is never
+// processed, e.g. because the game state that made the message necessary has changed.
+func (w *worldContext) CancelScheduled(txHash message.TxHash) error {
+	if err := checkWritable(w); err != nil {
+		return err
+	}
+	ids, scheduled, err := w.world.getScheduledMessages(w)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if message.TxHash(scheduled[id].Tx.HashHex()) == txHash {
+			return w.world.Remove(id)
+		}
+	}
+	return eris.Wrapf(ErrScheduledMessageNotFound, "tx hash %q", txHash)
+}
+
+// getScheduledMessages returns the entity IDs of all scheduled messages in the order they were scheduled along with
+// the scheduled messages themselves.
+func (w *World) getScheduledMessages(wCtx WorldContext) ([]entity.ID, map[entity.ID]*scheduledMessage, error) {
+	search, err := w.newInternalSearch(Exact(scheduledMessage{}))
+	if err != nil {
+		return nil, nil, err
+	}
+	var ids []entity.ID
+	scheduled := map[entity.ID]*scheduledMessage{}
+	var errs []error
+	err = search.Each(wCtx, func(id entity.ID) bool {
+		sm, err := GetComponent[scheduledMessage](wCtx, id)
+		if err != nil {
+			errs = append(errs, err)
+			return false
+		}
+		ids = append(ids, id)
+		scheduled[id] = sm
+		return true
+	})
+	if err = errors.Join(append(errs, err)...); err != nil {
+		return nil, nil, err
+	}
+	// Entity IDs are handed out in order, so this sorts the messages in the order they were scheduled.
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids, scheduled, nil
+}
+
+func (w *World) isMessageRegistered(msg message.Message) bool {
+	for _, registered := range w.registeredMessages {
+		if registered.Name() == msg.Name() {
+			return true
+		}
+	}
+	return false
+}
+
+// addScheduledMessages returns a copy of txQueue that also contains every scheduled message that is due in the
+// current tick. The scheduled messages are removed from the game state; if the tick fails, that removal is discarded
+// so the messages are added again when the tick is retried or recovered. txQueue itself is never modified.
+func (w *World) addScheduledMessages(txQueue *txpool.TxQueue) (*txpool.TxQueue, error) {
+	ids, scheduled, err := w.getScheduledMessages(NewWorldContext(w))
+	if err != nil {
+		return nil, err
+	}
+	var dueIDs []entity.ID
+	for _, id := range ids {
+		if scheduled[id].AtTick <= w.CurrentTick() {
+			dueIDs = append(dueIDs, id)
+		}
+	}
+	if len(dueIDs) == 0 {
+		return txQueue, nil
+	}
+	queue := txQueue.Clone()
+	for _, id := range dueIDs {
+		sm := scheduled[id]
+		msg := w.getMessage(sm.MessageID)
+		if msg == nil {
+			return nil, eris.Errorf("error adding scheduled tx with ID %d: tx id not found", sm.MessageID)
+		}
+		v, err := msg.Decode(sm.Tx.Body)
+		if err != nil {
+			return nil, err
+		}
+		queue.AddTransaction(sm.MessageID, v, sm.Tx)
+		if err = w.Remove(id); err != nil {
+			return nil, err
+		}
+	}
+	return queue, nil
+}
@@ -9,6 +9,10 @@ import (
 	"pkg.world.dev/world-engine/cardinal/types/entity"
 )
 
+// searchCancelCheckInterval is the number of entities a search visits between checks of whether its context was
+// cancelled.
+const searchCancelCheckInterval = 1024
+
 type cache struct {
 	archetypes []archetype.ID
 	seen       int
@@ -23,6 +27,12 @@ type cache struct {
 type Search struct {
 	archMatches map[Namespace]*cache
 	filter      filter.ComponentFilter
+	// limit and offset bound the entities visited by Each. See Search.Limit and Search.Offset.
+	limit  int
+	offset int
+	// includeInternal makes the search find entities with the components the world uses internally, which are
+	// skipped otherwise.
+	includeInternal bool
 }
 
 // NewSearch creates a new search.
@@ -36,19 +46,79 @@ func NewSearch(filter filter.ComponentFilter) *Search {
 
 type SearchCallBackFn func(entity.ID) bool
 
-// Each iterates over all entities that match the search.
+// Limit caps the number of entities visited by Each at n. A limit of 0 or less removes the cap. Count and First are
+// not affected. The search is modified in place and returned to allow chaining.
+func (q *Search) Limit(n int) *Search {
+	q.limit = n
+	return q
+}
+
+// Offset makes Each skip the first n matching entities. Combined with Limit this allows large searches to be
+// processed in bounded pages. Count and First are not affected. The search is modified in place and returned to allow
+// chaining.
+func (q *Search) Offset(n int) *Search {
+	q.offset = n
+	return q
+}
+
+// Each iterates over all entities that match the search, honoring any Offset and Limit.
 // If you would like to stop the iteration, return false to the callback. To continue iterating, return true.
 func (q *Search) Each(wCtx WorldContext, callback SearchCallBackFn) error {
+	return q.eachWithArchetype(wCtx, func(id entity.ID, _ archetype.ID) bool {
+		return callback(id)
+	})
+}
+
+// EachWithComponents is identical to Each, but also passes the names of all the components on each entity (its
+// archetype) to the callback. The names are looked up once per archetype, so this is nearly as cheap as Each. The
+// callback must not modify the names slice.
+func (q *Search) EachWithComponents(wCtx WorldContext, callback func(id entity.ID, components []string) bool) error {
 	reader := wCtx.StoreReader()
-	result := q.evaluateSearch(wCtx.GetWorld().Namespace(), reader)
-	iter := storage.NewEntityIterator(0, reader, result)
-	for iter.HasNext() {
-		entities, err := iter.Next()
+	archToNames := map[archetype.ID][]string{}
+	return q.eachWithArchetype(wCtx, func(id entity.ID, archID archetype.ID) bool {
+		names, ok := archToNames[archID]
+		if !ok {
+			for _, c := range reader.GetComponentTypesForArchID(archID) {
+				names = append(names, c.Name())
+			}
+			archToNames[archID] = names
+		}
+		return callback(id, names)
+	})
+}
+
+func (q *Search) eachWithArchetype(wCtx WorldContext, callback func(entity.ID, archetype.ID) bool) error {
+	reader := wCtx.StoreReader()
+	ctx := wCtx.Context()
+	result := q.evaluateSearch(wCtx.GetWorld(), reader)
+	skipped, visited := 0, 0
+	for _, archID := range result {
+		if err := ctx.Err(); err != nil {
+			return eris.Wrap(err, "search was cancelled")
+		}
+		entities, err := reader.GetEntitiesForArchID(archID)
 		if err != nil {
 			return err
 		}
+		if toSkip := q.offset - skipped; toSkip > 0 {
+			if toSkip >= len(entities) {
+				skipped += len(entities)
+				continue
+			}
+			entities = entities[toSkip:]
+			skipped = q.offset
+		}
 		for _, id := range entities {
-			cont := callback(id)
+			if q.limit > 0 && visited >= q.limit {
+				return nil
+			}
+			visited++
+			if visited%searchCancelCheckInterval == 0 {
+				if err := ctx.Err(); err != nil {
+					return eris.Wrap(err, "search was cancelled")
+				}
+			}
+			cont := callback(id, archID)
 			if !cont {
 				return nil
 			}
@@ -59,9 +129,8 @@ func (q *Search) Each(wCtx WorldContext, callback SearchCallBackFn) error {
 
 // Count returns the number of entities that match the search.
 func (q *Search) Count(wCtx WorldContext) (int, error) {
-	namespace := wCtx.GetWorld().Namespace()
 	reader := wCtx.StoreReader()
-	result := q.evaluateSearch(namespace, reader)
+	result := q.evaluateSearch(wCtx.GetWorld(), reader)
 	iter := storage.NewEntityIterator(0, reader, result)
 	ret := 0
 	for iter.HasNext() {
@@ -76,9 +145,8 @@ func (q *Search) Count(wCtx WorldContext) (int, error) {
 
 // First returns the first entity that matches the search.
 func (q *Search) First(wCtx WorldContext) (id entity.ID, err error) {
-	namespace := wCtx.GetWorld().Namespace()
 	reader := wCtx.StoreReader()
-	result := q.evaluateSearch(namespace, reader)
+	result := q.evaluateSearch(wCtx.GetWorld(), reader)
 	iter := storage.NewEntityIterator(0, reader, result)
 	if !iter.HasNext() {
 		return storage.BadID, eris.Wrap(err, "")
@@ -104,7 +172,8 @@ func (q *Search) MustFirst(wCtx WorldContext) entity.ID {
 	return id
 }
 
-func (q *Search) evaluateSearch(namespace Namespace, sm store.Reader) []archetype.ID {
+func (q *Search) evaluateSearch(world *World, sm store.Reader) []archetype.ID {
+	namespace := world.Namespace()
 	if _, ok := q.archMatches[namespace]; !ok {
 		q.archMatches[namespace] = &cache{
 			archetypes: make([]archetype.ID, 0),
@@ -113,7 +182,11 @@ func (q *Search) evaluateSearch(namespace Namespace, sm store.Reader) []archetyp
 	}
 	cache := q.archMatches[namespace]
 	for it := sm.SearchFrom(q.filter, cache.seen); it.HasNext(); {
-		cache.archetypes = append(cache.archetypes, it.Next())
+		archID := it.Next()
+		if !q.includeInternal && world.hasInternalComponent(sm.GetComponentTypesForArchID(archID)) {
+			continue
+		}
+		cache.archetypes = append(cache.archetypes, archID)
 	}
 	cache.seen = sm.ArchetypeCount()
 	return cache.archetypes
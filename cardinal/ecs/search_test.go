@@ -1,6 +1,7 @@
 package ecs_test
 
 import (
+	"context"
 	"testing"
 
 	"pkg.world.dev/world-engine/cardinal/testutils"
@@ -54,3 +55,96 @@ func TestSearchEarlyTermination(t *testing.T) {
 	)
 	assert.Equal(t, count, total)
 }
+
+func TestSearchLimitAndOffset(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[FooComponent](world))
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](world))
+
+	wCtx := ecs.NewWorldContext(world)
+	// Spread the entities over two archetypes so pages cross archetype boundaries.
+	fooIDs, err := ecs.CreateMany(wCtx, 5, FooComponent{})
+	assert.NilError(t, err)
+	fooEnergyIDs, err := ecs.CreateMany(wCtx, 5, FooComponent{}, EnergyComponent{})
+	assert.NilError(t, err)
+	allIDs := append(append([]entity.ID{}, fooIDs...), fooEnergyIDs...)
+
+	collect := func(q *ecs.Search) []entity.ID {
+		var ids []entity.ID
+		assert.NilError(t, q.Each(wCtx, func(id entity.ID) bool {
+			ids = append(ids, id)
+			return true
+		}))
+		return ids
+	}
+	q, err := world.NewSearch(ecs.Contains(FooComponent{}))
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, allIDs[:3], collect(q.Limit(3)))
+	assert.DeepEqual(t, allIDs[3:7], collect(q.Offset(3).Limit(4)))
+	assert.DeepEqual(t, allIDs[9:], collect(q.Offset(9).Limit(4)))
+	assert.Equal(t, 0, len(collect(q.Offset(10))))
+	assert.DeepEqual(t, allIDs, collect(q.Offset(0).Limit(0)))
+
+	// Count ignores the limit and offset so it can be used to compute the number of pages.
+	count, err := q.Offset(3).Limit(4).Count(wCtx)
+	assert.NilError(t, err)
+	assert.Equal(t, 10, count)
+}
+
+func TestSearchEachWithComponents(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[FooComponent](world))
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](world))
+
+	wCtx := ecs.NewWorldContext(world)
+	fooID, err := ecs.Create(wCtx, FooComponent{})
+	assert.NilError(t, err)
+	fooEnergyID, err := ecs.Create(wCtx, FooComponent{}, EnergyComponent{})
+	assert.NilError(t, err)
+
+	q, err := world.NewSearch(ecs.Contains(FooComponent{}))
+	assert.NilError(t, err)
+	got := map[entity.ID][]string{}
+	assert.NilError(t, q.EachWithComponents(wCtx, func(id entity.ID, components []string) bool {
+		got[id] = components
+		return true
+	}))
+	assert.DeepEqual(t, map[entity.ID][]string{
+		fooID:       {"foo"},
+		fooEnergyID: {"foo", "EnergyComponent"},
+	}, got)
+}
+
+func TestSearchStopsWhenContextIsCancelled(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[FooComponent](world))
+
+	total := 3000
+	_, err := ecs.CreateMany(ecs.NewWorldContext(world), total, FooComponent{})
+	assert.NilError(t, err)
+	q, err := world.NewSearch(ecs.Exact(FooComponent{}))
+	assert.NilError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wCtx := ecs.NewReadOnlyWorldContextWithContext(world, ctx)
+	count := 0
+	err = q.Each(wCtx, func(id entity.ID) bool {
+		count++
+		if count == 10 {
+			cancel()
+		}
+		return true
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Check(t, count < total, "the search should stop before visiting every entity")
+
+	// A search with a context that was cancelled beforehand visits nothing.
+	count = 0
+	err = q.Each(wCtx, func(id entity.ID) bool {
+		count++
+		return true
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, count)
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"fmt"
 	"reflect"
+	"time"
 
 	"pkg.world.dev/world-engine/cardinal/ecs/codec"
 	"pkg.world.dev/world-engine/cardinal/types/component"
@@ -68,3 +69,11 @@ func (m *MockComponentType[T]) Encode(a any) ([]byte, error) {
 func (m *MockComponentType[T]) GetSchema() []byte {
 	return m.schema
 }
+
+func (m *MockComponentType[T]) TTL() uint64 {
+	return 0
+}
+
+func (m *MockComponentType[T]) RedisTTL() time.Duration {
+	return 0
+}
@@ -11,6 +11,28 @@ func (r *NonceStorage) nonceSetKey(str string) string {
 	return fmt.Sprintf("USED_NONCES_%s", str)
 }
 
+/*
+	ACCEPTED TX STORAGE: ACCEPTED_TX_<hash> -> Tick in which a recently accepted transaction was added to the tx queue.
+	Only used with a nonce retry window. Each key expires once the retry window is over.
+*/
+
+func (r *NonceStorage) acceptedTxKey(txHash string) string {
+	return fmt.Sprintf("ACCEPTED_TX_%s", txHash)
+}
+
+/*
+	TX QUEUE STORAGE:   TX_QUEUE -> Transactions that have not been processed by a tick yet.
+	Hash of tx ID to the encoded transaction, and a sorted set of tx IDs in the order they were added.
+*/
+
+func (r *TxQueueStorage) txQueueKey() string {
+	return "TX_QUEUE"
+}
+
+func (r *TxQueueStorage) txQueueOrderKey() string {
+	return "TX_QUEUE_ORDER"
+}
+
 func (r *SchemaStorage) schemaStorageKey() string {
 	return "COMPONENT_NAME_TO_SCHEMA_DATA"
 }
@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/rotisserie/eris"
@@ -35,3 +36,54 @@ func (r *NonceStorage) UseNonce(signerAddress string, nonce uint64) error {
 	}
 	return nil
 }
+
+// IsNonceUsed reports whether the given nonce has already been used by the given signer. Unlike UseNonce, this does
+// not mark the nonce as used.
+func (r *NonceStorage) IsNonceUsed(signerAddress string, nonce uint64) (bool, error) {
+	ctx := context.Background()
+	key := r.nonceSetKey(signerAddress)
+	used, err := r.Client.SIsMember(ctx, key, nonce).Result()
+	if err != nil {
+		return false, eris.Wrap(err, "")
+	}
+	return used, nil
+}
+
+// AcceptTx records that the transaction with the given hash was added to the tx queue in the given tick. The record
+// expires after the given retry window. If the transaction was already accepted within the window, the tick it was
+// first accepted in is returned along with true, and the transaction must not be added to the tx queue again.
+func (r *NonceStorage) AcceptTx(txHash string, tick uint64, window time.Duration) (acceptedTick uint64, isRetry bool,
+	err error) {
+	ctx := context.Background()
+	key := r.acceptedTxKey(txHash)
+	added, err := r.Client.SetNX(ctx, key, tick, window).Result()
+	if err != nil {
+		return 0, false, eris.Wrap(err, "")
+	}
+	if added {
+		return tick, false, nil
+	}
+	acceptedTick, err = r.Client.Get(ctx, key).Uint64()
+	err = eris.Wrap(err, "")
+	if eris.Is(eris.Cause(err), redis.Nil) {
+		// The record expired in between the two calls, so the retry window is over.
+		return tick, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+	return acceptedTick, true, nil
+}
+
+// RemoveAcceptedTx removes the record made by AcceptTx for the transaction with the given hash.
+func (r *NonceStorage) RemoveAcceptedTx(txHash string) error {
+	return eris.Wrap(r.Client.Del(context.Background(), r.acceptedTxKey(txHash)).Err(), "")
+}
+
+// IsTxAccepted reports whether the transaction with the given hash was accepted within its retry window. See AcceptTx.
+func (r *NonceStorage) IsTxAccepted(txHash string) (bool, error) {
+	count, err := r.Client.Exists(context.Background(), r.acceptedTxKey(txHash)).Result()
+	if err != nil {
+		return false, eris.Wrap(err, "")
+	}
+	return count > 0, nil
+}
@@ -14,6 +14,7 @@ type Storage struct {
 	Log       zerolog.Logger
 	Nonce     NonceStorage
 	Schema    SchemaStorage
+	TxQueue   TxQueueStorage
 }
 
 type Options = redis.Options
@@ -26,6 +27,7 @@ func NewRedisStorage(options Options, namespace string) Storage {
 		Log:       zerolog.New(os.Stdout),
 		Nonce:     NewNonceStorage(client),
 		Schema:    NewSchemaStorage(client),
+		TxQueue:   NewTxQueueStorage(client),
 	}
 }
 
@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rotisserie/eris"
+)
+
+// TxQueueStorage durably stores transactions that have been accepted, but not yet processed by a tick.
+type TxQueueStorage struct {
+	Client *redis.Client
+}
+
+func NewTxQueueStorage(client *redis.Client) TxQueueStorage {
+	return TxQueueStorage{
+		Client: client,
+	}
+}
+
+// Add stores the given encoded transaction under the given ID.
+func (r *TxQueueStorage) Add(id string, bz []byte) error {
+	ctx := context.Background()
+	pipe := r.Client.TxPipeline()
+	pipe.ZAdd(ctx, r.txQueueOrderKey(), redis.Z{Score: float64(time.Now().UnixNano()), Member: id})
+	pipe.HSet(ctx, r.txQueueKey(), id, bz)
+	_, err := pipe.Exec(ctx)
+	return eris.Wrap(err, "")
+}
+
+// RemoveInPipe adds the commands that remove the transactions with the given IDs to the given pipe, so they are
+// removed in the same redis transaction as the other commands of the pipe.
+func (r *TxQueueStorage) RemoveInPipe(ctx context.Context, pipe redis.Pipeliner, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	members := make([]any, 0, len(ids))
+	for _, id := range ids {
+		members = append(members, id)
+	}
+	if err := pipe.ZRem(ctx, r.txQueueOrderKey(), members...).Err(); err != nil {
+		return eris.Wrap(err, "")
+	}
+	return eris.Wrap(pipe.HDel(ctx, r.txQueueKey(), ids...).Err(), "")
+}
+
+// GetAll returns the IDs of all stored transactions, and the transactions, in the order they were added.
+func (r *TxQueueStorage) GetAll() (ids []string, txs [][]byte, err error) {
+	ctx := context.Background()
+	storedIDs, err := r.Client.ZRange(ctx, r.txQueueOrderKey(), 0, -1).Result()
+	if err != nil {
+		return nil, nil, eris.Wrap(err, "")
+	}
+	if len(storedIDs) == 0 {
+		return nil, nil, nil
+	}
+	values, err := r.Client.HMGet(ctx, r.txQueueKey(), storedIDs...).Result()
+	if err != nil {
+		return nil, nil, eris.Wrap(err, "")
+	}
+	for i, value := range values {
+		// A missing value means the transaction was removed between the two reads.
+		if s, ok := value.(string); ok {
+			ids = append(ids, storedIDs[i])
+			txs = append(txs, []byte(s))
+		}
+	}
+	return ids, txs, nil
+}
@@ -33,6 +33,8 @@ type Reader interface {
 	// Misc
 	SearchFrom(filter filter.ComponentFilter, start int) *storage.ArchetypeIterator
 	ArchetypeCount() int
+	// EntityCount returns the number of live entities.
+	EntityCount() (int, error)
 }
 
 type Writer interface {
@@ -52,12 +54,19 @@ type Writer interface {
 	InjectLogger(logger *ecslog.Logger)
 	Close() error
 	RegisterComponents([]component.ComponentMetadata) error
+	// RemoveExpiredEntities removes the entities that lost a component with a redis TTL while the world was not
+	// running, and returns how many were removed. See component.WithRedisTTL.
+	RemoveExpiredEntities() (int, error)
 }
 
 type TickStorage interface {
 	GetTickNumbers() (start, end uint64, err error)
-	StartNextTick(txs []message.Message, queues *txpool.TxQueue) error
+	// StartNextTick saves the transactions of the next tick, and removes the transactions with the given IDs from the
+	// durable tx queue.
+	StartNextTick(txs []message.Message, queues *txpool.TxQueue, durableTxIDs ...string) error
 	FinalizeTick(event *zerolog.Event) error
+	// DiscardPending discards any state changes made since the last successful tick.
+	DiscardPending()
 	Recover(txs []message.Message) (*txpool.TxQueue, error)
 }
 
@@ -1,3 +1,171 @@
 package ecs
 
+import (
+	"errors"
+	"strings"
+
+	"github.com/rotisserie/eris"
+	ecslog "pkg.world.dev/world-engine/cardinal/ecs/log"
+	"pkg.world.dev/world-engine/cardinal/types/component"
+)
+
 type System func(WorldContext) error
+
+var (
+	ErrSystemDependencyCycle   = errors.New("system dependencies contain a cycle")
+	ErrSystemDependencyUnknown = errors.New("system depends on a system that is not registered")
+	ErrSystemComponentUnknown  = errors.New("system uses a component that is not registered")
+	ErrSystemNotFound          = errors.New("system is not registered")
+	// ErrTickSystemsWithAdapter is returned by TickSystems if the world persists its transactions with an adapter. A
+	// chain recovery would run every system on the transactions of the tick, so the recovered state would differ.
+	ErrTickSystemsWithAdapter = errors.New("ticks that skip systems can not be run when an adapter is set")
+)
+
+// SystemOption changes when a registered system runs.
+type SystemOption func(*systemRunConfig)
+
+type systemRunConfig struct {
+	skipDuringRecovery bool
+	recoveryOnly       bool
+	// components are the names of the components the system declared with WithUsedComponents.
+	components []string
+}
+
+// WithSkipDuringRecovery skips the system while the world is recovering from the chain, so it only runs during live
+// ticks. Use it for systems with external side effects (e.g. sending notifications) that must not be repeated when
+// the recovered ticks are replayed.
+func WithSkipDuringRecovery() SystemOption {
+	return func(c *systemRunConfig) {
+		c.skipDuringRecovery = true
+	}
+}
+
+// WithRecoveryOnly only runs the system while the world is recovering from the chain, e.g. to migrate the state that
+// is rebuilt from the replayed ticks.
+func WithRecoveryOnly() SystemOption {
+	return func(c *systemRunConfig) {
+		c.recoveryOnly = true
+	}
+}
+
+// WithUsedComponents declares the components the system reads or writes. LoadGameState returns
+// ErrSystemComponentUnknown if any of them is not registered, so a missing registration is caught at startup instead of
+// failing the first tick that runs the system.
+func WithUsedComponents(comps ...component.Component) SystemOption {
+	return func(c *systemRunConfig) {
+		for _, comp := range comps {
+			c.components = append(c.components, comp.Name())
+		}
+	}
+}
+
+// validateSystemComponents checks that every component declared with WithUsedComponents is registered.
+func (w *World) validateSystemComponents() error {
+	for i, config := range w.systemRunConfigs {
+		var missing []string
+		for _, name := range config.components {
+			if _, ok := w.nameToComponent[name]; !ok {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			return eris.Wrapf(ErrSystemComponentUnknown, "system %s uses %s", w.systemNames[i],
+				strings.Join(missing, ", "))
+		}
+	}
+	return nil
+}
+
+func (c systemRunConfig) shouldRun(isRecovering bool) bool {
+	if isRecovering {
+		return !c.skipDuringRecovery
+	}
+	return !c.recoveryOnly
+}
+
+// RegisterSystemWithNameAfter registers a system that must run after all the systems named in afterNames. A name
+// matches a system if it is equal to the system's registered name, or to the part of that name after the last ".",
+// so "MoveSystem" matches a system registered as "system.MoveSystem". Systems are sorted when the game state is
+// loaded, and LoadGameState returns an error if a dependency is not registered or the dependencies form a cycle.
+func (w *World) RegisterSystemWithNameAfter(system System, functionName string, afterNames ...string) {
+	w.RegisterSystemWithName(system, functionName)
+	if w.systemDependencies == nil {
+		// maps a system's index to the names of the systems it must run after.
+		w.systemDependencies = map[int][]string{}
+	}
+	w.systemDependencies[len(w.systems)-1] = afterNames
+}
+
+func systemNameMatches(systemName, name string) bool {
+	return systemName == name || strings.HasSuffix(systemName, "."+name)
+}
+
+// sortSystems orders the registered systems so that every system runs after the systems it depends on. Systems that
+// are not constrained by a dependency keep their registration order.
+func (w *World) sortSystems() error {
+	if len(w.systemDependencies) == 0 {
+		return nil
+	}
+	numSystems := len(w.systems)
+	// dependents[i] holds the indexes of the systems that must run after system i.
+	dependents := make([][]int, numSystems)
+	inDegree := make([]int, numSystems)
+	for i, afterNames := range w.systemDependencies {
+		for _, afterName := range afterNames {
+			found := false
+			for j, name := range w.systemNames {
+				if j == i || !systemNameMatches(name, afterName) {
+					continue
+				}
+				found = true
+				dependents[j] = append(dependents[j], i)
+				inDegree[i]++
+			}
+			if !found {
+				return eris.Wrapf(ErrSystemDependencyUnknown, "system %q must run after %q",
+					w.systemNames[i], afterName)
+			}
+		}
+	}
+
+	order := make([]int, 0, numSystems)
+	done := make([]bool, numSystems)
+	for len(order) < numSystems {
+		// pick the earliest registered system whose dependencies have all run.
+		next := -1
+		for i := 0; i < numSystems; i++ {
+			if !done[i] && inDegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			var cycle []string
+			for i := 0; i < numSystems; i++ {
+				if !done[i] {
+					cycle = append(cycle, w.systemNames[i])
+				}
+			}
+			return eris.Wrapf(ErrSystemDependencyCycle, "involving systems %v", cycle)
+		}
+		done[next] = true
+		order = append(order, next)
+		for _, dependent := range dependents[next] {
+			inDegree[dependent]--
+		}
+	}
+
+	systems := make([]System, 0, numSystems)
+	names := make([]string, 0, numSystems)
+	loggers := make([]*ecslog.Logger, 0, numSystems)
+	runConfigs := make([]systemRunConfig, 0, numSystems)
+	for _, i := range order {
+		systems = append(systems, w.systems[i])
+		names = append(names, w.systemNames[i])
+		loggers = append(loggers, w.systemLoggers[i])
+		runConfigs = append(runConfigs, w.systemRunConfigs[i])
+	}
+	w.systems, w.systemNames, w.systemLoggers, w.systemRunConfigs = systems, names, loggers, runConfigs
+	w.systemDependencies = nil
+	return nil
+}
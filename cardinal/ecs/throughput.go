@@ -0,0 +1,102 @@
+package ecs
+
+import (
+	"sync"
+	"time"
+
+	"pkg.world.dev/world-engine/cardinal/txpool"
+)
+
+// ThroughputWindow is the period over which ThroughputStats.PerSecond is averaged.
+const ThroughputWindow = time.Minute
+
+// ThroughputStats describes how many transactions the world has processed. See World.Throughput.
+type ThroughputStats struct {
+	// Processed is the number of transactions processed since the world started.
+	Processed uint64
+	// ProcessedLastTick is the number of transactions processed by the last tick.
+	ProcessedLastTick uint64
+	// PeakPerTick is the largest number of transactions processed by a single tick.
+	PeakPerTick uint64
+	// PerSecond is the average number of transactions processed per second over the last ThroughputWindow.
+	PerSecond float64
+	// Failed is the number of processed transactions whose receipt had at least one error.
+	Failed uint64
+}
+
+type throughputSample struct {
+	at  time.Time
+	txs uint64
+}
+
+// throughputCounter is updated at the end of every tick.
+type throughputCounter struct {
+	mutex     sync.Mutex
+	stats     ThroughputStats
+	startTime time.Time
+	// samples holds the number of transactions of each tick that ended within the last ThroughputWindow.
+	samples []throughputSample
+}
+
+// recordThroughput counts the transactions processed by the tick that just finished. Failures are read from the
+// receipts of the tick, so it must be called before the receipt history moves on to the next tick.
+func (w *World) recordThroughput(txQueue *txpool.TxQueue) {
+	now := time.Now()
+	txs := txQueue.GetTxs()
+	failed := uint64(0)
+	for _, tx := range txs {
+		if rec, found := w.receiptHistory.GetReceipt(tx.TxHash); found && len(rec.Errs) > 0 {
+			failed++
+		}
+	}
+
+	c := &w.throughput
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.startTime.IsZero() {
+		c.startTime = now
+	}
+	processed := uint64(len(txs))
+	c.stats.Processed += processed
+	c.stats.ProcessedLastTick = processed
+	c.stats.Failed += failed
+	if processed > c.stats.PeakPerTick {
+		c.stats.PeakPerTick = processed
+	}
+	c.samples = append(c.samples, throughputSample{at: now, txs: processed})
+	c.pruneSamples(now)
+}
+
+// pruneSamples drops the samples that are older than ThroughputWindow.
+func (c *throughputCounter) pruneSamples(now time.Time) {
+	i := 0
+	for i < len(c.samples) && now.Sub(c.samples[i].at) > ThroughputWindow {
+		i++
+	}
+	c.samples = c.samples[i:]
+}
+
+// Throughput returns the transaction throughput of the world since it started.
+func (w *World) Throughput() ThroughputStats {
+	c := &w.throughput
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now()
+	c.pruneSamples(now)
+	stats := c.stats
+	if len(c.samples) > 0 {
+		// Average over the part of the window the world has been running, so a young world is not under reported.
+		window := ThroughputWindow
+		if running := now.Sub(c.startTime); running < window {
+			window = running
+		}
+		total := uint64(0)
+		for _, sample := range c.samples {
+			total += sample.txs
+		}
+		if window > 0 {
+			stats.PerSecond = float64(total) / window.Seconds()
+		}
+	}
+	return stats
+}
@@ -0,0 +1,49 @@
+package ecs
+
+import (
+	"encoding/json"
+	"time"
+
+	"pkg.world.dev/world-engine/cardinal/events"
+	"pkg.world.dev/world-engine/cardinal/txpool"
+)
+
+// TickProgress is broadcast on the event hub, with the events.TickProgressTopic topic, before each system of a long
+// tick runs. See WithTickProgressEvents.
+type TickProgress struct {
+	Tick uint64 `json:"tick"`
+	// System is the name of the system that is about to run.
+	System      string `json:"system"`
+	SystemIndex int    `json:"systemIndex"`
+	SystemCount int    `json:"systemCount"`
+	// ProcessedTxs is the number of txs whose message type was read by the systems that already ran.
+	ProcessedTxs int   `json:"processedTxs"`
+	TotalTxs     int   `json:"totalTxs"`
+	ElapsedMs    int64 `json:"elapsedMs"`
+}
+
+// broadcastTickProgress tells event hub subscribers which system is about to run, once the tick has been running for
+// longer than the threshold given with WithTickProgressEvents.
+func (w *World) broadcastTickProgress(tickStart time.Time, systemIndex int, txQueue *txpool.TxQueue) {
+	if !w.tickProgressEvents || w.eventHub == nil || w.IsRecovering() {
+		return
+	}
+	elapsed := time.Since(tickStart)
+	if elapsed < w.tickProgressThreshold {
+		return
+	}
+	bz, err := json.Marshal(TickProgress{
+		Tick:         w.CurrentTick(),
+		System:       w.systemNames[systemIndex],
+		SystemIndex:  systemIndex,
+		SystemCount:  len(w.systems),
+		ProcessedTxs: txQueue.GetAmountOfConsumedTxs(),
+		TotalTxs:     txQueue.GetAmountOfTxs(),
+		ElapsedMs:    elapsed.Milliseconds(),
+	})
+	if err != nil {
+		w.Logger.Error().Err(err).Msg("failed to encode tick progress")
+		return
+	}
+	w.eventHub.Broadcast(&events.Event{Message: string(bz), Topic: events.TickProgressTopic})
+}
@@ -7,9 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/rotisserie/eris"
+	"pkg.world.dev/world-engine/cardinal"
 	"pkg.world.dev/world-engine/cardinal/testutils"
 
 	"pkg.world.dev/world-engine/assert"
@@ -20,6 +24,9 @@ import (
 	"pkg.world.dev/world-engine/cardinal/ecs/internal/testutil"
 	"pkg.world.dev/world-engine/cardinal/ecs/log"
 	"pkg.world.dev/world-engine/cardinal/ecs/storage"
+	"pkg.world.dev/world-engine/cardinal/types/message"
+	"pkg.world.dev/world-engine/evm/x/shard/types"
+	"pkg.world.dev/world-engine/sign"
 )
 
 func TestTickHappyPath(t *testing.T) {
@@ -179,6 +186,184 @@ func TestCanIdentifyAndFixSystemError(t *testing.T) {
 	assert.Equal(t, 4, p1.Power)
 }
 
+func TestRetryFailedTickDiscardsPartialChanges(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[onePowerComponent](world))
+
+	errTransient := errors.New("transient failure")
+	failuresLeft := 0
+	world.RegisterSystem(
+		func(wCtx ecs.WorldContext) error {
+			search, err := wCtx.NewSearch(ecs.Exact(onePowerComponent{}))
+			assert.NilError(t, err)
+			id := search.MustFirst(wCtx)
+			p, err := ecs.GetComponent[onePowerComponent](wCtx, id)
+			if err != nil {
+				return err
+			}
+			p.Power++
+			if err = ecs.SetComponent[onePowerComponent](wCtx, id, p); err != nil {
+				return err
+			}
+			if failuresLeft > 0 {
+				failuresLeft--
+				return errTransient
+			}
+			return nil
+		},
+	)
+	assert.NilError(t, world.LoadGameState())
+	wCtx := ecs.NewWorldContext(world)
+	id, err := ecs.Create(wCtx, onePowerComponent{})
+	assert.NilError(t, err)
+
+	// Power is set to 1
+	assert.NilError(t, world.Tick(context.Background()))
+	// There is nothing to retry yet.
+	assert.Check(t, world.RetryFailedTick(context.Background()) != nil)
+
+	failuresLeft = 1
+	assert.ErrorIs(t, errTransient, eris.Cause(world.Tick(context.Background())))
+	// Power is set to 2. The increment from the failed attempt should have been discarded.
+	assert.NilError(t, world.RetryFailedTick(context.Background()))
+
+	p, err := ecs.GetComponent[onePowerComponent](wCtx, id)
+	assert.NilError(t, err)
+	assert.Equal(t, 2, p.Power)
+	assert.Equal(t, uint64(2), world.CurrentTick())
+	assert.Check(t, !world.IsTickCircuitOpen())
+}
+
+func TestEVMEventsAreDeliveredOnceTheTickIsCommitted(t *testing.T) {
+	delivered := make(chan []ecs.EVMEvent, 10)
+	world := testutils.NewTestWorld(t, cardinal.WithEVMEventHandler(func(events []ecs.EVMEvent) {
+		delivered <- events
+	})).Instance()
+
+	errTransient := errors.New("transient failure")
+	failuresLeft := 0
+	world.RegisterSystem(
+		func(wCtx ecs.WorldContext) error {
+			if err := wCtx.EmitEVMEvent("Moved", []byte{byte(wCtx.CurrentTick())}); err != nil {
+				return err
+			}
+			if failuresLeft > 0 {
+				failuresLeft--
+				return errTransient
+			}
+			return nil
+		},
+	)
+	assert.NilError(t, world.LoadGameState())
+	assert.NilError(t, world.Tick(context.Background()))
+	assert.DeepEqual(t, []ecs.EVMEvent{{Tick: 0, Topic: "Moved", Data: []byte{0}}}, receiveEVMEvents(t, delivered))
+
+	// The event of the failed attempt is dropped, so the retried tick delivers a single event.
+	failuresLeft = 1
+	assert.ErrorIs(t, errTransient, eris.Cause(world.Tick(context.Background())))
+	assert.NilError(t, world.RetryFailedTick(context.Background()))
+	assert.DeepEqual(t, []ecs.EVMEvent{{Tick: 1, Topic: "Moved", Data: []byte{1}}}, receiveEVMEvents(t, delivered))
+	assert.Equal(t, 0, len(delivered))
+
+	assert.ErrorIs(t, ecs.NewReadOnlyWorldContext(world).EmitEVMEvent("Moved", nil),
+		ecs.ErrCannotModifyStateWithReadOnlyContext)
+}
+
+func TestSlowEVMEventHandlerDoesNotHoldUpTicks(t *testing.T) {
+	release := make(chan struct{})
+	delivered := make(chan []ecs.EVMEvent, 10)
+	world := testutils.NewTestWorld(t, cardinal.WithEVMEventHandler(func(events []ecs.EVMEvent) {
+		<-release
+		delivered <- events
+	})).Instance()
+	world.RegisterSystem(func(wCtx ecs.WorldContext) error {
+		return wCtx.EmitEVMEvent("Moved", nil)
+	})
+	assert.NilError(t, world.LoadGameState())
+
+	// The handler is blocked, but the ticks still complete.
+	for i := 0; i < 3; i++ {
+		assert.NilError(t, world.Tick(context.Background()))
+	}
+	close(release)
+	for tick := uint64(0); tick < 3; tick++ {
+		events := receiveEVMEvents(t, delivered)
+		assert.Equal(t, 1, len(events))
+		assert.Equal(t, tick, events[0].Tick)
+	}
+}
+
+func receiveEVMEvents(t *testing.T, delivered <-chan []ecs.EVMEvent) []ecs.EVMEvent {
+	select {
+	case events := <-delivered:
+		return events
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the EVM event handler")
+		return nil
+	}
+}
+
+func TestTransactionsOfCommittedTicksAreLogged(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "txs.log")
+	world := testutils.NewTestWorld(t, cardinal.WithTransactionLog(logPath)).Instance()
+	powerTx := ecs.NewMessageType[PowerComp, PowerComp]("change_power")
+	assert.NilError(t, world.RegisterMessages(powerTx))
+	assert.NilError(t, world.LoadGameState())
+
+	txHash := powerTx.AddToQueue(world, PowerComp{Val: 3}, &sign.Transaction{
+		PersonaTag: "foo",
+		Nonce:      7,
+		Body:       []byte(`{"Val":3}`),
+	})
+	assert.NilError(t, world.Tick(context.Background()))
+	// Ticks without transactions add nothing to the log.
+	assert.NilError(t, world.Tick(context.Background()))
+	world.Shutdown()
+
+	bz, err := os.ReadFile(logPath)
+	assert.NilError(t, err)
+	decoder := json.NewDecoder(bytes.NewReader(bz))
+	var entry ecs.TransactionLogEntry
+	assert.NilError(t, decoder.Decode(&entry))
+	assert.DeepEqual(t, ecs.TransactionLogEntry{
+		Tick:        0,
+		TxHash:      string(txHash),
+		PersonaTag:  "foo",
+		MessageName: "change_power",
+		Nonce:       7,
+		Body:        json.RawMessage(`{"Val":3}`),
+	}, entry)
+	assert.ErrorIs(t, decoder.Decode(&entry), io.EOF)
+}
+
+func TestTransactionsOfAPersonaAreProcessedInNonceOrder(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	powerTx := ecs.NewMessageType[PowerComp, PowerComp]("change_power")
+	assert.NilError(t, world.RegisterMessages(powerTx))
+	type processed struct {
+		Persona string
+		Nonce   uint64
+	}
+	var got []processed
+	world.RegisterSystem(func(wCtx ecs.WorldContext) error {
+		powerTx.Each(wCtx, func(tx ecs.TxData[PowerComp]) (PowerComp, error) {
+			got = append(got, processed{Persona: tx.Tx.PersonaTag, Nonce: tx.Tx.Nonce})
+			return tx.Msg, nil
+		})
+		return nil
+	})
+	assert.NilError(t, world.LoadGameState())
+
+	// The txs of each persona arrive out of nonce order, interleaved with the txs of the other persona.
+	for _, tx := range []processed{{"foo", 3}, {"bar", 9}, {"foo", 1}, {"foo", 2}, {"bar", 8}} {
+		powerTx.AddToQueue(world, PowerComp{}, &sign.Transaction{PersonaTag: tx.Persona, Nonce: tx.Nonce})
+	}
+	assert.NilError(t, world.Tick(context.Background()))
+
+	// Each persona keeps the positions its txs arrived in, but the txs are in nonce order.
+	assert.DeepEqual(t, []processed{{"foo", 1}, {"bar", 8}, {"foo", 2}, {"foo", 3}, {"bar", 9}}, got)
+}
+
 type ScalarComponentAlpha struct {
 	Val int
 }
@@ -195,6 +380,168 @@ func (ScalarComponentBeta) Name() string {
 	return "beta"
 }
 
+func TestReplayTickRunsOnlyTheGivenTransactions(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	powerTx := ecs.NewMessageType[PowerComp, PowerComp]("change_power")
+	assert.NilError(t, world.RegisterMessages(powerTx))
+	world.RegisterSystem(func(wCtx ecs.WorldContext) error {
+		powerTx.Each(wCtx, func(tx ecs.TxData[PowerComp]) (PowerComp, error) {
+			return PowerComp{Val: tx.Msg.Val * 2}, nil
+		})
+		return nil
+	})
+	assert.NilError(t, world.LoadGameState())
+
+	// A pending transaction must not be pulled into the replayed tick.
+	powerTx.AddToQueue(world, PowerComp{Val: 1})
+
+	result, err := world.ReplayTick(context.Background(), []ecs.SignedTx{
+		{
+			MessageID: powerTx.ID(),
+			Tx:        &sign.Transaction{PersonaTag: "foo", Body: []byte(`{"Val":21}`)},
+		},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(0), result.Tick)
+	assert.Equal(t, 1, len(result.Receipts))
+	assert.Equal(t, PowerComp{Val: 42}, result.Receipts[0].Result)
+	assert.Equal(t, 0, len(result.Receipts[0].Errs))
+	assert.Equal(t, 1, world.GetTxQueueAmount())
+}
+
+func TestTickSystemsRunsOnlyTheGivenSystems(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	var ranA, ranB int
+	world.RegisterSystemWithName(func(ecs.WorldContext) error {
+		ranA++
+		return nil
+	}, "system-a")
+	world.RegisterSystemWithName(func(ecs.WorldContext) error {
+		ranB++
+		return nil
+	}, "system-b")
+	assert.NilError(t, world.LoadGameState())
+
+	result, err := world.TickSystems(context.Background(), "system-b")
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(0), result.Tick)
+	assert.Equal(t, 0, ranA)
+	assert.Equal(t, 1, ranB)
+	assert.Equal(t, uint64(1), world.CurrentTick())
+
+	_, err = world.TickSystems(context.Background(), "system-c")
+	assert.Check(t, errors.Is(eris.Cause(err), ecs.ErrSystemNotFound))
+	assert.Equal(t, uint64(1), world.CurrentTick())
+
+	// Regular ticks still run every system.
+	assert.NilError(t, world.Tick(context.Background()))
+	assert.Equal(t, 1, ranA)
+	assert.Equal(t, 2, ranB)
+}
+
+func TestTickSystemsIsRefusedWithAnAdapter(t *testing.T) {
+	adapter := &DummyAdapter{txs: make(map[uint64][]*types.Transaction, 0)}
+	world := testutils.NewTestWorld(t, cardinal.WithAdapter(adapter)).Instance()
+	ran := 0
+	world.RegisterSystemWithName(func(ecs.WorldContext) error {
+		ran++
+		return nil
+	}, "system-a")
+	assert.NilError(t, world.LoadGameState())
+
+	_, err := world.TickSystems(context.Background(), "system-a")
+	assert.Check(t, errors.Is(eris.Cause(err), ecs.ErrTickSystemsWithAdapter))
+	assert.Equal(t, 0, ran)
+	assert.Equal(t, uint64(0), world.CurrentTick())
+}
+
+func TestTransactionsQueuedDuringATickAreSubmittedWithTheNextTick(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	powerTx := ecs.NewMessageType[PowerComp, PowerComp]("change_power")
+	assert.NilError(t, world.RegisterMessages(powerTx))
+	var submittedTick, processedTick uint64
+	queued := false
+	world.RegisterSystem(func(wCtx ecs.WorldContext) error {
+		powerTx.Each(wCtx, func(tx ecs.TxData[PowerComp]) (PowerComp, error) {
+			processedTick = wCtx.CurrentTick()
+			return tx.Msg, nil
+		})
+		if queued {
+			return nil
+		}
+		queued = true
+		// The tick already took the queued transactions, so this one is executed in the next tick.
+		tick, _, err := world.AddTransactionAfter(powerTx.ID(), PowerComp{}, &sign.Transaction{PersonaTag: "foo"},
+			func(_ context.Context, tick uint64) error {
+				submittedTick = tick
+				return nil
+			})
+		assert.NilError(t, err)
+		assert.Equal(t, submittedTick, tick)
+		return nil
+	})
+	assert.NilError(t, world.LoadGameState())
+
+	assert.NilError(t, world.Tick(context.Background()))
+	assert.Equal(t, uint64(1), submittedTick)
+	assert.NilError(t, world.Tick(context.Background()))
+	assert.Equal(t, uint64(1), processedTick)
+
+	// A transaction whose submission fails is not queued.
+	errSubmit := errors.New("submit failed")
+	_, _, err := world.AddTransactionAfter(powerTx.ID(), PowerComp{}, &sign.Transaction{PersonaTag: "foo"},
+		func(context.Context, uint64) error { return errSubmit })
+	assert.Check(t, errors.Is(err, errSubmit))
+	assert.Equal(t, 0, world.GetTxQueueAmount())
+}
+
+func TestTickWaitsForTransactionsBeingSubmitted(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	powerTx := ecs.NewMessageType[PowerComp, PowerComp]("change_power")
+	assert.NilError(t, world.RegisterMessages(powerTx))
+	processed := 0
+	world.RegisterSystem(func(wCtx ecs.WorldContext) error {
+		powerTx.Each(wCtx, func(tx ecs.TxData[PowerComp]) (PowerComp, error) {
+			processed++
+			return tx.Msg, nil
+		})
+		return nil
+	})
+	assert.NilError(t, world.LoadGameState())
+
+	submitting := make(chan struct{})
+	release := make(chan struct{})
+	queued := make(chan uint64)
+	go func() {
+		tick, _, err := world.AddTransactionAfter(powerTx.ID(), PowerComp{}, &sign.Transaction{PersonaTag: "foo"},
+			func(ctx context.Context, _ uint64) error {
+				_, hasDeadline := ctx.Deadline()
+				assert.Check(t, hasDeadline)
+				close(submitting)
+				<-release
+				return nil
+			})
+		assert.Check(t, err)
+		queued <- tick
+	}()
+	<-submitting
+
+	// The submission does not hold a lock of the world, but the tick it reserved waits for it.
+	tickDone := make(chan error)
+	go func() {
+		tickDone <- world.Tick(context.Background())
+	}()
+	select {
+	case <-tickDone:
+		t.Fatal("tick did not wait for the transaction being submitted")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	assert.Equal(t, uint64(0), <-queued)
+	assert.NilError(t, <-tickDone)
+	assert.Equal(t, 1, processed)
+}
+
 func TestCanModifyArchetypeAndGetEntity(t *testing.T) {
 	world := testutils.NewTestWorld(t).Instance()
 	assert.NilError(t, ecs.RegisterComponent[ScalarComponentAlpha](world))
@@ -404,3 +751,168 @@ func TestCanRecoverTransactionsFromFailedSystemRun(t *testing.T) {
 		}
 	}
 }
+
+func TestScheduledMessagesSurviveARestart(t *testing.T) {
+	rs := miniredis.RunT(t)
+	type ApplyBuff struct {
+		BuffID int
+	}
+	type ExpireBuff struct {
+		BuffID int
+	}
+	var expiredAt []uint64
+	newWorld := func() *ecs.World {
+		world := testutil.InitWorldWithRedis(t, rs)
+		applyMsg := ecs.NewMessageType[ApplyBuff, ApplyBuff]("apply-buff")
+		expireMsg := ecs.NewMessageType[ExpireBuff, ExpireBuff]("expire-buff")
+		assert.NilError(t, world.RegisterMessages(applyMsg, expireMsg))
+		world.RegisterSystem(func(wCtx ecs.WorldContext) error {
+			for _, tx := range applyMsg.In(wCtx) {
+				_, err := wCtx.ScheduleMessage(expireMsg, ExpireBuff{BuffID: tx.Msg.BuffID}, wCtx.CurrentTick()+3)
+				assert.NilError(t, err)
+			}
+			for _, tx := range expireMsg.In(wCtx) {
+				assert.Equal(t, 99, tx.Msg.BuffID)
+				expiredAt = append(expiredAt, wCtx.CurrentTick())
+			}
+			return nil
+		})
+		assert.NilError(t, world.LoadGameState())
+		if world.CurrentTick() == 0 {
+			applyMsg.AddToQueue(world, ApplyBuff{BuffID: 99})
+		}
+		return world
+	}
+
+	ctx := context.Background()
+	world := newWorld()
+	assert.NilError(t, world.Tick(ctx))
+	assert.NilError(t, world.Tick(ctx))
+
+	// The scheduled message was saved with the game state, so a restarted world still processes it.
+	world = newWorld()
+	for i := 0; i < 5; i++ {
+		assert.NilError(t, world.Tick(ctx))
+	}
+	assert.DeepEqual(t, []uint64{3}, expiredAt)
+}
+
+func TestCannotScheduleMessageInThePast(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	msg := ecs.NewMessageType[PowerComp, PowerComp]("change_power")
+	assert.NilError(t, world.RegisterMessages(msg))
+	assert.NilError(t, world.LoadGameState())
+	assert.NilError(t, world.Tick(context.Background()))
+
+	wCtx := ecs.NewWorldContext(world)
+	_, err := wCtx.ScheduleMessage(msg, PowerComp{Val: 1}, world.CurrentTick())
+	assert.ErrorIs(t, err, ecs.ErrScheduledTickNotInFuture)
+}
+
+func TestScheduledMessagesAreHiddenFromTheGame(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	msg := ecs.NewMessageType[PowerComp, PowerComp]("change_power")
+	assert.NilError(t, world.RegisterMessages(msg))
+	assert.NilError(t, world.LoadGameState())
+
+	wCtx := ecs.NewWorldContext(world)
+	_, err := wCtx.ScheduleMessage(msg, PowerComp{Val: 1}, 10)
+	assert.NilError(t, err)
+	assert.NilError(t, world.Tick(context.Background()))
+
+	scheduled, err := ecs.NewReadOnlyWorldContext(world).ScheduledMessages()
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(scheduled))
+	search, err := world.NewSearch(ecs.All())
+	assert.NilError(t, err)
+	count, err := search.Count(ecs.NewReadOnlyWorldContext(world))
+	assert.NilError(t, err)
+	assert.Equal(t, 0, count)
+	for _, name := range world.RegisteredComponents() {
+		assert.Check(t, name != "ScheduledMessage")
+	}
+}
+
+func TestScheduledMessagesCanBeListedAndCancelled(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	msg := ecs.NewMessageType[PowerComp, PowerComp]("change_power")
+	assert.NilError(t, world.RegisterMessages(msg))
+	var fired []float64
+	var keepHash, cancelHash message.TxHash
+	world.RegisterSystem(func(wCtx ecs.WorldContext) error {
+		var err error
+		switch wCtx.CurrentTick() {
+		case 0:
+			keepHash, err = wCtx.ScheduleMessage(msg, PowerComp{Val: 1}, 3)
+			assert.NilError(t, err)
+			cancelHash, err = wCtx.ScheduleMessage(msg, PowerComp{Val: 2}, 3)
+			assert.NilError(t, err)
+		case 1:
+			scheduled, err := wCtx.ScheduledMessages()
+			assert.NilError(t, err)
+			assert.Equal(t, 2, len(scheduled))
+			assert.Equal(t, keepHash, scheduled[0].TxHash)
+			assert.Equal(t, cancelHash, scheduled[1].TxHash)
+			assert.Equal(t, uint64(3), scheduled[1].AtTick)
+			assert.Equal(t, "change_power", scheduled[1].MessageName)
+
+			assert.NilError(t, wCtx.CancelScheduled(cancelHash))
+			err = wCtx.CancelScheduled(cancelHash)
+			assert.ErrorIs(t, err, ecs.ErrScheduledMessageNotFound)
+		}
+		for _, tx := range msg.In(wCtx) {
+			fired = append(fired, tx.Msg.Val)
+		}
+		return nil
+	})
+	assert.NilError(t, world.LoadGameState())
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		assert.NilError(t, world.Tick(ctx))
+	}
+	assert.DeepEqual(t, []float64{1}, fired)
+
+	scheduled, err := ecs.NewReadOnlyWorldContext(world).ScheduledMessages()
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(scheduled))
+}
+
+func TestDurableTxQueueIsRestoredAfterARestart(t *testing.T) {
+	rs := miniredis.RunT(t)
+	var processed []float64
+	newWorld := func() (*ecs.World, *ecs.MessageType[PowerComp, PowerComp]) {
+		world := testutils.NewTestWorldWithCustomRedis(t, rs, cardinal.WithDurableTxQueue()).Instance()
+		msg := ecs.NewMessageType[PowerComp, PowerComp]("change_power")
+		assert.NilError(t, world.RegisterMessages(msg))
+		world.RegisterSystem(func(wCtx ecs.WorldContext) error {
+			for _, tx := range msg.In(wCtx) {
+				processed = append(processed, tx.Msg.Val)
+			}
+			return nil
+		})
+		assert.NilError(t, world.LoadGameState())
+		return world, msg
+	}
+
+	ctx := context.Background()
+	world, msg := newWorld()
+	assert.NilError(t, world.Tick(ctx))
+	msg.AddToQueue(world, PowerComp{Val: 1}, &sign.Transaction{PersonaTag: "a", Nonce: 1})
+	msg.AddToQueue(world, PowerComp{Val: 2}, &sign.Transaction{PersonaTag: "a", Nonce: 2})
+	// Unsigned transactions all have the same hash, but are stored separately.
+	msg.AddToQueue(world, PowerComp{Val: 3})
+	msg.AddToQueue(world, PowerComp{Val: 4})
+
+	// The world "crashes" before the queued transactions are ticked. They are restored by the next world.
+	world, _ = newWorld()
+	assert.Equal(t, 4, world.GetTxQueueAmount())
+	assert.NilError(t, world.Tick(ctx))
+	assert.DeepEqual(t, []float64{1, 2, 3, 4}, processed)
+
+	// Processed transactions are not restored again.
+	world, _ = newWorld()
+	assert.Equal(t, 0, world.GetTxQueueAmount())
+	assert.NilError(t, world.Tick(ctx))
+	assert.DeepEqual(t, []float64{1, 2, 3, 4}, processed)
+}
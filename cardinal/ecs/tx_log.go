@@ -0,0 +1,136 @@
+package ecs
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/rotisserie/eris"
+	"github.com/rs/zerolog/log"
+	"pkg.world.dev/world-engine/cardinal/txpool"
+)
+
+// txLogBufferedTicks is the number of committed ticks whose transactions may wait to be written to the transaction log
+// before ticks block on the writer.
+const txLogBufferedTicks = 64
+
+// TransactionLogEntry is one line of the file written by WithTransactionLog.
+type TransactionLogEntry struct {
+	Tick        uint64          `json:"tick"`
+	TxHash      string          `json:"txHash"`
+	PersonaTag  string          `json:"personaTag"`
+	MessageName string          `json:"messageName"`
+	Nonce       uint64          `json:"nonce"`
+	Body        json.RawMessage `json:"body"`
+}
+
+// txLog appends the transactions of committed ticks to a file as JSON lines. Entries are written by a background
+// goroutine, so ticks only wait on the file when the writer falls more than txLogBufferedTicks ticks behind.
+type txLog struct {
+	file    *os.File
+	batches chan []TransactionLogEntry
+	done    chan struct{}
+	mutex   sync.Mutex
+	closed  bool
+}
+
+func openTxLog(path string) (*txLog, error) {
+	//nolint:gomnd // standard file permissions
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to open transaction log %q", path)
+	}
+	l := &txLog{
+		file:    file,
+		batches: make(chan []TransactionLogEntry, txLogBufferedTicks),
+		done:    make(chan struct{}),
+	}
+	go l.run()
+	return l, nil
+}
+
+func (l *txLog) run() {
+	defer close(l.done)
+	writer := bufio.NewWriter(l.file)
+	encoder := json.NewEncoder(writer)
+	for batch := range l.batches {
+		for _, entry := range batch {
+			if err := encoder.Encode(entry); err != nil {
+				log.Error().Err(err).Str("tx_hash", entry.TxHash).Msg("failed to write to the transaction log")
+			}
+		}
+		// Each tick is flushed on its own, so a crash loses at most the ticks that are still queued.
+		if err := writer.Flush(); err != nil {
+			log.Error().Err(err).Msg("failed to flush the transaction log")
+		}
+	}
+	if err := l.file.Close(); err != nil {
+		log.Error().Err(err).Msg("failed to close the transaction log")
+	}
+}
+
+func (l *txLog) write(batch []TransactionLogEntry) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.closed {
+		return
+	}
+	l.batches <- batch
+}
+
+// close writes the queued entries and closes the file.
+func (l *txLog) close() {
+	l.mutex.Lock()
+	if l.closed {
+		l.mutex.Unlock()
+		return
+	}
+	l.closed = true
+	close(l.batches)
+	l.mutex.Unlock()
+	<-l.done
+}
+
+// logTransactions hands the transactions of the tick that was just committed to the transaction log. Ticks replayed
+// while recovering from the chain are not logged again.
+func (w *World) logTransactions(tick uint64, txQueue *txpool.TxQueue) {
+	if w.txLog == nil || w.IsRecovering() {
+		return
+	}
+	txs := txQueue.GetTxs()
+	if len(txs) == 0 {
+		return
+	}
+	batch := make([]TransactionLogEntry, 0, len(txs))
+	for _, tx := range txs {
+		entry := TransactionLogEntry{
+			Tick:   tick,
+			TxHash: string(tx.TxHash),
+		}
+		if msg := w.getMessage(tx.MsgID); msg != nil {
+			entry.MessageName = msg.Name()
+		}
+		if tx.Tx != nil {
+			entry.PersonaTag = tx.Tx.PersonaTag
+			entry.Nonce = tx.Tx.Nonce
+			entry.Body = tx.Tx.Body
+		}
+		if len(entry.Body) == 0 {
+			body, err := json.Marshal(tx.Msg)
+			if err != nil {
+				w.Logger.Error().Err(err).Str("tx_hash", entry.TxHash).Msg("failed to encode transaction for the log")
+			}
+			entry.Body = body
+		}
+		batch = append(batch, entry)
+	}
+	w.txLog.write(batch)
+}
+
+// closeTxLog writes the remaining entries of the transaction log and closes its file.
+func (w *World) closeTxLog() {
+	if w.txLog != nil {
+		w.txLog.close()
+	}
+}
@@ -0,0 +1,87 @@
+package ecs
+
+import (
+	"github.com/google/uuid"
+	"github.com/rotisserie/eris"
+	"pkg.world.dev/world-engine/cardinal/ecs/codec"
+	"pkg.world.dev/world-engine/cardinal/types/message"
+	"pkg.world.dev/world-engine/sign"
+)
+
+// durableTx is a queued transaction as it is stored in redis when WithDurableTxQueue is used.
+type durableTx struct {
+	TypeID          message.TypeID
+	Data            []byte
+	Tx              *sign.Transaction
+	EVMSourceTxHash string
+}
+
+// persistQueuedTx stores a transaction that is about to be added to the tx queue, so it can be restored after a
+// restart. Transactions are stored under an ID of their own rather than under their hash, because transactions that
+// are not signed, e.g. those added with AddToQueue, all have the same hash.
+func (w *World) persistQueuedTx(id message.TypeID, v any, sig *sign.Transaction, evmTxHash string) error {
+	if !w.durableTxQueue {
+		return nil
+	}
+	msg := w.getMessage(id)
+	if msg == nil {
+		return eris.Errorf("message with ID %d is not registered", id)
+	}
+	data, err := msg.Encode(v)
+	if err != nil {
+		return err
+	}
+	bz, err := codec.Encode(durableTx{
+		TypeID:          id,
+		Data:            data,
+		Tx:              sig,
+		EVMSourceTxHash: evmTxHash,
+	})
+	if err != nil {
+		return err
+	}
+	durableID := uuid.NewString()
+	if err = w.redisStorage.TxQueue.Add(durableID, bz); err != nil {
+		return err
+	}
+	w.durableTxMutex.Lock()
+	defer w.durableTxMutex.Unlock()
+	w.durableTxIDs = append(w.durableTxIDs, durableID)
+	return nil
+}
+
+// restoreQueuedTxs adds the transactions in the durable tx queue back to the tx queue. Transactions of an interrupted
+// tick were removed from the durable tx queue when that tick started, so they are not restored twice. The nonces of
+// the restored transactions were marked as used when the transactions were first accepted, so they are not checked
+// again.
+func (w *World) restoreQueuedTxs() error {
+	if !w.durableTxQueue {
+		return nil
+	}
+	ids, stored, err := w.redisStorage.TxQueue.GetAll()
+	if err != nil {
+		return err
+	}
+	for _, bz := range stored {
+		tx, err := codec.Decode[durableTx](bz)
+		if err != nil {
+			return err
+		}
+		msg := w.getMessage(tx.TypeID)
+		if msg == nil {
+			return eris.Errorf("error restoring queued tx with ID %d: tx id not found", tx.TypeID)
+		}
+		v, err := msg.Decode(tx.Data)
+		if err != nil {
+			return err
+		}
+		w.txQueue.AddEVMTransaction(tx.TypeID, v, tx.Tx, tx.EVMSourceTxHash)
+	}
+	w.durableTxMutex.Lock()
+	defer w.durableTxMutex.Unlock()
+	w.durableTxIDs = append(w.durableTxIDs, ids...)
+	if len(stored) > 0 {
+		w.Logger.Info().Int("count", len(stored)).Msg("restored queued transactions")
+	}
+	return nil
+}
@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -24,6 +27,7 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"pkg.world.dev/world-engine/cardinal/ecs/cql"
 	ecslog "pkg.world.dev/world-engine/cardinal/ecs/log"
 	"pkg.world.dev/world-engine/cardinal/ecs/receipt"
 	storage "pkg.world.dev/world-engine/cardinal/ecs/storage/redis"
@@ -51,11 +55,16 @@ type World struct {
 	systemLoggers          []*ecslog.Logger
 	initSystem             System
 	initSystemLogger       *ecslog.Logger
+	perPersonaTickHooks    []PerPersonaTickHook
+	perPersonaHookLogger   *ecslog.Logger
 	systemNames            []string
+	systemRunConfigs       []systemRunConfig
+	systemDependencies     map[int][]string
 	tick                   *atomic.Uint64
 	timestamp              *atomic.Uint64
 	nameToComponent        map[string]component.ComponentMetadata
 	nameToQuery            map[string]Query
+	nameToCQLQuery         map[string]cql.CompiledQuery
 	registeredComponents   []component.ComponentMetadata
 	registeredMessages     []message.Message
 	registeredQueries      []Query
@@ -63,24 +72,124 @@ type World struct {
 	isEntitiesCreated      bool
 	isMessagesRegistered   bool
 	stateIsLoaded          bool
+	// isAddingPlugin is set while a plugin registers itself. See AddPlugin.
+	isAddingPlugin bool
+	// pluginMessages are the messages registered by plugins. They are registered after the messages of the game.
+	pluginMessages []message.Message
+	// hasPluginComponents is set once a plugin registered a component. The game can no longer register components
+	// after that, see ErrComponentRegisteredAfterPlugin.
+	hasPluginComponents bool
 
 	evmTxReceipts map[string]EVMTxReceipt
+	// evmEvents hands the events emitted with EmitEVMEvent to the handler. See WithEVMEventHandler.
+	evmEvents        *evmEventDispatcher
+	pendingEVMEvents []EVMEvent
+	// dependencies are the external clients given with WithDependency, keyed by their type.
+	dependencies map[reflect.Type]any
+	// txLogPath is the file the transactions of each committed tick are appended to. See WithTransactionLog.
+	txLogPath string
+	txLog     *txLog
+	// redisHealthCheckInterval is how long the result of IsRedisHealthy is reused. See WithRedisHealthCheckInterval.
+	redisHealthCheckInterval time.Duration
+	isRedisHealthy           bool
+	redisCheckedAt           time.Time
+	// isCheckingRedis is set while IsRedisHealthy waits for redis to answer a PING.
+	isCheckingRedis  bool
+	redisHealthMutex sync.Mutex
+	// tickProgressEvents makes long ticks broadcast TickProgress events. See WithTickProgressEvents.
+	tickProgressEvents    bool
+	tickProgressThreshold time.Duration
+	// idempotentComponentRegistration makes registering a component type twice a no-op. See
+	// WithIdempotentComponentRegistration.
+	idempotentComponentRegistration bool
+	// maxPersonasPerSigner is the number of persona tags a signer address may own. See WithMaxPersonasPerSigner.
+	maxPersonasPerSigner int
+	// externalKeys indexes the records of SetExternalKey. It is guarded by externalKeyMutex.
+	externalKeys     *externalKeyIndex
+	externalKeyMutex sync.Mutex
+	// personaDisplayNames enables SetDisplayNameMsg. See WithPersonaDisplayNames.
+	personaDisplayNames bool
+	// displayNames indexes the display name records by lower case persona tag. It is guarded by displayNameMutex.
+	displayNames     map[string]entity.ID
+	displayNameMutex sync.Mutex
+	// recordCreationTicks adds CreatedAt to every entity created by the game. See WithEntityCreationTicks.
+	recordCreationTicks bool
 
 	txQueue *txpool.TxQueue
+	// queueMutex makes reading the tick a transaction is queued for, and queuing it, atomic with respect to a tick
+	// taking the queued transactions.
+	queueMutex sync.RWMutex
+	// queueTaken reports whether a tick took the queued transactions and has not completed yet, in which case newly
+	// queued transactions run in the next tick. It is guarded by queueMutex.
+	queueTaken bool
+	// pendingSubmits counts the AddTransactionAfter calls that reserved the tick the queue is taken for next, and have
+	// not queued their transaction or given up yet. A tick waits for them before it takes the queue.
+	pendingSubmits sync.WaitGroup
 
 	receiptHistory *receipt.History
+	// unhandledMessageReceipts adds an error receipt for txs that no system read. See WithUnhandledMessageReceipts.
+	unhandledMessageReceipts bool
+	// durableTxQueue stores queued transactions in redis so they survive a restart.
+	durableTxQueue bool
+	// durableTxIDs are the IDs of the queued transactions in the durable tx queue. It is guarded by durableTxMutex.
+	durableTxIDs   []string
+	durableTxMutex sync.Mutex
+	// takenDurableTxIDs are the IDs of the transactions taken by the tick that is about to start. They are removed
+	// from the durable tx queue when the tick starts. It is guarded by tickMutex.
+	takenDurableTxIDs []string
+	// nonceRetryWindow is how long an accepted transaction can be resubmitted with the same nonce. See
+	// WithNonceRetryWindow.
+	nonceRetryWindow time.Duration
+	// consistentQueries makes queries read the state of a single tick. See WithConsistentQueries.
+	consistentQueries bool
+	tickSnapshotLock  sync.RWMutex
+	// messageMetrics counts the processed transactions of each message type. See WithMessageMetrics.
+	messageMetrics *messageMetrics
+	// throughput counts the processed transactions of every tick. See Throughput.
+	throughput throughputCounter
+	// lastSystemDurations holds how long each system took in the last tick. See LastSystemDurations.
+	lastSystemDurations  []time.Duration
+	systemDurationsMutex sync.RWMutex
 
 	chain shard.QueryAdapter
+	// adapterRequired makes loading the game state fail if no chain adapter was given. See WithRequiredAdapter.
+	adapterRequired bool
 	// isRecovering indicates that the world is recovering from the DA layer.
 	// this is used to prevent ticks from submitting duplicate transactions the DA layer.
 	isRecovering atomic.Bool
+	// recoveryStartHooks and recoveryCompleteHooks are called when RecoverFromChain starts and finishes. See
+	// OnRecoveryStart and OnRecoveryComplete.
+	recoveryStartHooks    []func()
+	recoveryCompleteHooks []func(err error)
+	// tickFailureHooks are called when the game loop gives up on a tick. See OnTickFailure.
+	tickFailureHooks    []func(tick uint64, err error)
+	lifecycleHooksMutex sync.Mutex
+	// isReadReplica makes the world serve state written by another world without ever running systems.
+	// See WithReadReplica.
+	isReadReplica bool
 
 	Logger *ecslog.Logger
 
 	endGameLoopCh     chan bool
 	isGameLoopRunning atomic.Bool
 
+	// initProgress holds the float64 bits of the progress reported by the init system. See SetInitProgress.
+	initProgress atomic.Uint64
+
+	// tickRetries is the number of times a failed tick is retried by the game loop before the tick circuit is opened.
+	// See WithTickRetry.
+	tickRetries       int
+	tickRetryDelay    time.Duration
+	isTickCircuitOpen atomic.Bool
+	// failedTick holds the transactions of the most recent tick if that tick did not complete.
+	failedTick *failedTick
+	// tickMutex makes ticks run one at a time, so TickSystems can be called while the game loop is running.
+	tickMutex sync.Mutex
+
 	nextComponentID component.TypeID
+	// internalComponents are the IDs of the components the world registers for its own bookkeeping. See
+	// registerInternalComponent.
+	internalComponents map[component.TypeID]bool
 
 	eventHub events.EventHub
 
@@ -98,10 +207,28 @@ var (
 	ErrStoreStateInvalid    = errors.New("saved world state is not valid")
 	ErrDuplicateMessageName = errors.New("message names must be unique")
 	ErrDuplicateQueryName   = errors.New("query names must be unique")
+	ErrAdapterRequired      = errors.New("an adapter is required to persist transactions, but none was given")
+	ErrReadReplica          = errors.New("world is a read replica and cannot process transactions")
+	ErrMessageNotHandled    = errors.New("message was processed, but no system handles it")
+	ErrMessageNotFound      = errors.New("message is not registered")
+
+	// ErrDuplicateComponentName is returned when a component is registered with the name of a component of a
+	// different type.
+	ErrDuplicateComponentName = errors.New("component names must be unique")
+	// ErrComponentAlreadyRegistered is returned when a component type is registered twice. See
+	// WithIdempotentComponentRegistration.
+	ErrComponentAlreadyRegistered = errors.New("component is already registered")
+	// ErrComponentRegisteredAfterPlugin is returned when the game registers a component after a plugin registered
+	// components. The component would get an ID that depends on the plugins of the world. See AddPlugin.
+	ErrComponentRegisteredAfterPlugin = errors.New(
+		"components of the game must be registered before plugins that register components are added",
+	)
 )
 
 const (
 	defaultReceiptHistorySize = 10
+	// submitTimeout bounds the submit call of AddTransactionAfter, since the next tick waits for it.
+	submitTimeout = 10 * time.Second
 )
 
 func (w *World) DoesWorldHaveAnEventHub() bool {
@@ -148,6 +275,13 @@ func (w *World) TickStore() store.TickStorage {
 	return w.entityStore
 }
 
+// EntityCount returns the number of live entities in the world as of the last completed tick. This reads a counter
+// kept by the store, so it does not scan any archetypes. Like queries, it reads the committed state, so it is safe to
+// call while a tick runs.
+func (w *World) EntityCount() (int, error) {
+	return w.StoreManager().ToReadOnly().EntityCount()
+}
+
 func (w *World) GetTxQueueAmount() int {
 	return w.txQueue.GetAmountOfTxs()
 }
@@ -162,7 +296,9 @@ func (w *World) RegisterSystems(systems ...System) {
 	}
 }
 
-func (w *World) RegisterSystemWithName(system System, functionName string) {
+// RegisterSystemWithName registers a system under the given name. If the name is empty, the name of the system's
+// function is used. Pass SystemOptions to change when the system runs.
+func (w *World) RegisterSystemWithName(system System, functionName string, opts ...SystemOption) {
 	if w.stateIsLoaded {
 		panic("cannot register systems after loading game state")
 	}
@@ -172,6 +308,11 @@ func (w *World) RegisterSystemWithName(system System, functionName string) {
 	sysLogger := w.Logger.CreateSystemLogger(functionName)
 	w.systemLoggers = append(w.systemLoggers, &sysLogger)
 	w.systemNames = append(w.systemNames, functionName)
+	var runConfig systemRunConfig
+	for _, opt := range opts {
+		opt(&runConfig)
+	}
+	w.systemRunConfigs = append(w.systemRunConfigs, runConfig)
 	// appends registeredSystem into the member system list in world.
 	w.systems = append(w.systems, system)
 	w.checkDuplicateSystemName()
@@ -195,22 +336,93 @@ func (w *World) AddInitSystem(system System) {
 	w.initSystem = system
 }
 
-func RegisterComponent[T component.Component](world *World) error {
+// IsInitialized reports whether the init system has run, i.e. whether tick 0 has been committed. A world that was
+// loaded from saved state is initialized.
+func (w *World) IsInitialized() bool {
+	return w.CurrentTick() > 0
+}
+
+// SetInitProgress records how far the init system has come, from 0 to 1, so it can be reported while a heavy init
+// system (e.g. one that spawns a large map) is running.
+func (w *World) SetInitProgress(progress float64) {
+	w.initProgress.Store(math.Float64bits(min(max(progress, 0), 1)))
+}
+
+// InitProgress returns the progress last reported by the init system with SetInitProgress, or 1 once the world is
+// initialized.
+func (w *World) InitProgress() float64 {
+	if w.IsInitialized() {
+		return 1
+	}
+	return math.Float64frombits(w.initProgress.Load())
+}
+
+// PerPersonaTickHook is run once per tick for every persona tag that had at least one transaction in that tick.
+type PerPersonaTickHook func(wCtx WorldContext, personaTag string) error
+
+// RegisterPerPersonaTickHook registers a hook that runs after all systems, once for each distinct persona tag that
+// submitted a transaction during the tick. Hooks run in registration order, and persona tags are visited in sorted
+// order so ticks remain deterministic.
+func (w *World) RegisterPerPersonaTickHook(hook PerPersonaTickHook) {
+	if w.stateIsLoaded {
+		panic("cannot register per persona tick hooks after loading game state")
+	}
+	if w.perPersonaHookLogger == nil {
+		logger := w.Logger.CreateSystemLogger("PerPersonaTickHook")
+		w.perPersonaHookLogger = &logger
+	}
+	w.perPersonaTickHooks = append(w.perPersonaTickHooks, hook)
+}
+
+// RegisterComponent registers the component type T. Use component.WithDefault to give newly added components a
+// default value instead of the zero value, and component.WithTTL to remove them automatically after a number of ticks.
+// component.WithRedisTTL makes redis expire components once the world stopped committing for a while.
+//
+// Component IDs are persisted and handed out in registration order, so the game must register all of its components
+// before adding plugins that register components.
+func RegisterComponent[T component.Component](world *World, opts ...component.ComponentOption[T]) error {
+	if world.hasPluginComponents && !world.isAddingPlugin {
+		var t T
+		if _, err := world.GetComponentByName(t.Name()); err != nil {
+			return eris.Wrapf(ErrComponentRegisteredAfterPlugin, "component %q", t.Name())
+		}
+	}
+	registered, err := registerComponent[T](world, world.nextComponentID, opts...)
+	if err != nil || !registered {
+		return err
+	}
+	world.nextComponentID++
+	if world.isAddingPlugin {
+		world.hasPluginComponents = true
+	}
+	return nil
+}
+
+// registerComponent registers the component type T with the given ID. False is returned if T was already registered
+// and the world allows idempotent registration.
+func registerComponent[T component.Component](world *World, id component.TypeID, opts ...component.ComponentOption[T],
+) (registered bool, err error) {
 	if world.stateIsLoaded {
 		panic("cannot register components after loading game state")
 	}
 	var t T
-	_, err := world.GetComponentByName(t.Name())
-	if err == nil {
-		return eris.Errorf("component with name '%s' is already registered", t.Name())
+	if existing, err := world.GetComponentByName(t.Name()); err == nil {
+		if !component.IsOfType[T](existing) {
+			return false, eris.Wrapf(ErrDuplicateComponentName,
+				"component %q is already registered with a different type", t.Name())
+		}
+		if world.idempotentComponentRegistration {
+			return false, nil
+		}
+		return false, eris.Wrapf(ErrComponentAlreadyRegistered, "component %q", t.Name())
 	}
-	c, err := component.NewComponentMetadata[T]()
+	c, err := component.NewComponentMetadata[T](opts...)
 	if err != nil {
-		return err
+		return false, err
 	}
-	err = c.SetID(world.nextComponentID)
+	err = c.SetID(id)
 	if err != nil {
-		return err
+		return false, err
 	}
 	world.registeredComponents = append(world.registeredComponents, c)
 
@@ -219,30 +431,29 @@ func RegisterComponent[T component.Component](world *World) error {
 	// if error is redis.Nil that means schema does not exist in the db, continue
 	if err != nil {
 		if !eris.Is(eris.Cause(err), redis.Nil) {
-			return err
+			return false, err
 		}
 	} else {
 		valid, err := component.IsComponentValid(t, storedSchema)
 		if err != nil {
-			return err
+			return false, err
 		}
 		if !valid {
-			return eris.Errorf("Component: %s does not match the type stored in the db", c.Name())
+			return false, eris.Errorf("Component: %s does not match the type stored in the db", c.Name())
 		}
 	}
 
 	err = world.redisStorage.Schema.SetSchema(c.Name(), c.GetSchema())
 	if err != nil {
-		return err
+		return false, err
 	}
-	world.nextComponentID++
 	world.nameToComponent[t.Name()] = c
 	world.isComponentsRegistered = true
-	return nil
+	return true, nil
 }
 
-func MustRegisterComponent[T component.Component](world *World) {
-	err := RegisterComponent[T](world)
+func MustRegisterComponent[T component.Component](world *World, opts ...component.ComponentOption[T]) {
+	err := RegisterComponent[T](world, opts...)
 	if err != nil {
 		panic(err)
 	}
@@ -291,16 +502,49 @@ func (w *World) GetQueryByName(name string) (Query, error) {
 	return nil, eris.Errorf("query with name %s not found", name)
 }
 
+// RegisterCQLQuery compiles the given CQL string and registers it under the given name, so clients can run it by name
+// without it being parsed again on every request. Unknown component names are reported when the game state is loaded.
+func (w *World) RegisterCQLQuery(name, cqlText string) error {
+	if w.stateIsLoaded {
+		panic("cannot register queries after loading game state")
+	}
+	if _, ok := w.nameToCQLQuery[name]; ok {
+		return eris.Errorf("CQL query with name %s is already registered", name)
+	}
+	compiled, err := cql.Compile(cqlText)
+	if err != nil {
+		return eris.Wrapf(err, "CQL query %s is not valid", name)
+	}
+	w.nameToCQLQuery[name] = compiled
+	return nil
+}
+
+func (w *World) GetCQLQueryByName(name string) (cql.CompiledQuery, error) {
+	if q, ok := w.nameToCQLQuery[name]; ok {
+		return q, nil
+	}
+	return cql.CompiledQuery{}, eris.Errorf("CQL query with name %s not found", name)
+}
+
 func (w *World) RegisterMessages(txs ...message.Message) error {
 	if w.stateIsLoaded {
 		panic("cannot register messages after loading game state")
 	}
+	if w.isAddingPlugin {
+		return w.addPluginMessages(txs)
+	}
 	if w.isMessagesRegistered {
 		return eris.Wrap(ErrMessageRegistrationMustHappenOnce, "")
 	}
 	w.isMessagesRegistered = true
 	w.registerInternalMessages()
 	w.registeredMessages = append(w.registeredMessages, txs...)
+	if w.personaDisplayNames {
+		// Internal messages added after the game's own messages go last, so the IDs of the game's messages stay the
+		// same.
+		w.registeredMessages = append(w.registeredMessages, SetDisplayNameMsg)
+	}
+	w.registeredMessages = append(w.registeredMessages, w.pluginMessages...)
 
 	seenTxNames := map[string]bool{}
 	for i, t := range w.registeredMessages {
@@ -337,6 +581,22 @@ func (w *World) ListMessages() ([]message.Message, error) {
 	return w.registeredMessages, nil
 }
 
+// MessageEndpoint returns the HTTP path that transactions of the message with the given name are sent to, e.g.
+// "/tx/game/attack", so clients do not need to build the path themselves. The path does not include the base path
+// given with server.WithBasePath.
+func (w *World) MessageEndpoint(name string) (string, error) {
+	for _, msg := range w.registeredMessages {
+		if msg.Name() != name {
+			continue
+		}
+		if name == CreatePersonaMsg.Name() {
+			return "/tx/persona/" + name, nil
+		}
+		return "/tx/game/" + name, nil
+	}
+	return "", eris.Wrapf(ErrMessageNotFound, "no message named %q", name)
+}
+
 // NewWorld creates a new world.
 func NewWorld(
 	storage *storage.Storage,
@@ -358,6 +618,7 @@ func NewWorld(
 		initSystem:        func(_ WorldContext) error { return nil },
 		nameToComponent:   make(map[string]component.ComponentMetadata),
 		nameToQuery:       make(map[string]Query),
+		nameToCQLQuery:    make(map[string]cql.CompiledQuery),
 		txQueue:           txpool.NewTxQueue(),
 		Logger:            logger,
 		isGameLoopRunning: atomic.Bool{},
@@ -366,6 +627,8 @@ func NewWorld(
 		nextComponentID:   1,
 		evmTxReceipts:     make(map[string]EVMTxReceipt),
 
+		redisHealthCheckInterval: DefaultRedisHealthCheckInterval,
+
 		addChannelWaitingForNextTick: make(chan chan struct{}),
 	}
 	w.isGameLoopRunning.Store(false)
@@ -374,10 +637,37 @@ func NewWorld(
 	if err != nil {
 		return nil, err
 	}
+	if err = registerInternalComponent[scheduledMessage](w, scheduledMessageComponentID); err != nil {
+		return nil, err
+	}
+	if err = registerInternalComponent[componentExpiry](w, componentExpiryComponentID); err != nil {
+		return nil, err
+	}
+	if err = registerInternalComponent[externalKey](w, externalKeyComponentID); err != nil {
+		return nil, err
+	}
 	opts = append([]Option{WithEventHub(events.CreateWebSocketEventHub())}, opts...)
 	for _, opt := range opts {
 		opt(w)
 	}
+	if w.messageMetrics != nil {
+		if err = w.messageMetrics.validateLabels(); err != nil {
+			return nil, err
+		}
+	}
+	if w.personaDisplayNames {
+		w.RegisterSystems(SetDisplayNameSystem)
+		if err = registerInternalComponent[personaDisplayName](w, personaDisplayNameComponentID); err != nil {
+			return nil, err
+		}
+	}
+	if w.recordCreationTicks {
+		// CreatedAt is visible to the game, but has a fixed ID so enabling the option does not change the IDs of the
+		// game's components.
+		if _, err = registerComponent[CreatedAt](w, createdAtComponentID); err != nil {
+			return nil, err
+		}
+	}
 	if w.receiptHistory == nil {
 		w.receiptHistory = receipt.NewHistory(w.CurrentTick(), defaultReceiptHistorySize)
 	}
@@ -407,37 +697,188 @@ func (w *World) ConsumeEVMMsgResult(evmTxHash string) (EVMTxReceipt, bool) {
 
 // AddTransaction adds a transaction to the transaction queue. This should not be used directly.
 // Instead, use a MessageType.AddToQueue to ensure type consistency. Returns the tick this transaction will be
-// executed in.
+// executed in. Errors are logged; use QueueTransaction to handle them.
 func (w *World) AddTransaction(id message.TypeID, v any, sig *sign.Transaction) (
 	tick uint64, txHash message.TxHash,
 ) {
-	// TODO: There's no locking between getting the tick and adding the transaction, so there's no guarantee that this
-	// transaction is actually added to the returned tick.
-	tick = w.CurrentTick()
-	txHash = w.txQueue.AddTransaction(id, v, sig)
+	tick, txHash, err := w.QueueTransaction(id, v, sig)
+	if err != nil {
+		w.Logger.Error().Err(err).Str("tx_hash", sig.HashHex()).Msg("failed to queue transaction")
+	}
 	return tick, txHash
 }
 
+// QueueTransaction is identical to AddTransaction, but returns an error if the transaction could not be queued, e.g.
+// because it could not be saved to the durable tx queue.
+func (w *World) QueueTransaction(id message.TypeID, v any, sig *sign.Transaction) (
+	tick uint64, txHash message.TxHash, err error,
+) {
+	w.queueMutex.RLock()
+	defer w.queueMutex.RUnlock()
+	return w.addTransaction(id, v, sig, "")
+}
+
+// AddTransactionAfter calls submit with the tick the transaction will be executed in, and only adds the transaction
+// to the queue if submit succeeds. The tick is reserved before submit is called: the next tick waits for running
+// submit calls before it takes the queued transactions, so the tick given to submit is the tick the transaction is
+// executed in. submit is called without holding any lock of the world, with a context that is canceled after
+// submitTimeout, so a slow submission holds up the next tick for at most that long.
+func (w *World) AddTransactionAfter(id message.TypeID, v any, sig *sign.Transaction,
+	submit func(ctx context.Context, tick uint64) error,
+) (tick uint64, txHash message.TxHash, err error) {
+	w.queueMutex.RLock()
+	tick = w.queuedTick()
+	w.pendingSubmits.Add(1)
+	w.queueMutex.RUnlock()
+	defer w.pendingSubmits.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), submitTimeout)
+	defer cancel()
+	if err = submit(ctx, tick); err != nil {
+		return 0, "", err
+	}
+	// The queue cannot be taken until pendingSubmits is done, so the transaction still runs in the reserved tick.
+	if err = w.persistQueuedTx(id, v, sig, ""); err != nil {
+		return 0, "", err
+	}
+	return tick, w.txQueue.AddEVMTransaction(id, v, sig, ""), nil
+}
+
+// queuedTick returns the tick in which the transactions that are queued now are executed. The caller must hold
+// queueMutex.
+func (w *World) queuedTick() uint64 {
+	if w.queueTaken {
+		return w.CurrentTick() + 1
+	}
+	return w.CurrentTick()
+}
+
+// takeTxQueue empties the transaction queue and returns the transactions that were in it, for the tick that is about
+// to start.
+func (w *World) takeTxQueue() *txpool.TxQueue {
+	w.queueMutex.Lock()
+	defer w.queueMutex.Unlock()
+	// No submit can reserve this tick anymore, so wait for the ones that did to queue their transactions.
+	w.pendingSubmits.Wait()
+	w.queueTaken = true
+	w.durableTxMutex.Lock()
+	w.takenDurableTxIDs = w.durableTxIDs
+	w.durableTxIDs = nil
+	w.durableTxMutex.Unlock()
+	return w.txQueue.CopyTransactions()
+}
+
+// addTransaction adds a transaction to the durable tx queue, if it is used, and to the tx queue. The caller must hold
+// queueMutex.
+func (w *World) addTransaction(id message.TypeID, v any, sig *sign.Transaction, evmTxHash string) (
+	tick uint64, txHash message.TxHash, err error,
+) {
+	tick = w.queuedTick()
+	if err = w.persistQueuedTx(id, v, sig, evmTxHash); err != nil {
+		return 0, "", err
+	}
+	txHash = w.txQueue.AddEVMTransaction(id, v, sig, evmTxHash)
+	return tick, txHash, nil
+}
+
 func (w *World) AddEVMTransaction(
 	id message.TypeID,
 	v any,
 	sig *sign.Transaction,
 	evmTxHash string,
 ) (
-	tick uint64, txHash message.TxHash,
+	tick uint64, txHash message.TxHash, err error,
 ) {
-	tick = w.CurrentTick()
-	txHash = w.txQueue.AddEVMTransaction(id, v, sig, evmTxHash)
-	return tick, txHash
+	w.queueMutex.RLock()
+	defer w.queueMutex.RUnlock()
+	return w.addTransaction(id, v, sig, evmTxHash)
 }
 
 const (
 	warningThreshold = 100 * time.Millisecond
 )
 
+// failedTick tracks the transactions of a tick that did not complete so the tick can be retried.
+type failedTick struct {
+	txQueue *txpool.TxQueue
+	// started reports whether the tick store was told about this tick before it failed.
+	started bool
+	// onlySystems are the systems the tick runs, if it was started by TickSystems.
+	onlySystems map[string]bool
+}
+
 // Tick performs one game tick. This consists of taking a snapshot of all pending transactions, then calling
 // each System in turn with the snapshot of transactions.
-func (w *World) Tick(_ context.Context) error {
+func (w *World) Tick(ctx context.Context) error {
+	if !w.stateIsLoaded {
+		return eris.New("must load state before first tick")
+	}
+	if w.isReadReplica {
+		return eris.Wrap(ErrReadReplica, "")
+	}
+	w.tickMutex.Lock()
+	defer w.tickMutex.Unlock()
+	return w.runTick(ctx, w.takeTxQueue(), false, nil)
+}
+
+// TickSystems performs one game tick in which only the systems with the given names run, and returns the resulting
+// receipts. It is meant for debugging a single system against real state without the interference of the others.
+// The tick consumes the queued transactions and commits its state changes like any other tick, so transactions that
+// are only handled by the skipped systems are never processed. TickSystems can not be used if the world was created
+// with an adapter, because the transactions of the tick were already submitted to the chain.
+func (w *World) TickSystems(ctx context.Context, systemNames ...string) (TickResult, error) {
+	if !w.stateIsLoaded {
+		return TickResult{}, eris.New("must load state before first tick")
+	}
+	if w.isReadReplica {
+		return TickResult{}, eris.Wrap(ErrReadReplica, "")
+	}
+	if w.chain != nil {
+		return TickResult{}, eris.Wrap(ErrTickSystemsWithAdapter, "")
+	}
+	if len(systemNames) == 0 {
+		return TickResult{}, eris.New("at least one system must be given")
+	}
+	onlySystems := make(map[string]bool, len(systemNames))
+	for _, name := range systemNames {
+		if !slices.Contains(w.systemNames, name) {
+			return TickResult{}, eris.Wrapf(ErrSystemNotFound, "system %q", name)
+		}
+		onlySystems[name] = true
+	}
+	w.tickMutex.Lock()
+	defer w.tickMutex.Unlock()
+	tick := w.CurrentTick()
+	if err := w.runTick(ctx, w.takeTxQueue(), false, onlySystems); err != nil {
+		return TickResult{Tick: tick}, err
+	}
+	receipts, err := w.GetTransactionReceiptsForTick(tick)
+	if err != nil {
+		return TickResult{Tick: tick}, err
+	}
+	return TickResult{Tick: tick, Receipts: receipts}, nil
+}
+
+// RetryFailedTick discards any state changes made by the most recent tick, which must have failed, and runs that tick
+// again with the same transactions.
+func (w *World) RetryFailedTick(ctx context.Context) error {
+	w.tickMutex.Lock()
+	defer w.tickMutex.Unlock()
+	failed := w.failedTick
+	if failed == nil {
+		return eris.New("there is no failed tick to retry")
+	}
+	w.TickStore().DiscardPending()
+	w.dropRecordIndexes()
+	w.receiptHistory.ClearCurrentTick()
+	return w.runTick(ctx, failed.txQueue, failed.started, failed.onlySystems)
+}
+
+// runTick runs a tick with the given transactions. If onlySystems is not nil, only the systems it contains run. The
+// caller must hold tickMutex.
+func (w *World) runTick(
+	_ context.Context, txQueue *txpool.TxQueue, alreadyStarted bool, onlySystems map[string]bool,
+) error {
 	nullSystemName := "No system is running."
 	nameOfCurrentRunningSystem := nullSystemName
 	defer func() {
@@ -450,15 +891,37 @@ func (w *World) Tick(_ context.Context) error {
 	startTime := time.Now()
 	tickAsString := strconv.FormatUint(w.CurrentTick(), 10)
 	w.Logger.Info().Str("tick", tickAsString).Msg("Tick started")
-	if !w.stateIsLoaded {
-		return eris.New("must load state before first tick")
+	// This is cleared once the tick completes successfully.
+	w.failedTick = &failedTick{txQueue: txQueue, started: alreadyStarted, onlySystems: onlySystems}
+	// EVM events of an earlier attempt of this tick are dropped along with its state changes.
+	w.pendingEVMEvents = nil
+
+	if !alreadyStarted {
+		if err := w.TickStore().StartNextTick(w.registeredMessages, txQueue, w.takenDurableTxIDs...); err != nil {
+			return err
+		}
+		w.failedTick.started = true
+		w.takenDurableTxIDs = nil
+	}
+	for _, tx := range txQueue.GetTracedTxs() {
+		w.receiptHistory.SetTraceID(tx.TxHash, tx.Tx.TraceID)
+		w.Logger.Debug().
+			Str("tick", tickAsString).
+			Str("tx_hash", string(tx.TxHash)).
+			Str("trace_id", tx.Tx.TraceID).
+			Msg("processing traced transaction")
 	}
-	txQueue := w.txQueue.CopyTransactions()
 
-	if err := w.TickStore().StartNextTick(w.registeredMessages, txQueue); err != nil {
+	if err := w.removeExpiredComponents(NewWorldContext(w)); err != nil {
 		return err
 	}
-
+	txQueue, err := w.addScheduledMessages(txQueue)
+	if err != nil {
+		return err
+	}
+	// Systems see the txs of each persona in nonce order, so e.g. two moves of a player are applied in the order they
+	// were signed, no matter in which order they arrived. This only holds within a message type.
+	txQueue.OrderByNonce()
 	if w.CurrentTick() == 0 {
 		wCtx := NewWorldContextForTick(w, txQueue, w.initSystemLogger)
 		err := w.initSystem(wCtx)
@@ -467,32 +930,68 @@ func (w *World) Tick(_ context.Context) error {
 		}
 	}
 	systemTiming := make(map[string]int, len(w.systemNames))
+	systemDurations := make([]time.Duration, len(w.systems))
 	w.timestamp.Store(uint64(startTime.Unix()))
+	isRecovering := w.IsRecovering()
 	for i, sys := range w.systems {
+		if !w.systemRunConfigs[i].shouldRun(isRecovering) {
+			continue
+		}
+		if onlySystems != nil && !onlySystems[w.systemNames[i]] {
+			continue
+		}
 		nameOfCurrentRunningSystem = w.systemNames[i]
+		w.broadcastTickProgress(startTime, i, txQueue)
 		wCtx := NewWorldContextForTick(w, txQueue, w.systemLoggers[i])
 		systemStartTime := time.Now()
 		err := eris.Wrapf(sys(wCtx), "system %s generated an error", nameOfCurrentRunningSystem)
 		systemElapsedTime := time.Since(systemStartTime)
 		systemTiming[nameOfCurrentRunningSystem] = int(systemElapsedTime.Milliseconds())
+		systemDurations[i] = systemElapsedTime
 		nameOfCurrentRunningSystem = nullSystemName
 		if err != nil {
 			return err
 		}
 	}
+	w.systemDurationsMutex.Lock()
+	w.lastSystemDurations = systemDurations
+	w.systemDurationsMutex.Unlock()
+	if len(w.perPersonaTickHooks) > 0 {
+		wCtx := NewWorldContextForTick(w, txQueue, w.perPersonaHookLogger)
+		for _, personaTag := range txQueue.GetPersonaTags() {
+			for _, hook := range w.perPersonaTickHooks {
+				if err := hook(wCtx, personaTag); err != nil {
+					return eris.Wrapf(err, "per persona tick hook failed for persona tag %q", personaTag)
+				}
+			}
+		}
+	}
+	if w.unhandledMessageReceipts {
+		for _, tx := range txQueue.GetUnconsumedTxs() {
+			w.AddMessageError(tx.TxHash, eris.Wrap(ErrMessageNotHandled, ""))
+		}
+	}
 	if w.eventHub != nil {
 		// world can be optionally loaded with or without an eventHub. If there is one, on every tick it must flush events.
 		w.eventHub.FlushEvents()
 	}
 	event := w.Logger.Info()
 	finalizeTickStartTime := time.Now()
-	if err := w.TickStore().FinalizeTick(event); err != nil {
+	if err := w.finalizeTick(event); err != nil {
 		return err
 	}
 	finalizeTickElapsedTime := time.Since(finalizeTickStartTime)
+	w.failedTick = nil
 
+	w.logTransactions(w.CurrentTick(), txQueue)
 	w.setEvmResults(txQueue.GetEVMTxs())
+	w.deliverEVMEvents()
+	w.recordMessageMetrics(txQueue)
+	w.recordThroughput(txQueue)
+	w.queueMutex.Lock()
 	w.tick.Add(1)
+	w.queueTaken = false
+	w.queueMutex.Unlock()
 	w.receiptHistory.NextTick()
 	elapsedTime := time.Since(startTime)
 
@@ -573,19 +1072,31 @@ func (w *World) StartGameLoop(
 		for {
 			select {
 			case <-tickStart:
-				w.tickTheWorld(ctx, tickDone)
+				// Once the tick circuit is open, the loop keeps running so WaitForNextTick and Shutdown do not block,
+				// but it no longer ticks.
+				if !w.IsTickCircuitOpen() {
+					w.tickTheWorld(ctx, tickDone)
+					if w.IsTickCircuitOpen() {
+						w.Logger.Error().Msg("Game loop stopped ticking: tick circuit is open")
+					}
+				}
 				closeAllChannels(waitingChs)
 				waitingChs = waitingChs[:0]
 			case <-w.endGameLoopCh:
 				w.drainChannelsWaitingForNextTick()
 				w.drainEndLoopChannels()
 				closeAllChannels(waitingChs)
-				if w.GetTxQueueAmount() > 0 {
+				if w.GetTxQueueAmount() > 0 && !w.IsTickCircuitOpen() {
 					// immediately tick if queue is not empty to process all txs if queue is not empty.
 					w.tickTheWorld(ctx, tickDone)
 				}
 				break loop
 			case ch := <-w.addChannelWaitingForNextTick:
+				if w.IsTickCircuitOpen() {
+					// There will be no next tick.
+					close(ch)
+					continue
+				}
 				waitingChs = append(waitingChs, ch)
 			}
 		}
@@ -600,22 +1111,77 @@ func closeAllChannels(chs []chan struct{}) {
 }
 
 func (w *World) tickTheWorld(ctx context.Context, tickDone chan<- uint64) {
+	if w.isReadReplica {
+		if err := w.RefreshReadReplica(); err != nil {
+			w.Logger.Error().Err(err).Msg("Failed to refresh read replica")
+		}
+		if tickDone != nil {
+			tickDone <- w.CurrentTick()
+		}
+		return
+	}
 	currTick := w.CurrentTick()
-	// this is the final point where errors bubble up and hit a panic. There are other places where this occurs
-	// but this is the highest terminal point.
-	// the panic may point you to here, (or the tick function) but the real stack trace is in the error message.
-	if err := w.Tick(ctx); err != nil {
-		bytes, err := json.Marshal(eris.ToJSON(err, true))
-		if err != nil {
-			panic(err)
+	err := w.Tick(ctx)
+	for attempt := 1; err != nil && attempt <= w.tickRetries; attempt++ {
+		w.Logger.Warn().Err(err).Int("attempt", attempt).Uint64("tick", currTick).Msg("Tick failed, retrying")
+		time.Sleep(w.tickRetryDelay)
+		err = w.RetryFailedTick(ctx)
+	}
+	if err != nil {
+		w.lifecycleHooksMutex.Lock()
+		failureHooks := w.tickFailureHooks
+		w.lifecycleHooksMutex.Unlock()
+		for _, fn := range failureHooks {
+			fn(currTick, err)
+		}
+		bytes, marshalErr := json.Marshal(eris.ToJSON(err, true))
+		if marshalErr != nil {
+			panic(marshalErr)
 		}
-		w.Logger.Panic().Err(err).Str("tickError", "Error running Tick in Game Loop.").RawJSON("error", bytes)
+		if w.tickRetries == 0 {
+			// this is the final point where errors bubble up and hit a panic. There are other places where this occurs
+			// but this is the highest terminal point.
+			// the panic may point you to here, (or the tick function) but the real stack trace is in the error message.
+			w.Logger.Panic().Err(err).Str("tickError", "Error running Tick in Game Loop.").RawJSON("error", bytes)
+		}
+		// Retries are enabled, so rather than crashing, open the circuit. The game loop stops ticking and the world
+		// reports itself as unhealthy. The failed tick will be completed the next time the game state is loaded.
+		w.isTickCircuitOpen.Store(true)
+		w.Logger.Error().Str("tickError", "Tick failed after retries, opening tick circuit.").RawJSON("error", bytes).
+			Msg("")
 	}
 	if tickDone != nil {
 		tickDone <- currTick
 	}
 }
 
+// IsReadReplica reports whether the world was created with WithReadReplica.
+func (w *World) IsReadReplica() bool {
+	return w.isReadReplica
+}
+
+// RefreshReadReplica updates a read replica's view of the world to the last tick committed to storage by the primary
+// world. The game loop of a read replica calls this on every tick instead of running systems.
+func (w *World) RefreshReadReplica() error {
+	if !w.isReadReplica {
+		return eris.New("world is not a read replica")
+	}
+	_, end, err := w.TickStore().GetTickNumbers()
+	if err != nil {
+		return err
+	}
+	// Drop anything cached by the store so reads see the latest committed state.
+	w.TickStore().DiscardPending()
+	w.dropRecordIndexes()
+	w.tick.Store(end)
+	return nil
+}
+
+// IsTickCircuitOpen reports whether the game loop has stopped ticking because a tick kept failing after all retries.
+func (w *World) IsTickCircuitOpen() bool {
+	return w.isTickCircuitOpen.Load()
+}
+
 // drainChannelsWaitingForNextTick continually closes any channels that are added to the
 // addChannelWaitingForNextTick channel. This is used when the world is shut down; it ensures
 // any calls to WaitForNextTick that happen after a shutdown will not block.
@@ -651,6 +1217,8 @@ func (w *World) IsGameLoopRunning() bool {
 func (w *World) Shutdown() {
 	w.shutdownMutex.Lock() // This queues up Shutdown calls so they happen one after the other.
 	defer w.shutdownMutex.Unlock()
+	defer w.closeTxLog()
+	defer w.closeEVMEvents()
 	if !w.IsGameLoopRunning() {
 		return
 	}
@@ -683,13 +1251,16 @@ func (w *World) recoverGameState() (recoveredTxs *txpool.TxQueue, err error) {
 	return w.TickStore().Recover(w.registeredMessages)
 }
 
-func (w *World) LoadGameState() error {
+func (w *World) LoadGameState() (err error) {
 	if w.IsEntitiesCreated() {
 		return eris.Wrap(ErrEntitiesCreatedBeforeLoadingGameState, "")
 	}
 	if w.stateIsLoaded {
 		return eris.New("cannot load game state multiple times")
 	}
+	if w.adapterRequired && w.chain == nil {
+		return eris.Wrap(ErrAdapterRequired, "")
+	}
 	if !w.isMessagesRegistered {
 		if err := w.RegisterMessages(); err != nil {
 			return err
@@ -706,8 +1277,36 @@ func (w *World) LoadGameState() error {
 	if err := w.entityStore.RegisterComponents(w.registeredComponents); err != nil {
 		return err
 	}
+	for name, q := range w.nameToCQLQuery {
+		if _, err := q.ToComponentFilter(w.GetComponentByName); err != nil {
+			return eris.Wrapf(err, "CQL query %s is not valid", name)
+		}
+	}
+
+	if err := w.validateSystemComponents(); err != nil {
+		return err
+	}
+	if err := w.sortSystems(); err != nil {
+		return err
+	}
 
 	w.stateIsLoaded = true
+	if w.isReadReplica {
+		// The primary world is responsible for recovering any partially completed tick.
+		return w.RefreshReadReplica()
+	}
+	if w.txLogPath != "" {
+		if w.txLog, err = openTxLog(w.txLogPath); err != nil {
+			return err
+		}
+		defer func() {
+			// The transaction log is only kept open if the game state could be loaded.
+			if err != nil {
+				w.closeTxLog()
+				w.txLog = nil
+			}
+		}()
+	}
 	recoveredTxs, err := w.recoverGameState()
 	if err != nil {
 		return err
@@ -719,6 +1318,16 @@ func (w *World) LoadGameState() error {
 			return err
 		}
 	}
+	removed, err := w.entityStore.RemoveExpiredEntities()
+	if err != nil {
+		return err
+	}
+	if removed > 0 {
+		w.Logger.Info().Int("removed", removed).Msg("Removed entities whose components expired in redis")
+	}
+	if err = w.restoreQueuedTxs(); err != nil {
+		return err
+	}
 	w.receiptHistory.SetTick(w.CurrentTick())
 
 	return nil
@@ -727,9 +1336,50 @@ func (w *World) LoadGameState() error {
 // RecoverFromChain will attempt to recover the state of the world based on historical transaction data.
 // The function puts the world in a recovery state, and then queries all transaction batches under the world's
 // namespace. The function will continuously ask the EVM base shard for batches, and run ticks for each batch returned.
-//
-//nolint:gocognit
+// Once recovery finishes, successfully or not, the callbacks registered with OnRecoveryComplete are called.
 func (w *World) RecoverFromChain(ctx context.Context) error {
+	w.lifecycleHooksMutex.Lock()
+	startHooks := w.recoveryStartHooks
+	w.lifecycleHooksMutex.Unlock()
+	for _, fn := range startHooks {
+		fn()
+	}
+	err := w.recoverFromChain(ctx)
+	w.lifecycleHooksMutex.Lock()
+	hooks := w.recoveryCompleteHooks
+	w.lifecycleHooksMutex.Unlock()
+	for _, fn := range hooks {
+		fn(err)
+	}
+	return err
+}
+
+// OnRecoveryStart registers fn to be called when RecoverFromChain starts.
+func (w *World) OnRecoveryStart(fn func()) {
+	w.lifecycleHooksMutex.Lock()
+	defer w.lifecycleHooksMutex.Unlock()
+	w.recoveryStartHooks = append(w.recoveryStartHooks, fn)
+}
+
+// OnTickFailure registers fn to be called when a tick of the game loop fails and is not retried anymore, right before
+// the game loop panics or opens the tick circuit (see WithTickRetry). The game loop waits for fn, so fn may e.g.
+// deliver a notification before the process exits, but it must return within a bounded time.
+func (w *World) OnTickFailure(fn func(tick uint64, err error)) {
+	w.lifecycleHooksMutex.Lock()
+	defer w.lifecycleHooksMutex.Unlock()
+	w.tickFailureHooks = append(w.tickFailureHooks, fn)
+}
+
+// OnRecoveryComplete registers fn to be called when RecoverFromChain finishes. fn receives the error returned by
+// RecoverFromChain, which is nil if the world recovered successfully and is ready to accept transactions.
+func (w *World) OnRecoveryComplete(fn func(err error)) {
+	w.lifecycleHooksMutex.Lock()
+	defer w.lifecycleHooksMutex.Unlock()
+	w.recoveryCompleteHooks = append(w.recoveryCompleteHooks, fn)
+}
+
+//nolint:gocognit
+func (w *World) recoverFromChain(ctx context.Context) error {
 	if w.chain == nil {
 		return eris.Errorf(
 			"chain adapter was nil. " +
@@ -784,6 +1434,10 @@ func (w *World) RecoverFromChain(ctx context.Context) error {
 				if err != nil {
 					return err
 				}
+				// Transactions submitted by an adapter with compression may have a compressed body.
+				if sp.Body, err = shard.DecompressBody(sp); err != nil {
+					return err
+				}
 				msg := w.getMessage(message.TypeID(tx.TxId))
 				if msg == nil {
 					return eris.Errorf("error recovering tx with ID %d: tx id not found", tx.TxId)
@@ -815,6 +1469,50 @@ func (w *World) RecoverFromChain(ctx context.Context) error {
 	return nil
 }
 
+// SignedTx is a transaction for a registered message, as captured from the receipts or the adapter.
+type SignedTx struct {
+	MessageID message.TypeID
+	Tx        *sign.Transaction
+}
+
+// TickResult holds the outcome of a tick run by ReplayTick.
+type TickResult struct {
+	Tick     uint64
+	Receipts []receipt.Receipt
+}
+
+// ReplayTick runs a single tick with exactly the given transactions and returns the resulting receipts. Any pending
+// transactions in the world's queue are left untouched for the next regular tick. Signatures are not verified, so
+// transactions captured from production can be replayed against a clean world to reproduce a bug deterministically.
+func (w *World) ReplayTick(ctx context.Context, txs []SignedTx) (TickResult, error) {
+	if !w.stateIsLoaded {
+		return TickResult{}, eris.New("must load state before replaying a tick")
+	}
+	txQueue := txpool.NewTxQueue()
+	for _, tx := range txs {
+		msg := w.getMessage(tx.MessageID)
+		if msg == nil {
+			return TickResult{}, eris.Errorf("error replaying tx with ID %d: tx id not found", tx.MessageID)
+		}
+		v, err := msg.Decode(tx.Tx.Body)
+		if err != nil {
+			return TickResult{}, eris.Wrapf(err, "error decoding tx with ID %d", tx.MessageID)
+		}
+		txQueue.AddTransaction(tx.MessageID, v, tx.Tx)
+	}
+	w.tickMutex.Lock()
+	defer w.tickMutex.Unlock()
+	tick := w.CurrentTick()
+	if err := w.runTick(ctx, txQueue, false, nil); err != nil {
+		return TickResult{Tick: tick}, err
+	}
+	receipts, err := w.GetTransactionReceiptsForTick(tick)
+	if err != nil {
+		return TickResult{Tick: tick}, err
+	}
+	return TickResult{Tick: tick, Receipts: receipts}, nil
+}
+
 func (w *World) protoTransactionToGo(sp *shardv1.Transaction) *sign.Transaction {
 	return &sign.Transaction{
 		PersonaTag: sp.PersonaTag,
@@ -846,6 +1544,50 @@ func (w *World) UseNonce(signerAddress string, nonce uint64) error {
 	return w.redisStorage.Nonce.UseNonce(signerAddress, nonce)
 }
 
+// UseNonceForTx is like UseNonce, but it also accepts an already used nonce if the given transaction is byte-identical
+// to a transaction that was accepted with AcceptTx within the nonce retry window (see WithNonceRetryWindow). Such a
+// retry must not be added to the tx queue again.
+func (w *World) UseNonceForTx(signerAddress string, tx *sign.Transaction) error {
+	err := w.UseNonce(signerAddress, tx.Nonce)
+	if w.nonceRetryWindow == 0 || !eris.Is(eris.Cause(err), storage.ErrNonceHasAlreadyBeenUsed) {
+		return err
+	}
+	accepted, checkErr := w.redisStorage.Nonce.IsTxAccepted(tx.HashHex())
+	if checkErr != nil {
+		return checkErr
+	}
+	if accepted {
+		return nil
+	}
+	return err
+}
+
+// AcceptTx records that the given transaction, whose nonce was checked with UseNonceForTx, is about to be queued for
+// the given tick. If the same transaction was already accepted within the nonce retry window, the tick it was queued
+// for is returned along with true, and the transaction must not be queued again. Nothing is recorded unless the world
+// was created with WithNonceRetryWindow, or for unsigned and system transactions.
+func (w *World) AcceptTx(tx *sign.Transaction, tick uint64) (acceptedTick uint64, isRetry bool, err error) {
+	if w.nonceRetryWindow == 0 || tx.Signature == "" || tx.IsSystemTransaction() {
+		return tick, false, nil
+	}
+	return w.redisStorage.Nonce.AcceptTx(tx.HashHex(), tick, w.nonceRetryWindow)
+}
+
+// RevokeTx removes the record made by AcceptTx, for a transaction that could not be queued after all. A retry of the
+// transaction is then rejected by UseNonceForTx.
+func (w *World) RevokeTx(tx *sign.Transaction) error {
+	if w.nonceRetryWindow == 0 || tx.Signature == "" || tx.IsSystemTransaction() {
+		return nil
+	}
+	return w.redisStorage.Nonce.RemoveAcceptedTx(tx.HashHex())
+}
+
+// IsNonceUsed reports whether a transaction from the given signer with the given nonce would be rejected because the
+// nonce was already used. The nonce is not marked as used.
+func (w *World) IsNonceUsed(signerAddress string, nonce uint64) (bool, error) {
+	return w.redisStorage.Nonce.IsNonceUsed(signerAddress, nonce)
+}
+
 func (w *World) AddMessageError(id message.TxHash, err error) {
 	w.receiptHistory.AddError(id, err)
 }
@@ -854,6 +1596,10 @@ func (w *World) SetMessageResult(id message.TxHash, a any) {
 	w.receiptHistory.SetResult(id, a)
 }
 
+func (w *World) AddMessageResult(id message.TxHash, a any) {
+	w.receiptHistory.AddResult(id, a)
+}
+
 func (w *World) GetTransactionReceipt(id message.TxHash) (any, []error, bool) {
 	rec, ok := w.receiptHistory.GetReceipt(id)
 	if !ok {
@@ -866,14 +1612,50 @@ func (w *World) GetTransactionReceiptsForTick(tick uint64) ([]receipt.Receipt, e
 	return w.receiptHistory.GetReceiptsForTick(tick)
 }
 
+// GetComponents returns the registered components, except for the components the world uses internally.
 func (w *World) GetComponents() []component.ComponentMetadata {
-	return w.registeredComponents
+	if len(w.internalComponents) == 0 {
+		return w.registeredComponents
+	}
+	comps := make([]component.ComponentMetadata, 0, len(w.registeredComponents))
+	for _, c := range w.registeredComponents {
+		if !w.internalComponents[c.ID()] {
+			comps = append(comps, c)
+		}
+	}
+	return comps
+}
+
+// RegisteredComponents returns the names of all registered components, sorted by name, so the expected set of
+// components can be asserted at startup.
+func (w *World) RegisteredComponents() []string {
+	comps := w.GetComponents()
+	names := make([]string, 0, len(comps))
+	for _, c := range comps {
+		names = append(names, c.Name())
+	}
+	sort.Strings(names)
+	return names
 }
 
 func (w *World) GetSystemNames() []string {
 	return w.systemNames
 }
 
+// ListSystems returns the names of the registered systems. Once the game state is loaded, they are in the order the
+// systems run in each tick.
+func (w *World) ListSystems() []string {
+	return slices.Clone(w.systemNames)
+}
+
+// LastSystemDurations returns how long each system took in the last tick, in the order of ListSystems. Systems that
+// were skipped in the last tick took 0. Nil is returned until a tick has run all of its systems.
+func (w *World) LastSystemDurations() []time.Duration {
+	w.systemDurationsMutex.RLock()
+	defer w.systemDurationsMutex.RUnlock()
+	return slices.Clone(w.lastSystemDurations)
+}
+
 func (w *World) InjectLogger(logger *ecslog.Logger) {
 	w.Logger = logger
 	w.StoreManager().InjectLogger(logger)
@@ -1,12 +1,16 @@
 package ecs
 
 import (
+	"context"
 	"errors"
+	"sync/atomic"
 
 	"github.com/rs/zerolog"
 	ecslog "pkg.world.dev/world-engine/cardinal/ecs/log"
 	"pkg.world.dev/world-engine/cardinal/ecs/store"
 	"pkg.world.dev/world-engine/cardinal/txpool"
+	"pkg.world.dev/world-engine/cardinal/types/entity"
+	"pkg.world.dev/world-engine/cardinal/types/message"
 )
 
 type WorldContext interface {
@@ -14,6 +18,21 @@ type WorldContext interface {
 	CurrentTick() uint64
 	Logger() *zerolog.Logger
 	NewSearch(filter Filterable) (*Search, error)
+	// Context returns the context of the request this WorldContext was made for. Long iterations, like searches, stop
+	// early once it is cancelled.
+	Context() context.Context
+	// ScheduleMessage adds the given message to the transaction queue of a future tick. See worldContext.ScheduleMessage.
+	ScheduleMessage(msg message.Message, body any, atTick uint64) (message.TxHash, error)
+	// ScheduledMessages returns the messages that are scheduled but not processed yet.
+	ScheduledMessages() ([]ScheduledMessage, error)
+	// CancelScheduled removes a scheduled message before it is processed.
+	CancelScheduled(txHash message.TxHash) error
+	// EmitEVMEvent queues an event for the EVM event handler. See worldContext.EmitEVMEvent.
+	EmitEVMEvent(topic string, data []byte) error
+	// SetExternalKey tags an entity with a stable, game defined key. See worldContext.SetExternalKey.
+	SetExternalKey(id entity.ID, key string) error
+	// LookupByExternalKey returns the entity that was tagged with the given key.
+	LookupByExternalKey(key string) (entity.ID, bool)
 
 	// For internal use.
 	GetWorld() *World
@@ -32,6 +51,14 @@ type worldContext struct {
 	txQueue  *txpool.TxQueue
 	logger   *ecslog.Logger
 	readOnly bool
+	ctx      context.Context
+	// reader, if set, is returned by StoreReader instead of a reader of the world's store. It is set for the views made
+	// by ReadOnly.
+	reader store.Reader
+	// mutationAttempts counts the attempts to change state through a view made by ReadOnly.
+	mutationAttempts *atomic.Int64
+	// snapshotPinned is set while the context holds a pin on the tick snapshot. See PinTickSnapshot.
+	snapshotPinned *atomic.Bool
 }
 
 func NewWorldContextForTick(world *World, queue *txpool.TxQueue, logger *ecslog.Logger) WorldContext {
@@ -52,9 +79,22 @@ func NewWorldContext(world *World) WorldContext {
 
 func NewReadOnlyWorldContext(world *World) WorldContext {
 	return &worldContext{
-		world:    world,
-		txQueue:  nil,
-		readOnly: true,
+		world:          world,
+		txQueue:        nil,
+		readOnly:       true,
+		snapshotPinned: &atomic.Bool{},
+	}
+}
+
+// NewReadOnlyWorldContextWithContext is identical to NewReadOnlyWorldContext, but searches made with the returned
+// WorldContext stop once the given context is cancelled. It is used to abort queries whose request went away.
+func NewReadOnlyWorldContextWithContext(world *World, ctx context.Context) WorldContext {
+	return &worldContext{
+		world:          world,
+		txQueue:        nil,
+		readOnly:       true,
+		ctx:            ctx,
+		snapshotPinned: &atomic.Bool{},
 	}
 }
 
@@ -74,6 +114,13 @@ func (w *worldContext) Logger() *zerolog.Logger {
 	return w.world.Logger.Logger
 }
 
+func (w *worldContext) Context() context.Context {
+	if w.ctx == nil {
+		return context.Background()
+	}
+	return w.ctx
+}
+
 func (w *worldContext) GetWorld() *World {
 	return w.world
 }
@@ -91,6 +138,9 @@ func (w *worldContext) StoreManager() store.IManager {
 }
 
 func (w *worldContext) StoreReader() store.Reader {
+	if w.reader != nil {
+		return w.reader
+	}
 	sm := w.StoreManager()
 	if w.IsReadOnly() {
 		return sm.ToReadOnly()
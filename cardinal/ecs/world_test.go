@@ -3,9 +3,13 @@ package ecs_test
 import (
 	"context"
 	"errors"
+	"slices"
+	"sort"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"pkg.world.dev/world-engine/cardinal"
 	"pkg.world.dev/world-engine/cardinal/testutils"
 
 	"pkg.world.dev/world-engine/sign"
@@ -13,6 +17,8 @@ import (
 	"pkg.world.dev/world-engine/assert"
 
 	"pkg.world.dev/world-engine/cardinal/ecs"
+	"pkg.world.dev/world-engine/cardinal/types/component"
+	"pkg.world.dev/world-engine/cardinal/types/entity"
 )
 
 func TestCanWaitForNextTick(t *testing.T) {
@@ -89,6 +95,37 @@ func TestWaitForNextTickReturnsFalseWhenWorldIsShutDown(t *testing.T) {
 	}
 }
 
+func TestGameLoopKeepsRunningWhenTheTickCircuitOpens(t *testing.T) {
+	w := testutils.NewTestWorld(t, cardinal.WithTickRetry(1, 0)).Instance()
+	errTickFailed := errors.New("tick failed")
+	w.RegisterSystem(func(ecs.WorldContext) error { return errTickFailed })
+	startTickCh := make(chan time.Time)
+	doneTickCh := make(chan uint64)
+	assert.NilError(t, w.LoadGameState())
+	w.StartGameLoop(context.Background(), startTickCh, doneTickCh)
+
+	startTickCh <- time.Now()
+	<-doneTickCh
+	assert.Check(t, w.IsTickCircuitOpen())
+
+	// The loop still drains the tick channel without ticking, and there is no next tick to wait for.
+	startTickCh <- time.Now()
+	assert.Check(t, w.IsGameLoopRunning())
+	assert.Check(t, !w.WaitForNextTick())
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		w.Shutdown()
+		close(shutdownDone)
+	}()
+	select {
+	case <-shutdownDone:
+	case <-time.After(5 * time.Second):
+		assert.Check(t, false, "shutdown timed out")
+	}
+	assert.Check(t, !w.IsGameLoopRunning())
+}
+
 func TestCannotWaitForNextTickAfterWorldIsShutDown(t *testing.T) {
 	w := testutils.NewTestWorld(t).Instance()
 	startTickCh := make(chan time.Time)
@@ -208,6 +245,164 @@ func TestSystemExecutionOrder(t *testing.T) {
 	}
 }
 
+func TestSystemDependencyOrder(t *testing.T) {
+	w := testutils.NewTestWorld(t).Instance()
+	var order []string
+	makeSystem := func(name string) ecs.System {
+		return func(ecs.WorldContext) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+	w.RegisterSystemWithNameAfter(makeSystem("render"), "game.render", "physics", "input")
+	w.RegisterSystemWithNameAfter(makeSystem("physics"), "game.physics", "input")
+	w.RegisterSystemWithName(makeSystem("input"), "game.input")
+	w.RegisterSystemWithName(makeSystem("audio"), "game.audio")
+	assert.NilError(t, w.LoadGameState())
+	assert.NilError(t, w.Tick(context.Background()))
+
+	assert.DeepEqual(t, []string{"input", "physics", "render", "audio"}, order)
+	assert.DeepEqual(t, []string{"game.input", "game.physics", "game.render", "game.audio"}, w.GetSystemNames())
+}
+
+func TestSystemDependencyCycleIsAnError(t *testing.T) {
+	w := testutils.NewTestWorld(t).Instance()
+	noop := func(ecs.WorldContext) error { return nil }
+	w.RegisterSystemWithNameAfter(noop, "a", "c")
+	w.RegisterSystemWithNameAfter(noop, "b", "a")
+	w.RegisterSystemWithNameAfter(noop, "c", "b")
+	assert.ErrorIs(t, w.LoadGameState(), ecs.ErrSystemDependencyCycle)
+}
+
+func TestSystemDependencyOnUnknownSystemIsAnError(t *testing.T) {
+	w := testutils.NewTestWorld(t).Instance()
+	w.RegisterSystemWithNameAfter(func(ecs.WorldContext) error { return nil }, "a", "missing")
+	assert.ErrorIs(t, w.LoadGameState(), ecs.ErrSystemDependencyUnknown)
+}
+
+func TestSystemUsingUnregisteredComponentIsAnError(t *testing.T) {
+	w := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](w))
+	w.RegisterSystemWithName(func(ecs.WorldContext) error { return nil }, "a",
+		ecs.WithUsedComponents(EnergyComponent{}, OwnableComponent{}))
+	err := w.LoadGameState()
+	assert.ErrorIs(t, err, ecs.ErrSystemComponentUnknown)
+	assert.ErrorContains(t, err, OwnableComponent{}.Name())
+}
+
+func TestRegisteredComponentsAreSortedByName(t *testing.T) {
+	w := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[OwnableComponent](w))
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](w))
+	assert.NilError(t, w.LoadGameState())
+	names := w.RegisteredComponents()
+	assert.Assert(t, sort.StringsAreSorted(names))
+	assert.Assert(t, slices.Contains(names, EnergyComponent{}.Name()))
+	assert.Assert(t, slices.Contains(names, OwnableComponent{}.Name()))
+}
+
+func TestDuplicateComponentRegistrationIsAnError(t *testing.T) {
+	w := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](w))
+	assert.ErrorIs(t, ecs.RegisterComponent[EnergyComponent](w), ecs.ErrComponentAlreadyRegistered)
+	assert.ErrorIs(t, ecs.RegisterComponent[AlteredEnergyComponent](w), ecs.ErrDuplicateComponentName)
+}
+
+func TestIdempotentComponentRegistration(t *testing.T) {
+	w := testutils.NewTestWorld(t, cardinal.WithIdempotentComponentRegistration()).Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](w))
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](w))
+	assert.ErrorIs(t, ecs.RegisterComponent[AlteredEnergyComponent](w), ecs.ErrDuplicateComponentName)
+	assert.NilError(t, w.LoadGameState())
+	assert.Equal(t, 1, len(slices.DeleteFunc(w.RegisteredComponents(), func(name string) bool {
+		return name != EnergyComponent{}.Name()
+	})))
+}
+
+func TestRequiredAdapterIsEnforcedWhenLoadingGameState(t *testing.T) {
+	w := testutils.NewTestWorld(t, cardinal.WithRequiredAdapter()).Instance()
+	assert.ErrorIs(t, w.LoadGameState(), ecs.ErrAdapterRequired)
+}
+
+func TestReadReplicaServesCommittedStateWithoutTicking(t *testing.T) {
+	rs := miniredis.RunT(t)
+	primary := testutils.NewTestWorldWithCustomRedis(t, rs).Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](primary))
+	assert.NilError(t, primary.LoadGameState())
+	_, err := ecs.Create(ecs.NewWorldContext(primary), EnergyComponent{Amt: 10})
+	assert.NilError(t, err)
+	assert.NilError(t, primary.Tick(context.Background()))
+
+	replica := testutils.NewTestWorldWithCustomRedis(t, rs, cardinal.WithReadReplica()).Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](replica))
+	assert.NilError(t, replica.LoadGameState())
+	assert.Equal(t, primary.CurrentTick(), replica.CurrentTick())
+	assert.ErrorIs(t, replica.Tick(context.Background()), ecs.ErrReadReplica)
+
+	countEnergy := func() int {
+		search, err := replica.NewSearch(ecs.Contains(EnergyComponent{}))
+		assert.NilError(t, err)
+		n, err := search.Count(ecs.NewReadOnlyWorldContext(replica))
+		assert.NilError(t, err)
+		return n
+	}
+	assert.Equal(t, 1, countEnergy())
+
+	_, err = ecs.Create(ecs.NewWorldContext(primary), EnergyComponent{Amt: 20})
+	assert.NilError(t, err)
+	assert.NilError(t, primary.Tick(context.Background()))
+	assert.NilError(t, replica.RefreshReadReplica())
+	assert.Equal(t, primary.CurrentTick(), replica.CurrentTick())
+	assert.Equal(t, 2, countEnergy())
+	entityCount, err := replica.EntityCount()
+	assert.NilError(t, err)
+	assert.Equal(t, 2, entityCount)
+}
+
+func TestReadReplicaWithReadCacheSeesRefreshedValues(t *testing.T) {
+	rs := miniredis.RunT(t)
+	primary := testutils.NewTestWorldWithCustomRedis(t, rs).Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](primary))
+	assert.NilError(t, primary.LoadGameState())
+	id, err := ecs.Create(ecs.NewWorldContext(primary), EnergyComponent{Amt: 10})
+	assert.NilError(t, err)
+	assert.NilError(t, primary.Tick(context.Background()))
+
+	replica := testutils.NewTestWorldWithCustomRedis(t, rs, cardinal.WithReadReplica(), cardinal.WithReadCache()).
+		Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](replica))
+	assert.NilError(t, replica.LoadGameState())
+
+	readEnergy := func() int64 {
+		energy, err := ecs.GetComponent[EnergyComponent](ecs.NewReadOnlyWorldContext(replica), id)
+		assert.NilError(t, err)
+		return energy.Amt
+	}
+	assert.Equal(t, int64(10), readEnergy())
+
+	assert.NilError(t, ecs.SetComponent[EnergyComponent](ecs.NewWorldContext(primary), id, &EnergyComponent{Amt: 20}))
+	assert.NilError(t, primary.Tick(context.Background()))
+	assert.NilError(t, replica.RefreshReadReplica())
+	assert.Equal(t, int64(20), readEnergy())
+}
+
+func TestEntityCountOnlyCountsCommittedEntities(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](world))
+	assert.NilError(t, world.LoadGameState())
+
+	_, err := ecs.CreateMany(ecs.NewWorldContext(world), 3, EnergyComponent{})
+	assert.NilError(t, err)
+	count, err := world.EntityCount()
+	assert.NilError(t, err)
+	assert.Equal(t, 0, count)
+
+	assert.NilError(t, world.Tick(context.Background()))
+	count, err = world.EntityCount()
+	assert.NilError(t, err)
+	assert.Equal(t, 3, count)
+}
+
 func TestSetNamespace(t *testing.T) {
 	namespace := "test"
 	t.Setenv("CARDINAL_NAMESPACE", namespace)
@@ -257,3 +452,164 @@ func TestWithoutRegistration(t *testing.T) {
 	)
 	assert.NilError(t, err)
 }
+
+func TestPerPersonaTickHookRunsOncePerPersona(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	fooMsg := ecs.NewMessageType[struct{}, struct{}]("foo")
+	assert.NilError(t, world.RegisterMessages(fooMsg))
+
+	seen := map[string]int{}
+	world.RegisterPerPersonaTickHook(func(wCtx ecs.WorldContext, personaTag string) error {
+		seen[personaTag]++
+		return nil
+	})
+	assert.NilError(t, world.LoadGameState())
+
+	fooMsg.AddToQueue(world, struct{}{}, testutils.UniqueSignatureWithName("alice"))
+	fooMsg.AddToQueue(world, struct{}{}, testutils.UniqueSignatureWithName("alice"))
+	fooMsg.AddToQueue(world, struct{}{}, testutils.UniqueSignatureWithName("bob"))
+	assert.NilError(t, world.Tick(context.Background()))
+	assert.Equal(t, len(seen), 2)
+	assert.Equal(t, seen["alice"], 1)
+	assert.Equal(t, seen["bob"], 1)
+
+	// No transactions means no hooks are run.
+	assert.NilError(t, world.Tick(context.Background()))
+	assert.Equal(t, seen["alice"], 1)
+	assert.Equal(t, seen["bob"], 1)
+}
+
+type pluginFunc func(w *ecs.World) error
+
+func (f pluginFunc) Register(w *ecs.World) error {
+	return f(w)
+}
+
+func TestPluginRegistersComponentsMessagesAndSystems(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	type Msg struct{}
+	gameMsg := ecs.NewMessageType[Msg, Msg]("game-msg")
+	pluginMsg := ecs.NewMessageType[Msg, Msg]("plugin-msg")
+	handled := 0
+	assert.NilError(t, world.AddPlugin(pluginFunc(func(w *ecs.World) error {
+		if err := ecs.RegisterComponent[EnergyComponent](w); err != nil {
+			return err
+		}
+		if err := w.RegisterMessages(pluginMsg); err != nil {
+			return err
+		}
+		w.RegisterSystem(func(wCtx ecs.WorldContext) error {
+			pluginMsg.Each(wCtx, func(ecs.TxData[Msg]) (Msg, error) {
+				handled++
+				return Msg{}, nil
+			})
+			return nil
+		})
+		return nil
+	})))
+	assert.NilError(t, world.RegisterMessages(gameMsg))
+	assert.NilError(t, world.LoadGameState())
+
+	_, err := world.GetComponentByName(EnergyComponent{}.Name())
+	assert.NilError(t, err)
+	msgs, err := world.ListMessages()
+	assert.NilError(t, err)
+	// The plugin's messages come after the game's, so the IDs of the game's messages do not depend on plugins.
+	assert.Equal(t, "plugin-msg", msgs[len(msgs)-1].Name())
+	assert.Check(t, gameMsg.ID() < pluginMsg.ID())
+
+	pluginMsg.AddToQueue(world, Msg{}, testutils.UniqueSignature())
+	assert.NilError(t, world.Tick(context.Background()))
+	assert.Equal(t, 1, handled)
+}
+
+func TestFailingPluginIsRemovedFromTheWorld(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	type Msg struct{}
+	pluginMsg := ecs.NewMessageType[Msg, Msg]("plugin-msg")
+	errBroken := errors.New("plugin is broken")
+	numSystems := len(world.GetSystemNames())
+	err := world.AddPlugin(pluginFunc(func(w *ecs.World) error {
+		if err := ecs.RegisterComponent[EnergyComponent](w); err != nil {
+			return err
+		}
+		if err := w.RegisterMessages(pluginMsg); err != nil {
+			return err
+		}
+		w.RegisterSystemWithName(func(ecs.WorldContext) error { return nil }, "plugin-system")
+		return errBroken
+	}))
+	assert.ErrorIs(t, err, errBroken)
+
+	assert.Equal(t, numSystems, len(world.GetSystemNames()))
+	// The component can be registered again, because the failed plugin left no trace of it.
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](world))
+	assert.NilError(t, world.LoadGameState())
+	msgs, err := world.ListMessages()
+	assert.NilError(t, err)
+	for _, msg := range msgs {
+		assert.Check(t, msg.Name() != "plugin-msg")
+	}
+}
+
+func TestGameComponentsCannotBeRegisteredAfterPluginComponents(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[OwnableComponent](world))
+	assert.NilError(t, world.AddPlugin(pluginFunc(func(w *ecs.World) error {
+		return ecs.RegisterComponent[EnergyComponent](w)
+	})))
+
+	// The component would get an ID that depends on the plugins of the world.
+	err := ecs.RegisterComponent[Pos](world)
+	assert.ErrorIs(t, err, ecs.ErrComponentRegisteredAfterPlugin)
+	// Registering a component that is already registered is still reported as such.
+	assert.ErrorIs(t, ecs.RegisterComponent[OwnableComponent](world), ecs.ErrComponentAlreadyRegistered)
+	assert.NilError(t, world.LoadGameState())
+}
+
+func TestEntitiesCreatedBetweenUsesTheCreationTick(t *testing.T) {
+	world := testutils.NewTestWorld(t, cardinal.WithEntityCreationTicks()).Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](world))
+	assert.NilError(t, world.LoadGameState())
+	ctx := context.Background()
+
+	var createdInTick [][]entity.ID
+	for tick := 0; tick < 3; tick++ {
+		ids, err := ecs.CreateMany(ecs.NewWorldContext(world), tick+1, EnergyComponent{})
+		assert.NilError(t, err)
+		createdInTick = append(createdInTick, ids)
+		assert.NilError(t, world.Tick(ctx))
+	}
+
+	createdAt, err := ecs.GetComponent[ecs.CreatedAt](ecs.NewWorldContext(world), createdInTick[2][0])
+	assert.NilError(t, err)
+	assert.Equal(t, createdAt.Tick, uint64(2))
+
+	ids, err := world.EntitiesCreatedBetween(1, 3)
+	assert.NilError(t, err)
+	assert.ElementsMatch(t, ids, append(createdInTick[1], createdInTick[2]...))
+	ids, err = world.EntitiesCreatedBetween(0, 1)
+	assert.NilError(t, err)
+	assert.ElementsMatch(t, ids, createdInTick[0])
+	ids, err = world.EntitiesCreatedBetween(3, 100)
+	assert.NilError(t, err)
+	assert.Equal(t, len(ids), 0)
+}
+
+func TestEntityCreationTicksDoNotChangeComponentIDs(t *testing.T) {
+	world := testutils.NewTestWorld(t, cardinal.WithEntityCreationTicks()).Instance()
+	assert.NilError(t, ecs.RegisterComponent[EnergyComponent](world))
+	assert.NilError(t, world.LoadGameState())
+
+	// SignerComponent is the first component, so the first component of the game has ID 2 with or without the option.
+	energy, err := world.GetComponentByName(EnergyComponent{}.Name())
+	assert.NilError(t, err)
+	assert.Equal(t, energy.ID(), component.TypeID(2))
+}
+
+func TestEntitiesCreatedBetweenRequiresTheOption(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, world.LoadGameState())
+	_, err := world.EntitiesCreatedBetween(0, 1)
+	assert.ErrorIs(t, err, ecs.ErrCreationTicksNotRecorded)
+}
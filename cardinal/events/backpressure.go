@@ -0,0 +1,310 @@
+package events
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rotisserie/eris"
+	"github.com/rs/zerolog/log"
+)
+
+// BackpressureStrategy decides what happens to the events of a subscriber that reads them slower than they are
+// emitted. See SubscriptionOptions.
+type BackpressureStrategy int
+
+const (
+	// DropNewest drops the events that are flushed while the buffer of the subscriber is full. This is the default.
+	DropNewest BackpressureStrategy = iota
+	// DropOldest makes room for the flushed events by dropping the oldest events in the buffer of the subscriber.
+	DropOldest
+	// BlockWithTimeout waits for room in the buffer of the subscriber, so no event is dropped. A subscriber that does
+	// not make room within its BlockTimeout, or that falls more than pendingBatches behind, is disconnected, so it
+	// knows it missed events. The wait happens on a goroutine of the subscriber, so other subscribers are not held up.
+	// Servers only accept it when SubscriptionLimits.AllowBlocking is set.
+	BlockWithTimeout
+)
+
+const (
+	// DefaultSubscriptionBufferSize is the number of events that may wait to be written to a subscriber.
+	DefaultSubscriptionBufferSize = 1024
+	// DefaultBlockTimeout is how long a BlockWithTimeout subscriber is waited for.
+	DefaultBlockTimeout = writeDeadline
+	// DefaultMaxSubscriptionBufferSize is the largest buffer a client may ask for, unless SubscriptionLimits says
+	// otherwise.
+	DefaultMaxSubscriptionBufferSize = 16 * DefaultSubscriptionBufferSize
+	// DefaultMaxBlockTimeout is the longest block timeout a client may ask for, unless SubscriptionLimits says
+	// otherwise.
+	DefaultMaxBlockTimeout = 30 * time.Second
+)
+
+var backpressureStrategyNames = map[string]BackpressureStrategy{
+	"drop-newest": DropNewest,
+	"drop-oldest": DropOldest,
+	"block":       BlockWithTimeout,
+}
+
+// SubscriptionOptions configure how events are delivered to a single subscriber. Websocket clients choose them with
+// the query parameters of the /events URL: backpressure (drop-newest, drop-oldest or block), bufferSize,
+// blockTimeoutMs, envelope and topics, e.g. /events?backpressure=block&blockTimeoutMs=10000&topics=system.
+type SubscriptionOptions struct {
+	Strategy BackpressureStrategy
+	// BufferSize defaults to DefaultSubscriptionBufferSize.
+	BufferSize int
+	// BlockTimeout is only used by BlockWithTimeout. It defaults to DefaultBlockTimeout.
+	BlockTimeout time.Duration
+	// Envelope sends every event as a JSON encoded TopicMessage, with an empty topic for the events of the game, so
+	// the subscriber can trust the topic: a game event whose message looks like a TopicMessage stays inside Message.
+	Envelope bool
+	// Topics are the topics of the events the subscriber receives besides the events of the game, which have no topic.
+	// Events with any other topic, e.g. events.TickProgressTopic, are not sent to the subscriber.
+	Topics []string
+	// PersonaTag is the persona the connection authenticated as, if any. It is never read from the query parameters;
+	// see ContextWithPersonaTag.
+	PersonaTag string
+}
+
+// SubscriptionLimits are the bounds the server puts on the SubscriptionOptions websocket clients ask for. The zero
+// value uses DefaultMaxSubscriptionBufferSize and DefaultMaxBlockTimeout, and does not allow BlockWithTimeout.
+type SubscriptionLimits struct {
+	// MaxBufferSize is the largest BufferSize a client gets. Larger sizes are reduced to it.
+	MaxBufferSize int
+	// MaxBlockTimeout is the longest BlockTimeout a client gets. Longer timeouts are reduced to it.
+	MaxBlockTimeout time.Duration
+	// AllowBlocking makes the server accept the BlockWithTimeout strategy.
+	AllowBlocking bool
+}
+
+// ParseSubscriptionOptions reads SubscriptionOptions from the query parameters of a websocket URL. Parameters that
+// are not given keep their default, and the buffer size and block timeout are reduced to the given limits.
+func ParseSubscriptionOptions(query url.Values, limits SubscriptionLimits) (SubscriptionOptions, error) {
+	var opts SubscriptionOptions
+	if name := query.Get("backpressure"); name != "" {
+		strategy, ok := backpressureStrategyNames[name]
+		if !ok {
+			return opts, eris.Errorf("unknown backpressure strategy %q: must be drop-newest, drop-oldest or block", name)
+		}
+		if strategy == BlockWithTimeout && !limits.AllowBlocking {
+			return opts, eris.New("the block backpressure strategy is not enabled on this server")
+		}
+		opts.Strategy = strategy
+	}
+	if size := query.Get("bufferSize"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n <= 0 {
+			return opts, eris.Errorf("bufferSize must be a positive integer, got %q", size)
+		}
+		opts.BufferSize = min(n, limits.maxBufferSize())
+	}
+	if timeout := query.Get("blockTimeoutMs"); timeout != "" {
+		ms, err := strconv.Atoi(timeout)
+		if err != nil || ms <= 0 {
+			return opts, eris.Errorf("blockTimeoutMs must be a positive integer, got %q", timeout)
+		}
+		// Limit the milliseconds before converting them, so huge values cannot overflow the duration.
+		opts.BlockTimeout = time.Duration(min(ms, int(limits.maxBlockTimeout().Milliseconds()))) * time.Millisecond
+	}
+	if envelope := query.Get("envelope"); envelope != "" {
+		enabled, err := strconv.ParseBool(envelope)
+		if err != nil {
+			return opts, eris.Errorf("envelope must be true or false, got %q", envelope)
+		}
+		opts.Envelope = enabled
+	}
+	if topics := query.Get("topics"); topics != "" {
+		for _, topic := range strings.Split(topics, ",") {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				opts.Topics = append(opts.Topics, topic)
+			}
+		}
+	}
+	return opts, nil
+}
+
+func (l SubscriptionLimits) maxBufferSize() int {
+	if l.MaxBufferSize <= 0 {
+		return DefaultMaxSubscriptionBufferSize
+	}
+	return l.MaxBufferSize
+}
+
+func (l SubscriptionLimits) maxBlockTimeout() time.Duration {
+	if l.MaxBlockTimeout <= 0 {
+		return DefaultMaxBlockTimeout
+	}
+	return l.MaxBlockTimeout
+}
+
+// subscription buffers the events of one websocket connection, which are written by a goroutine of its own so a slow
+// connection does not hold up the others.
+type subscription struct {
+	conn          *websocket.Conn
+	remoteAddress string
+	connectedAt   time.Time
+	opts          SubscriptionOptions
+	// topics holds opts.Topics, so deliver can tell which events the subscriber asked for.
+	topics map[string]bool
+	events chan *Event
+	// pending holds the batches of events of a BlockWithTimeout subscription until forward has room for them in
+	// events. It is nil for the other strategies.
+	pending chan []*Event
+}
+
+func newSubscription(conn *websocket.Conn, opts SubscriptionOptions) *subscription {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultSubscriptionBufferSize
+	}
+	if opts.BlockTimeout <= 0 {
+		opts.BlockTimeout = DefaultBlockTimeout
+	}
+	s := &subscription{
+		conn:          conn,
+		remoteAddress: conn.RemoteAddr().String(),
+		connectedAt:   time.Now(),
+		opts:          opts,
+		topics:        map[string]bool{},
+		events:        make(chan *Event, opts.BufferSize),
+	}
+	for _, topic := range opts.Topics {
+		s.topics[topic] = true
+	}
+	if opts.Strategy == BlockWithTimeout {
+		s.pending = make(chan []*Event, pendingBatches)
+	}
+	return s
+}
+
+// deliver hands the given events to the writer of the subscription according to its backpressure strategy, leaving
+// out the events with a topic the subscriber did not ask for. It never blocks. False is returned if a BlockWithTimeout
+// subscriber fell more than pendingBatches behind, in which case it should be disconnected.
+func (s *subscription) deliver(events []*Event) bool {
+	events = s.wanted(events)
+	if len(events) == 0 {
+		return true
+	}
+	if s.opts.Strategy == BlockWithTimeout {
+		select {
+		case s.pending <- events:
+			return true
+		default:
+			log.Logger.Warn().Str("remote_address", s.remoteAddress).
+				Msg("websocket subscriber did not keep up with events and is disconnected")
+			return false
+		}
+	}
+	dropped := 0
+	for _, event := range events {
+		switch s.opts.Strategy {
+		case BlockWithTimeout:
+			// Handed to forward above.
+		case DropOldest:
+			for sent := false; !sent; {
+				select {
+				case s.events <- event:
+					sent = true
+				default:
+					select {
+					case <-s.events:
+						dropped++
+					default:
+					}
+				}
+			}
+		case DropNewest:
+			select {
+			case s.events <- event:
+			default:
+				dropped++
+			}
+		}
+	}
+	if dropped > 0 {
+		log.Logger.Warn().Str("remote_address", s.remoteAddress).Int("dropped", dropped).
+			Msg("dropped events of a websocket subscriber that did not keep up")
+	}
+	return true
+}
+
+// wanted returns the given events without the ones whose topic the subscriber did not ask for. The given slice is
+// shared with the other subscribers, so it is not modified.
+func (s *subscription) wanted(events []*Event) []*Event {
+	filtered := make([]*Event, 0, len(events))
+	for _, event := range events {
+		if event.Topic == "" || s.topics[event.Topic] {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// forward moves the pending batches of a BlockWithTimeout subscription into its buffer, waiting up to BlockTimeout for
+// room. The connection is unregistered when the subscriber does not make room in time. forward closes the buffer once
+// the hub closed the pending batches, so run stops.
+func (s *subscription) forward(unregister func(*websocket.Conn)) {
+	defer close(s.events)
+	for events := range s.pending {
+		if !s.waitForRoom(events) {
+			log.Logger.Warn().Str("remote_address", s.remoteAddress).
+				Msg("websocket subscriber did not keep up with events and is disconnected")
+			go unregister(s.conn)
+			// Keep draining until the hub closes the subscription, so deliver never reports it as behind.
+			for range s.pending { //nolint:revive // This pattern drains the channel until closed
+			}
+			return
+		}
+	}
+}
+
+// waitForRoom buffers the given events, waiting up to BlockTimeout for room. False is returned on timeout.
+func (s *subscription) waitForRoom(events []*Event) bool {
+	timer := time.NewTimer(s.opts.BlockTimeout)
+	defer timer.Stop()
+	for _, event := range events {
+		select {
+		case s.events <- event:
+		case <-timer.C:
+			return false
+		}
+	}
+	return true
+}
+
+// run writes the events of the subscription to its connection until the subscription is closed. The connection is
+// unregistered when a write fails.
+func (s *subscription) run(unregister func(*websocket.Conn)) {
+	for event := range s.events {
+		err := eris.Wrap(s.conn.SetWriteDeadline(time.Now().Add(writeDeadline)), "")
+		if err == nil {
+			var payload []byte
+			payload, err = event.payload(s.opts.Envelope)
+			if err != nil {
+				log.Logger.Error().Err(err).Msg(eris.ToString(err, true))
+				continue
+			}
+			err = eris.Wrap(s.conn.WriteMessage(websocket.TextMessage, payload), "")
+		}
+		if err != nil {
+			log.Logger.Error().Err(err).Msg(eris.ToString(err, true))
+			go unregister(s.conn)
+			// Keep draining until the hub closes the subscription, so the hub never blocks on it.
+			for range s.events { //nolint:revive // This pattern drains the channel until closed
+			}
+			return
+		}
+	}
+}
+
+// close stops the writer of the subscription and closes its connection. It must only be called by the hub.
+func (s *subscription) close() {
+	if s.pending != nil {
+		// forward closes the buffer once it is done with the pending batches.
+		close(s.pending)
+	} else {
+		close(s.events)
+	}
+	if err := eris.Wrap(s.conn.Close(), ""); err != nil {
+		log.Logger.Error().Err(err).Msg(eris.ToString(err, true))
+	}
+}
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"pkg.world.dev/world-engine/assert"
+)
+
+// slowSubscription returns a subscription whose events are never written, like a subscriber that stopped reading.
+func slowSubscription(opts SubscriptionOptions) *subscription {
+	s := &subscription{
+		opts:   opts,
+		events: make(chan *Event, opts.BufferSize),
+	}
+	if opts.Strategy == BlockWithTimeout {
+		s.pending = make(chan []*Event, pendingBatches)
+	}
+	return s
+}
+
+func makeEvents(messages ...string) []*Event {
+	events := make([]*Event, 0, len(messages))
+	for _, msg := range messages {
+		events = append(events, &Event{Message: msg})
+	}
+	return events
+}
+
+func bufferedMessages(s *subscription) []string {
+	var messages []string
+	for len(s.events) > 0 {
+		messages = append(messages, (<-s.events).Message)
+	}
+	return messages
+}
+
+func TestBackpressureStrategies(t *testing.T) {
+	testCases := []struct {
+		strategy BackpressureStrategy
+		want     []string
+	}{
+		{strategy: DropNewest, want: []string{"a", "b"}},
+		{strategy: DropOldest, want: []string{"c", "d"}},
+	}
+	for _, tc := range testCases {
+		s := slowSubscription(SubscriptionOptions{Strategy: tc.strategy, BufferSize: 2})
+		assert.Check(t, s.deliver(makeEvents("a", "b", "c", "d")))
+		assert.DeepEqual(t, tc.want, bufferedMessages(s))
+	}
+}
+
+func TestBlockWithTimeoutWaitsForTheSubscriber(t *testing.T) {
+	s := slowSubscription(SubscriptionOptions{Strategy: BlockWithTimeout, BufferSize: 1, BlockTimeout: time.Second})
+	received := make(chan []string)
+	go func() {
+		var messages []string
+		for len(messages) < 3 {
+			messages = append(messages, (<-s.events).Message)
+		}
+		received <- messages
+	}()
+	assert.Check(t, s.waitForRoom(makeEvents("a", "b", "c")))
+	assert.DeepEqual(t, []string{"a", "b", "c"}, <-received)
+
+	// A subscriber that does not read at all times out.
+	s = slowSubscription(SubscriptionOptions{
+		Strategy: BlockWithTimeout, BufferSize: 1, BlockTimeout: 10 * time.Millisecond,
+	})
+	assert.Check(t, !s.waitForRoom(makeEvents("a", "b")))
+}
+
+func TestBlockWithTimeoutDoesNotBlockTheHub(t *testing.T) {
+	s := slowSubscription(SubscriptionOptions{Strategy: BlockWithTimeout, BufferSize: 1, BlockTimeout: time.Hour})
+	// Nothing forwards the pending batches, so the subscriber falls behind without deliver ever waiting for it.
+	for i := 0; i < pendingBatches; i++ {
+		assert.Check(t, s.deliver(makeEvents("a", "b")))
+	}
+	assert.Check(t, !s.deliver(makeEvents("c")))
+}
+
+func TestParseSubscriptionOptions(t *testing.T) {
+	opts, err := ParseSubscriptionOptions(url.Values{}, SubscriptionLimits{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, SubscriptionOptions{}, opts)
+
+	blocking := url.Values{
+		"backpressure":   {"block"},
+		"bufferSize":     {"16"},
+		"blockTimeoutMs": {"250"},
+	}
+	opts, err = ParseSubscriptionOptions(blocking, SubscriptionLimits{AllowBlocking: true})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, SubscriptionOptions{
+		Strategy:     BlockWithTimeout,
+		BufferSize:   16,
+		BlockTimeout: 250 * time.Millisecond,
+	}, opts)
+
+	opts, err = ParseSubscriptionOptions(url.Values{"envelope": {"true"}}, SubscriptionLimits{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, SubscriptionOptions{Envelope: true}, opts)
+
+	opts, err = ParseSubscriptionOptions(url.Values{"topics": {"system, tick-progress,"}}, SubscriptionLimits{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, SubscriptionOptions{Topics: []string{"system", "tick-progress"}}, opts)
+
+	for _, query := range []url.Values{
+		{"backpressure": {"drop-everything"}},
+		{"bufferSize": {"0"}},
+		{"blockTimeoutMs": {"soon"}},
+		{"envelope": {"maybe"}},
+	} {
+		_, err = ParseSubscriptionOptions(query, SubscriptionLimits{AllowBlocking: true})
+		assert.IsError(t, err)
+	}
+
+	// The block strategy must be allowed by the server.
+	_, err = ParseSubscriptionOptions(blocking, SubscriptionLimits{})
+	assert.IsError(t, err)
+}
+
+func TestSubscriptionOptionsAreLimited(t *testing.T) {
+	query := url.Values{
+		"backpressure":   {"block"},
+		"bufferSize":     {"2000000000"},
+		"blockTimeoutMs": {"9223372036854775807"},
+	}
+	opts, err := ParseSubscriptionOptions(query, SubscriptionLimits{AllowBlocking: true})
+	assert.NilError(t, err)
+	assert.Equal(t, DefaultMaxSubscriptionBufferSize, opts.BufferSize)
+	assert.Equal(t, DefaultMaxBlockTimeout, opts.BlockTimeout)
+
+	opts, err = ParseSubscriptionOptions(query, SubscriptionLimits{
+		MaxBufferSize:   8,
+		MaxBlockTimeout: time.Second,
+		AllowBlocking:   true,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, 8, opts.BufferSize)
+	assert.Equal(t, time.Second, opts.BlockTimeout)
+}
@@ -1,8 +1,10 @@
 package events
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -18,11 +20,24 @@ const shutdownPollInterval = 200
 
 type EventHub interface {
 	EmitEvent(event *Event)
+	// Broadcast sends the event to subscribers right away, rather than with the events of the current tick.
+	Broadcast(event *Event)
 	FlushEvents()
 	ShutdownEventHub()
 	Run()
 	UnregisterConnection(ws *websocket.Conn)
 	RegisterConnection(ws *websocket.Conn)
+	// RegisterConnectionWithOptions is identical to RegisterConnection, but the events of the connection are delivered
+	// as configured by the given options.
+	RegisterConnectionWithOptions(ws *websocket.Conn, opts SubscriptionOptions)
+	Subscribers() []Subscriber
+}
+
+// Subscriber describes a websocket connection that receives the events of the hub. PersonaTag is empty unless the
+// connection authenticated as a persona.
+type Subscriber struct {
+	PersonaTag  string    `json:"personaTag,omitempty"`
+	ConnectedAt time.Time `json:"connectedAt"`
 }
 
 const (
@@ -30,27 +45,54 @@ const (
 	bufferSize    = 1024
 )
 
+// loggingEventHub logs events instead of sending them to subscribers. Like webSocketEventHub, it never blocks the
+// systems that emit events or the tick that flushes them: the events of a tick are dropped when the hub falls more
+// than pendingBatches behind.
 type loggingEventHub struct {
-	logger     *ecslog.Logger
-	eventQueue []*Event
-	running    atomic.Bool
-	broadcast  chan *Event
-	shutdown   chan bool
-	flush      chan bool
+	logger *ecslog.Logger
+	// eventQueue holds the events emitted during the current tick until they are flushed.
+	eventQueue      []*Event
+	eventQueueMutex sync.Mutex
+	running         atomic.Bool
+	outgoing        chan []*Event
+	shutdown        chan bool
 }
 
 func (eh *loggingEventHub) EmitEvent(event *Event) {
-	eh.broadcast <- event
+	eh.eventQueueMutex.Lock()
+	defer eh.eventQueueMutex.Unlock()
+	eh.eventQueue = append(eh.eventQueue, event)
+}
+
+func (eh *loggingEventHub) Broadcast(event *Event) {
+	eh.logger.Info().Str("topic", event.Topic).Msg("EVENT: " + event.Message)
 }
 
 func (eh *loggingEventHub) FlushEvents() {
-	eh.flush <- true
+	eh.eventQueueMutex.Lock()
+	events := eh.eventQueue
+	eh.eventQueue = nil
+	eh.eventQueueMutex.Unlock()
+	if len(events) == 0 {
+		return
+	}
+	select {
+	case eh.outgoing <- events:
+	default:
+		log.Warn().Int("dropped", len(events)).Msg("event hub is behind, dropped the events of a tick")
+	}
 }
 
 func (eh *loggingEventHub) UnregisterConnection(_ *websocket.Conn) {}
 
 func (eh *loggingEventHub) RegisterConnection(_ *websocket.Conn) {}
 
+func (eh *loggingEventHub) RegisterConnectionWithOptions(_ *websocket.Conn, _ SubscriptionOptions) {}
+
+func (eh *loggingEventHub) Subscribers() []Subscriber {
+	return []Subscriber{}
+}
+
 func (eh *loggingEventHub) Run() {
 	if eh.running.Load() {
 		return
@@ -58,19 +100,14 @@ func (eh *loggingEventHub) Run() {
 	eh.running.Store(true)
 	for eh.running.Load() {
 		select {
-		case event := <-eh.broadcast:
-			eh.eventQueue = append(eh.eventQueue, event)
-		case <-eh.flush:
-			wg := sync.WaitGroup{}
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for _, event := range eh.eventQueue {
-					eh.logger.Info().Msg("EVENT: " + event.Message)
+		case events := <-eh.outgoing:
+			for _, event := range events {
+				if event.Topic != "" {
+					eh.logger.Info().Str("topic", event.Topic).Msg("EVENT: " + event.Message)
+					continue
 				}
-			}() // a goroutine is not technically necessary here but this imitates the websocket eventhub as much as possible.
-			wg.Wait()
-			eh.eventQueue = eh.eventQueue[:0]
+				eh.logger.Info().Msg("EVENT: " + event.Message)
+			}
 		case <-eh.shutdown:
 			eh.running.Store(false)
 		}
@@ -83,12 +120,10 @@ func (eh *loggingEventHub) ShutdownEventHub() {
 
 func CreateLoggingEventHub(logger *ecslog.Logger) EventHub {
 	res := loggingEventHub{
-		eventQueue: make([]*Event, 0),
-		running:    atomic.Bool{},
-		broadcast:  make(chan *Event),
-		shutdown:   make(chan bool),
-		flush:      make(chan bool),
-		logger:     logger,
+		running:  atomic.Bool{},
+		outgoing: make(chan []*Event, pendingBatches),
+		shutdown: make(chan bool),
+		logger:   logger,
 	}
 	res.running.Store(false)
 	go func() {
@@ -99,10 +134,9 @@ func CreateLoggingEventHub(logger *ecslog.Logger) EventHub {
 
 func CreateWebSocketEventHub() EventHub {
 	res := webSocketEventHub{
-		websocketConnections: map[*websocket.Conn]bool{},
-		broadcast:            make(chan *Event),
-		flush:                make(chan bool),
-		register:             make(chan *websocket.Conn),
+		websocketConnections: map[*websocket.Conn]*subscription{},
+		outgoing:             make(chan []*Event, pendingBatches),
+		register:             make(chan *subscription),
 		unregister:           make(chan *websocket.Conn),
 		shutdown:             make(chan bool),
 		running:              atomic.Bool{},
@@ -114,37 +148,124 @@ func CreateWebSocketEventHub() EventHub {
 	return &res
 }
 
+// SystemTopic is the reserved topic of messages that are broadcast by the operator of the server, e.g. to announce a
+// restart, rather than emitted by the game.
+const SystemTopic = "system"
+
+// TickProgressTopic is the reserved topic of the progress messages the world broadcasts during long ticks. See
+// ecs.WithTickProgressEvents.
+const TickProgressTopic = "tick-progress"
+
 type Event struct {
 	Message string
+	// Topic is empty for events emitted by the game. Events with a topic are only sent to the clients that ask for the
+	// topic (see SubscriptionOptions.Topics), as a JSON encoded TopicMessage so they can be told apart from game events.
+	Topic string
+}
+
+// TopicMessage is the format in which events with a topic are sent to websocket clients.
+type TopicMessage struct {
+	Topic   string `json:"topic"`
+	Message string `json:"message"`
 }
 
+// payload returns the bytes that are written to websocket clients for the event. With envelope, events of the game
+// are also sent as a TopicMessage. See SubscriptionOptions.Envelope.
+func (e *Event) payload(envelope bool) ([]byte, error) {
+	if e.Topic == "" && !envelope {
+		return []byte(e.Message), nil
+	}
+	bz, err := json.Marshal(TopicMessage{Topic: e.Topic, Message: e.Message})
+	return bz, eris.Wrap(err, "")
+}
+
+// pendingBatches is the number of flushed ticks, or broadcast events, that may wait for the hub to deliver them before
+// further ones are dropped.
+const pendingBatches = 64
+
+// webSocketEventHub never blocks the systems that emit events or the tick that flushes them: events are discarded
+// when they are flushed while there are no subscribers, and are dropped when the hub falls more than pendingBatches
+// behind.
 type webSocketEventHub struct {
-	websocketConnections map[*websocket.Conn]bool
-	broadcast            chan *Event
-	flush                chan bool
-	unregister           chan *websocket.Conn
-	register             chan *websocket.Conn
-	shutdown             chan bool
-	eventQueue           []*Event
-	running              atomic.Bool
+	// websocketConnections maps each connection to its subscription. It is only modified by Run, which holds
+	// connectionsMutex while doing so, so that Subscribers can read it from other goroutines.
+	websocketConnections map[*websocket.Conn]*subscription
+	connectionsMutex     sync.RWMutex
+	// subscriberCount is the number of entries in websocketConnections, so it can be read without waiting on Run.
+	subscriberCount atomic.Int64
+	// outgoing carries both the broadcast events and the flushed events of each tick, so subscribers receive them in
+	// the order they were sent.
+	outgoing   chan []*Event
+	unregister chan *websocket.Conn
+	register   chan *subscription
+	shutdown   chan bool
+	// eventQueue holds the events emitted during the current tick until they are flushed.
+	eventQueue      []*Event
+	eventQueueMutex sync.Mutex
+	running         atomic.Bool
 }
 
 func (eh *webSocketEventHub) EmitEvent(event *Event) {
-	eh.broadcast <- event
+	eh.eventQueueMutex.Lock()
+	defer eh.eventQueueMutex.Unlock()
+	eh.eventQueue = append(eh.eventQueue, event)
+}
+
+func (eh *webSocketEventHub) Broadcast(event *Event) {
+	if eh.subscriberCount.Load() == 0 {
+		return
+	}
+	select {
+	case eh.outgoing <- []*Event{event}:
+	default:
+		log.Warn().Str("topic", event.Topic).Msg("event hub is behind, dropped a broadcast event")
+	}
 }
 
 func (eh *webSocketEventHub) FlushEvents() {
-	eh.flush <- true
+	eh.eventQueueMutex.Lock()
+	events := eh.eventQueue
+	eh.eventQueue = nil
+	eh.eventQueueMutex.Unlock()
+	if len(events) == 0 || eh.subscriberCount.Load() == 0 {
+		return
+	}
+	select {
+	case eh.outgoing <- events:
+	default:
+		log.Warn().Int("dropped", len(events)).Msg("event hub is behind, dropped the events of a tick")
+	}
 }
 
 func (eh *webSocketEventHub) RegisterConnection(ws *websocket.Conn) {
-	eh.register <- ws
+	eh.RegisterConnectionWithOptions(ws, SubscriptionOptions{})
+}
+
+func (eh *webSocketEventHub) RegisterConnectionWithOptions(ws *websocket.Conn, opts SubscriptionOptions) {
+	eh.register <- newSubscription(ws, opts)
 }
 
 func (eh *webSocketEventHub) UnregisterConnection(ws *websocket.Conn) {
 	eh.unregister <- ws
 }
 
+// Subscribers returns the connections that are currently registered with the hub, in the order they connected.
+func (eh *webSocketEventHub) Subscribers() []Subscriber {
+	eh.connectionsMutex.RLock()
+	defer eh.connectionsMutex.RUnlock()
+	subscribers := make([]Subscriber, 0, len(eh.websocketConnections))
+	for _, sub := range eh.websocketConnections {
+		subscribers = append(subscribers, Subscriber{
+			PersonaTag:  sub.opts.PersonaTag,
+			ConnectedAt: sub.connectedAt,
+		})
+	}
+	sort.Slice(subscribers, func(i, j int) bool {
+		return subscribers[i].ConnectedAt.Before(subscribers[j].ConnectedAt)
+	})
+	return subscribers
+}
+
 func (eh *webSocketEventHub) ShutdownEventHub() {
 	eh.shutdown <- true
 	// block until the loop fully exits.
@@ -156,59 +277,42 @@ func (eh *webSocketEventHub) ShutdownEventHub() {
 	}
 }
 
-//nolint:gocognit
 func (eh *webSocketEventHub) Run() {
 	if eh.running.Load() {
 		return
 	}
 	eh.running.Store(true)
 	unregisterConnection := func(conn *websocket.Conn) {
-		if _, ok := eh.websocketConnections[conn]; ok {
+		if sub, ok := eh.websocketConnections[conn]; ok {
+			eh.connectionsMutex.Lock()
 			delete(eh.websocketConnections, conn)
-			err := eris.Wrap(conn.Close(), "")
-			if err != nil {
-				log.Logger.Error().Err(err).Msg(eris.ToString(err, true))
-			}
+			eh.subscriberCount.Store(int64(len(eh.websocketConnections)))
+			eh.connectionsMutex.Unlock()
+			sub.close()
 		}
 	}
 Loop:
 	for eh.running.Load() {
 		select {
-		case conn := <-eh.register:
-			eh.websocketConnections[conn] = true
+		case sub := <-eh.register:
+			eh.connectionsMutex.Lock()
+			eh.websocketConnections[sub.conn] = sub
+			eh.subscriberCount.Store(int64(len(eh.websocketConnections)))
+			eh.connectionsMutex.Unlock()
+			go sub.run(eh.UnregisterConnection)
+			if sub.pending != nil {
+				go sub.forward(eh.UnregisterConnection)
+			}
 		case conn := <-eh.unregister:
 			unregisterConnection(conn)
-		case event := <-eh.broadcast:
-			eh.eventQueue = append(eh.eventQueue, event)
-		case <-eh.flush:
-			var waitGroup sync.WaitGroup
-			for conn := range eh.websocketConnections {
-				waitGroup.Add(1)
-				conn := conn
-				go func() {
-					defer waitGroup.Done()
-					for _, event := range eh.eventQueue {
-						err := eris.Wrap(conn.SetWriteDeadline(time.Now().Add(writeDeadline)), "")
-						if err != nil {
-							go func() {
-								eh.UnregisterConnection(conn)
-							}()
-							log.Logger.Error().Err(err).Msg(eris.ToString(err, true))
-							break
-						}
-						err = eris.Wrap(conn.WriteMessage(websocket.TextMessage, []byte(event.Message)), "")
-						if err != nil {
-							go func() {
-								eh.UnregisterConnection(conn)
-							}()
-							log.Logger.Error().Err(err).Msg(eris.ToString(err, true))
-							break
-						}
-					}
-				}()
+		case events := <-eh.outgoing:
+			// Each subscription writes its events on its own goroutine, and deliver never blocks, so a slow
+			// subscriber does not hold up the others.
+			for conn, sub := range eh.websocketConnections {
+				if !sub.deliver(events) {
+					unregisterConnection(conn)
+				}
 			}
-			waitGroup.Wait()
-			eh.eventQueue = eh.eventQueue[:0]
 		case <-eh.shutdown:
 			go func() {
 				for range eh.shutdown { //nolint:revive // This pattern drains the channel until closed
@@ -224,7 +328,9 @@ Loop:
 }
 
 type webSocketHandler struct {
-	internalServe func(*websocket.Conn) error
+	internalServe func(*websocket.Conn, *http.Request) error
+	// checkRequest, if set, rejects upgrade requests with 400 Bad Request when it returns an error.
+	checkRequest  func(*http.Request) error
 	path          string
 	parentHandler http.Handler
 	upgrader      websocket.Upgrader
@@ -235,6 +341,12 @@ var upgrader = websocket.Upgrader{}
 func (w *webSocketHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
 	//nolint:nestif // its ok
 	if request.URL.Path == w.path {
+		if w.checkRequest != nil {
+			if err := w.checkRequest(request); err != nil {
+				http.Error(responseWriter, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
 		ws, err := w.upgrader.Upgrade(responseWriter, request, nil)
 		err = eris.Wrap(err, "")
 		if err != nil {
@@ -243,7 +355,7 @@ func (w *webSocketHandler) ServeHTTP(responseWriter http.ResponseWriter, request
 				panic(err)
 			}
 		} else {
-			err = eris.Wrap(w.internalServe(ws), "")
+			err = eris.Wrap(w.internalServe(ws, request), "")
 			if err != nil {
 				err = sendError(responseWriter, err)
 				if err != nil {
@@ -264,7 +376,9 @@ func CreateNewWebSocketBuilder(path string, websocketConnectionHandler func(conn
 			WriteBufferSize: bufferSize,
 		}
 		res := webSocketHandler{
-			internalServe: websocketConnectionHandler,
+			internalServe: func(conn *websocket.Conn, _ *http.Request) error {
+				return websocketConnectionHandler(conn)
+			},
 			path:          path,
 			parentHandler: handler,
 			upgrader:      up,
@@ -273,6 +387,42 @@ func CreateNewWebSocketBuilder(path string, websocketConnectionHandler func(conn
 	}
 }
 
+type personaTagContextKey struct{}
+
+// ContextWithPersonaTag returns a copy of ctx that marks the websocket connection of its request as authenticated as
+// the given persona tag. The handlers created by CreateEventHubWebSocketBuilder report it in Subscriber.PersonaTag.
+func ContextWithPersonaTag(ctx context.Context, personaTag string) context.Context {
+	return context.WithValue(ctx, personaTagContextKey{}, personaTag)
+}
+
+// CreateEventHubWebSocketBuilder serves the events of the given hub on the given websocket path. Each client chooses
+// how its events are delivered with the query parameters of the URL, within the given limits. See SubscriptionOptions.
+func CreateEventHubWebSocketBuilder(path string, hub EventHub, limits SubscriptionLimits) middleware.Builder {
+	return func(handler http.Handler) http.Handler {
+		return &webSocketHandler{
+			internalServe: func(conn *websocket.Conn, request *http.Request) error {
+				opts, err := ParseSubscriptionOptions(request.URL.Query(), limits)
+				if err != nil {
+					return err
+				}
+				opts.PersonaTag, _ = request.Context().Value(personaTagContextKey{}).(string)
+				hub.RegisterConnectionWithOptions(conn, opts)
+				return nil
+			},
+			checkRequest: func(request *http.Request) error {
+				_, err := ParseSubscriptionOptions(request.URL.Query(), limits)
+				return err
+			},
+			path:          path,
+			parentHandler: handler,
+			upgrader: websocket.Upgrader{
+				ReadBufferSize:  bufferSize,
+				WriteBufferSize: bufferSize,
+			},
+		}
+	}
+}
+
 func CreateWebSocketEventHandler(hub EventHub) func(conn *websocket.Conn) error {
 	return func(conn *websocket.Conn) error {
 		hub.RegisterConnection(conn)
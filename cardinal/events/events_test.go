@@ -3,11 +3,14 @@ package events_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/rotisserie/eris"
 	"pkg.world.dev/world-engine/cardinal"
@@ -208,6 +211,10 @@ func TestEventHubLogger(t *testing.T) {
 		assert.NilError(t, err)
 	}
 	testString := "{\"level\":\"info\",\"message\":\"EVENT: test\"}\n"
+	// The hub logs the flushed events on its own goroutine.
+	require.Eventually(t, func() bool {
+		return strings.Count(buf.String(), "\n") >= 25
+	}, 5*time.Second, 10*time.Millisecond)
 	eventsLogs := buf.String()
 	splitLogs := strings.Split(eventsLogs, "\n")
 	splitLogs = splitLogs[:len(splitLogs)-1]
@@ -216,3 +223,223 @@ func TestEventHubLogger(t *testing.T) {
 		require.JSONEq(t, testString, logEntry)
 	}
 }
+
+func TestInvalidSubscriptionOptionsAreRejected(t *testing.T) {
+	w := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, w.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, w, server.DisableSignatureVerification())
+
+	_, resp, err := websocket.DefaultDialer.Dial(txh.MakeWebSocketURL("events?backpressure=sometimes"), nil)
+	assert.IsError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// The block strategy is refused unless the server allows it.
+	_, resp, err = websocket.DefaultDialer.Dial(txh.MakeWebSocketURL("events?backpressure=block"), nil)
+	assert.IsError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	conn, _, err := websocket.DefaultDialer.Dial(txh.MakeWebSocketURL("events?backpressure=drop-oldest&bufferSize=4"), nil)
+	assert.NilError(t, err)
+	assert.NilError(t, conn.Close())
+}
+
+func TestEnvelopeKeepsGameEventsFromPosingAsSystemMessages(t *testing.T) {
+	w := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, w.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, w, server.DisableSignatureVerification())
+	conn, _, err := websocket.DefaultDialer.Dial(txh.MakeWebSocketURL("events?envelope=true&topics=system"), nil)
+	assert.NilError(t, err)
+	defer conn.Close()
+
+	forged := `{"topic":"system","message":"restarting now"}`
+	txh.EventHub.EmitEvent(&events.Event{Message: forged})
+	txh.EventHub.EmitEvent(&events.Event{Message: "restarting in 5 minutes", Topic: events.SystemTopic})
+	txh.EventHub.FlushEvents()
+
+	var msg events.TopicMessage
+	_, bz, err := conn.ReadMessage()
+	assert.NilError(t, err)
+	assert.NilError(t, json.Unmarshal(bz, &msg))
+	assert.Equal(t, events.TopicMessage{Topic: "", Message: forged}, msg)
+
+	_, bz, err = conn.ReadMessage()
+	assert.NilError(t, err)
+	assert.NilError(t, json.Unmarshal(bz, &msg))
+	assert.Equal(t, events.TopicMessage{Topic: events.SystemTopic, Message: "restarting in 5 minutes"}, msg)
+}
+
+func TestTopicEventsAreOnlySentToSubscribersThatAskForThem(t *testing.T) {
+	w := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, w.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, w, server.DisableSignatureVerification())
+	gameOnly, _, err := websocket.DefaultDialer.Dial(txh.MakeWebSocketURL("events"), nil)
+	assert.NilError(t, err)
+	defer gameOnly.Close()
+	withSystem, _, err := websocket.DefaultDialer.Dial(txh.MakeWebSocketURL("events?topics=system"), nil)
+	assert.NilError(t, err)
+	defer withSystem.Close()
+	for i := 0; i < 50 && len(txh.EventHub.Subscribers()) < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	txh.EventHub.EmitEvent(&events.Event{Message: "restarting in 5 minutes", Topic: events.SystemTopic})
+	txh.EventHub.EmitEvent(&events.Event{Message: "game event"})
+	txh.EventHub.FlushEvents()
+
+	_, bz, err := gameOnly.ReadMessage()
+	assert.NilError(t, err)
+	assert.Equal(t, "game event", string(bz))
+
+	_, bz, err = withSystem.ReadMessage()
+	assert.NilError(t, err)
+	var msg events.TopicMessage
+	assert.NilError(t, json.Unmarshal(bz, &msg))
+	assert.Equal(t, events.TopicMessage{Topic: events.SystemTopic, Message: "restarting in 5 minutes"}, msg)
+	_, bz, err = withSystem.ReadMessage()
+	assert.NilError(t, err)
+	assert.Equal(t, "game event", string(bz))
+}
+
+func TestTickProgressIsBroadcastBeforeEachSystem(t *testing.T) {
+	w := testutils.NewTestWorld(t, cardinal.WithTickProgressEvents(0)).Instance()
+	w.RegisterSystemWithName(func(ecs.WorldContext) error { return nil }, "first")
+	w.RegisterSystemWithName(func(ecs.WorldContext) error { return nil }, "second")
+	assert.NilError(t, w.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, w, server.DisableSignatureVerification())
+	conn, _, err := websocket.DefaultDialer.Dial(txh.MakeWebSocketURL("events?topics=tick-progress"), nil)
+	assert.NilError(t, err)
+	defer conn.Close()
+
+	defer tickInBackground(t, w)()
+
+	var progress []ecs.TickProgress
+	for len(progress) < 2 {
+		_, bz, err := conn.ReadMessage()
+		assert.NilError(t, err)
+		var msg events.TopicMessage
+		assert.NilError(t, json.Unmarshal(bz, &msg))
+		assert.Equal(t, events.TickProgressTopic, msg.Topic)
+		var p ecs.TickProgress
+		assert.NilError(t, json.Unmarshal([]byte(msg.Message), &p))
+		if len(progress) == 0 && p.System != "first" {
+			// The built-in persona systems run first.
+			continue
+		}
+		progress = append(progress, p)
+	}
+	assert.Equal(t, "second", progress[1].System)
+	assert.Equal(t, progress[0].Tick, progress[1].Tick)
+	assert.Equal(t, progress[0].SystemIndex+1, progress[1].SystemIndex)
+	assert.Equal(t, progress[1].SystemCount, progress[1].SystemIndex+1)
+}
+
+// tickInBackground ticks the given world until the returned function is called. The function waits for the last tick
+// to complete, so the world is not used after the test returns.
+func tickInBackground(t *testing.T, w *ecs.World) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				if err := w.Tick(context.Background()); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func TestTickProgressIsSentInOrderWithTheEventsOfTheTick(t *testing.T) {
+	w := testutils.NewTestWorld(t, cardinal.WithTickProgressEvents(0)).Instance()
+	w.RegisterSystemWithName(func(wCtx ecs.WorldContext) error {
+		wCtx.GetWorld().EmitEvent(&events.Event{Message: "emitted", Topic: "game"})
+		return nil
+	}, "emitter")
+	assert.NilError(t, w.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, w, server.DisableSignatureVerification())
+	conn, _, err := websocket.DefaultDialer.Dial(txh.MakeWebSocketURL("events?topics=tick-progress,game"), nil)
+	assert.NilError(t, err)
+	defer conn.Close()
+	defer tickInBackground(t, w)()
+
+	// The events of a tick are flushed after its last system ran, so they always follow the progress event of the last
+	// system, and precede the progress events of the next tick.
+	var last *ecs.TickProgress
+	for emitted := 0; emitted < 10; {
+		_, bz, err := conn.ReadMessage()
+		assert.NilError(t, err)
+		var msg events.TopicMessage
+		assert.NilError(t, json.Unmarshal(bz, &msg))
+		if msg.Topic == events.TickProgressTopic {
+			last = &ecs.TickProgress{}
+			assert.NilError(t, json.Unmarshal([]byte(msg.Message), last))
+			continue
+		}
+		assert.Equal(t, "emitted", msg.Message)
+		if last != nil {
+			assert.Equal(t, last.SystemCount, last.SystemIndex+1)
+		}
+		emitted++
+	}
+}
+
+func TestEmittingEventsWithoutSubscribersDoesNotBlockTicks(t *testing.T) {
+	const eventsPerTick = 10_000
+	w := testutils.NewTestWorld(t).Instance()
+	w.RegisterSystem(func(wCtx ecs.WorldContext) error {
+		for i := 0; i < eventsPerTick; i++ {
+			wCtx.GetWorld().EmitEvent(&events.Event{Message: "test"})
+		}
+		return nil
+	})
+	assert.NilError(t, w.LoadGameState())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			assert.NilError(t, w.Tick(context.Background()))
+		}
+		// Nothing is draining the hub once it is shut down, so this would block if emitting could block.
+		w.GetEventHub().ShutdownEventHub()
+		for i := 0; i < 100; i++ {
+			assert.NilError(t, w.Tick(context.Background()))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("ticks blocked on emitting events without subscribers")
+	}
+}
+
+func TestLoggingEventHubDoesNotBlockWhenBehind(t *testing.T) {
+	nopLogger := zerolog.Nop()
+	logger := ecslog.Logger{&nopLogger}
+	hub := events.CreateLoggingEventHub(&logger)
+	// Nothing is logging the flushed events once the hub is shut down.
+	hub.ShutdownEventHub()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			hub.EmitEvent(&events.Event{Message: "test"})
+			hub.FlushEvents()
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("emitting and flushing events blocked on the logging event hub")
+	}
+}
@@ -20,3 +20,14 @@ func WithPort(port string) Option {
 		impl.port = port
 	}
 }
+
+// WithQueryChunkSize sets the maximum number of bytes sent in a single chunk of a QueryShardStream reply. The default
+// is 64KiB.
+func WithQueryChunkSize(size int) Option {
+	return func(impl *msgServerImpl) {
+		if size <= 0 {
+			panic("query chunk size must be positive")
+		}
+		impl.queryChunkSize = size
+	}
+}
@@ -26,6 +26,8 @@ var (
 	_ routerv1.MsgServer = &msgServerImpl{}
 
 	defaultPort = "9020"
+	// defaultQueryChunkSize is the default maximum number of bytes in a single QueryShardStream chunk.
+	defaultQueryChunkSize = 64 * 1024
 
 	cardinalEvmPortEnv    = "CARDINAL_EVM_PORT"
 	serverCertFilePathEnv = "SERVER_CERT_PATH"
@@ -58,8 +60,9 @@ type msgServerImpl struct {
 	world    *ecs.World
 
 	// opts
-	creds credentials.TransportCredentials
-	port  string
+	creds          credentials.TransportCredentials
+	port           string
+	queryChunkSize int
 
 	shutdown func()
 }
@@ -97,7 +100,13 @@ func NewServer(w *ecs.World, opts ...Option) (Server, error) {
 		return nil, eris.Wrap(ErrNoEVMTypes, "no evm txs or queries")
 	}
 
-	s := &msgServerImpl{txMap: it, queryMap: ir, world: w, port: defaultPort}
+	s := &msgServerImpl{
+		txMap:          it,
+		queryMap:       ir,
+		world:          w,
+		port:           defaultPort,
+		queryChunkSize: defaultQueryChunkSize,
+	}
 	for _, opt := range opts {
 		opt(s)
 	}
@@ -202,6 +211,7 @@ const (
 	CodeUnauthorized
 	CodeUnsupportedTransaction
 	CodeInvalidFormat
+	CodeTxNotQueued
 )
 
 func (s *msgServerImpl) SendMessage(_ context.Context, msg *routerv1.SendMessageRequest) (
@@ -232,6 +242,14 @@ func (s *msgServerImpl) SendMessage(_ context.Context, msg *routerv1.SendMessage
 		}, nil
 	}
 
+	if err = message.Validate(itx, tx); err != nil {
+		return &routerv1.SendMessageResponse{
+			Errs:      eris.Wrap(err, "message validation failed").Error(),
+			EvmTxHash: msg.EvmTxHash,
+			Code:      CodeInvalidFormat,
+		}, nil
+	}
+
 	// check if the sender has a linked persona address. if not don't process the transaction.
 	sc, err := s.getSignerComponentForAuthorizedAddr(msg.Sender)
 	if err != nil {
@@ -246,7 +264,13 @@ func (s *msgServerImpl) SendMessage(_ context.Context, msg *routerv1.SendMessage
 	// since we are injecting the tx directly, all we need is the persona tag in the signed payload.
 	// the sig checking happens in the server's Handler, not in ecs.World.
 	sig := &sign.Transaction{PersonaTag: sc.PersonaTag}
-	s.world.AddEVMTransaction(itx.ID(), tx, sig, msg.EvmTxHash)
+	if _, _, err = s.world.AddEVMTransaction(itx.ID(), tx, sig, msg.EvmTxHash); err != nil {
+		return &routerv1.SendMessageResponse{
+			Errs:      eris.Wrap(err, "failed to queue transaction").Error(),
+			EvmTxHash: msg.EvmTxHash,
+			Code:      CodeTxNotQueued,
+		}, nil
+	}
 
 	// wait for the next tick so the tx gets processed
 	success := s.world.WaitForNextTick()
@@ -327,6 +351,38 @@ func (s *msgServerImpl) getSignerComponentForAuthorizedAddr(
 func (s *msgServerImpl) QueryShard(_ context.Context, req *routerv1.QueryShardRequest) (
 	*routerv1.QueryShardResponse, error,
 ) {
+	bz, err := s.handleQuery(req)
+	if err != nil {
+		return nil, err
+	}
+	return &routerv1.QueryShardResponse{Response: bz}, nil
+}
+
+// QueryShardStream handles the query the same way QueryShard does, but sends the ABI encoded reply back in chunks of
+// at most queryChunkSize bytes. The consumer reassembles the reply by concatenating the chunks in order. A reply is
+// always sent as at least one chunk, even if it is empty.
+func (s *msgServerImpl) QueryShardStream(req *routerv1.QueryShardRequest, stream routerv1.Msg_QueryShardStreamServer,
+) error {
+	bz, err := s.handleQuery(req)
+	if err != nil {
+		return err
+	}
+	totalSize := uint64(len(bz))
+	for start := 0; start == 0 || start < len(bz); start += s.queryChunkSize {
+		end := start + s.queryChunkSize
+		if end > len(bz) {
+			end = len(bz)
+		}
+		err = stream.Send(&routerv1.QueryShardChunk{Chunk: bz[start:end], TotalSize: totalSize})
+		if err != nil {
+			return eris.Wrap(err, "failed to send query reply chunk")
+		}
+	}
+	zerolog.Logger.Debug().Msgf("streamed back a reply of %d bytes", totalSize)
+	return nil
+}
+
+func (s *msgServerImpl) handleQuery(req *routerv1.QueryShardRequest) ([]byte, error) {
 	zerolog.Logger.Debug().Msgf("get request for %q", req.Resource)
 	query, ok := s.queryMap[req.Resource]
 	if !ok {
@@ -349,5 +405,5 @@ func (s *msgServerImpl) QueryShard(_ context.Context, req *routerv1.QueryShardRe
 		return nil, err
 	}
 	zerolog.Logger.Debug().Msgf("sending back reply: %v", reply)
-	return &routerv1.QueryShardResponse{Response: bz}, nil
+	return bz, nil
 }
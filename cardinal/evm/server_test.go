@@ -10,6 +10,7 @@ import (
 	"pkg.world.dev/world-engine/cardinal/evm"
 	"pkg.world.dev/world-engine/cardinal/testutils"
 
+	"google.golang.org/grpc"
 	"gotest.tools/v3/assert/cmp"
 
 	"pkg.world.dev/world-engine/assert"
@@ -184,6 +185,66 @@ func TestServer_Query(t *testing.T) {
 	assert.Equal(t, got.Y, request.X)
 }
 
+// queryChunkCollector is a routerv1.Msg_QueryShardStreamServer that records the chunks it is sent.
+type queryChunkCollector struct {
+	grpc.ServerStream
+	chunks []*routerv1.QueryShardChunk
+}
+
+func (c *queryChunkCollector) Send(chunk *routerv1.QueryShardChunk) error {
+	c.chunks = append(c.chunks, chunk)
+	return nil
+}
+
+func TestServer_QueryStream(t *testing.T) {
+	type FooReq struct {
+		N uint64
+	}
+	type FooReply struct {
+		Values []uint64
+	}
+	// set up a query that returns N values
+	handleFooQuery := func(wCtx cardinal.WorldContext, req *FooReq) (*FooReply, error) {
+		values := make([]uint64, req.N)
+		for i := range values {
+			values[i] = uint64(i)
+		}
+		return &FooReply{Values: values}, nil
+	}
+	w := testutils.NewTestWorld(t)
+	world := w.Instance()
+	err := cardinal.RegisterQueryWithEVMSupport[FooReq, FooReply](w, "foo", handleFooQuery)
+	assert.NilError(t, err)
+	chunkSize := 100
+	s, err := evm.NewServer(world, evm.WithQueryChunkSize(chunkSize))
+	assert.NilError(t, err)
+
+	query, err := world.GetQueryByName("foo")
+	assert.NilError(t, err)
+	bz, err := query.EncodeAsABI(FooReq{N: 50})
+	assert.NilError(t, err)
+
+	collector := &queryChunkCollector{}
+	err = s.QueryShardStream(&routerv1.QueryShardRequest{Resource: "foo", Request: bz}, collector)
+	assert.NilError(t, err)
+	assert.Check(t, len(collector.chunks) > 1)
+
+	var reply []byte
+	for _, chunk := range collector.chunks {
+		assert.Check(t, len(chunk.Chunk) <= chunkSize)
+		assert.Equal(t, chunk.TotalSize, collector.chunks[0].TotalSize)
+		reply = append(reply, chunk.Chunk...)
+	}
+	assert.Equal(t, uint64(len(reply)), collector.chunks[0].TotalSize)
+
+	gotAny, err := query.DecodeEVMReply(reply)
+	assert.NilError(t, err)
+	got, ok := gotAny.(FooReply)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, len(got.Values), 50)
+	assert.Equal(t, got.Values[49], uint64(49))
+}
+
 // TestServer_UnauthorizedAddress tests that when a transaction is sent to Cardinal's EVM server, and there is no
 // Authorized address for the sender, an error occurs.
 func TestServer_UnauthorizedAddress(t *testing.T) {
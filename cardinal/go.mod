@@ -30,14 +30,15 @@ require (
 	github.com/rs/cors v1.10.1
 	github.com/rs/zerolog v1.31.0
 	github.com/stretchr/testify v1.8.4
+	github.com/vmihailenco/msgpack/v5 v5.3.5
 	github.com/wI2L/jsondiff v0.5.0
 	google.golang.org/grpc v1.59.0
 	google.golang.org/protobuf v1.31.0
 	gotest.tools/v3 v3.5.1
 	pkg.world.dev/world-engine/assert v1.0.0-beta
 	pkg.world.dev/world-engine/evm v1.0.0-beta
-	pkg.world.dev/world-engine/rift v1.0.0-beta
-	pkg.world.dev/world-engine/sign v1.0.0-beta
+	pkg.world.dev/world-engine/rift v1.0.1-beta
+	pkg.world.dev/world-engine/sign v1.0.1-beta
 )
 
 require (
@@ -135,6 +136,7 @@ require (
 	github.com/tidwall/match v1.1.1 // indirect
 	github.com/tidwall/pretty v1.2.1 // indirect
 	github.com/tidwall/sjson v1.2.5 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	github.com/yuin/gopher-lua v1.1.0 // indirect
 	go.etcd.io/bbolt v1.3.7 // indirect
 	go.mongodb.org/mongo-driver v1.11.3 // indirect
@@ -0,0 +1,97 @@
+package cardinal
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/invopop/jsonschema"
+	"github.com/rotisserie/eris"
+
+	"pkg.world.dev/world-engine/cardinal/types/message"
+)
+
+// Manifest is a machine-readable description of the public contract of a world. See World.Manifest.
+type Manifest struct {
+	Namespace  string              `json:"namespace"`
+	Mode       string              `json:"mode"`
+	Components []ComponentManifest `json:"components"`
+	Messages   []MessageManifest   `json:"messages"`
+	Queries    []QueryManifest     `json:"queries"`
+	// Systems are listed in the order they run in.
+	Systems []string `json:"systems"`
+}
+
+type ComponentManifest struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+type MessageManifest struct {
+	Name          string             `json:"name"`
+	EVMCompatible bool               `json:"evmCompatible"`
+	InputSchema   *jsonschema.Schema `json:"inputSchema"`
+	OutputSchema  *jsonschema.Schema `json:"outputSchema"`
+}
+
+type QueryManifest struct {
+	Name          string             `json:"name"`
+	EVMCompatible bool               `json:"evmCompatible"`
+	RequestSchema *jsonschema.Schema `json:"requestSchema"`
+	ReplySchema   *jsonschema.Schema `json:"replySchema"`
+}
+
+// Manifest returns a JSON encoded Manifest that describes the namespace and mode of the world along with every
+// registered component, message, query and system. Components, messages and queries are sorted by name, so manifests
+// of two deploys can be diffed to catch breaking changes. It must be called after the world's state is loaded.
+func (w *World) Manifest() ([]byte, error) {
+	msgs, err := w.instance.ListMessages()
+	if err != nil {
+		return nil, err
+	}
+	manifest := Manifest{
+		Namespace:  w.instance.Namespace().String(),
+		Mode:       w.mode,
+		Components: []ComponentManifest{},
+		Messages:   []MessageManifest{},
+		Queries:    []QueryManifest{},
+		Systems:    append([]string{}, w.instance.GetSystemNames()...),
+	}
+	for _, c := range w.instance.GetComponents() {
+		manifest.Components = append(manifest.Components, ComponentManifest{
+			Name:   c.Name(),
+			Schema: c.GetSchema(),
+		})
+	}
+	for _, msg := range msgs {
+		var in, out *jsonschema.Schema
+		if provider, ok := msg.(message.SchemaProvider); ok {
+			in, out = provider.Schema()
+		}
+		manifest.Messages = append(manifest.Messages, MessageManifest{
+			Name:          msg.Name(),
+			EVMCompatible: msg.IsEVMCompatible(),
+			InputSchema:   in,
+			OutputSchema:  out,
+		})
+	}
+	for _, q := range w.instance.ListQueries() {
+		request, reply := q.Schema()
+		manifest.Queries = append(manifest.Queries, QueryManifest{
+			Name:          q.Name(),
+			EVMCompatible: q.IsEVMCompatible(),
+			RequestSchema: request,
+			ReplySchema:   reply,
+		})
+	}
+	sort.Slice(manifest.Components, func(i, j int) bool {
+		return manifest.Components[i].Name < manifest.Components[j].Name
+	})
+	sort.Slice(manifest.Messages, func(i, j int) bool {
+		return manifest.Messages[i].Name < manifest.Messages[j].Name
+	})
+	sort.Slice(manifest.Queries, func(i, j int) bool {
+		return manifest.Queries[i].Name < manifest.Queries[j].Name
+	})
+	bz, err := json.MarshalIndent(manifest, "", "  ")
+	return bz, eris.Wrap(err, "")
+}
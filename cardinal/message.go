@@ -2,6 +2,8 @@ package cardinal
 
 import (
 	"pkg.world.dev/world-engine/cardinal/ecs"
+	"pkg.world.dev/world-engine/cardinal/ecs/abi"
+	"pkg.world.dev/world-engine/cardinal/ecs/receipt"
 	"pkg.world.dev/world-engine/cardinal/types/message"
 	"pkg.world.dev/world-engine/sign"
 )
@@ -23,19 +25,48 @@ type MessageType[Input, Result any] struct {
 	impl *ecs.MessageType[Input, Result]
 }
 
+// MessageOption changes the behavior of a MessageType created with NewMessageType.
+type MessageOption[Input, Result any] func() func(*ecs.MessageType[Input, Result])
+
+// WithMsgValidator validates each message input when a transaction is submitted, before it is queued. If the given
+// function returns an error, the transaction is rejected (the HTTP server replies with 422 Unprocessable Entity and
+// the error message), so systems only ever see valid inputs.
+func WithMsgValidator[Input, Result any](validate func(Input) error) MessageOption[Input, Result] {
+	return ecs.WithMsgValidator[Input, Result](validate)
+}
+
 // NewMessageType creates a new instance of a MessageType.
-func NewMessageType[Input, Result any](name string) *MessageType[Input, Result] {
+func NewMessageType[Input, Result any](name string, opts ...MessageOption[Input, Result]) *MessageType[Input, Result] {
 	return &MessageType[Input, Result]{
-		impl: ecs.NewMessageType[Input, Result](name),
+		impl: ecs.NewMessageType[Input, Result](name, toECSMessageOptions(opts)...),
 	}
 }
 
 // NewMessageTypeWithEVMSupport creates a new instance of a MessageType, with EVM messages enabled.
 // This allows this message to be sent from EVM smart contracts on the EVM base shard.
-func NewMessageTypeWithEVMSupport[Input, Result any](name string) *MessageType[Input, Result] {
+func NewMessageTypeWithEVMSupport[Input, Result any](name string, opts ...MessageOption[Input, Result],
+) *MessageType[Input, Result] {
+	ecsOpts := append(toECSMessageOptions(opts), ecs.WithMsgEVMSupport[Input, Result])
 	return &MessageType[Input, Result]{
-		impl: ecs.NewMessageType[Input, Result](name, ecs.WithMsgEVMSupport[Input, Result]),
+		impl: ecs.NewMessageType[Input, Result](name, ecsOpts...),
+	}
+}
+
+// RegisterABITypeCodec makes every field of type T in EVM enabled messages and queries be encoded as the given
+// solidity type, using the given functions to convert between T and the Go value of the solidity type. This allows
+// matching contract ABIs the default encoding does not produce. It must be called before the messages and queries
+// that use T are created. See abi.RegisterTypeCodec.
+func RegisterABITypeCodec[T any](solidityType string, encode func(T) (any, error), decode func(any) (T, error)) error {
+	return abi.RegisterTypeCodec[T](solidityType, encode, decode)
+}
+
+func toECSMessageOptions[Input, Result any](opts []MessageOption[Input, Result],
+) []func() func(*ecs.MessageType[Input, Result]) {
+	ecsOpts := make([]func() func(*ecs.MessageType[Input, Result]), 0, len(opts)+1)
+	for _, opt := range opts {
+		ecsOpts = append(ecsOpts, opt)
 	}
+	return ecsOpts
 }
 
 // AddToQueue is not meant to be used in production whatsoever, it is exposed here for usage in tests.
@@ -50,6 +81,29 @@ func (t *MessageType[Input, Result]) GetReceipt(wCtx WorldContext, hash TxHash)
 	return t.impl.GetReceipt(wCtx.Instance(), hash)
 }
 
+// AddResult appends a result to the receipt of the given hash. Use it for messages with more than one outcome (e.g. an
+// area attack that hits several targets); the receipt's result is then a list of all added results.
+func (t *MessageType[Input, Result]) AddResult(wCtx WorldContext, hash TxHash, result Result) {
+	t.impl.AddResult(wCtx.Instance(), hash, result)
+}
+
+// GetReceiptResults returns all results (if any) and errors (if any) associated with the given hash, including the
+// results added with AddResult. If false is returned, the hash is not recognized.
+func (t *MessageType[Input, Result]) GetReceiptResults(wCtx WorldContext, hash TxHash) ([]Result, []error, bool) {
+	return t.impl.GetReceiptResults(wCtx.Instance(), hash)
+}
+
+// NewCodedError creates an error with a machine readable code (e.g. "INSUFFICIENT_FUNDS"). When it is returned from the
+// function given to Each, the code is included in the receipt of the transaction, so clients can branch on it.
+func NewCodedError(code, message string) error {
+	return receipt.NewCodedError(code, message)
+}
+
+// Each calls fn for every transaction of this message's type in the current tick, and stores the returned result or
+// error in the transaction's receipt. The transactions of each persona are in nonce order. Transactions of different
+// message types are not ordered against each other, so a sequence of actions that must be applied in the order a
+// player signed them (e.g. "move then attack") has to be sent as one message type, or handled by systems that are
+// registered in that order.
 func (t *MessageType[Input, Result]) Each(wCtx WorldContext, fn func(TxData[Input]) (Result, error)) {
 	adapterFn := func(ecsTxData ecs.TxData[Input]) (Result, error) {
 		adaptedTx := TxData[Input]{impl: ecsTxData}
@@ -58,7 +112,8 @@ func (t *MessageType[Input, Result]) Each(wCtx WorldContext, fn func(TxData[Inpu
 	t.impl.Each(wCtx.Instance(), adapterFn)
 }
 
-// In returns the TxData in the given transaction queue that match this message's type.
+// In returns the TxData in the given transaction queue that match this message's type. As in Each, the transactions
+// of each persona are in nonce order within this message type only.
 func (t *MessageType[Input, Result]) In(wCtx WorldContext) []TxData[Input] {
 	ecsTxData := t.impl.In(wCtx.Instance())
 	out := make([]TxData[Input], 0, len(ecsTxData))
@@ -1,11 +1,15 @@
 package cardinal
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
+	"pkg.world.dev/world-engine/cardinal/ecs/ecb"
 	ecslog "pkg.world.dev/world-engine/cardinal/ecs/log"
+	"pkg.world.dev/world-engine/cardinal/ecs/storage/redis"
 	"pkg.world.dev/world-engine/cardinal/ecs/store"
 	"pkg.world.dev/world-engine/cardinal/events"
 
@@ -18,6 +22,7 @@ import (
 type WorldOption struct {
 	ecsOption      ecs.Option
 	serverOption   server.Option
+	storeOption    ecb.Option
 	cardinalOption func(*World)
 }
 
@@ -30,6 +35,282 @@ func WithAdapter(adapter shard.Adapter) WorldOption {
 	}
 }
 
+// WithComponentShard stores the values of the component with the given name in a dedicated redis instance. This
+// spreads the redis load of worlds where a single component (e.g. a position that is updated every tick) dominates.
+// Ticks remain atomic: the changes of a tick are committed to the dedicated redis instance if, and only if, they are
+// committed to the main redis instance.
+func WithComponentShard(componentName string, options redis.Options) WorldOption {
+	return WorldOption{
+		storeOption: ecb.WithComponentShard(componentName, goredis.NewClient(&options)),
+	}
+}
+
+// WithReadCache caches the component values read by queries and other read only world contexts until the end of the
+// tick, saving redis round trips when the same components are read many times. Cached values are dropped whenever a
+// tick is committed, so a read never returns a value from before the last committed tick.
+func WithReadCache() WorldOption {
+	return WorldOption{
+		storeOption: ecb.WithReadCache(),
+	}
+}
+
+// WithEntityIDRange makes the world allocate the entity IDs start, start+stride, start+2*stride and so on. Giving each
+// shard of a game the same stride and a different start (e.g. 0 and 1 with a stride of 2) makes entity IDs unique
+// across shards, so entities can be referenced from another shard. The start must be below the stride.
+func WithEntityIDRange(start, stride uint64) WorldOption {
+	return WorldOption{
+		storeOption: ecb.WithEntityIDRange(start, stride),
+	}
+}
+
+// WithBindAddress restricts the HTTP server to the interface with the given host or IP address, e.g. "127.0.0.1" when
+// only a co-located relay should be able to reach the world. By default, the server listens on all interfaces.
+func WithBindAddress(address string) WorldOption {
+	return WorldOption{
+		serverOption: server.WithBindAddress(address),
+	}
+}
+
+// WithWebSocketOrigins only allows browsers on the given origins to open websocket connections (e.g. to /events), which
+// prevents cross-site websocket hijacking in production. Upgrade requests from other origins are rejected with 403.
+func WithWebSocketOrigins(origins ...string) WorldOption {
+	return WorldOption{
+		serverOption: server.WithWebSocketOrigins(origins...),
+	}
+}
+
+// WithDebugAuth requires requests to the debug endpoints (e.g. /debug/state) to be accepted by the given function, for
+// example one that checks a shared secret header. Requests that are not accepted get 401 Unauthorized.
+func WithDebugAuth(isAuthorized func(r *http.Request) bool) WorldOption {
+	return WorldOption{
+		serverOption: server.WithDebugAuth(isAuthorized),
+	}
+}
+
+// WithDebugBroadcast enables /debug/broadcast outside of development mode, so the operator of the server can send
+// messages on the reserved system topic to every event subscriber. Protect it with WithDebugAuth.
+func WithDebugBroadcast() WorldOption {
+	return WorldOption{
+		serverOption: server.WithDebugBroadcast(),
+	}
+}
+
+// WithDebugSubscribers enables /debug/subscribers outside of development mode, so the operator of the server can see
+// which personas are connected to /events. Protect it with WithDebugAuth.
+func WithDebugSubscribers() WorldOption {
+	return WorldOption{
+		serverOption: server.WithDebugSubscribers(),
+	}
+}
+
+// WithCORSMaxAge lets browsers cache CORS preflight results for the given duration, so web clients do not send an
+// OPTIONS request before every transaction or query.
+func WithCORSMaxAge(maxAge time.Duration) WorldOption {
+	return WorldOption{
+		serverOption: server.WithCORSMaxAge(maxAge),
+	}
+}
+
+// WithAsyncTxResponses answers accepted transactions with 202 Accepted and a Location header that clients can poll for
+// the receipt of the transaction, instead of 200 OK.
+func WithAsyncTxResponses() WorldOption {
+	return WorldOption{
+		serverOption: server.WithAsyncTxResponses(),
+	}
+}
+
+// WithReadyAfterInit makes /health answer 503 Service Unavailable until the init system registered with World.Init has
+// run, so clients are not sent to a world that is not populated yet.
+func WithReadyAfterInit() WorldOption {
+	return WorldOption{
+		serverOption: server.WithReadyAfterInit(),
+	}
+}
+
+// WithSlowQueryThreshold logs a warning for every query whose handler takes longer than the given duration, and counts
+// such queries per query name on the /metrics endpoint, to find expensive queries that slow down the read path.
+func WithSlowQueryThreshold(threshold time.Duration) WorldOption {
+	return WorldOption{
+		serverOption: server.WithSlowQueryThreshold(threshold),
+	}
+}
+
+// WithMaxCQLResults rejects CQL queries that match more than n entities, regardless of the limit in the request. A
+// value of 0 or less removes the cap. See server.WithMaxCQLResults.
+func WithMaxCQLResults(n int) WorldOption {
+	return WorldOption{
+		serverOption: server.WithMaxCQLResults(n),
+	}
+}
+
+// WithTransactionLog appends every processed transaction (tick, tx hash, persona tag, message name, nonce and body) to
+// the file at the given path as JSON lines, for audits and replays. See ecs.WithTransactionLog for the durability
+// tradeoff.
+func WithTransactionLog(path string) WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithTransactionLog(path),
+	}
+}
+
+// WithUnknownFieldsIgnored makes the server accept transaction requests with fields it does not know, ignoring those
+// fields, so newer clients keep working with an older server. By default such requests are rejected.
+func WithUnknownFieldsIgnored() WorldOption {
+	return WorldOption{
+		serverOption: server.WithUnknownFieldsIgnored(),
+	}
+}
+
+// WithUnavailableWithoutRedis makes /health answer 503 Service Unavailable while redis can not be reached. By default
+// the reply only reports it in its redisHealthy field. See server.WithUnavailableWithoutRedis.
+func WithUnavailableWithoutRedis() WorldOption {
+	return WorldOption{
+		serverOption: server.WithUnavailableWithoutRedis(),
+	}
+}
+
+// WithRedisHealthCheckInterval sets how often the /health endpoint checks that redis is reachable. Health checks in
+// between reuse the last result.
+func WithRedisHealthCheckInterval(interval time.Duration) WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithRedisHealthCheckInterval(interval),
+	}
+}
+
+// WithMaxPersonasPerSigner caps the number of persona tags a single signer address can create, so one key can not
+// squat many names. Creating a persona over the cap fails with ecs.ErrTooManyPersonas. The default is unlimited.
+func WithMaxPersonasPerSigner(maxPersonas int) WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithMaxPersonasPerSigner(maxPersonas),
+	}
+}
+
+// WithPersonaDisplayNames registers the set-display-name message, so personas can set a display name that is shown to
+// other players instead of their persona tag. See GetDisplayName.
+func WithPersonaDisplayNames() WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithPersonaDisplayNames(),
+	}
+}
+
+// WithEntityCreationTicks records the tick in which each entity is created in a CreatedAt component, e.g. to measure
+// spawn rates or to remove entities older than a number of ticks. See EntitiesCreatedBetween.
+func WithEntityCreationTicks() WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithEntityCreationTicks(),
+	}
+}
+
+// WithTickProgressEvents broadcasts the progress of ticks that run for longer than the given threshold to event
+// subscribers: the system that is about to run and the number of transactions processed so far. See
+// ecs.WithTickProgressEvents.
+func WithTickProgressEvents(threshold time.Duration) WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithTickProgressEvents(threshold),
+	}
+}
+
+// WithIdempotentComponentRegistration lets the same component type be registered more than once, e.g. by several
+// packages that share it. Only the first registration takes effect. See ecs.WithIdempotentComponentRegistration.
+func WithIdempotentComponentRegistration() WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithIdempotentComponentRegistration(),
+	}
+}
+
+// WithPrettyLog makes the world and its server log human readable lines if pretty is true, or JSON if it is false.
+// By default, logs are human readable in development mode and JSON in production mode; this option decouples the log
+// format from the mode, e.g. for CI that collects structured logs in development mode.
+func WithPrettyLog(pretty bool) WorldOption {
+	return WorldOption{
+		ecsOption:    ecs.WithPrettyLog(pretty),
+		serverOption: server.WithPrettyPrint(pretty),
+	}
+}
+
+// WithLifecycleWebhook posts a LifecycleEvent as JSON to the given URL when the world starts, when it shuts down, when
+// a recovery from the chain starts and completes, and when a tick fails. Delivery is best-effort: failed deliveries are
+// retried with backoff, and events are dropped if the URL keeps failing. Only a failed tick makes the game loop wait,
+// for at most a few seconds, so the event is delivered before the game loop panics.
+func WithLifecycleWebhook(url string) WorldOption {
+	return WorldOption{
+		cardinalOption: func(world *World) {
+			world.lifecycleWebhook = newLifecycleWebhook(url)
+			world.instance.OnRecoveryStart(func() {
+				world.notifyLifecycle(LifecycleRecoveryStarted, nil)
+			})
+			world.instance.OnRecoveryComplete(func(err error) {
+				world.notifyLifecycle(LifecycleRecoveryCompleted, err)
+			})
+			world.instance.OnTickFailure(func(_ uint64, err error) {
+				world.notifyTickFailed(err)
+			})
+		},
+	}
+}
+
+// WithNonceRetryWindow allows a transaction to be resubmitted with an already used nonce for the given amount of time
+// after it was first accepted, as long as the resubmitted transaction is byte-identical to the original. The retry is
+// not processed again; it gets the same tx hash and tick as the original submission.
+func WithNonceRetryWindow(window time.Duration) WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithNonceRetryWindow(window),
+	}
+}
+
+// WithConsistentQueries makes each query read the state of a single, fully committed tick, so a query never sees
+// components from two different ticks. Committing a tick waits for running queries, so long queries delay the game
+// loop.
+func WithConsistentQueries() WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithConsistentQueries(),
+	}
+}
+
+// WithMessageMetrics counts the processed transactions of each message type, along with how many of them ended up with
+// an error or a result. The counters are served in the Prometheus text format on the /metrics endpoint, and the given
+// labels are added to every metric. The labels must be valid Prometheus labels. See ecs.WithMessageMetrics.
+func WithMessageMetrics(labels map[string]string) WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithMessageMetrics(labels),
+	}
+}
+
+// WithDurableTxQueue saves every accepted transaction to redis until a tick processes it, so transactions that were
+// queued when the process crashed are processed after a restart instead of being lost.
+func WithDurableTxQueue() WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithDurableTxQueue(),
+	}
+}
+
+// WithUnhandledMessageReceipts guarantees that every accepted transaction yields a receipt, so clients can always
+// reconcile. A transaction whose message type no system read during the tick gets a receipt with an error saying the
+// message was not handled.
+func WithUnhandledMessageReceipts() WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithUnhandledMessageReceipts(),
+	}
+}
+
+// WithReadReplica makes this world a read replica of another world that uses the same redis instance. The replica
+// serves queries at the last tick committed by the primary world, but never runs systems and rejects transactions.
+// Use it to keep read-heavy query traffic (e.g. leaderboards) from contending with the ticking world.
+func WithReadReplica() WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithReadReplica(),
+	}
+}
+
+// WithRequiredAdapter makes on-chain persistence a hard guarantee. The world fails to start if no adapter was given
+// with WithAdapter, and transactions that can not be submitted to the adapter are rejected with an error instead of
+// being processed locally.
+func WithRequiredAdapter() WorldOption {
+	return WorldOption{
+		ecsOption:    ecs.WithRequiredAdapter(),
+		serverOption: server.WithRequiredAdapter(),
+	}
+}
+
 // WithReceiptHistorySize specifies how many ticks worth of transaction receipts should be kept in memory. The default
 // is 10. A smaller number uses less memory, but limits the amount of historical receipts available.
 func WithReceiptHistorySize(size int) WorldOption {
@@ -57,6 +338,33 @@ func WithTickChannel(ch <-chan time.Time) WorldOption {
 	}
 }
 
+// WithEVMEventHandler hands the events emitted by systems with WorldContext.EmitEVMEvent to the given handler after
+// each committed tick. Cardinal does not submit the events to the chain itself, so the handler has to forward them,
+// e.g. to a contract of the game. The handler runs on a goroutine of its own, in tick order. Events are not handed out
+// again while the world recovers, and are dropped while the handler is more than 64 ticks behind.
+func WithEVMEventHandler(handler func(events []EVMEvent)) WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithEVMEventHandler(handler),
+	}
+}
+
+// WithDependency makes the given instance (e.g. the client of a payment service or an analytics SDK) available to
+// systems and queries via GetDependency, instead of a package level variable. Dependencies are looked up by the type T,
+// so T can be an interface to allow injecting fakes in tests.
+func WithDependency[T any](instance T) WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithDependency[T](instance),
+	}
+}
+
+// WithTickRetry makes the game loop retry a failed tick up to maxRetries times, waiting delay between attempts. If the
+// tick still fails, the game loop stops ticking and the health endpoint reports the tick circuit as open.
+func WithTickRetry(maxRetries int, delay time.Duration) WorldOption {
+	return WorldOption{
+		ecsOption: ecs.WithTickRetry(maxRetries, delay),
+	}
+}
+
 // WithTickDoneChannel sets a channel that will be notified each time a tick completes. The completed tick will be
 // pushed to the channel. This option is useful in tests when assertions need to be performed at the end of a tick.
 func WithTickDoneChannel(ch chan<- uint64) WorldOption {
@@ -79,6 +387,17 @@ func WithEventHub(eventHub events.EventHub) WorldOption {
 	}
 }
 
+// WithEventSubscriptionLimits bounds the buffer size and block timeout that clients of the /events websocket may ask
+// for, and decides whether they may use the block backpressure strategy, which is refused by default. See
+// events.SubscriptionOptions.
+func WithEventSubscriptionLimits(limits events.SubscriptionLimits) WorldOption {
+	return WorldOption{
+		cardinalOption: func(world *World) {
+			world.subscriptionLimits = limits
+		},
+	}
+}
+
 func WithLoggingEventHub(logger *ecslog.Logger) WorldOption {
 	return WorldOption{
 		ecsOption: ecs.WithLoggingEventHub(logger),
@@ -24,6 +24,25 @@ func (q *Search) Each(wCtx WorldContext, callback SearchCallBackFn) error {
 	)
 }
 
+// Limit caps the number of entities processed by Each at n. A limit of 0 or less removes the cap.
+func (q *Search) Limit(n int) *Search {
+	q.impl.Limit(n)
+	return q
+}
+
+// Offset makes Each skip the first n entities that match this search. Use it with Limit to process a large search in
+// bounded pages.
+func (q *Search) Offset(n int) *Search {
+	q.impl.Offset(n)
+	return q
+}
+
+// EachWithComponents is identical to Each, but also passes the names of all the components the entity has to the
+// callback. This is useful to find out why an entity matched a search.
+func (q *Search) EachWithComponents(wCtx WorldContext, callback func(id EntityID, components []string) bool) error {
+	return q.impl.EachWithComponents(wCtx.Instance(), callback)
+}
+
 // Count returns the number of entities that match this search.
 func (q *Search) Count(wCtx WorldContext) (int, error) {
 	return q.impl.Count(wCtx.Instance())
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/go-openapi/runtime"
+	"github.com/rotisserie/eris"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	mimeJSON    = runtime.JSONMime
+	mimeMsgPack = "application/msgpack"
+)
+
+// msgPackConsumer decodes MessagePack request bodies. The decoded value is round-tripped through JSON so that
+// downstream handlers see exactly the same data types (e.g. float64 for numbers) they would for a JSON request. The
+// JSON it produces is not what a client signed, so routes whose body is signed do not consume MessagePack.
+func msgPackConsumer() runtime.Consumer {
+	return runtime.ConsumerFunc(func(reader io.Reader, data interface{}) error {
+		var raw interface{}
+		if err := msgpack.NewDecoder(reader).Decode(&raw); err != nil {
+			return eris.Wrap(err, "error decoding msgpack body")
+		}
+		bz, err := json.Marshal(raw)
+		if err != nil {
+			return eris.Wrap(err, "error converting msgpack body to json")
+		}
+		return eris.Wrap(json.Unmarshal(bz, data), "error converting msgpack body to json")
+	})
+}
+
+// msgPackProducer encodes responses as MessagePack. Responses are first marshalled to JSON so json struct tags and
+// json.RawMessage query replies are respected, then re-encoded as MessagePack.
+func msgPackProducer() runtime.Producer {
+	return runtime.ProducerFunc(func(writer io.Writer, data interface{}) error {
+		bz, err := json.Marshal(data)
+		if err != nil {
+			return eris.Wrap(err, "error marshalling response")
+		}
+		var raw interface{}
+		if err = json.Unmarshal(bz, &raw); err != nil {
+			return eris.Wrap(err, "error marshalling response")
+		}
+		return eris.Wrap(msgpack.NewEncoder(writer).Encode(raw), "error encoding msgpack response")
+	})
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware/untyped"
+)
+
+// ConfigReply is the non-secret configuration of the world returned by /query/http/config. Clients can use it to
+// check that they are compatible with the world, e.g. to skip signing when signature verification is disabled.
+type ConfigReply struct {
+	Namespace string `json:"namespace"`
+	// Mode is the CARDINAL_MODE of the world. It is empty if the server was not told the mode. See WithMode.
+	Mode string `json:"mode"`
+	// TickIntervalMs is the time between ticks in milliseconds. It is 0 if the world ticks on a custom schedule.
+	TickIntervalMs        int64  `json:"tickIntervalMs"`
+	ReceiptHistorySize    uint64 `json:"receiptHistorySize"`
+	EVMEnabled            bool   `json:"evmEnabled"`
+	SignatureVerification bool   `json:"signatureVerification"`
+}
+
+func (handler *Handler) registerConfigHandlerSwagger(api *untyped.API) {
+	configHandler := runtime.OperationHandlerFunc(func(params interface{}) (interface{}, error) {
+		return ConfigReply{
+			Namespace:             handler.w.Namespace().String(),
+			Mode:                  handler.mode,
+			TickIntervalMs:        handler.tickInterval.Milliseconds(),
+			ReceiptHistorySize:    handler.w.ReceiptHistorySize(),
+			EVMEnabled:            handler.isEVMEnabled(),
+			SignatureVerification: !handler.disableSigVerification,
+		}, nil
+	})
+	api.RegisterOperation("POST", "/query/http/config", configHandler)
+}
+
+// isEVMEnabled reports if the world accepts messages and queries from the EVM base shard. Read replicas never do.
+func (handler *Handler) isEVMEnabled() bool {
+	if handler.w.IsReadReplica() {
+		return false
+	}
+	msgs, err := handler.w.ListMessages()
+	if err != nil {
+		return false
+	}
+	for _, msg := range msgs {
+		if msg.IsEVMCompatible() {
+			return true
+		}
+	}
+	for _, q := range handler.w.ListQueries() {
+		if q.IsEVMCompatible() {
+			return true
+		}
+	}
+	return false
+}
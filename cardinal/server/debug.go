@@ -1,11 +1,20 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+	"time"
 
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware"
 	"github.com/go-openapi/runtime/middleware/untyped"
+	"github.com/rotisserie/eris"
 	"pkg.world.dev/world-engine/cardinal/ecs"
 	"pkg.world.dev/world-engine/cardinal/ecs/filter"
+	"pkg.world.dev/world-engine/cardinal/events"
 	"pkg.world.dev/world-engine/cardinal/types/component"
 	"pkg.world.dev/world-engine/cardinal/types/entity"
 )
@@ -17,6 +26,58 @@ type DebugStateElement struct {
 
 type DebugStateResponse = []*DebugStateElement
 
+type DebugSubscribersResponse struct {
+	Count       int                 `json:"count"`
+	Subscribers []events.Subscriber `json:"subscribers"`
+}
+
+type DebugSystem struct {
+	// Order is the position of the system in each tick, starting at 0.
+	Order int    `json:"order"`
+	Name  string `json:"name"`
+	// LastDurationMs is how long the system took in the last tick. It is omitted until a tick has run.
+	LastDurationMs *float64 `json:"lastDurationMs,omitempty"`
+}
+
+type DebugSystemsResponse struct {
+	Systems []DebugSystem `json:"systems"`
+}
+
+type DebugTickRequest struct {
+	// Systems are the names of the systems that run in the tick, as listed by /debug/systems.
+	Systems []string `json:"systems"`
+}
+
+type DebugTickResponse struct {
+	Tick     uint64    `json:"tick"`
+	Receipts []Receipt `json:"receipts"`
+}
+
+type DebugBroadcastRequest struct {
+	Message string `json:"message"`
+}
+
+type DebugBroadcastResponse struct {
+	// Subscribers is the number of websocket connections to /events when the message was queued.
+	Subscribers int `json:"subscribers"`
+}
+
+// withDebugAuth rejects requests to the debug endpoints with 401 Unauthorized unless the function given with
+// WithDebugAuth accepts them.
+func (handler *Handler) withDebugAuth(next http.Handler) http.Handler {
+	if handler.debugAuth == nil {
+		return next
+	}
+	debugPrefix := strings.ToLower(handler.BasePath + "/debug/")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(strings.ToLower(path.Clean(r.URL.Path)+"/"), debugPrefix) && !handler.debugAuth(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // register debug endpoints for swagger server.
 func (handler *Handler) registerDebugHandlerSwagger(api *untyped.API) {
 	// request name not required. This handler doesn't use anything in the request.
@@ -26,6 +87,7 @@ func (handler *Handler) registerDebugHandlerSwagger(api *untyped.API) {
 				result := make(DebugStateResponse, 0)
 				search := ecs.NewSearch(filter.All())
 				wCtx := ecs.NewReadOnlyWorldContext(handler.w)
+				defer ecs.PinTickSnapshot(wCtx)()
 				var eachClosureErr error
 				searchEachErr := search.Each(
 					wCtx, func(id entity.ID) bool {
@@ -62,4 +124,111 @@ func (handler *Handler) registerDebugHandlerSwagger(api *untyped.API) {
 		)
 
 	api.RegisterOperation("GET", "/debug/state", debugStateHandler)
+
+	// debug/subscribers reveals who is connected, so it is only available if the server was created with
+	// WithDebugSubscribers.
+	debugSubscribersHandler := runtime.OperationHandlerFunc(
+		func(interface{}) (interface{}, error) {
+			if !handler.debugSubscribersEnabled {
+				return middleware.Error(http.StatusForbidden,
+					eris.New("the debug subscriber list is only available in development")), nil
+			}
+			subscribers := []events.Subscriber{}
+			if handler.w.DoesWorldHaveAnEventHub() {
+				subscribers = handler.w.GetEventHub().Subscribers()
+			}
+			return &DebugSubscribersResponse{
+				Count:       len(subscribers),
+				Subscribers: subscribers,
+			}, nil
+		},
+	)
+
+	api.RegisterOperation("GET", "/debug/subscribers", debugSubscribersHandler)
+
+	debugSystemsHandler :=
+		createSwaggerQueryHandler[interface{}, DebugSystemsResponse](
+			"", func(i *interface{}) (*DebugSystemsResponse, error) {
+				names := handler.w.ListSystems()
+				durations := handler.w.LastSystemDurations()
+				systems := make([]DebugSystem, 0, len(names))
+				for order, name := range names {
+					system := DebugSystem{Order: order, Name: name}
+					if order < len(durations) {
+						ms := float64(durations[order]) / float64(time.Millisecond)
+						system.LastDurationMs = &ms
+					}
+					systems = append(systems, system)
+				}
+				return &DebugSystemsResponse{Systems: systems}, nil
+			},
+		)
+
+	api.RegisterOperation("GET", "/debug/systems", debugSystemsHandler)
+
+	// debug/tick changes the state of the world, so it is only available if the server was created with WithDebugTick.
+	debugTickHandler := runtime.OperationHandlerFunc(
+		func(params interface{}) (interface{}, error) {
+			if !handler.debugTickEnabled {
+				return middleware.Error(http.StatusForbidden, eris.New("debug ticks are only available in development")),
+					nil
+			}
+			req, ok := getValueFromParams[DebugTickRequest](params, "DebugTickRequest")
+			if !ok {
+				return middleware.Error(http.StatusBadRequest, eris.New("DebugTickRequest not found")), nil
+			}
+			if len(req.Systems) == 0 {
+				return middleware.Error(http.StatusBadRequest, eris.New("at least one system must be given")), nil
+			}
+			result, err := handler.w.TickSystems(context.Background(), req.Systems...)
+			if eris.Is(eris.Cause(err), ecs.ErrSystemNotFound) {
+				return middleware.Error(http.StatusBadRequest, eris.ToString(err, true)), nil
+			} else if eris.Is(eris.Cause(err), ecs.ErrTickSystemsWithAdapter) {
+				return middleware.Error(http.StatusConflict, eris.ToString(err, true)), nil
+			} else if err != nil {
+				return nil, err
+			}
+			reply := &DebugTickResponse{Tick: result.Tick, Receipts: make([]Receipt, 0, len(result.Receipts))}
+			for _, r := range result.Receipts {
+				reply.Receipts = append(reply.Receipts, Receipt{
+					TxHash:       string(r.TxHash),
+					Tick:         result.Tick,
+					Result:       r.Result,
+					Errors:       errsToStringSlice(r.Errs),
+					ErrorDetails: errsToReceiptErrors(r.Errs),
+					TraceID:      r.TraceID,
+				})
+			}
+			return reply, nil
+		},
+	)
+
+	api.RegisterOperation("POST", "/debug/tick", debugTickHandler)
+
+	// debug/broadcast sends messages that clients trust to come from the operator of the server, so it is only
+	// available if the server was created with WithDebugBroadcast. The message is queued on the event hub, so it is sent
+	// to subscribers along with the events of the current tick.
+	debugBroadcastHandler := runtime.OperationHandlerFunc(
+		func(params interface{}) (interface{}, error) {
+			if !handler.debugBroadcastEnabled {
+				return middleware.Error(http.StatusForbidden,
+					eris.New("debug broadcasts are only available in development")), nil
+			}
+			req, ok := getValueFromParams[DebugBroadcastRequest](params, "DebugBroadcastRequest")
+			if !ok {
+				return middleware.Error(http.StatusBadRequest, eris.New("DebugBroadcastRequest not found")), nil
+			}
+			if req.Message == "" {
+				return nil, eris.New("broadcast message must not be empty")
+			}
+			if !handler.w.DoesWorldHaveAnEventHub() {
+				return nil, eris.New("world has no event hub to broadcast with")
+			}
+			hub := handler.w.GetEventHub()
+			hub.EmitEvent(&events.Event{Message: req.Message, Topic: events.SystemTopic})
+			return &DebugBroadcastResponse{Subscribers: len(hub.Subscribers())}, nil
+		},
+	)
+
+	api.RegisterOperation("POST", "/debug/broadcast", debugBroadcastHandler)
 }
@@ -5,14 +5,21 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/websocket"
 	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
 
 	"pkg.world.dev/world-engine/cardinal/ecs"
+	"pkg.world.dev/world-engine/cardinal/events"
 	"pkg.world.dev/world-engine/cardinal/server"
 	"pkg.world.dev/world-engine/cardinal/testutils"
 	"pkg.world.dev/world-engine/cardinal/types/entity"
+	"pkg.world.dev/world-engine/sign"
 )
 
 func TestDebugEndpoint(t *testing.T) {
@@ -159,3 +166,228 @@ func TestDebugAndCQLEndpointMustAccessReadOnlyData(t *testing.T) {
 		midTickCh <- struct{}{}
 	}
 }
+
+func TestDebugEndpointsCanRequireAuth(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, world.LoadGameState())
+	assert.NilError(t, world.Tick(context.Background()))
+	isAuthorized := func(r *http.Request) bool {
+		return r.Header.Get("X-Debug-Token") == "secret"
+	}
+	txh := testutils.MakeTestTransactionHandler(t, world, server.WithDebugAuth(isAuthorized))
+	defer txh.Close()
+
+	getDebugState := func(token string) int {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, txh.MakeHTTPURL("debug/state"), nil)
+		assert.NilError(t, err)
+		if token != "" {
+			req.Header.Set("X-Debug-Token", token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NilError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusUnauthorized, getDebugState(""))
+	assert.Equal(t, http.StatusUnauthorized, getDebugState("wrong"))
+	assert.Equal(t, http.StatusOK, getDebugState("secret"))
+
+	// Other endpoints do not require the debug auth.
+	resp := txh.Get("health")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestDebugSubscribersRequiresTheDebugSubscribersOption(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification())
+
+	resp := txh.Get("debug/subscribers")
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusForbidden)
+}
+
+func TestDebugSubscribersListsEventConnections(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, world.LoadGameState())
+	personaTag := "CoolMage"
+	privateKey, err := crypto.GenerateKey()
+	assert.NilError(t, err)
+	ecs.CreatePersonaMsg.AddToQueue(world, ecs.CreatePersona{
+		PersonaTag:    personaTag,
+		SignerAddress: crypto.PubkeyToAddress(privateKey.PublicKey).Hex(),
+	})
+	assert.NilError(t, world.Tick(context.Background()))
+	txh := testutils.MakeTestTransactionHandler(t, world, server.WithDebugSubscribers())
+
+	getSubscribers := func() server.DebugSubscribersResponse {
+		resp := txh.Get("debug/subscribers")
+		assert.Equal(t, resp.StatusCode, 200)
+		var reply server.DebugSubscribersResponse
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&reply))
+		return reply
+	}
+	assert.Equal(t, getSubscribers().Count, 0)
+
+	anonymous, _, err := websocket.DefaultDialer.Dial(txh.MakeWebSocketURL("events"), nil)
+	assert.NilError(t, err)
+	defer anonymous.Close()
+
+	auth, err := sign.NewTransaction(privateKey, personaTag, world.Namespace().String(), 100, struct{}{})
+	assert.NilError(t, err)
+	bz, err := auth.Marshal()
+	assert.NilError(t, err)
+	authenticated, _, err := websocket.DefaultDialer.Dial(
+		txh.MakeWebSocketURL("events")+"?auth="+url.QueryEscape(string(bz)), nil)
+	assert.NilError(t, err)
+	defer authenticated.Close()
+
+	// The connections are registered with the hub asynchronously.
+	var reply server.DebugSubscribersResponse
+	for i := 0; i < 50; i++ {
+		if reply = getSubscribers(); reply.Count > 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, reply.Count, 2)
+	assert.Equal(t, len(reply.Subscribers), 2)
+	var personaTags []string
+	for _, subscriber := range reply.Subscribers {
+		assert.Assert(t, !subscriber.ConnectedAt.IsZero())
+		personaTags = append(personaTags, subscriber.PersonaTag)
+	}
+	assert.Assert(t, cmp.Contains(personaTags, ""))
+	assert.Assert(t, cmp.Contains(personaTags, personaTag))
+
+	// The nonce of the auth was used up, so it can not be replayed.
+	_, resp, err := websocket.DefaultDialer.Dial(
+		txh.MakeWebSocketURL("events")+"?auth="+url.QueryEscape(string(bz)), nil)
+	assert.Assert(t, err != nil)
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusUnauthorized)
+}
+
+func TestDebugBroadcastRequiresTheDebugBroadcastOption(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification())
+
+	resp := txh.Post("debug/broadcast", server.DebugBroadcastRequest{Message: "restarting in 5 minutes"})
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusForbidden)
+}
+
+func TestDebugBroadcastSendsASystemMessageToSubscribers(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification(),
+		server.WithDebugBroadcast())
+
+	conn, _, err := websocket.DefaultDialer.Dial(txh.MakeWebSocketURL("events?topics=system"), nil)
+	assert.NilError(t, err)
+	defer conn.Close()
+	for i := 0; i < 50 && len(world.GetEventHub().Subscribers()) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	resp := txh.Post("debug/broadcast", server.DebugBroadcastRequest{Message: "restarting in 5 minutes"})
+	assert.Equal(t, resp.StatusCode, 200)
+	var reply server.DebugBroadcastResponse
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&reply))
+	assert.Equal(t, reply.Subscribers, 1)
+
+	// The message is sent along with the events of the tick.
+	assert.NilError(t, world.Tick(context.Background()))
+	_, bz, err := conn.ReadMessage()
+	assert.NilError(t, err)
+	var msg events.TopicMessage
+	assert.NilError(t, json.Unmarshal(bz, &msg))
+	assert.Equal(t, msg, events.TopicMessage{Topic: events.SystemTopic, Message: "restarting in 5 minutes"})
+}
+
+func TestDebugSystemsListsSystemsInExecutionOrder(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	world.RegisterSystem(func(ecs.WorldContext) error { return nil })
+	world.RegisterSystem(func(ecs.WorldContext) error {
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification())
+
+	getSystems := func() server.DebugSystemsResponse {
+		resp := txh.Get("debug/systems")
+		defer resp.Body.Close()
+		assert.Equal(t, resp.StatusCode, 200)
+		var reply server.DebugSystemsResponse
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&reply))
+		return reply
+	}
+
+	names := world.ListSystems()
+	reply := getSystems()
+	assert.Equal(t, len(reply.Systems), len(names))
+	for i, system := range reply.Systems {
+		assert.Equal(t, system.Order, i)
+		assert.Equal(t, system.Name, names[i])
+		// No tick has run yet.
+		assert.Assert(t, system.LastDurationMs == nil)
+	}
+
+	assert.NilError(t, world.Tick(context.Background()))
+	reply = getSystems()
+	assert.Equal(t, len(reply.Systems), len(names))
+	for _, system := range reply.Systems {
+		assert.Assert(t, system.LastDurationMs != nil)
+	}
+	slowest := reply.Systems[len(reply.Systems)-1]
+	assert.Assert(t, *slowest.LastDurationMs >= 5)
+}
+
+func TestDebugTickRequiresTheDebugTickOption(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	ran := false
+	world.RegisterSystemWithName(func(ecs.WorldContext) error {
+		ran = true
+		return nil
+	}, "system-a")
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification())
+
+	resp := txh.Post("debug/tick", server.DebugTickRequest{Systems: []string{"system-a"}})
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusForbidden)
+	assert.Assert(t, !ran)
+	assert.Equal(t, world.CurrentTick(), uint64(0))
+}
+
+func TestDebugTickRunsOnlyTheGivenSystems(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	var ranA, ranB int
+	world.RegisterSystemWithName(func(ecs.WorldContext) error {
+		ranA++
+		return nil
+	}, "system-a")
+	world.RegisterSystemWithName(func(ecs.WorldContext) error {
+		ranB++
+		return nil
+	}, "system-b")
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification(), server.WithDebugTick())
+
+	resp := txh.Post("debug/tick", server.DebugTickRequest{Systems: []string{"system-a"}})
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, 200)
+	var reply server.DebugTickResponse
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&reply))
+	assert.Equal(t, reply.Tick, uint64(0))
+	assert.Equal(t, ranA, 1)
+	assert.Equal(t, ranB, 0)
+
+	resp = txh.Post("debug/tick", server.DebugTickRequest{Systems: []string{"system-c"}})
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusBadRequest)
+	assert.Equal(t, world.CurrentTick(), uint64(1))
+}
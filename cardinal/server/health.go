@@ -1,21 +1,52 @@
 package server
 
 import (
+	"net/http"
+
 	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware"
 	"github.com/go-openapi/runtime/middleware/untyped"
+	"github.com/rs/zerolog/log"
 )
 
 type HealthReply struct {
 	IsServerRunning   bool `json:"isServerRunning"`
 	IsGameLoopRunning bool `json:"isGameLoopRunning"`
+	// IsTickCircuitOpen is true when the game loop stopped ticking because ticks kept failing.
+	IsTickCircuitOpen bool `json:"isTickCircuitOpen"`
+	// IsReady is true once the init system has run, i.e. the world is populated.
+	IsReady bool `json:"isReady"`
+	// InitProgress is the progress reported by the init system, from 0 to 1. See ecs.World.SetInitProgress.
+	InitProgress float64 `json:"initProgress"`
+	// RedisHealthy is true when redis answered the last health check. See ecs.World.IsRedisHealthy.
+	RedisHealthy bool `json:"redisHealthy"`
 }
 
 func (handler *Handler) registerHealthHandlerSwagger(api *untyped.API) {
 	healthHandler := runtime.OperationHandlerFunc(func(params interface{}) (interface{}, error) {
 		res := HealthReply{
 			true, // see http://ismycomputeron.com/
-			handler.w.IsGameLoopRunning()}
+			handler.w.IsGameLoopRunning(),
+			handler.w.IsTickCircuitOpen(),
+			handler.w.IsInitialized(),
+			handler.w.InitProgress(),
+			handler.w.IsRedisHealthy(),
+		}
+		if (handler.unavailableWithoutRedis && !res.RedisHealthy) || (handler.readyAfterInit && !res.IsReady) {
+			return unavailableResponder(res), nil
+		}
 		return res, nil
 	})
 	api.RegisterOperation("GET", "/health", healthHandler)
 }
+
+// unavailableResponder answers a health check with 503 Service Unavailable when redis can not be reached if
+// WithUnavailableWithoutRedis is used, or while the init system has not run yet if WithReadyAfterInit is used.
+func unavailableResponder(res HealthReply) middleware.Responder {
+	return middleware.ResponderFunc(func(rw http.ResponseWriter, producer runtime.Producer) {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		if err := producer.Produce(rw, res); err != nil {
+			log.Error().Err(err).Msg("failed to write health reply")
+		}
+	})
+}
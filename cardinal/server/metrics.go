@@ -0,0 +1,71 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// metricsHandler serves the per message counters of ecs.WithMessageMetrics, and the slow query counters of
+// WithSlowQueryThreshold, in the Prometheus text exposition format. It is only registered if either is enabled.
+// The endpoint is registered outside the swagger spec because Prometheus expects a plain text reply to a GET request.
+func (handler *Handler) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	metrics, labels := handler.w.MessageMetrics()
+	var sb strings.Builder
+	writeMetric := func(name, help, kind string, value func(i int) uint64) {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for i := range metrics {
+			fmt.Fprintf(&sb, "%s{%s} %d\n", name, formatLabels(labels, "message", metrics[i].MessageName), value(i))
+		}
+	}
+	if handler.w.MessageMetricsEnabled() {
+		writeMetric("cardinal_messages_processed_total", "Number of processed transactions.", "counter",
+			func(i int) uint64 { return metrics[i].Processed })
+		writeMetric("cardinal_message_errors_total", "Number of processed transactions that had an error.", "counter",
+			func(i int) uint64 { return metrics[i].Errors })
+		writeMetric("cardinal_message_results_total", "Number of processed transactions that had a result.", "counter",
+			func(i int) uint64 { return metrics[i].Results })
+		writeMetric("cardinal_messages_processed_last_tick", "Number of transactions processed by the last tick.",
+			"gauge", func(i int) uint64 { return metrics[i].ProcessedLastTick })
+	}
+	if handler.slowQueryThreshold > 0 {
+		const name = "cardinal_slow_queries_total"
+		fmt.Fprintf(&sb, "# HELP %s Number of queries that exceeded the slow query threshold.\n# TYPE %s counter\n",
+			name, name)
+		for _, count := range handler.SlowQueryCounts() {
+			fmt.Fprintf(&sb, "%s{%s} %d\n", name, formatLabels(labels, "query", count.QueryName), count.Count)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if _, err := w.Write([]byte(sb.String())); err != nil {
+		log.Error().Err(err).Msg("failed to write metrics")
+	}
+}
+
+// formatLabels returns the label set of a metric for the given message or query, including the configured labels,
+// sorted by label name.
+func formatLabels(labels map[string]string, key, value string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names)+1)
+	pairs = append(pairs, fmt.Sprintf(`%s="%s"`, key, escapeLabelValue(value)))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, escapeLabelValue(labels[name])))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+}
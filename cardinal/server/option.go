@@ -1,7 +1,10 @@
 package server
 
 import (
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -16,20 +19,192 @@ func DisableSignatureVerification() Option {
 	}
 }
 
+// WithPort sets the port the server listens on. Use "0" to listen on a random free port; the chosen port is available
+// via Handler.Port once Handler.Listen or Handler.Serve has been called.
+func WithPort(port string) Option {
+	return func(th *Handler) {
+		th.Port = port
+	}
+}
+
+// WithBindAddress sets the host or IP address of the interface the server listens on, e.g. "127.0.0.1" to only
+// accept connections from the local machine. By default, the server listens on all interfaces.
+func WithBindAddress(address string) Option {
+	return func(th *Handler) {
+		th.bindAddress = address
+	}
+}
+
 func WithAdapter(a shard.Adapter) Option {
 	return func(th *Handler) {
 		th.adapter = a
 	}
 }
 
+// WithBasePath serves all routes under the given path prefix (e.g. "/game1"). This is useful when cardinal sits
+// behind a path based reverse proxy.
+func WithBasePath(basePath string) Option {
+	return func(th *Handler) {
+		basePath = strings.TrimRight(basePath, "/")
+		if basePath != "" && !strings.HasPrefix(basePath, "/") {
+			basePath = "/" + basePath
+		}
+		th.BasePath = basePath
+	}
+}
+
+// WithRequiredAdapter makes submitting transactions to the adapter mandatory. The handler can not be created without an
+// adapter, and a transaction that can not be submitted to the adapter is rejected instead of being processed locally.
+func WithRequiredAdapter() Option {
+	return func(th *Handler) {
+		th.adapterRequired = true
+	}
+}
+
+// WithAsyncTxResponses makes the server answer accepted game transactions with 202 Accepted instead of 200 OK. The
+// Location header of the reply points at /query/receipt/byhash, which clients can poll until the receipt of the
+// transaction is available. The body of the reply is unchanged.
+func WithAsyncTxResponses() Option {
+	return func(th *Handler) {
+		th.asyncTxResponses = true
+	}
+}
+
+// WithReadyAfterInit makes /health answer 503 Service Unavailable until the init system of the world has run, so load
+// balancers and orchestrators do not send traffic to a world that is not populated yet. The reply still holds the
+// health of the world, including the progress of the init system.
+func WithReadyAfterInit() Option {
+	return func(th *Handler) {
+		th.readyAfterInit = true
+	}
+}
+
+// WithUnavailableWithoutRedis makes /health answer 503 Service Unavailable while redis can not be reached, so load
+// balancers stop sending traffic to a world whose ticks would fail. By default /health answers 200 OK and only reports
+// the state of redis in the redisHealthy field of the reply.
+func WithUnavailableWithoutRedis() Option {
+	return func(th *Handler) {
+		th.unavailableWithoutRedis = true
+	}
+}
+
+// WithSlowQueryThreshold logs a warning, with the query name and request size, for every game query whose handler takes
+// longer than the given duration. Slow queries are also counted per query name on the /metrics endpoint, which this
+// option enables.
+func WithSlowQueryThreshold(threshold time.Duration) Option {
+	return func(th *Handler) {
+		th.slowQueryThreshold = threshold
+		th.slowQueries = map[string]uint64{}
+	}
+}
+
+// WithMaxCQLResults caps the number of entities a CQL query may match, regardless of the limit given in the request.
+// Queries that match more entities are rejected with 413 Request Entity Too Large, which protects the server from
+// queries that would build a reply holding the whole world. A value of 0 or less removes the cap. Without this option,
+// the cap is DefaultMaxCQLResults.
+func WithMaxCQLResults(n int) Option {
+	return func(th *Handler) {
+		th.maxCQLResults = n
+	}
+}
+
+// WithUnknownFieldsIgnored makes the server ignore fields it does not know in transaction requests, both in the
+// transaction itself and in the create persona message, instead of rejecting the request. This lets clients send new
+// optional fields before the server is updated to understand them. Game message bodies always ignore unknown fields.
+func WithUnknownFieldsIgnored() Option {
+	return func(th *Handler) {
+		th.allowUnknownFields = true
+	}
+}
+
+// WithMode sets the CARDINAL_MODE reported by /query/http/config.
+func WithMode(mode string) Option {
+	return func(th *Handler) {
+		th.mode = mode
+	}
+}
+
+// WithTickInterval sets the time between ticks reported by /query/http/config. Leave it unset if the world ticks on a
+// custom schedule.
+func WithTickInterval(interval time.Duration) Option {
+	return func(th *Handler) {
+		th.tickInterval = interval
+	}
+}
+
+// WithWebSocketOrigins only allows browsers on the given origins (e.g. "https://game.example.com") to open a websocket
+// connection, such as the one to /events. Upgrade requests from any other origin are rejected with 403 Forbidden. Use
+// this in production to prevent cross-site websocket hijacking. Without this option, the default same origin check of
+// the websocket upgrader applies.
+func WithWebSocketOrigins(origins ...string) Option {
+	return func(th *Handler) {
+		if th.webSocketOrigins == nil {
+			th.webSocketOrigins = map[string]bool{}
+		}
+		for _, origin := range origins {
+			th.webSocketOrigins[normalizeOrigin(origin)] = true
+		}
+	}
+}
+
+// WithDebugAuth requires every request to a debug endpoint (e.g. /debug/state) to be accepted by the given function,
+// for example by checking a shared secret in a header. Requests that are not accepted are rejected with 401
+// Unauthorized. Without this option, debug endpoints are available to anyone who can reach the server.
+func WithDebugAuth(isAuthorized func(r *http.Request) bool) Option {
+	return func(th *Handler) {
+		th.debugAuth = isAuthorized
+	}
+}
+
+// WithDebugTick enables /debug/tick, which runs a tick in which only the given systems run. The tick changes the
+// state of the world, so this is only meant for development. Without this option, /debug/tick answers 403 Forbidden.
+func WithDebugTick() Option {
+	return func(th *Handler) {
+		th.debugTickEnabled = true
+	}
+}
+
+// WithDebugBroadcast enables /debug/broadcast, which sends a message on the reserved system topic to every event
+// subscriber. Clients trust these messages to come from the operator of the server, so this is only meant for
+// development, or for servers whose debug endpoints are protected with WithDebugAuth. Without this option,
+// /debug/broadcast answers 403 Forbidden.
+func WithDebugBroadcast() Option {
+	return func(th *Handler) {
+		th.debugBroadcastEnabled = true
+	}
+}
+
+// WithDebugSubscribers enables /debug/subscribers, which lists the websocket connections to /events along with the
+// persona tags they authenticated as. This is only meant for development, or for servers whose debug endpoints are
+// protected with WithDebugAuth. Without this option, /debug/subscribers answers 403 Forbidden.
+func WithDebugSubscribers() Option {
+	return func(th *Handler) {
+		th.debugSubscribersEnabled = true
+	}
+}
+
 func WithCORS() Option {
 	return func(th *Handler) {
 		th.withCORS = true
 	}
 }
 
-func WithPrettyPrint() Option {
+// WithCORSMaxAge lets browsers cache the result of a CORS preflight request for the given duration, so they do not send
+// an OPTIONS request before every request to the server. It only has an effect together with WithCORS. Browsers cap
+// the duration (e.g. Chrome at 2 hours).
+func WithCORSMaxAge(maxAge time.Duration) Option {
+	return func(th *Handler) {
+		th.corsMaxAge = maxAge
+	}
+}
+
+// WithPrettyPrint makes the server log human readable lines instead of JSON, or JSON if pretty is false.
+func WithPrettyPrint(pretty bool) Option {
 	return func(_ *Handler) {
+		if !pretty {
+			log.Logger = zerolog.New(os.Stderr).With().Timestamp().Logger()
+			return
+		}
 		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 	}
 }
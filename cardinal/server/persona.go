@@ -3,6 +3,7 @@ package server
 import (
 	"errors"
 
+	"github.com/rotisserie/eris"
 	"pkg.world.dev/world-engine/cardinal/types/message"
 
 	"pkg.world.dev/world-engine/cardinal/ecs"
@@ -30,6 +31,36 @@ type QueryPersonaSignerResponse struct {
 	SignerAddress string `json:"signerAddress"`
 }
 
+// QueryNonceUsedRequest is the desired request body for the query-nonce-used endpoint.
+type QueryNonceUsedRequest struct {
+	SignerAddress string `json:"signerAddress"`
+	Nonce         uint64 `json:"nonce"`
+}
+
+// QueryNonceUsedResponse is used as the response body for the query-nonce-used endpoint. A transaction signed with
+// a used nonce will be rejected.
+type QueryNonceUsedResponse struct {
+	Used bool `json:"used"`
+}
+
+// QueryPersonaMeResponse is used as the response body for the query-persona-me endpoint. It holds the signer component
+// of the persona that signed the request.
+type QueryPersonaMeResponse struct {
+	PersonaTag          string   `json:"personaTag"`
+	SignerAddress       string   `json:"signerAddress"`
+	AuthorizedAddresses []string `json:"authorizedAddresses"`
+	// DisplayName is the name set with the set-display-name message. It is empty if the persona has not set one.
+	DisplayName string `json:"displayName"`
+}
+
+func (handler *Handler) getNonceUsedResponse(req *QueryNonceUsedRequest) (*QueryNonceUsedResponse, error) {
+	used, err := handler.w.IsNonceUsed(req.SignerAddress, req.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	return &QueryNonceUsedResponse{Used: used}, nil
+}
+
 func (handler *Handler) getPersonaSignerResponse(req *QueryPersonaSignerRequest) (*QueryPersonaSignerResponse, error) {
 	var status string
 	addr, err := handler.w.GetSignerForPersonaTag(req.PersonaTag, req.Tick)
@@ -51,6 +82,31 @@ func (handler *Handler) getPersonaSignerResponse(req *QueryPersonaSignerRequest)
 	return &res, nil
 }
 
+// getPersonaMeResponse returns the signer component of the persona that signed the given (already verified)
+// transaction. Only the signing persona's own record can be returned.
+func (handler *Handler) getPersonaMeResponse(sp *sign.Transaction) (*QueryPersonaMeResponse, error) {
+	wCtx := ecs.NewReadOnlyWorldContext(handler.w)
+	id, ok := ecs.GetEntityForPersonaInContext(wCtx, sp.PersonaTag)
+	if !ok {
+		return nil, eris.Wrapf(ecs.ErrPersonaTagHasNoSigner, "persona tag %q", sp.PersonaTag)
+	}
+	signer, err := ecs.GetComponent[ecs.SignerComponent](wCtx, id)
+	if err != nil {
+		return nil, err
+	}
+	authorized := signer.AuthorizedAddresses
+	if authorized == nil {
+		authorized = []string{}
+	}
+	displayName, _ := ecs.GetDisplayNameInContext(wCtx, signer.PersonaTag)
+	return &QueryPersonaMeResponse{
+		PersonaTag:          signer.PersonaTag,
+		SignerAddress:       signer.SignerAddress,
+		AuthorizedAddresses: authorized,
+		DisplayName:         displayName,
+	}, nil
+}
+
 func (handler *Handler) generateCreatePersonaResponseFromPayload(
 	payload []byte,
 	sp *sign.Transaction,
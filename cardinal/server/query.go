@@ -1,13 +1,18 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"math"
 	"net/http"
+	"strings"
 
 	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/runtime/middleware"
 	"github.com/go-openapi/runtime/middleware/untyped"
 	"github.com/rotisserie/eris"
+	"github.com/rs/zerolog/log"
 	"pkg.world.dev/world-engine/cardinal/ecs"
 	"pkg.world.dev/world-engine/cardinal/ecs/cql"
 	"pkg.world.dev/world-engine/cardinal/types/entity"
@@ -64,12 +69,20 @@ func (handler *Handler) registerQueryHandlerSwagger(api *untyped.API) error {
 			if err != nil {
 				return nil, eris.Wrap(err, "could not unmarshal data into map")
 			}
-			wCtx := ecs.NewReadOnlyWorldContext(handler.w)
-			rawJSONReply, err := q.HandleQueryRaw(wCtx, rawJSONBody)
-			if err != nil {
-				return nil, err
-			}
-			return json.RawMessage(rawJSONReply), nil
+			return contextResponder(func(ctx context.Context) (interface{}, error) {
+				wCtx := ecs.NewReadOnlyWorldContextWithContext(handler.w, ctx)
+				rawJSONReply, err := handler.handleQueryRaw(q, wCtx, rawJSONBody)
+				if err != nil {
+					return nil, err
+				}
+				if len(rawJSONReply) == 0 {
+					return noContentResponder(), nil
+				}
+				if deprecation, ok := q.Deprecation(); ok {
+					return deprecatedQueryResponder(deprecation, json.RawMessage(rawJSONReply)), nil
+				}
+				return json.RawMessage(rawJSONReply), nil
+			}), nil
 		},
 	)
 	endpoints, err := createAllEndpoints(handler.w)
@@ -87,11 +100,55 @@ func (handler *Handler) registerQueryHandlerSwagger(api *untyped.API) error {
 		handler.getPersonaSignerResponse,
 	)
 
+	nonceUsedHandler := createSwaggerQueryHandler[QueryNonceUsedRequest, QueryNonceUsedResponse](
+		"QueryNonceUsedRequest",
+		handler.getNonceUsedResponse,
+	)
+
 	receiptsHandler := createSwaggerQueryHandler[ListTxReceiptsRequest, ListTxReceiptsReply](
 		"ListTxReceiptsRequest",
 		getListTxReceiptsReplyFromRequest(handler.w),
 	)
 
+	// query/receipt/byhash is a GET endpoint so the Location header of an asynchronous transaction reply can be
+	// followed. It answers 202 Accepted while the tick of the transaction has not been processed yet.
+	receiptByHashHandler := runtime.OperationHandlerFunc(
+		func(params interface{}) (interface{}, error) {
+			mapStruct, ok := params.(map[string]interface{})
+			if !ok {
+				return nil, eris.New("invalid parameter input, map could not be created")
+			}
+			hash, ok := mapStruct["hash"].(string)
+			if !ok {
+				return middleware.Error(http.StatusBadRequest, eris.New("hash must be a string")), nil
+			}
+			if rec, found := getReceiptByHash(handler.w, hash); found {
+				return rec, nil
+			}
+			if tick, ok := mapStruct["tick"].(int64); ok && tick >= 0 && uint64(tick) >= handler.w.CurrentTick() {
+				return middleware.Error(http.StatusAccepted, eris.Errorf("tick %d has not been processed yet", tick)), nil
+			}
+			return middleware.Error(http.StatusNotFound, eris.Errorf("no receipt found for transaction %q", hash)), nil
+		},
+	)
+
+	// query/persona/me requires a signed request, so a persona can only read its own signer component.
+	personaMeHandler := runtime.OperationHandlerFunc(
+		func(params interface{}) (interface{}, error) {
+			_, sp, err := handler.getBodyAndSigFromParams(params, false, nil)
+			if eris.Is(err, eris.Cause(ErrInvalidSignature)) {
+				return middleware.Error(http.StatusUnauthorized, eris.ToString(err, true)), nil
+			} else if err != nil {
+				return nil, err
+			}
+			reply, err := handler.getPersonaMeResponse(sp)
+			if eris.Is(eris.Cause(err), ecs.ErrPersonaTagHasNoSigner) {
+				return middleware.Error(http.StatusNotFound, eris.ToString(err, true)), nil
+			}
+			return reply, err
+		},
+	)
+
 	cqlHandler := runtime.OperationHandlerFunc(
 		func(params interface{}) (interface{}, error) {
 			mapStruct, ok := params.(map[string]interface{})
@@ -109,55 +166,92 @@ func (handler *Handler) registerQueryHandlerSwagger(api *untyped.API) error {
 					eris.Errorf("json is invalid"),
 				), nil
 			}
-			cqlStringUntyped, ok := cqlRequest["CQL"]
-			if !ok {
-				return middleware.Error(
-					http.StatusUnprocessableEntity,
-					eris.Errorf("json is invalid"),
-				), nil
+			compiled, err := handler.compileCQLRequest(cqlRequest)
+			if err != nil {
+				return middleware.Error(http.StatusUnprocessableEntity, err), nil
 			}
-			cqlString, ok := cqlStringUntyped.(string)
-			if !ok {
-				return middleware.Error(
-					http.StatusUnprocessableEntity,
-					eris.Errorf("json is invalid"),
-				), nil
+			resultFilter, err := compiled.ToComponentFilter(handler.w.GetComponentByName)
+			if err != nil {
+				return middleware.Error(http.StatusUnprocessableEntity, err), nil
 			}
-			resultFilter, err := cql.Parse(cqlString, handler.w.GetComponentByName)
+			include, err := parseCQLInclude(handler.w, cqlRequest["include"])
+			if err != nil {
+				return middleware.Error(http.StatusUnprocessableEntity, err), nil
+			}
+			includeArchetype, ok := cqlRequest["includeArchetype"].(bool)
+			if _, isSet := cqlRequest["includeArchetype"]; isSet && !ok {
+				return middleware.Error(http.StatusUnprocessableEntity, eris.New("includeArchetype must be a boolean")), nil
+			}
+			limit, err := parseCQLPageParam(cqlRequest, "limit")
+			if err != nil {
+				return middleware.Error(http.StatusUnprocessableEntity, err), nil
+			}
+			offset, err := parseCQLPageParam(cqlRequest, "offset")
 			if err != nil {
 				return middleware.Error(http.StatusUnprocessableEntity, err), nil
 			}
 
-			result := make([]cql.QueryResponse, 0)
-
-			wCtx := ecs.NewReadOnlyWorldContext(handler.w)
-			err = ecs.NewSearch(resultFilter).Each(
-				wCtx, func(id entity.ID) bool {
-					components, err := wCtx.StoreReader().GetComponentTypesForEntity(id)
-					if err != nil {
-						return false
-					}
-					resultElement := cql.QueryResponse{
-						ID:   id,
-						Data: make([]json.RawMessage, 0),
-					}
+			return contextResponder(func(ctx context.Context) (interface{}, error) {
+				result := make([]cql.QueryResponse, 0)
 
-					for _, c := range components {
-						data, err := wCtx.StoreReader().GetComponentForEntityInRawJSON(c, id)
+				wCtx := ecs.NewReadOnlyWorldContextWithContext(handler.w, ctx)
+				defer ecs.PinTickSnapshot(wCtx)()
+				var eachErr error
+				tooManyResults := false
+				err := ecs.NewSearch(resultFilter).Offset(offset).Limit(limit).EachWithComponents(
+					wCtx, func(id entity.ID, componentNames []string) bool {
+						if handler.maxCQLResults > 0 && len(result) == handler.maxCQLResults {
+							tooManyResults = true
+							return false
+						}
+						components, err := wCtx.StoreReader().GetComponentTypesForEntity(id)
 						if err != nil {
+							eachErr = err
 							return false
 						}
-						resultElement.Data = append(resultElement.Data, data)
-					}
-					result = append(result, resultElement)
-					return true
-				},
-			)
-			if err != nil {
-				return nil, err
-			}
+						resultElement := cql.QueryResponse{
+							ID:   id,
+							Data: make([]json.RawMessage, 0),
+						}
+						if len(include) > 0 {
+							resultElement.Components = make(map[string]json.RawMessage, len(include))
+						}
+						if includeArchetype {
+							resultElement.Archetype = componentNames
+						}
 
-			return result, nil
+						for _, c := range components {
+							if len(include) > 0 && !include[c.Name()] {
+								continue
+							}
+							data, err := wCtx.StoreReader().GetComponentForEntityInRawJSON(c, id)
+							if err != nil {
+								eachErr = err
+								return false
+							}
+							if len(include) > 0 {
+								resultElement.Components[c.Name()] = data
+							} else {
+								resultElement.Data = append(resultElement.Data, data)
+							}
+						}
+						result = append(result, resultElement)
+						return true
+					},
+				)
+				if err != nil {
+					return nil, err
+				}
+				if eachErr != nil {
+					return nil, eachErr
+				}
+				if tooManyResults {
+					return middleware.Error(http.StatusRequestEntityTooLarge, eris.Errorf(
+						"cql query matches more than %d entities, narrow the query or use limit", handler.maxCQLResults)), nil
+				}
+
+				return result, nil
+			}), nil
 		},
 	)
 
@@ -165,7 +259,199 @@ func (handler *Handler) registerQueryHandlerSwagger(api *untyped.API) error {
 	api.RegisterOperation("POST", "/query/game/{queryType}", queryHandler)
 	api.RegisterOperation("POST", "/query/http/endpoints", listHandler)
 	api.RegisterOperation("POST", "/query/persona/signer", personaHandler)
+	api.RegisterOperation("POST", "/query/persona/nonce-used", nonceUsedHandler)
+	api.RegisterOperation("POST", "/query/persona/me", personaMeHandler)
 	api.RegisterOperation("POST", "/query/receipts/list", receiptsHandler)
+	api.RegisterOperation("GET", receiptByHashPath, receiptByHashHandler)
 
 	return nil
 }
+
+// deprecatedQueryResponder writes the query reply along with headers that tell clients the query is deprecated. See
+// RFC 8594 for the Sunset header.
+func deprecatedQueryResponder(deprecation ecs.QueryDeprecation, reply json.RawMessage) middleware.Responder {
+	return middleware.ResponderFunc(func(rw http.ResponseWriter, producer runtime.Producer) {
+		rw.Header().Set("Deprecation", "true")
+		if !deprecation.Sunset.IsZero() {
+			rw.Header().Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if deprecation.Message != "" {
+			rw.Header().Set("Warning", fmt.Sprintf("299 - %q", deprecation.Message))
+		}
+		rw.WriteHeader(http.StatusOK)
+		if err := producer.Produce(rw, reply); err != nil {
+			log.Error().Err(err).Msg("failed to write deprecated query reply")
+		}
+	})
+}
+
+// contextResponder runs the given query when the reply is written, with the context of its request, so the query
+// stops once the client goes away. The query returns either a reply, which is answered with 200, or a
+// middleware.Responder.
+func contextResponder(query func(ctx context.Context) (interface{}, error)) middleware.Responder {
+	return queryResponder{query: query}
+}
+
+// queryResponder is the middleware.Responder returned by contextResponder. go-openapi does not hand the request to
+// operation handlers or responders, so withRequestContext wraps the response writer of every query request in a
+// requestContextWriter, which hands the request context to respond.
+type queryResponder struct {
+	query func(ctx context.Context) (interface{}, error)
+}
+
+func (q queryResponder) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+	crw, ok := rw.(*requestContextWriter)
+	if !ok {
+		// Only happens if a query route is registered outside the prefix given to withRequestContext.
+		log.Error().Msg("query reply was written without the context of its request")
+		middleware.Error(http.StatusInternalServerError, queryErrorReply{
+			Code:    http.StatusInternalServerError,
+			Message: "query was not run with a request context",
+		}).WriteResponse(rw, producer)
+		return
+	}
+	crw.respond(q, producer)
+}
+
+// respond runs the query with the given context and writes its reply.
+func (q queryResponder) respond(ctx context.Context, rw http.ResponseWriter, producer runtime.Producer) {
+	reply, err := q.query(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			log.Debug().Err(err).Msg("query was cancelled")
+			middleware.Error(http.StatusServiceUnavailable, queryErrorReply{
+				Code:    http.StatusServiceUnavailable,
+				Message: "query was cancelled",
+			}).WriteResponse(rw, producer)
+			return
+		}
+		// Same reply as the one go-openapi writes for errors of operation handlers.
+		middleware.Error(http.StatusInternalServerError, queryErrorReply{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		}).WriteResponse(rw, producer)
+		return
+	}
+	if responder, ok := reply.(middleware.Responder); ok {
+		responder.WriteResponse(rw, producer)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+	if err := producer.Produce(rw, reply); err != nil {
+		log.Error().Err(err).Msg("failed to write query reply")
+	}
+}
+
+type queryErrorReply struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// requestContextWriter carries the context of a query request to the queryResponder that writes its reply. go-openapi
+// does not hand the request to operation handlers, so the context travels with the response writer instead.
+type requestContextWriter struct {
+	http.ResponseWriter
+	ctx context.Context
+}
+
+// respond runs the query of the given responder with the context of the request.
+func (w *requestContextWriter) respond(q queryResponder, producer runtime.Producer) {
+	q.respond(w.ctx, w.ResponseWriter, producer)
+}
+
+// withRequestContext passes the context of query requests on to the queryResponder that writes the reply. Other
+// requests are left alone, so e.g. the /events websocket can still hijack the connection.
+func withRequestContext(queryPrefix string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, queryPrefix) {
+			w = &requestContextWriter{ResponseWriter: w, ctx: r.Context()}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// noContentResponder answers queries whose handler returned a nil reply when the query was registered with
+// ecs.NilReplyAsNoContent.
+func noContentResponder() middleware.Responder {
+	return middleware.ResponderFunc(func(rw http.ResponseWriter, _ runtime.Producer) {
+		rw.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// maxCompiledCQLCacheSize bounds the number of distinct CQL strings the CQL endpoint keeps compiled.
+const maxCompiledCQLCacheSize = 1000
+
+// compileCQLRequest returns the compiled query for a CQL request, which either holds a CQL string or the name of a
+// CQL query registered with the world.
+func (handler *Handler) compileCQLRequest(cqlRequest map[string]interface{}) (cql.CompiledQuery, error) {
+	if nameUntyped, ok := cqlRequest["name"]; ok {
+		name, ok := nameUntyped.(string)
+		if !ok {
+			return cql.CompiledQuery{}, eris.New("json is invalid")
+		}
+		return handler.w.GetCQLQueryByName(name)
+	}
+	cqlStringUntyped, ok := cqlRequest["CQL"]
+	if !ok {
+		return cql.CompiledQuery{}, eris.New("json is invalid")
+	}
+	cqlString, ok := cqlStringUntyped.(string)
+	if !ok {
+		return cql.CompiledQuery{}, eris.New("json is invalid")
+	}
+
+	handler.compiledCQLMutex.Lock()
+	defer handler.compiledCQLMutex.Unlock()
+	if compiled, ok := handler.compiledCQL[cqlString]; ok {
+		return compiled, nil
+	}
+	compiled, err := cql.Compile(cqlString)
+	if err != nil {
+		return cql.CompiledQuery{}, err
+	}
+	if handler.compiledCQL == nil {
+		handler.compiledCQL = map[string]cql.CompiledQuery{}
+	}
+	if len(handler.compiledCQL) < maxCompiledCQLCacheSize {
+		handler.compiledCQL[cqlString] = compiled
+	}
+	return compiled, nil
+}
+
+// parseCQLPageParam returns the value of the optional paging field (limit or offset) of a CQL request. 0 is returned if
+// the field is not set.
+func parseCQLPageParam(cqlRequest map[string]interface{}, field string) (int, error) {
+	valueUntyped, ok := cqlRequest[field]
+	if !ok || valueUntyped == nil {
+		return 0, nil
+	}
+	value, ok := valueUntyped.(float64)
+	if !ok || value < 0 || value != math.Trunc(value) {
+		return 0, eris.Errorf("%s must be a non-negative integer", field)
+	}
+	return int(value), nil
+}
+
+// parseCQLInclude validates the optional "include" field of a CQL request and returns the set of included component
+// names. The set is empty if no components were listed.
+func parseCQLInclude(world *ecs.World, includeUntyped any) (map[string]bool, error) {
+	include := map[string]bool{}
+	if includeUntyped == nil {
+		return include, nil
+	}
+	includeList, ok := includeUntyped.([]interface{})
+	if !ok {
+		return nil, eris.New("include must be a list of component names")
+	}
+	for _, nameUntyped := range includeList {
+		name, ok := nameUntyped.(string)
+		if !ok {
+			return nil, eris.New("include must be a list of component names")
+		}
+		if _, err := world.GetComponentByName(name); err != nil {
+			return nil, err
+		}
+		include[name] = true
+	}
+	return include, nil
+}
@@ -1,9 +1,19 @@
 package server
 
 import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/rs/zerolog/log"
 	"pkg.world.dev/world-engine/cardinal/ecs"
+	"pkg.world.dev/world-engine/cardinal/ecs/receipt"
 )
 
+const receiptByHashPath = "/query/receipt/byhash"
+
 type ListTxReceiptsRequest struct {
 	StartTick uint64 `json:"startTick" mapstructure:"startTick"`
 }
@@ -20,15 +30,27 @@ type ListTxReceiptsReply struct {
 
 // Receipt represents a single transaction receipt. It contains an ID, a result, and a list of errors.
 type Receipt struct {
-	TxHash string   `json:"txHash"`
-	Tick   uint64   `json:"tick"`
-	Result any      `json:"result"`
+	TxHash string `json:"txHash"`
+	Tick   uint64 `json:"tick"`
+	Result any    `json:"result"`
+	// Errors holds the message of each error. It is kept for clients that do not read ErrorDetails.
 	Errors []string `json:"errors"`
+	// ErrorDetails holds the same errors as Errors along with their codes.
+	ErrorDetails []ReceiptError `json:"errorDetails,omitempty"`
+	// TraceID is the client supplied trace ID of the transaction, if any.
+	TraceID string `json:"traceId,omitempty"`
+}
+
+// ReceiptError is an error of a receipt. Code is empty if the error was not created with a code.
+type ReceiptError struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
 }
 
 type TransactionReply struct {
-	TxHash string `json:"txHash"`
-	Tick   uint64 `json:"tick"`
+	TxHash  string `json:"txHash"`
+	Tick    uint64 `json:"tick"`
+	TraceID string `json:"traceId,omitempty"`
 }
 
 // errsToStringSlice convert a slice of errors into a slice of strings. This is needed as json.Marshal does not
@@ -41,6 +63,19 @@ func errsToStringSlice(errs []error) []string {
 	return r
 }
 
+// errsToReceiptErrors converts a slice of errors into receipt errors that carry the code of each error created with
+// receipt.NewCodedError.
+func errsToReceiptErrors(errs []error) []ReceiptError {
+	if len(errs) == 0 {
+		return nil
+	}
+	r := make([]ReceiptError, 0, len(errs))
+	for _, err := range errs {
+		r = append(r, ReceiptError{Code: receipt.ErrorCode(err), Message: err.Error()})
+	}
+	return r
+}
+
 // with world construct a function that takes a receipts request and returns a reply.
 func getListTxReceiptsReplyFromRequest(world *ecs.World) func(*ListTxReceiptsRequest) (*ListTxReceiptsReply, error) {
 	return func(req *ListTxReceiptsRequest) (*ListTxReceiptsReply, error) {
@@ -68,13 +103,64 @@ func getListTxReceiptsReplyFromRequest(world *ecs.World) func(*ListTxReceiptsReq
 			}
 			for _, r := range currReceipts {
 				reply.Receipts = append(reply.Receipts, Receipt{
-					TxHash: string(r.TxHash),
-					Tick:   t,
-					Result: r.Result,
-					Errors: errsToStringSlice(r.Errs),
+					TxHash:       string(r.TxHash),
+					Tick:         t,
+					Result:       r.Result,
+					Errors:       errsToStringSlice(r.Errs),
+					ErrorDetails: errsToReceiptErrors(r.Errs),
+					TraceID:      r.TraceID,
 				})
 			}
 		}
 		return &reply, nil
 	}
 }
+
+// getReceiptByHash searches the receipt history of the world for the receipt of the transaction with the given hash.
+func getReceiptByHash(world *ecs.World, hash string) (*Receipt, bool) {
+	endTick := world.CurrentTick()
+	startTick := uint64(0)
+	if size := world.ReceiptHistorySize(); size < endTick {
+		startTick = endTick - size
+	}
+	for t := startTick; t < endTick; t++ {
+		currReceipts, err := world.GetTransactionReceiptsForTick(t)
+		if err != nil {
+			continue
+		}
+		for _, r := range currReceipts {
+			if string(r.TxHash) != hash {
+				continue
+			}
+			return &Receipt{
+				TxHash:       string(r.TxHash),
+				Tick:         t,
+				Result:       r.Result,
+				Errors:       errsToStringSlice(r.Errs),
+				ErrorDetails: errsToReceiptErrors(r.Errs),
+				TraceID:      r.TraceID,
+			}, true
+		}
+	}
+	return nil, false
+}
+
+// receiptByHashURL returns the URL, relative to the host, at which the receipt of the given transaction can be polled.
+func (handler *Handler) receiptByHashURL(txReply *TransactionReply) string {
+	values := url.Values{}
+	values.Set("hash", txReply.TxHash)
+	values.Set("tick", strconv.FormatUint(txReply.Tick, 10))
+	return handler.BasePath + receiptByHashPath + "?" + values.Encode()
+}
+
+// acceptedTxResponder answers a transaction request with 202 Accepted and a Location header pointing at the receipt of
+// the transaction. See WithAsyncTxResponses.
+func (handler *Handler) acceptedTxResponder(txReply *TransactionReply) middleware.Responder {
+	return middleware.ResponderFunc(func(rw http.ResponseWriter, producer runtime.Producer) {
+		rw.Header().Set("Location", handler.receiptByHashURL(txReply))
+		rw.WriteHeader(http.StatusAccepted)
+		if err := producer.Produce(rw, txReply); err != nil {
+			log.Error().Err(err).Msg("failed to write transaction reply")
+		}
+	})
+}
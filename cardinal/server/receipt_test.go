@@ -0,0 +1,41 @@
+package server_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"pkg.world.dev/world-engine/cardinal/server"
+)
+
+// The field names are part of the API of cardinal, so they must only change on purpose.
+func TestReceiptFieldNames(t *testing.T) {
+	bz, err := json.Marshal(server.ListTxReceiptsReply{
+		StartTick: 1,
+		EndTick:   2,
+		Receipts: []server.Receipt{{
+			TxHash:  "hash",
+			Tick:    1,
+			Result:  map[string]any{"success": true},
+			Errors:  []string{"oops"},
+			TraceID: "trace",
+		}},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, `{"startTick":1,"endTick":2,"receipts":[{"txHash":"hash","tick":1,`+
+		`"result":{"success":true},"errors":["oops"],"traceId":"trace"}]}`, string(bz))
+
+	bz, err = json.Marshal(server.TransactionReply{TxHash: "hash", Tick: 1})
+	assert.NilError(t, err)
+	assert.Equal(t, `{"txHash":"hash","tick":1}`, string(bz))
+
+	bz, err = json.Marshal(server.Receipt{
+		TxHash:       "hash",
+		Errors:       []string{"not enough gold"},
+		ErrorDetails: []server.ReceiptError{{Code: "INSUFFICIENT_FUNDS", Message: "not enough gold"}},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, `{"txHash":"hash","tick":0,"result":null,"errors":["not enough gold"],`+
+		`"errorDetails":[{"code":"INSUFFICIENT_FUNDS","message":"not enough gold"}]}`, string(bz))
+}
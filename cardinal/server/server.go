@@ -5,6 +5,7 @@ import (
 	_ "embed"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"strconv"
@@ -21,35 +22,82 @@ import (
 	"github.com/rs/cors"
 	"github.com/rs/zerolog/log"
 	"pkg.world.dev/world-engine/cardinal/ecs"
+	"pkg.world.dev/world-engine/cardinal/ecs/cql"
 	"pkg.world.dev/world-engine/cardinal/shard"
 )
 
 // Handler is a type that contains endpoints for messages and queries in a given ecs world.
 type Handler struct {
-	w                      *ecs.World
-	Mux                    *http.ServeMux
-	server                 *http.Server
-	disableSigVerification bool
-	Port                   string
-	withCORS               bool
-	running                atomic.Bool
-	shutdownMutex          sync.Mutex
+	w                       *ecs.World
+	Mux                     *http.ServeMux
+	server                  *http.Server
+	listener                net.Listener
+	disableSigVerification  bool
+	Port                    string
+	bindAddress             string
+	BasePath                string
+	withCORS                bool
+	corsMaxAge              time.Duration
+	webSocketOrigins        map[string]bool
+	debugAuth               func(r *http.Request) bool
+	debugTickEnabled        bool
+	debugBroadcastEnabled   bool
+	debugSubscribersEnabled bool
+	running                 atomic.Bool
+	shutdownMutex           sync.Mutex
+	startTime               time.Time
+
+	// slowQueryThreshold is the duration after which a query is logged and counted as slow. See
+	// WithSlowQueryThreshold.
+	slowQueryThreshold time.Duration
+	slowQueries        map[string]uint64
+	slowQueriesMutex   sync.Mutex
+
+	// txRejections counts the transactions the server rejected, by reason. See TxRejectionCounts.
+	txRejections      map[string]uint64
+	txRejectionsMutex sync.Mutex
+
+	// maxCQLResults is the largest number of entities a CQL query may match. See WithMaxCQLResults.
+	maxCQLResults int
+
+	// compiledCQL caches the CQL strings sent to the CQL endpoint so each string is only parsed once.
+	compiledCQL      map[string]cql.CompiledQuery
+	compiledCQLMutex sync.Mutex
 
 	// plugins
 	adapter shard.WriteAdapter
+	// adapterRequired makes transactions fail if they can not be submitted to the adapter.
+	adapterRequired bool
+	// asyncTxResponses makes game transactions answer with 202 Accepted. See WithAsyncTxResponses.
+	asyncTxResponses bool
+	// allowUnknownFields makes transaction requests ignore fields the server does not know. See WithUnknownFieldsIgnored.
+	allowUnknownFields bool
+	// readyAfterInit makes /health answer 503 until the init system has run. See WithReadyAfterInit.
+	readyAfterInit bool
+	// unavailableWithoutRedis makes /health answer 503 while redis is down. See WithUnavailableWithoutRedis.
+	unavailableWithoutRedis bool
+
+	// mode and tickInterval are reported by /query/http/config. See WithMode and WithTickInterval.
+	mode         string
+	tickInterval time.Duration
 }
 
 var (
 	// ErrInvalidSignature is returned when a signature is incorrect in some way (e.g. namespace mismatch, nonce invalid,
 	// the actual Verify fails). Other failures (e.g. Redis is down) should not wrap this error.
 	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrMessageValidationFailed is returned when a message input is rejected by the validator given with
+	// ecs.WithMsgValidator.
+	ErrMessageValidationFailed = errors.New("message validation failed")
 )
 
 const (
 	gameQueryPrefix = "/query/game/"
-	gameTxPrefix    = "/tx/game/"
 
 	readHeaderTimeout = 5 * time.Second
+
+	// DefaultMaxCQLResults is the number of entities a CQL query may match when WithMaxCQLResults is not used.
+	DefaultMaxCQLResults = 100_000
 )
 
 // NewHandler instantiates handler function for creating a swagger server that validates itself based on a swagger spec.
@@ -57,10 +105,10 @@ const (
 // of 4040, but can be changed via options or by setting an environment variable with key CARDINAL_PORT.
 func NewHandler(w *ecs.World, builder middleware.Builder, opts ...Option) (*Handler, error) {
 	h, err := newSwaggerHandlerEmbed(w, builder, opts...)
-	h.running.Store(false)
 	if err != nil {
 		return nil, err
 	}
+	h.running.Store(false)
 	return h, nil
 }
 
@@ -69,20 +117,34 @@ var swaggerData []byte
 
 func newSwaggerHandlerEmbed(w *ecs.World, builder middleware.Builder, opts ...Option) (*Handler, error) {
 	th := &Handler{
-		w:        w,
-		Mux:      http.NewServeMux(),
-		withCORS: false,
+		w:             w,
+		Mux:           http.NewServeMux(),
+		withCORS:      false,
+		startTime:     time.Now(),
+		maxCQLResults: DefaultMaxCQLResults,
 	}
 	for _, opt := range opts {
 		opt(th)
 	}
+	if th.adapterRequired && th.adapter == nil {
+		return nil, eris.Wrap(ecs.ErrAdapterRequired, "")
+	}
 	specDoc, err := loads.Analyzed(swaggerData, "")
 	if err != nil {
 		return nil, eris.Wrap(err, "error loading swagger spec")
 	}
+	if th.BasePath != "" {
+		specDoc.Spec().BasePath = th.BasePath
+		builder = withBasePath(th.BasePath, builder)
+	}
 	api := untyped.NewAPI(specDoc).WithoutJSONDefaults()
-	api.RegisterConsumer("application/json", runtime.JSONConsumer())
-	api.RegisterProducer("application/json", runtime.JSONProducer())
+	// JSON is the default encoding. Clients can opt in to MessagePack by setting the Content-Type and Accept headers
+	// to application/msgpack. Signed requests (transactions and /query/persona/me) only consume JSON, see swagger.yml,
+	// because their signature covers the JSON body the client sent, which msgPackConsumer can not reproduce.
+	api.RegisterConsumer(mimeJSON, runtime.JSONConsumer())
+	api.RegisterProducer(mimeJSON, runtime.JSONProducer())
+	api.RegisterConsumer(mimeMsgPack, msgPackConsumer())
+	api.RegisterProducer(mimeMsgPack, msgPackProducer())
 	err = th.registerTxHandlerSwagger(api)
 	if err != nil {
 		return nil, err
@@ -93,6 +155,9 @@ func newSwaggerHandlerEmbed(w *ecs.World, builder middleware.Builder, opts ...Op
 	}
 	th.registerDebugHandlerSwagger(api)
 	th.registerHealthHandlerSwagger(api)
+	th.registerStatsHandlerSwagger(api)
+	th.registerConfigHandlerSwagger(api)
+	th.registerThroughputHandlerSwagger(api)
 
 	// This is here to meet the swagger spec. Actual /events will be intercepted before this route.
 	api.RegisterOperation("GET", "/events", runtime.OperationHandlerFunc(func(params interface{}) (interface{}, error) {
@@ -104,16 +169,49 @@ func newSwaggerHandlerEmbed(w *ecs.World, builder middleware.Builder, opts ...Op
 	}
 
 	app := middleware.NewContext(specDoc, api, nil)
-	var handler = app.APIHandler(builder)
+	var handler = withRequestContext(th.BasePath+"/query/", app.APIHandler(builder))
 	if th.withCORS {
-		handler = cors.AllowAll().Handler(handler)
+		handler = th.newCORS().Handler(handler)
+	}
+	th.Mux.Handle(th.BasePath+"/", handler)
+	if th.w.MessageMetricsEnabled() || th.slowQueryThreshold > 0 {
+		th.Mux.HandleFunc(th.BasePath+"/metrics", th.metricsHandler)
 	}
-	th.Mux.Handle("/", handler)
 	th.Initialize()
 
 	return th, nil
 }
 
+// newCORS returns the same CORS policy as cors.AllowAll, with the preflight max age given with WithCORSMaxAge.
+func (handler *Handler) newCORS() *cors.Cors {
+	return cors.New(cors.Options{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{
+			http.MethodHead,
+			http.MethodGet,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+		},
+		AllowedHeaders:   []string{"*"},
+		AllowCredentials: false,
+		MaxAge:           int(handler.corsMaxAge.Seconds()),
+	})
+}
+
+// withBasePath wraps the given builder so that it sees request paths relative to basePath. Swagger routes include
+// the base path, but builders (e.g. the /events websocket handler) are unaware of it.
+func withBasePath(basePath string, builder middleware.Builder) middleware.Builder {
+	return func(next http.Handler) http.Handler {
+		restorePrefix := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.URL.Path = basePath + r.URL.Path
+			next.ServeHTTP(w, r)
+		})
+		return http.StripPrefix(basePath, builder(restorePrefix))
+	}
+}
+
 // utility function to create a swagger handler from a request name, request constructor, request to response function.
 func createSwaggerQueryHandler[Request any, Response any](requestName string,
 	requestHandler func(*Request) (*Response, error)) runtime.OperationHandlerFunc {
@@ -175,6 +273,16 @@ type EndpointsResult struct {
 	TxEndpoints    []string `json:"txEndpoints"`
 	QueryEndpoints []string `json:"queryEndpoints"`
 	DebugEndpoints []string `json:"debugEndpoints"`
+	// DeprecatedQueryEndpoints lists the query endpoints that have been registered with a deprecation.
+	DeprecatedQueryEndpoints []DeprecatedEndpoint `json:"deprecatedQueryEndpoints,omitempty"`
+}
+
+// DeprecatedEndpoint describes a deprecated endpoint in the /query/http/endpoints listing.
+type DeprecatedEndpoint struct {
+	Endpoint string `json:"endpoint"`
+	Message  string `json:"message"`
+	// Sunset is the RFC 3339 time after which the endpoint may be removed. It is empty if no sunset is planned.
+	Sunset string `json:"sunset,omitempty"`
 }
 
 func createAllEndpoints(world *ecs.World) (*EndpointsResult, error) {
@@ -184,35 +292,55 @@ func createAllEndpoints(world *ecs.World) (*EndpointsResult, error) {
 	}
 	txEndpoints := make([]string, 0, len(txs))
 	for _, tx := range txs {
-		if tx.Name() == ecs.CreatePersonaMsg.Name() {
-			txEndpoints = append(txEndpoints, "/tx/persona/"+tx.Name())
-		} else {
-			txEndpoints = append(txEndpoints, gameTxPrefix+tx.Name())
+		endpoint, err := world.MessageEndpoint(tx.Name())
+		if err != nil {
+			return nil, err
 		}
+		txEndpoints = append(txEndpoints, endpoint)
 	}
 
 	queries := world.ListQueries()
 	queryEndpoints := make([]string, 0, len(queries))
+	var deprecatedEndpoints []DeprecatedEndpoint
 	for _, query := range queries {
-		queryEndpoints = append(queryEndpoints, gameQueryPrefix+query.Name())
+		endpoint := gameQueryPrefix + query.Name()
+		queryEndpoints = append(queryEndpoints, endpoint)
+		if deprecation, ok := query.Deprecation(); ok {
+			deprecated := DeprecatedEndpoint{Endpoint: endpoint, Message: deprecation.Message}
+			if !deprecation.Sunset.IsZero() {
+				deprecated.Sunset = deprecation.Sunset.UTC().Format(time.RFC3339)
+			}
+			deprecatedEndpoints = append(deprecatedEndpoints, deprecated)
+		}
 	}
 	queryEndpoints = append(queryEndpoints,
 		"/query/http/endpoints",
+		"/query/http/stats",
+		"/query/http/config",
+		"/query/http/throughput",
 		"/query/persona/signer",
+		"/query/persona/nonce-used",
+		"/query/persona/me",
 		"/query/receipt/list",
 		"/query/game/cql",
 	)
-	debugEndpoints := make([]string, 1)
+	debugEndpoints := make([]string, 5)
 	debugEndpoints[0] = "/debug/state"
+	debugEndpoints[1] = "/debug/subscribers"
+	debugEndpoints[2] = "/debug/broadcast"
+	debugEndpoints[3] = "/debug/systems"
+	debugEndpoints[4] = "/debug/tick"
 	return &EndpointsResult{
-		TxEndpoints:    txEndpoints,
-		QueryEndpoints: queryEndpoints,
+		TxEndpoints:              txEndpoints,
+		QueryEndpoints:           queryEndpoints,
+		DeprecatedQueryEndpoints: deprecatedEndpoints,
 	}, nil
 }
 
 // Initialize initializes the server. It firsts checks for a port set on the handler via options.
 // if no port is found, or a bad port was passed into the option, it falls back to an environment variable,
-// CARDINAL_PORT. If not set, it falls back to a default port of 4040.
+// CARDINAL_PORT. If not set, it falls back to a default port of 4040. The server listens on all interfaces unless a
+// bind address was set with WithBindAddress.
 func (handler *Handler) Initialize() {
 	if _, err := strconv.Atoi(handler.Port); err != nil || len(handler.Port) == 0 {
 		envPort := os.Getenv("CARDINAL_PORT")
@@ -223,12 +351,33 @@ func (handler *Handler) Initialize() {
 		}
 	}
 	handler.server = &http.Server{
-		Addr:              fmt.Sprintf(":%s", handler.Port),
-		Handler:           handler.Mux,
+		Addr:              net.JoinHostPort(handler.bindAddress, handler.Port),
+		Handler:           handler.withDebugAuth(handler.withWebSocketOriginCheck(handler.withEventsAuth(handler.Mux))),
 		ReadHeaderTimeout: readHeaderTimeout,
 	}
 }
 
+// Listen binds the server's port without serving any requests. If the port is "0", an ephemeral port is chosen and
+// handler.Port is updated to the port that was actually bound. Calling Listen is optional; Serve will call it if
+// needed. Call Listen before Serve when the actual port must be known before the server starts (e.g. in tests).
+func (handler *Handler) Listen() error {
+	if handler.listener != nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", handler.server.Addr)
+	if err != nil {
+		return eris.Wrap(err, "error listening")
+	}
+	addr, ok := listener.Addr().(*net.TCPAddr)
+	if !ok {
+		_ = listener.Close()
+		return eris.Errorf("unexpected listener address %v", listener.Addr())
+	}
+	handler.Port = strconv.Itoa(addr.Port)
+	handler.listener = listener
+	return nil
+}
+
 // Serve serves the application, blocking the calling thread.
 // Call this in a new go routine to prevent blocking.
 func (handler *Handler) Serve() error {
@@ -236,9 +385,12 @@ func (handler *Handler) Serve() error {
 	if err != nil {
 		return eris.Wrap(err, "error getting hostname")
 	}
+	if err = handler.Listen(); err != nil {
+		return err
+	}
 	log.Info().Msgf("serving cardinal at %s:%s", hostname, handler.Port)
 	handler.running.Store(true)
-	err = eris.Wrap(handler.server.ListenAndServe(), "error listening and serving")
+	err = eris.Wrap(handler.server.Serve(handler.listener), "error listening and serving")
 	handler.running.Store(false)
 	return err
 }
@@ -3,15 +3,18 @@ package server_test
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -20,7 +23,9 @@ import (
 
 	"pkg.world.dev/world-engine/cardinal/testutils"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
 	"pkg.world.dev/world-engine/cardinal/shard"
 	"pkg.world.dev/world-engine/evm/x/shard/types"
 
@@ -30,7 +35,9 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"pkg.world.dev/world-engine/cardinal/ecs"
 	"pkg.world.dev/world-engine/cardinal/ecs/cql"
+	"pkg.world.dev/world-engine/cardinal/ecs/receipt"
 	"pkg.world.dev/world-engine/cardinal/server"
+	"pkg.world.dev/world-engine/cardinal/types/entity"
 	"pkg.world.dev/world-engine/sign"
 )
 
@@ -69,6 +76,111 @@ func TestHealthEndpoint(t *testing.T) {
 	}
 }
 
+func TestHealthIsNotReadyUntilInitSystemRan(t *testing.T) {
+	w := testutils.NewTestWorld(t).Instance()
+	w.AddInitSystem(func(wCtx ecs.WorldContext) error {
+		wCtx.GetWorld().SetInitProgress(1)
+		return nil
+	})
+	assert.NilError(t, w.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, w, server.DisableSignatureVerification(),
+		server.WithReadyAfterInit())
+	getHealth := func() (int, server.HealthReply) {
+		resp := txh.Get("health")
+		defer resp.Body.Close()
+		var reply server.HealthReply
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&reply))
+		return resp.StatusCode, reply
+	}
+
+	w.SetInitProgress(0.5)
+	code, reply := getHealth()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Check(t, reply.IsServerRunning)
+	assert.Check(t, !reply.IsReady)
+	assert.Equal(t, 0.5, reply.InitProgress)
+
+	assert.NilError(t, w.Tick(context.Background()))
+	code, reply = getHealth()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Check(t, reply.IsReady)
+	assert.Equal(t, 1.0, reply.InitProgress)
+}
+
+func TestHealthIsUnavailableWhenRedisIsDown(t *testing.T) {
+	redis := miniredis.RunT(t)
+	w := testutils.NewTestWorldWithCustomRedis(t, redis, cardinal.WithRedisHealthCheckInterval(0)).Instance()
+	assert.NilError(t, w.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, w, server.DisableSignatureVerification(),
+		server.WithUnavailableWithoutRedis())
+	getHealth := func() (int, server.HealthReply) {
+		resp := txh.Get("health")
+		defer resp.Body.Close()
+		var reply server.HealthReply
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&reply))
+		return resp.StatusCode, reply
+	}
+
+	code, reply := getHealth()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Check(t, reply.RedisHealthy)
+
+	redis.Close()
+	code, reply = getHealth()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Check(t, reply.IsServerRunning)
+	assert.Check(t, !reply.RedisHealthy)
+
+	assert.NilError(t, redis.Restart())
+	code, reply = getHealth()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Check(t, reply.RedisHealthy)
+}
+
+func TestHealthReportsRedisIsDownWithoutAnOption(t *testing.T) {
+	redis := miniredis.RunT(t)
+	w := testutils.NewTestWorldWithCustomRedis(t, redis, cardinal.WithRedisHealthCheckInterval(0)).Instance()
+	assert.NilError(t, w.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, w, server.DisableSignatureVerification())
+
+	redis.Close()
+	resp := txh.Get("health")
+	defer resp.Body.Close()
+	// Existing deployments keep getting 200 OK, and can read the state of redis from the reply.
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var reply server.HealthReply
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&reply))
+	assert.Check(t, !reply.RedisHealthy)
+	assert.NilError(t, redis.Restart())
+}
+
+func TestCanServeUnderBasePath(t *testing.T) {
+	w := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, w.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(
+		t, w, server.DisableSignatureVerification(), server.WithBasePath("game1/"),
+	)
+	assert.Equal(t, txh.BasePath, "/game1")
+
+	resp := txh.Get("health")
+	assert.Equal(t, resp.StatusCode, 200)
+
+	resp, err := http.Post(txh.MakeHTTPURL("query/http/endpoints"), "application/json", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, resp.StatusCode, 200)
+
+	// Routes are no longer served at the root.
+	resp, err = http.Get("http://localhost:4040/health")
+	assert.NilError(t, err)
+	assert.Equal(t, resp.StatusCode, 404)
+
+	// The websocket event endpoint is also served under the base path.
+	dialer := websocket.Dialer{}
+	conn, _, err := dialer.Dial(txh.MakeWebSocketURL("events"), nil)
+	assert.NilError(t, err)
+	assert.NilError(t, conn.Close())
+}
+
 type Alpha struct{}
 
 func (Alpha) Name() string { return "alpha" }
@@ -172,6 +284,44 @@ func TestIfServeSetEnvVarForPort(t *testing.T) {
 	assert.Equal(t, txh.Port, "4555")
 }
 
+func TestCanListenOnRandomPort(t *testing.T) {
+	w := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, w.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, w, server.DisableSignatureVerification(), server.WithPort("0"))
+	assert.Check(t, txh.Port != "0")
+	assert.Check(t, txh.Port != "4040")
+
+	resp := txh.Get("health")
+	assert.Equal(t, resp.StatusCode, 200)
+}
+
+func TestCanBindToSpecificAddress(t *testing.T) {
+	w := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, w.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, w, server.DisableSignatureVerification(), server.WithPort("0"),
+		server.WithBindAddress("127.0.0.1"))
+
+	resp, err := http.Get("http://" + net.JoinHostPort("127.0.0.1", txh.Port) + "/health")
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, 200)
+}
+
+func TestCORSPreflightCanBeCached(t *testing.T) {
+	w := testutils.NewTestWorld(t).Instance()
+	txh := testutils.MakeTestTransactionHandler(t, w, server.DisableSignatureVerification(), server.WithCORS(),
+		server.WithCORSMaxAge(10*time.Minute))
+	req, err := http.NewRequest(http.MethodOptions, txh.MakeHTTPURL("query/http/endpoints"), nil)
+	assert.NilError(t, err)
+	req.Header.Set("Origin", "http://www.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, resp.Header.Get("Access-Control-Allow-Origin"), "*")
+	assert.Equal(t, resp.Header.Get("Access-Control-Max-Age"), "600")
+}
+
 func TestCanListTransactionEndpoints(t *testing.T) {
 	w := testutils.NewTestWorld(t).Instance()
 	alphaTx := ecs.NewMessageType[SendEnergyTx, SendEnergyTxResult]("alpha")
@@ -265,6 +415,186 @@ func TestHandleTransactionWithNoSignatureVerification(t *testing.T) {
 	assert.NilError(t, err)
 }
 
+func TestInvalidMessageIsRejectedBeforeQueuing(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	sendTx := ecs.NewMessageType[SendEnergyTx, SendEnergyTxResult]("send-energy",
+		ecs.WithMsgValidator[SendEnergyTx, SendEnergyTxResult](func(tx SendEnergyTx) error {
+			if tx.Amount == 0 {
+				return errors.New("amount must be positive")
+			}
+			return nil
+		}))
+	assert.NilError(t, world.RegisterMessages(sendTx))
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification())
+	defer txh.Close()
+
+	postSendEnergy := func(nonce, amount uint64) *http.Response {
+		bz, err := json.Marshal(SendEnergyTx{From: "me", To: "you", Amount: amount})
+		assert.NilError(t, err)
+		tx := &sign.Transaction{
+			PersonaTag: "meow",
+			Namespace:  world.Namespace().String(),
+			Nonce:      nonce,
+			Signature:  "doesnt matter what goes in here",
+			Body:       bz,
+		}
+		bz, err = json.Marshal(tx)
+		assert.NilError(t, err)
+		resp, err := http.Post(txh.MakeHTTPURL("tx/game/send-energy"), "application/json", bytes.NewReader(bz))
+		assert.NilError(t, err)
+		return resp
+	}
+
+	resp := postSendEnergy(1, 0)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Check(t, strings.Contains(mustReadBody(t, resp), "amount must be positive"))
+	assert.Equal(t, 0, world.GetTxQueueAmount())
+
+	resp = postSendEnergy(2, 420)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "request failed with body: %v", mustReadBody(t, resp))
+	assert.Equal(t, 1, world.GetTxQueueAmount())
+}
+
+func TestAsyncTxResponsePointsAtTheReceipt(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	sendTx := ecs.NewMessageType[SendEnergyTx, SendEnergyTxResult]("send-energy")
+	assert.NilError(t, world.RegisterMessages(sendTx))
+	world.RegisterSystem(func(wCtx ecs.WorldContext) error {
+		sendTx.Each(wCtx, func(ecs.TxData[SendEnergyTx]) (SendEnergyTxResult, error) {
+			return SendEnergyTxResult{}, nil
+		})
+		return nil
+	})
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification(),
+		server.WithAsyncTxResponses())
+	defer txh.Close()
+
+	bz, err := json.Marshal(SendEnergyTx{From: "me", To: "you", Amount: 420})
+	assert.NilError(t, err)
+	resp := txh.Post("tx/game/send-energy", &sign.Transaction{
+		PersonaTag: "meow",
+		Namespace:  world.Namespace().String(),
+		Nonce:      1,
+		Signature:  "doesnt matter what goes in here",
+		Body:       bz,
+	})
+	body := mustReadBody(t, resp)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode, "request failed with body: %v", body)
+	var txReply server.TransactionReply
+	assert.NilError(t, json.Unmarshal([]byte(body), &txReply))
+	location := resp.Header.Get("Location")
+	assert.Check(t, strings.HasPrefix(location, "/query/receipt/byhash?"))
+
+	getReceipt := func() *http.Response {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://"+txh.Host+location, nil)
+		assert.NilError(t, err)
+		res, err := http.DefaultClient.Do(req)
+		assert.NilError(t, err)
+		return res
+	}
+
+	// The tick of the transaction has not run yet.
+	resp = getReceipt()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode, "request failed with body: %v", mustReadBody(t, resp))
+
+	assert.NilError(t, world.Tick(context.Background()))
+	resp = getReceipt()
+	body = mustReadBody(t, resp)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "request failed with body: %v", body)
+	var rec server.Receipt
+	assert.NilError(t, json.Unmarshal([]byte(body), &rec))
+	assert.Equal(t, txReply.TxHash, rec.TxHash)
+	assert.Equal(t, txReply.Tick, rec.Tick)
+	assert.Equal(t, 0, len(rec.Errors))
+
+	resp = txh.Get("query/receipt/byhash?hash=unknown")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestReceiptErrorsCarryTheirCode(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	sendTx := ecs.NewMessageType[SendEnergyTx, SendEnergyTxResult]("send-energy")
+	assert.NilError(t, world.RegisterMessages(sendTx))
+	world.RegisterSystem(func(wCtx ecs.WorldContext) error {
+		sendTx.Each(wCtx, func(ecs.TxData[SendEnergyTx]) (SendEnergyTxResult, error) {
+			return SendEnergyTxResult{}, receipt.NewCodedError("INSUFFICIENT_FUNDS", "not enough energy")
+		})
+		return nil
+	})
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification())
+	defer txh.Close()
+
+	sendTx.AddToQueue(world, SendEnergyTx{From: "me", To: "you", Amount: 1000}, testutils.UniqueSignature())
+	assert.NilError(t, world.Tick(context.Background()))
+
+	resp := txh.Post("query/receipts/list", server.ListTxReceiptsRequest{})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var reply server.ListTxReceiptsReply
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&reply))
+	assert.Equal(t, 1, len(reply.Receipts))
+	assert.DeepEqual(t, []string{"not enough energy"}, reply.Receipts[0].Errors)
+	assert.DeepEqual(t, []server.ReceiptError{{Code: "INSUFFICIENT_FUNDS", Message: "not enough energy"}},
+		reply.Receipts[0].ErrorDetails)
+}
+
+func TestInvalidMessageDoesNotUseUpTheNonce(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	sendTx := ecs.NewMessageType[SendEnergyTx, SendEnergyTxResult]("send-energy",
+		ecs.WithMsgValidator[SendEnergyTx, SendEnergyTxResult](func(tx SendEnergyTx) error {
+			if tx.Amount == 0 {
+				return errors.New("amount must be positive")
+			}
+			return nil
+		}))
+	assert.NilError(t, world.RegisterMessages(sendTx))
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world)
+	defer txh.Close()
+	namespace := world.Namespace().String()
+
+	privateKey, err := crypto.GenerateKey()
+	assert.NilError(t, err)
+	signerAddr := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+	personaTag := "some_dude"
+	createPersonaTx, err := sign.NewSystemTransaction(privateKey, namespace, 100, ecs.CreatePersona{
+		PersonaTag:    personaTag,
+		SignerAddress: signerAddr,
+	})
+	assert.NilError(t, err)
+	bz, err := createPersonaTx.Marshal()
+	assert.NilError(t, err)
+	resp, err := http.Post(txh.MakeHTTPURL("tx/persona/create-persona"), "application/json", bytes.NewReader(bz))
+	assert.NilError(t, err)
+	assert.Equal(t, resp.StatusCode, 200)
+	assert.NilError(t, world.Tick(context.Background()))
+
+	postSendEnergy := func(amount uint64) *http.Response {
+		tx, err := sign.NewTransaction(privateKey, personaTag, namespace, 101,
+			SendEnergyTx{From: "me", To: "you", Amount: amount})
+		assert.NilError(t, err)
+		bz, err := tx.Marshal()
+		assert.NilError(t, err)
+		resp, err := http.Post(txh.MakeHTTPURL("tx/game/send-energy"), "application/json", bytes.NewReader(bz))
+		assert.NilError(t, err)
+		return resp
+	}
+
+	resp = postSendEnergy(0)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Check(t, strings.Contains(mustReadBody(t, resp), "amount must be positive"))
+	used, err := world.IsNonceUsed(signerAddr, 101)
+	assert.NilError(t, err)
+	assert.Check(t, !used)
+
+	// The nonce of the rejected transaction can be used for a corrected one.
+	resp = postSendEnergy(420)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "request failed with body: %v", mustReadBody(t, resp))
+	assert.Equal(t, 1, world.GetTxQueueAmount())
+}
+
 type garbageStructAlpha struct {
 	Something int `json:"something"`
 }
@@ -339,7 +669,8 @@ func TestHandleSwaggerServer(t *testing.T) {
 			"/tx/persona/create-persona", "/tx/game/authorize-persona-address", "/tx/game/send-energy",
 		},
 		QueryEndpoints: []string{
-			"/query/game/foo", "/query/http/endpoints", "/query/persona/signer",
+			"/query/game/foo", "/query/http/endpoints", "/query/http/stats", "/query/http/config",
+			"/query/http/throughput", "/query/persona/signer", "/query/persona/nonce-used", "/query/persona/me",
 			"/query/receipt/list", "/query/game/cql",
 		},
 	}
@@ -483,6 +814,144 @@ func TestHandleSwaggerServer(t *testing.T) {
 	resp8, err := http.Post(txh.MakeHTTPURL("query/game/cql"), "application/json", bytes.NewBuffer(jsonQueryBytes))
 	assert.NilError(t, err)
 	assert.Equal(t, resp8.StatusCode, 422)
+
+	// Test query/game/cql with an include list
+	includeQueryBytes, err := json.Marshal(cql.QueryRequest{CQL: "CONTAINS(alpha)", Include: []string{"beta"}})
+	assert.NilError(t, err)
+	resp9, err := http.Post(txh.MakeHTTPURL("query/game/cql"), "application/json", bytes.NewBuffer(includeQueryBytes))
+	assert.NilError(t, err)
+	assert.Equal(t, resp9.StatusCode, 200)
+	var included []cql.QueryResponse
+	assert.NilError(t, json.NewDecoder(resp9.Body).Decode(&included))
+	assert.Equal(t, len(included), bothCount+alphaCount)
+	withBeta := 0
+	for _, e := range included {
+		assert.Equal(t, len(e.Data), 0)
+		if _, ok := e.Components["beta"]; ok {
+			withBeta++
+		}
+		_, ok := e.Components["alpha"]
+		assert.Check(t, !ok)
+	}
+	assert.Equal(t, withBeta, bothCount)
+
+	// Test query/game/cql with the archetype of each entity
+	archetypeQueryBytes, err := json.Marshal(cql.QueryRequest{CQL: "CONTAINS(beta)", IncludeArchetype: true})
+	assert.NilError(t, err)
+	resp12, err := http.Post(txh.MakeHTTPURL("query/game/cql"), "application/json", bytes.NewBuffer(archetypeQueryBytes))
+	assert.NilError(t, err)
+	assert.Equal(t, resp12.StatusCode, 200)
+	var withArchetype []cql.QueryResponse
+	assert.NilError(t, json.NewDecoder(resp12.Body).Decode(&withArchetype))
+	assert.Equal(t, len(withArchetype), bothCount)
+	for _, e := range withArchetype {
+		assert.DeepEqual(t, e.Archetype, []string{"alpha", "beta"})
+	}
+	for _, e := range included {
+		assert.Equal(t, len(e.Archetype), 0)
+	}
+
+	// Test query/game/cql with paging
+	var pagedIDs []entity.ID
+	for offset := 0; offset < alphaCount; offset += 50 {
+		pageQueryBytes, err := json.Marshal(cql.QueryRequest{CQL: "EXACT(alpha)", Limit: 50, Offset: offset})
+		assert.NilError(t, err)
+		resp, err := http.Post(txh.MakeHTTPURL("query/game/cql"), "application/json", bytes.NewBuffer(pageQueryBytes))
+		assert.NilError(t, err)
+		assert.Equal(t, resp.StatusCode, 200)
+		var page []cql.QueryResponse
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&page))
+		assert.Check(t, len(page) <= 50)
+		for _, e := range page {
+			pagedIDs = append(pagedIDs, e.ID)
+		}
+	}
+	assert.Equal(t, len(pagedIDs), alphaCount)
+	seen := map[entity.ID]bool{}
+	for _, id := range pagedIDs {
+		assert.Check(t, !seen[id])
+		seen[id] = true
+	}
+	badLimitBytes, err := json.Marshal(map[string]any{"CQL": "EXACT(alpha)", "limit": -1})
+	assert.NilError(t, err)
+	resp11, err := http.Post(txh.MakeHTTPURL("query/game/cql"), "application/json", bytes.NewBuffer(badLimitBytes))
+	assert.NilError(t, err)
+	assert.Equal(t, resp11.StatusCode, 422)
+
+	unknownIncludeBytes, err := json.Marshal(cql.QueryRequest{CQL: "CONTAINS(alpha)", Include: []string{"nope"}})
+	assert.NilError(t, err)
+	resp10, err := http.Post(txh.MakeHTTPURL("query/game/cql"), "application/json", bytes.NewBuffer(unknownIncludeBytes))
+	assert.NilError(t, err)
+	assert.Equal(t, resp10.StatusCode, 422)
+}
+
+func TestCanRunRegisteredCQLQueryByName(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[garbageStructAlpha](world))
+	assert.NilError(t, world.RegisterCQLQuery("all-alphas", "CONTAINS(alpha)"))
+	assert.Check(t, world.RegisterCQLQuery("all-alphas", "EXACT(alpha)") != nil)
+	assert.Check(t, world.RegisterCQLQuery("broken", "CONTAINS(alpha") != nil)
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world)
+
+	wCtx := ecs.NewWorldContext(world)
+	_, err := ecs.CreateMany(wCtx, 3, garbageStructAlpha{})
+	assert.NilError(t, err)
+	assert.NilError(t, world.Tick(context.Background()))
+
+	runCQL := func(req cql.QueryRequest) *http.Response {
+		bz, err := json.Marshal(req)
+		assert.NilError(t, err)
+		resp, err := http.Post(txh.MakeHTTPURL("query/game/cql"), "application/json", bytes.NewReader(bz))
+		assert.NilError(t, err)
+		return resp
+	}
+	resp := runCQL(cql.QueryRequest{Name: "all-alphas"})
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, 200)
+	var entities []cql.QueryResponse
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&entities))
+	assert.Equal(t, 3, len(entities))
+
+	unknownResp := runCQL(cql.QueryRequest{Name: "nope"})
+	defer unknownResp.Body.Close()
+	assert.Equal(t, unknownResp.StatusCode, 422)
+
+	// Unknown component names in a registered CQL query are reported when the game state is loaded.
+	otherWorld := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, otherWorld.RegisterCQLQuery("all-betas", "CONTAINS(beta)"))
+	assert.ErrorContains(t, otherWorld.LoadGameState(), "CQL query all-betas is not valid")
+}
+
+func TestCQLQueriesOverTheResultCapAreRejected(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, ecs.RegisterComponent[garbageStructAlpha](world))
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.WithMaxCQLResults(2))
+
+	wCtx := ecs.NewWorldContext(world)
+	_, err := ecs.CreateMany(wCtx, 3, garbageStructAlpha{})
+	assert.NilError(t, err)
+	assert.NilError(t, world.Tick(context.Background()))
+
+	runCQL := func(req cql.QueryRequest) *http.Response {
+		bz, err := json.Marshal(req)
+		assert.NilError(t, err)
+		resp, err := http.Post(txh.MakeHTTPURL("query/game/cql"), "application/json", bytes.NewReader(bz))
+		assert.NilError(t, err)
+		return resp
+	}
+	resp := runCQL(cql.QueryRequest{CQL: "CONTAINS(alpha)"})
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusRequestEntityTooLarge)
+
+	// Queries that stay within the cap are served as usual.
+	limitedResp := runCQL(cql.QueryRequest{CQL: "CONTAINS(alpha)", Limit: 2})
+	defer limitedResp.Body.Close()
+	assert.Equal(t, limitedResp.StatusCode, 200)
+	var entities []cql.QueryResponse
+	assert.NilError(t, json.NewDecoder(limitedResp.Body).Decode(&entities))
+	assert.Equal(t, 2, len(entities))
 }
 
 func TestHandleWrappedTransactionWithNoSignatureVerification(t *testing.T) {
@@ -533,6 +1002,38 @@ func TestHandleWrappedTransactionWithNoSignatureVerification(t *testing.T) {
 	assert.NilError(t, err)
 }
 
+func TestUnknownTransactionFieldsCanBeIgnored(t *testing.T) {
+	bz, err := json.Marshal(map[string]any{
+		"personaTag":    "some_persona",
+		"namespace":     "some_namespace",
+		"nonce":         100,
+		"signature":     common.Bytes2Hex([]byte{1, 2, 3, 4}),
+		"body":          SendEnergyTx{From: "me", To: "you", Amount: 420},
+		"clientVersion": "2.0.0",
+	})
+	assert.NilError(t, err)
+	postTx := func(opts ...server.Option) *http.Response {
+		w := testutils.NewTestWorld(t).Instance()
+		sendTx := ecs.NewMessageType[SendEnergyTx, SendEnergyTxResult]("move")
+		assert.NilError(t, w.RegisterMessages(sendTx))
+		assert.NilError(t, w.LoadGameState())
+		opts = append(opts, server.DisableSignatureVerification())
+		txh := testutils.MakeTestTransactionHandler(t, w, opts...)
+		resp, err := http.Post(txh.MakeHTTPURL("tx/game/move"), "application/json", bytes.NewReader(bz))
+		assert.NilError(t, err)
+		return resp
+	}
+
+	// Unknown fields are rejected by default.
+	resp := postTx()
+	defer resp.Body.Close()
+	assert.Check(t, resp.StatusCode != 200)
+
+	resp = postTx(server.WithUnknownFieldsIgnored())
+	defer resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode, "request failed with body: %v", mustReadBody(t, resp))
+}
+
 func TestCanCreateAndVerifyPersonaSigner(t *testing.T) {
 	urlSet := []string{"tx/persona/create-persona", "query/persona/signer"}
 	world := testutils.NewTestWorld(t).Instance()
@@ -731,6 +1232,162 @@ func TestSigVerificationChecksNonce(t *testing.T) {
 	assert.NilError(t, err)
 }
 
+func TestIdenticalTransactionCanBeRetriedWithinNonceRetryWindow(t *testing.T) {
+	world := testutils.NewTestWorld(t, cardinal.WithNonceRetryWindow(time.Minute)).Instance()
+	assert.NilError(t, world.LoadGameState())
+	privateKey, err := crypto.GenerateKey()
+	assert.NilError(t, err)
+	txh := testutils.MakeTestTransactionHandler(t, world)
+	defer txh.Close()
+	namespace := world.Namespace().String()
+	signerAddr := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+
+	post := func(url string, tx *sign.Transaction) (int, server.TransactionReply) {
+		bz, err := tx.Marshal()
+		assert.NilError(t, err)
+		resp, err := http.Post(txh.MakeHTTPURL(url), "application/json", bytes.NewReader(bz))
+		assert.NilError(t, err)
+		defer resp.Body.Close()
+		var reply server.TransactionReply
+		if resp.StatusCode == 200 {
+			assert.NilError(t, json.NewDecoder(resp.Body).Decode(&reply))
+		}
+		return resp.StatusCode, reply
+	}
+	createPersonaTx, err := sign.NewSystemTransaction(privateKey, namespace, 100, ecs.CreatePersona{
+		PersonaTag:    "some_dude",
+		SignerAddress: signerAddr,
+	})
+	assert.NilError(t, err)
+	status, _ := post("tx/persona/create-persona", createPersonaTx)
+	assert.Equal(t, 200, status)
+	assert.NilError(t, world.Tick(context.Background()))
+	// System transactions can not be retried.
+	status, _ = post("tx/persona/create-persona", createPersonaTx)
+	assert.Equal(t, 401, status)
+
+	postAuthorize := func(address string) (int, server.TransactionReply) {
+		tx, err := sign.NewTransaction(privateKey, "some_dude", namespace, 101, ecs.AuthorizePersonaAddress{
+			Address: address,
+		})
+		assert.NilError(t, err)
+		return post("tx/game/authorize-persona-address", tx)
+	}
+
+	status, first := postAuthorize("0xabc")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, 1, world.GetTxQueueAmount())
+
+	// Resubmitting the identical transaction succeeds, but it is not queued a second time.
+	status, retry := postAuthorize("0xabc")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, first.TxHash, retry.TxHash)
+	assert.Equal(t, first.Tick, retry.Tick)
+	assert.Equal(t, 1, world.GetTxQueueAmount())
+
+	// A retry is not processed again after the original transaction was processed.
+	assert.NilError(t, world.Tick(context.Background()))
+	status, _ = postAuthorize("0xabc")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, 0, world.GetTxQueueAmount())
+
+	// A different transaction can not reuse the nonce.
+	status, _ = postAuthorize("0xdef")
+	assert.Equal(t, 401, status)
+}
+
+func TestCanQueryWhetherNonceIsUsed(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, world.LoadGameState())
+	privateKey, err := crypto.GenerateKey()
+	assert.NilError(t, err)
+	txh := testutils.MakeTestTransactionHandler(t, world)
+	signerAddr := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+
+	isNonceUsed := func(nonce uint64) bool {
+		body, err := json.Marshal(server.QueryNonceUsedRequest{SignerAddress: signerAddr, Nonce: nonce})
+		assert.NilError(t, err)
+		resp, err := http.Post(txh.MakeHTTPURL("query/persona/nonce-used"), "application/json", bytes.NewReader(body))
+		assert.NilError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, resp.StatusCode, 200)
+		var reply server.QueryNonceUsedResponse
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&reply))
+		return reply.Used
+	}
+	assert.Check(t, !isNonceUsed(100))
+
+	sigPayload, err := sign.NewSystemTransaction(privateKey, world.Namespace().String(), 100, ecs.CreatePersona{
+		PersonaTag:    "some_dude",
+		SignerAddress: signerAddr,
+	})
+	assert.NilError(t, err)
+	bz, err := sigPayload.Marshal()
+	assert.NilError(t, err)
+	resp, err := http.Post(txh.MakeHTTPURL("tx/persona/create-persona"), "application/json", bytes.NewReader(bz))
+	assert.NilError(t, err)
+	assert.Equal(t, resp.StatusCode, 200)
+
+	assert.Check(t, isNonceUsed(100))
+	assert.Check(t, !isNonceUsed(101))
+	// Checking a nonce must not use it up.
+	used, err := world.IsNonceUsed(signerAddr, 101)
+	assert.NilError(t, err)
+	assert.Check(t, !used)
+}
+
+func TestPersonaCanQueryItsOwnSignerComponent(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world)
+	defer txh.Close()
+	namespace := world.Namespace().String()
+
+	privateKey, err := crypto.GenerateKey()
+	assert.NilError(t, err)
+	signerAddr := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+	personaTag := "some_dude"
+	createPersonaTx, err := sign.NewSystemTransaction(privateKey, namespace, 100, ecs.CreatePersona{
+		PersonaTag:    personaTag,
+		SignerAddress: signerAddr,
+	})
+	assert.NilError(t, err)
+	bz, err := createPersonaTx.Marshal()
+	assert.NilError(t, err)
+	resp, err := http.Post(txh.MakeHTTPURL("tx/persona/create-persona"), "application/json", bytes.NewReader(bz))
+	assert.NilError(t, err)
+	assert.Equal(t, resp.StatusCode, 200)
+	assert.NilError(t, world.Tick(context.Background()))
+
+	postQueryPersonaMe := func(pk *ecdsa.PrivateKey, tag string, nonce uint64) *http.Response {
+		tx, err := sign.NewTransaction(pk, tag, namespace, nonce, map[string]any{})
+		assert.NilError(t, err)
+		bz, err := tx.Marshal()
+		assert.NilError(t, err)
+		resp, err := http.Post(txh.MakeHTTPURL("query/persona/me"), "application/json", bytes.NewReader(bz))
+		assert.NilError(t, err)
+		return resp
+	}
+
+	resp = postQueryPersonaMe(privateKey, personaTag, 101)
+	assert.Equal(t, resp.StatusCode, 200)
+	var reply server.QueryPersonaMeResponse
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&reply))
+	assert.Equal(t, reply.PersonaTag, personaTag)
+	assert.Equal(t, reply.SignerAddress, signerAddr)
+	assert.Equal(t, len(reply.AuthorizedAddresses), 0)
+
+	// Replaying the same signed request is rejected.
+	resp = postQueryPersonaMe(privateKey, personaTag, 101)
+	assert.Equal(t, resp.StatusCode, 401)
+
+	// Another key cannot read the persona's record.
+	otherKey, err := crypto.GenerateKey()
+	assert.NilError(t, err)
+	resp = postQueryPersonaMe(otherKey, personaTag, 102)
+	assert.Equal(t, resp.StatusCode, 401)
+}
+
 func TestOutOfOrderNonceIsOK(t *testing.T) {
 	url := "tx/persona/create-persona"
 	world := testutils.NewTestWorld(t).Instance()
@@ -778,6 +1435,308 @@ func TestOutOfOrderNonceIsOK(t *testing.T) {
 	claimNewPersonaTagWithNonce(3, false)
 }
 
+func TestNilQueryReplyCanBeReturnedAsNoContent(t *testing.T) {
+	w := testutils.NewTestWorld(t)
+	world := w.Instance()
+	type FooRequest struct {
+		Foo int `json:"foo"`
+	}
+	type FooResponse struct {
+		Foo int `json:"foo"`
+	}
+	handleFoo := func(_ cardinal.WorldContext, req *FooRequest) (*FooResponse, error) {
+		return nil, nil //nolint:nilnil // the nil reply is what is being tested
+	}
+	assert.NilError(t, cardinal.RegisterQuery[FooRequest, FooResponse](w, "foo", handleFoo,
+		cardinal.WithNilReplyPolicy[FooRequest, FooResponse](cardinal.NilReplyAsNoContent)))
+	assert.NilError(t, world.LoadGameState())
+
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification())
+
+	resp, err := http.Post(txh.MakeHTTPURL("query/game/foo"), "application/json", bytes.NewBufferString(`{"foo":5}`))
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusNoContent)
+	assert.Equal(t, len(mustReadBody(t, resp)), 0)
+}
+
+func TestSlowQueriesAreCounted(t *testing.T) {
+	w := testutils.NewTestWorld(t)
+	world := w.Instance()
+	type FooRequest struct {
+		Slow bool `json:"slow"`
+	}
+	type FooResponse struct{}
+	handleFoo := func(_ cardinal.WorldContext, req *FooRequest) (*FooResponse, error) {
+		if req.Slow {
+			time.Sleep(50 * time.Millisecond)
+		}
+		return &FooResponse{}, nil
+	}
+	assert.NilError(t, cardinal.RegisterQuery[FooRequest, FooResponse](w, "foo", handleFoo))
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification(),
+		server.WithSlowQueryThreshold(20*time.Millisecond))
+	defer txh.Close()
+
+	for _, slow := range []bool{true, false, true} {
+		resp := txh.Post("query/game/foo", FooRequest{Slow: slow})
+		assert.Equal(t, resp.StatusCode, 200)
+	}
+	assert.DeepEqual(t, []server.SlowQueryCount{{QueryName: "foo", Count: 2}}, txh.SlowQueryCounts())
+
+	resp := txh.Get("metrics")
+	assert.Equal(t, resp.StatusCode, 200)
+	body := mustReadBody(t, resp)
+	assert.Check(t, strings.Contains(body, `cardinal_slow_queries_total{query="foo"} 2`+"\n"), body)
+}
+
+func TestCancelledQueryRequestStopsTheQuery(t *testing.T) {
+	w := testutils.NewTestWorld(t)
+	world := w.Instance()
+	type FooRequest struct{}
+	type FooResponse struct{}
+	started := make(chan struct{})
+	stopped := make(chan error, 1)
+	handleFoo := func(wCtx cardinal.WorldContext, _ *FooRequest) (*FooResponse, error) {
+		close(started)
+		select {
+		case <-wCtx.Context().Done():
+			stopped <- wCtx.Context().Err()
+		case <-time.After(5 * time.Second):
+			stopped <- errors.New("the query was not cancelled")
+		}
+		return nil, wCtx.Context().Err()
+	}
+	assert.NilError(t, cardinal.RegisterQuery[FooRequest, FooResponse](w, "foo", handleFoo))
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification())
+	defer txh.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, txh.MakeHTTPURL("query/game/foo"),
+		strings.NewReader("{}"))
+	assert.NilError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	go func() {
+		<-started
+		cancel()
+	}()
+	resp, err := http.DefaultClient.Do(req)
+	if err == nil {
+		resp.Body.Close()
+	}
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, <-stopped, context.Canceled)
+}
+
+func TestDeprecatedQueryIsFlagged(t *testing.T) {
+	w := testutils.NewTestWorld(t)
+	world := w.Instance()
+	type FooRequest struct {
+		Foo int `json:"foo"`
+	}
+	type FooResponse struct {
+		Foo int `json:"foo"`
+	}
+	handleFoo := func(_ cardinal.WorldContext, req *FooRequest) (*FooResponse, error) {
+		return &FooResponse{Foo: req.Foo}, nil
+	}
+	sunset := time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC)
+	assert.NilError(t, cardinal.RegisterQuery[FooRequest, FooResponse](w, "foo", handleFoo,
+		cardinal.WithQueryDeprecation[FooRequest, FooResponse]("use foo-v2", sunset)))
+	assert.NilError(t, cardinal.RegisterQuery[FooRequest, FooResponse](w, "foo-v2", handleFoo))
+	assert.NilError(t, world.LoadGameState())
+
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification())
+
+	resp, err := http.Post(txh.MakeHTTPURL("query/game/foo"), "application/json", bytes.NewBufferString(`{"foo":5}`))
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, 200)
+	assert.Equal(t, resp.Header.Get("Deprecation"), "true")
+	assert.Equal(t, resp.Header.Get("Sunset"), sunset.Format(http.TimeFormat))
+	var reply FooResponse
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&reply))
+	assert.Equal(t, reply.Foo, 5)
+
+	resp2, err := http.Post(txh.MakeHTTPURL("query/game/foo-v2"), "application/json", bytes.NewBufferString(`{"foo":5}`))
+	assert.NilError(t, err)
+	defer resp2.Body.Close()
+	assert.Equal(t, resp2.Header.Get("Deprecation"), "")
+
+	resp3, err := http.Post(txh.MakeHTTPURL("query/http/endpoints"), "application/json", nil)
+	assert.NilError(t, err)
+	defer resp3.Body.Close()
+	var endpoints server.EndpointsResult
+	assert.NilError(t, json.NewDecoder(resp3.Body).Decode(&endpoints))
+	assert.DeepEqual(t, endpoints.DeprecatedQueryEndpoints, []server.DeprecatedEndpoint{
+		{Endpoint: "/query/game/foo", Message: "use foo-v2", Sunset: "2030-01-02T03:04:05Z"},
+	})
+}
+
+func TestStatsEndpoint(t *testing.T) {
+	w := testutils.NewTestWorld(t)
+	world := w.Instance()
+	assert.NilError(t, ecs.RegisterComponent[Alpha](world))
+	assert.NilError(t, world.LoadGameState())
+	_, err := ecs.CreateMany(ecs.NewWorldContext(world), 3, Alpha{})
+	assert.NilError(t, err)
+	assert.NilError(t, world.Tick(context.Background()))
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification())
+
+	resp, err := http.Post(txh.MakeHTTPURL("query/http/stats"), "application/json", nil)
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, 200)
+	var stats server.StatsReply
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&stats))
+	assert.Equal(t, 3, stats.EntityCount)
+	assert.Equal(t, len(world.GetComponents()), stats.ComponentCount)
+	assert.Equal(t, uint64(1), stats.Tick)
+}
+
+func TestConfigEndpoint(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world,
+		server.DisableSignatureVerification(),
+		server.WithMode("development"),
+		server.WithTickInterval(500*time.Millisecond),
+	)
+
+	resp, err := http.Post(txh.MakeHTTPURL("query/http/config"), "application/json", nil)
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, 200)
+	var config server.ConfigReply
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&config))
+	assert.DeepEqual(t, server.ConfigReply{
+		Namespace:             world.Namespace().String(),
+		Mode:                  "development",
+		TickIntervalMs:        500,
+		ReceiptHistorySize:    world.ReceiptHistorySize(),
+		EVMEnabled:            false,
+		SignatureVerification: false,
+	}, config)
+}
+
+func TestThroughputEndpoint(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	move := ecs.NewMessageType[SendEnergyTx, SendEnergyTxResult]("move")
+	assert.NilError(t, world.RegisterMessages(move))
+	world.RegisterSystem(func(wCtx ecs.WorldContext) error {
+		move.Each(wCtx, func(txData ecs.TxData[SendEnergyTx]) (SendEnergyTxResult, error) {
+			if txData.Msg.Amount == 0 {
+				return SendEnergyTxResult{}, errors.New("nothing to send")
+			}
+			return SendEnergyTxResult{}, nil
+		})
+		return nil
+	})
+	assert.NilError(t, world.LoadGameState())
+	move.AddToQueue(world, SendEnergyTx{Amount: 1}, testutils.UniqueSignature())
+	move.AddToQueue(world, SendEnergyTx{Amount: 2}, testutils.UniqueSignature())
+	move.AddToQueue(world, SendEnergyTx{Amount: 0}, testutils.UniqueSignature())
+	assert.NilError(t, world.Tick(context.Background()))
+	move.AddToQueue(world, SendEnergyTx{Amount: 3}, testutils.UniqueSignature())
+	assert.NilError(t, world.Tick(context.Background()))
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification())
+	defer txh.Close()
+
+	resp := txh.Post("tx/game/teleport", &sign.Transaction{
+		PersonaTag: "meow",
+		Namespace:  world.Namespace().String(),
+		Nonce:      1,
+		Signature:  "doesnt matter what goes in here",
+		Body:       []byte("{}"),
+	})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.NilError(t, resp.Body.Close())
+
+	resp, err := http.Post(txh.MakeHTTPURL("query/http/throughput"), "application/json", nil)
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, 200)
+	var throughput server.ThroughputReply
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&throughput))
+	assert.Equal(t, uint64(4), throughput.Processed)
+	assert.Equal(t, uint64(1), throughput.ProcessedLastTick)
+	assert.Equal(t, uint64(3), throughput.PeakPerTick)
+	assert.Check(t, throughput.PerSecond > 0)
+	assert.Equal(t, uint64(1), throughput.Failed)
+	assert.Equal(t, uint64(1), throughput.Rejected)
+	assert.DeepEqual(t, []server.TxRejectionCount{
+		{Reason: server.RejectionUnknownMessage, Count: 1, Rate: 0.2},
+	}, throughput.Rejections)
+	assert.Equal(t, 0.2, throughput.FailedRate)
+}
+
+func TestMetricsEndpointBreaksDownMessagesByName(t *testing.T) {
+	w := testutils.NewTestWorld(t, cardinal.WithMessageMetrics(map[string]string{"shard": "game"}))
+	world := w.Instance()
+	move := ecs.NewMessageType[SendEnergyTx, SendEnergyTxResult]("move")
+	attack := ecs.NewMessageType[SendEnergyTx, SendEnergyTxResult]("attack")
+	assert.NilError(t, world.RegisterMessages(move, attack))
+	world.RegisterSystem(func(wCtx ecs.WorldContext) error {
+		move.Each(wCtx, func(ecs.TxData[SendEnergyTx]) (SendEnergyTxResult, error) {
+			return SendEnergyTxResult{}, nil
+		})
+		attack.Each(wCtx, func(ecs.TxData[SendEnergyTx]) (SendEnergyTxResult, error) {
+			return SendEnergyTxResult{}, errors.New("out of range")
+		})
+		return nil
+	})
+	assert.NilError(t, world.LoadGameState())
+	move.AddToQueue(world, SendEnergyTx{Amount: 1}, testutils.UniqueSignature())
+	move.AddToQueue(world, SendEnergyTx{Amount: 2}, testutils.UniqueSignature())
+	attack.AddToQueue(world, SendEnergyTx{Amount: 3}, testutils.UniqueSignature())
+	assert.NilError(t, world.Tick(context.Background()))
+	move.AddToQueue(world, SendEnergyTx{Amount: 4}, testutils.UniqueSignature())
+	assert.NilError(t, world.Tick(context.Background()))
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification())
+	defer txh.Close()
+
+	resp := txh.Get("metrics")
+	assert.Equal(t, resp.StatusCode, 200)
+	body := mustReadBody(t, resp)
+	for _, line := range []string{
+		`cardinal_messages_processed_total{message="attack",shard="game"} 1`,
+		`cardinal_messages_processed_total{message="move",shard="game"} 3`,
+		`cardinal_message_errors_total{message="attack",shard="game"} 1`,
+		`cardinal_message_errors_total{message="move",shard="game"} 0`,
+		`cardinal_message_results_total{message="attack",shard="game"} 0`,
+		`cardinal_message_results_total{message="move",shard="game"} 3`,
+		`cardinal_messages_processed_last_tick{message="attack",shard="game"} 0`,
+		`cardinal_messages_processed_last_tick{message="move",shard="game"} 1`,
+	} {
+		assert.Check(t, strings.Contains(body, line+"\n"), "missing %q in:\n%s", line, body)
+	}
+}
+
+func TestMetricsEndpointRequiresAnOption(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification())
+	defer txh.Close()
+
+	resp := txh.Get("metrics")
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusNotFound)
+}
+
+func TestInvalidMetricLabelsAreRejected(t *testing.T) {
+	for _, labels := range []map[string]string{
+		{"shard-name": "game"},
+		{"__name__": "game"},
+		{"message": "game"},
+		{"shard": "\xff"},
+	} {
+		_, err := cardinal.NewMockWorld(cardinal.WithMessageMetrics(labels))
+		assert.ErrorIs(t, err, ecs.ErrInvalidMetricLabel)
+	}
+}
+
 // TestCanListQueries tests that we can list the available queries in the handler.
 func TestCanListQueries(t *testing.T) {
 	w := testutils.NewTestWorld(t)
@@ -825,7 +1784,12 @@ func TestCanListQueries(t *testing.T) {
 		"/query/game/bar",
 		"/query/game/baz",
 		"/query/http/endpoints",
+		"/query/http/stats",
+		"/query/http/config",
+		"/query/http/throughput",
 		"/query/persona/signer",
+		"/query/persona/nonce-used",
+		"/query/persona/me",
 		"/query/receipt/list",
 		"/query/game/cql",
 	}
@@ -883,6 +1847,69 @@ func TestQueryEncodeDecode(t *testing.T) {
 	assert.NilError(t, err)
 }
 
+func TestQueryEncodeDecodeWithMsgPack(t *testing.T) {
+	type FooRequest struct {
+		Foo  int    `json:"foo,omitempty"`
+		Meow string `json:"bar,omitempty"`
+	}
+	type FooResponse struct {
+		Meow string `json:"meow,omitempty"`
+	}
+
+	handleFooQuery := func(wCtx cardinal.WorldContext, req *FooRequest) (*FooResponse, error) {
+		return &FooResponse{Meow: req.Meow}, nil
+	}
+
+	w := testutils.NewTestWorld(t)
+	world := w.Instance()
+	assert.NilError(t, cardinal.RegisterQuery[FooRequest, FooResponse](w, "foo", handleFooQuery))
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification())
+
+	bz, err := msgpack.Marshal(map[string]any{"foo": 12, "bar": "hello"})
+	assert.NilError(t, err)
+	req, err := http.NewRequestWithContext(
+		context.Background(), http.MethodPost, txh.MakeHTTPURL("query/game/foo"), bytes.NewReader(bz),
+	)
+	assert.NilError(t, err)
+	req.Header.Set("Content-Type", "application/msgpack")
+	req.Header.Set("Accept", "application/msgpack")
+	res, err := http.DefaultClient.Do(req)
+	assert.NilError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, res.StatusCode, 200)
+	assert.Equal(t, res.Header.Get("Content-Type"), "application/msgpack")
+
+	var fooRes map[string]any
+	assert.NilError(t, msgpack.NewDecoder(res.Body).Decode(&fooRes))
+	assert.Equal(t, fooRes["meow"], "hello")
+}
+
+func TestSignedRequestsMustBeJSON(t *testing.T) {
+	world := testutils.NewTestWorld(t).Instance()
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world)
+
+	privateKey, err := crypto.GenerateKey()
+	assert.NilError(t, err)
+	tx, err := sign.NewSystemTransaction(privateKey, world.Namespace().String(), 100, ecs.CreatePersona{
+		PersonaTag:    "CoolMage",
+		SignerAddress: crypto.PubkeyToAddress(privateKey.PublicKey).Hex(),
+	})
+	assert.NilError(t, err)
+	bz, err := msgpack.Marshal(tx)
+	assert.NilError(t, err)
+	req, err := http.NewRequestWithContext(
+		context.Background(), http.MethodPost, txh.MakeHTTPURL("tx/persona/create-persona"), bytes.NewReader(bz),
+	)
+	assert.NilError(t, err)
+	req.Header.Set("Content-Type", "application/msgpack")
+	res, err := http.DefaultClient.Do(req)
+	assert.NilError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, res.StatusCode, http.StatusUnsupportedMediaType)
+}
+
 func TestMalformedRequestToGetTransactionReceiptsProducesError(t *testing.T) {
 	url := "query/receipts/list"
 	world := testutils.NewTestWorld(t).Instance()
@@ -1270,6 +2297,81 @@ func TestTransactionsSubmittedToChain(t *testing.T) {
 	assert.Equal(t, adapter.called, 2)
 }
 
+type failingAdapterMock struct {
+	adapterMock
+}
+
+func (a *failingAdapterMock) Submit(_ context.Context, _ *sign.Transaction, _, _ uint64) error {
+	a.called++
+	return errors.New("base shard is unavailable")
+}
+
+func TestRequiredAdapterRejectsTransactionsThatFailToSubmit(t *testing.T) {
+	w := testutils.NewTestWorld(t)
+	world := w.Instance()
+	assert.NilError(t, world.LoadGameState())
+	adapter := failingAdapterMock{}
+	txh := testutils.MakeTestTransactionHandler(
+		t, world, server.WithAdapter(&adapter),
+		server.WithRequiredAdapter(),
+		server.DisableSignatureVerification(),
+	)
+
+	privateKey, err := crypto.GenerateKey()
+	assert.NilError(t, err)
+	sigPayload, err := sign.NewSystemTransaction(
+		privateKey, world.Namespace().String(), 1,
+		ecs.CreatePersona{
+			PersonaTag:    "clifford_the_big_red_dog",
+			SignerAddress: crypto.PubkeyToAddress(privateKey.PublicKey).Hex(),
+		},
+	)
+	assert.NilError(t, err)
+	bz, err := sigPayload.Marshal()
+	assert.NilError(t, err)
+
+	resp, err := http.Post(txh.MakeHTTPURL("tx/persona/create-persona"), "application/json", bytes.NewReader(bz))
+	assert.NilError(t, err)
+	assert.NotEqual(t, 200, resp.StatusCode)
+	assert.Equal(t, adapter.called, 1)
+	// The transaction must not be processed locally if it was not persisted.
+	assert.Equal(t, 0, world.GetTxQueueAmount())
+}
+
+func TestReadReplicaRejectsTransactions(t *testing.T) {
+	w := testutils.NewTestWorld(t, cardinal.WithReadReplica())
+	world := w.Instance()
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification())
+
+	privateKey, err := crypto.GenerateKey()
+	assert.NilError(t, err)
+	sigPayload, err := sign.NewSystemTransaction(
+		privateKey, world.Namespace().String(), 1,
+		ecs.CreatePersona{
+			PersonaTag:    "replica",
+			SignerAddress: crypto.PubkeyToAddress(privateKey.PublicKey).Hex(),
+		},
+	)
+	assert.NilError(t, err)
+	bz, err := sigPayload.Marshal()
+	assert.NilError(t, err)
+
+	resp, err := http.Post(txh.MakeHTTPURL("tx/persona/create-persona"), "application/json", bytes.NewReader(bz))
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, 0, world.GetTxQueueAmount())
+}
+
+func TestRequiredAdapterIsEnforcedAtStartup(t *testing.T) {
+	w := testutils.NewTestWorld(t)
+	world := w.Instance()
+	assert.NilError(t, world.LoadGameState())
+	_, err := server.NewHandler(world, nil, server.WithRequiredAdapter())
+	assert.ErrorIs(t, err, ecs.ErrAdapterRequired)
+}
+
 func TestWebSocket(t *testing.T) {
 	w := testutils.NewTestWorld(t)
 	world := w.Instance()
@@ -1293,6 +2395,29 @@ func TestWebSocket(t *testing.T) {
 	assert.NilError(t, err)
 }
 
+func TestWebSocketOriginsCanBeRestricted(t *testing.T) {
+	w := testutils.NewTestWorld(t)
+	world := w.Instance()
+	assert.NilError(t, world.LoadGameState())
+	txh := testutils.MakeTestTransactionHandler(t, world, server.DisableSignatureVerification(),
+		server.WithWebSocketOrigins("https://game.example.com"))
+
+	for _, path := range []string{"echo", "events"} {
+		url := txh.MakeWebSocketURL(path)
+		_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://evil.example.com"}})
+		assert.Check(t, err != nil)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.NilError(t, resp.Body.Close())
+
+		// Allowed origins and non-browser clients that send no origin can connect.
+		for _, header := range []http.Header{{"Origin": {"https://game.example.com/"}}, nil} {
+			conn, _, err := websocket.DefaultDialer.Dial(url, header)
+			assert.NilError(t, err)
+			assert.NilError(t, conn.Close())
+		}
+	}
+}
+
 func TestEmptyFieldsAreOKForDisabledSignatureVerification(t *testing.T) {
 	w := testutils.NewTestWorld(t).Instance()
 
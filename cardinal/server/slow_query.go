@@ -0,0 +1,51 @@
+package server
+
+import (
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"pkg.world.dev/world-engine/cardinal/ecs"
+)
+
+// SlowQueryCount is the number of times a query took longer than the threshold given with WithSlowQueryThreshold.
+type SlowQueryCount struct {
+	QueryName string
+	Count     uint64
+}
+
+// handleQueryRaw runs the given query and, when a slow query threshold is set, logs and counts the query if its handler
+// took longer than the threshold.
+func (handler *Handler) handleQueryRaw(q ecs.Query, wCtx ecs.WorldContext, request []byte) ([]byte, error) {
+	if handler.slowQueryThreshold <= 0 {
+		return q.HandleQueryRaw(wCtx, request)
+	}
+	startTime := time.Now()
+	reply, err := q.HandleQueryRaw(wCtx, request)
+	elapsed := time.Since(startTime)
+	if elapsed > handler.slowQueryThreshold {
+		log.Warn().
+			Str("query", q.Name()).
+			Int("request_size", len(request)).
+			Int("execution_time_ms", int(elapsed.Milliseconds())).
+			Msg("slow query")
+		handler.slowQueriesMutex.Lock()
+		handler.slowQueries[q.Name()]++
+		handler.slowQueriesMutex.Unlock()
+	}
+	return reply, err
+}
+
+// SlowQueryCounts returns how often each query exceeded the slow query threshold, sorted by query name.
+func (handler *Handler) SlowQueryCounts() []SlowQueryCount {
+	handler.slowQueriesMutex.Lock()
+	defer handler.slowQueriesMutex.Unlock()
+	counts := make([]SlowQueryCount, 0, len(handler.slowQueries))
+	for name, count := range handler.slowQueries {
+		counts = append(counts, SlowQueryCount{QueryName: name, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].QueryName < counts[j].QueryName
+	})
+	return counts
+}
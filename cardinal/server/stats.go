@@ -0,0 +1,32 @@
+package server
+
+import (
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware/untyped"
+)
+
+// StatsReply is a high level snapshot of the world returned by /query/http/stats.
+type StatsReply struct {
+	EntityCount    int    `json:"entityCount"`
+	ComponentCount int    `json:"componentCount"`
+	Tick           uint64 `json:"tick"`
+	UptimeSeconds  int64  `json:"uptimeSeconds"`
+}
+
+func (handler *Handler) registerStatsHandlerSwagger(api *untyped.API) {
+	statsHandler := runtime.OperationHandlerFunc(func(params interface{}) (interface{}, error) {
+		entityCount, err := handler.w.EntityCount()
+		if err != nil {
+			return nil, err
+		}
+		return StatsReply{
+			EntityCount:    entityCount,
+			ComponentCount: len(handler.w.GetComponents()),
+			Tick:           handler.w.CurrentTick(),
+			UptimeSeconds:  int64(time.Since(handler.startTime).Seconds()),
+		}, nil
+	})
+	api.RegisterOperation("POST", "/query/http/stats", statsHandler)
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware/untyped"
+	"github.com/rotisserie/eris"
+	"pkg.world.dev/world-engine/cardinal/ecs"
+	storage "pkg.world.dev/world-engine/cardinal/ecs/storage/redis"
+)
+
+// The reasons a transaction can be rejected by the server before it reaches the world. See ThroughputReply.
+const (
+	RejectionInvalidSignature = "invalid-signature"
+	RejectionNonceUsed        = "nonce-already-used"
+	RejectionUnknownPersona   = "unknown-persona"
+	RejectionUnknownMessage   = "unknown-message"
+	RejectionValidationFailed = "validation-failed"
+	RejectionReadReplica      = "read-replica"
+	RejectionOther            = "other"
+)
+
+// ThroughputReply is the transaction throughput of the world returned by /query/http/throughput. Rates are the share
+// of all submitted transactions, i.e. the processed transactions plus the rejected ones.
+type ThroughputReply struct {
+	Tick uint64 `json:"tick"`
+	// Processed is the number of transactions processed by ticks since the world started.
+	Processed         uint64 `json:"processed"`
+	ProcessedLastTick uint64 `json:"processedLastTick"`
+	PeakPerTick       uint64 `json:"peakPerTick"`
+	// PerSecond is averaged over the last WindowSeconds.
+	PerSecond     float64 `json:"perSecond"`
+	WindowSeconds int     `json:"windowSeconds"`
+	// Failed is the number of processed transactions whose receipt had an error.
+	Failed     uint64             `json:"failed"`
+	FailedRate float64            `json:"failedRate"`
+	Rejected   uint64             `json:"rejected"`
+	Rejections []TxRejectionCount `json:"rejections"`
+}
+
+// TxRejectionCount is the number of transactions the server rejected for a single reason.
+type TxRejectionCount struct {
+	Reason string  `json:"reason"`
+	Count  uint64  `json:"count"`
+	Rate   float64 `json:"rate"`
+}
+
+// rejectionReason returns the reason a transaction that failed with the given error is counted under.
+func rejectionReason(err error) string {
+	switch {
+	case eris.Is(err, ErrInvalidSignature), eris.Is(err, ErrSystemTransactionRequired),
+		eris.Is(err, ErrSystemTransactionForbidden):
+		return RejectionInvalidSignature
+	case eris.Is(err, storage.ErrNonceHasAlreadyBeenUsed):
+		return RejectionNonceUsed
+	case eris.Is(err, ecs.ErrPersonaTagHasNoSigner):
+		return RejectionUnknownPersona
+	case errors.Is(err, ErrMessageValidationFailed):
+		return RejectionValidationFailed
+	case eris.Is(err, ecs.ErrReadReplica):
+		return RejectionReadReplica
+	default:
+		return RejectionOther
+	}
+}
+
+// countRejection counts a transaction that was rejected for the given reason.
+func (handler *Handler) countRejection(reason string) {
+	handler.txRejectionsMutex.Lock()
+	defer handler.txRejectionsMutex.Unlock()
+	if handler.txRejections == nil {
+		handler.txRejections = map[string]uint64{}
+	}
+	handler.txRejections[reason]++
+}
+
+// TxRejectionCounts returns how many transactions the server rejected for each reason, sorted by reason. The Rate of
+// the counts is not set.
+func (handler *Handler) TxRejectionCounts() []TxRejectionCount {
+	handler.txRejectionsMutex.Lock()
+	defer handler.txRejectionsMutex.Unlock()
+	counts := make([]TxRejectionCount, 0, len(handler.txRejections))
+	for reason, count := range handler.txRejections {
+		counts = append(counts, TxRejectionCount{Reason: reason, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Reason < counts[j].Reason
+	})
+	return counts
+}
+
+func (handler *Handler) registerThroughputHandlerSwagger(api *untyped.API) {
+	throughputHandler := runtime.OperationHandlerFunc(func(params interface{}) (interface{}, error) {
+		stats := handler.w.Throughput()
+		reply := ThroughputReply{
+			Tick:              handler.w.CurrentTick(),
+			Processed:         stats.Processed,
+			ProcessedLastTick: stats.ProcessedLastTick,
+			PeakPerTick:       stats.PeakPerTick,
+			PerSecond:         stats.PerSecond,
+			WindowSeconds:     int(ecs.ThroughputWindow / time.Second),
+			Failed:            stats.Failed,
+			Rejections:        handler.TxRejectionCounts(),
+		}
+		for _, rejection := range reply.Rejections {
+			reply.Rejected += rejection.Count
+		}
+		if submitted := reply.Processed + reply.Rejected; submitted > 0 {
+			reply.FailedRate = float64(reply.Failed) / float64(submitted)
+			for i := range reply.Rejections {
+				reply.Rejections[i].Rate = float64(reply.Rejections[i].Count) / float64(submitted)
+			}
+		}
+		return reply, nil
+	})
+	api.RegisterOperation("POST", "/query/http/throughput", throughputHandler)
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/rotisserie/eris"
@@ -16,13 +17,17 @@ import (
 	"pkg.world.dev/world-engine/sign"
 )
 
-func (handler *Handler) processTransaction(tx message.Message, payload []byte, sp *sign.Transaction,
-) (*TransactionReply, error) {
+// decodeTransaction decodes the payload of a transaction of the given message type and validates it with the
+// validator given with ecs.WithMsgValidator.
+func decodeTransaction(tx message.Message, payload []byte) (any, error) {
 	txVal, err := tx.Decode(payload)
 	if err != nil {
 		return nil, eris.Wrap(err, "unable to decode transaction")
 	}
-	return handler.submitTransaction(txVal, tx, sp)
+	if err = message.Validate(tx, txVal); err != nil {
+		return nil, eris.Wrap(errors.Join(ErrMessageValidationFailed, err), "")
+	}
+	return txVal, nil
 }
 
 func getTxFromParams(pathParam string, params interface{}, txNameToTx map[string]message.Message,
@@ -46,9 +51,14 @@ func getTxFromParams(pathParam string, params interface{}, txNameToTx map[string
 	return tx, nil
 }
 
+// getBodyAndSigFromParams returns the payload and the verified signature of a transaction request. If validate is
+// set, it is called with the payload before the nonce of the transaction is used, so a rejected payload does not use
+// up the nonce. Errors of validate are returned as they are.
 func (handler *Handler) getBodyAndSigFromParams(
 	params interface{},
-	isSystemTransaction bool) ([]byte, *sign.Transaction, error) {
+	isSystemTransaction bool,
+	validate func(payload []byte) error,
+) ([]byte, *sign.Transaction, error) {
 	mappedParams, ok := params.(map[string]interface{})
 	if !ok {
 		return nil, nil, eris.New("params not readable")
@@ -61,10 +71,18 @@ func (handler *Handler) getBodyAndSigFromParams(
 	if !ok {
 		return nil, nil, eris.New("txBody needs to be a json object in the body")
 	}
-	payload, sp, err := handler.verifySignatureOfMapRequest(txBodyMap, isSystemTransaction)
+	payload, sp, err := handler.mapRequestToTransaction(txBodyMap)
 	if err != nil {
 		return nil, nil, eris.Wrap(err, "error verifying signature of map request")
 	}
+	if validate != nil {
+		if err = validate(payload); err != nil {
+			return nil, nil, err
+		}
+	}
+	if sp, err = handler.verifySignature(sp, isSystemTransaction); err != nil {
+		return nil, nil, eris.Wrap(eris.Wrap(err, ErrInvalidSignature.Error()), "error verifying signature of map request")
+	}
 	return payload, sp, nil
 }
 
@@ -82,20 +100,45 @@ func (handler *Handler) registerTxHandlerSwagger(api *untyped.API) error {
 	}
 
 	gameHandler := runtime.OperationHandlerFunc(func(params interface{}) (interface{}, error) {
-		payload, sp, err := handler.getBodyAndSigFromParams(params, false)
-		if err != nil {
-			return nil, err
+		if world.IsReadReplica() {
+			handler.countRejection(RejectionReadReplica)
+			return middleware.Error(http.StatusForbidden, eris.Wrap(ecs.ErrReadReplica, "")), nil
 		}
 		tx, err := getTxFromParams("txType", params, txNameToTx)
 		if err != nil {
+			handler.countRejection(RejectionUnknownMessage)
 			return middleware.Error(http.StatusNotFound, err), nil
 		}
-		return handler.processTransaction(tx, payload, sp)
+		var txVal any
+		_, sp, err := handler.getBodyAndSigFromParams(params, false, func(payload []byte) (err error) {
+			txVal, err = decodeTransaction(tx, payload)
+			return err
+		})
+		if err != nil {
+			handler.countRejection(rejectionReason(err))
+			if errors.Is(err, ErrMessageValidationFailed) {
+				return middleware.Error(http.StatusUnprocessableEntity, err.Error()), nil
+			}
+			return nil, err
+		}
+		txReply, err := handler.submitTransaction(txVal, tx, sp)
+		if err != nil {
+			handler.countRejection(rejectionReason(err))
+		}
+		if err == nil && handler.asyncTxResponses {
+			return handler.acceptedTxResponder(txReply), nil
+		}
+		return txReply, err
 	})
 
 	createPersonaHandler := runtime.OperationHandlerFunc(func(params interface{}) (interface{}, error) {
-		payload, sp, err := handler.getBodyAndSigFromParams(params, true)
+		if world.IsReadReplica() {
+			handler.countRejection(RejectionReadReplica)
+			return middleware.Error(http.StatusForbidden, eris.Wrap(ecs.ErrReadReplica, "")), nil
+		}
+		payload, sp, err := handler.getBodyAndSigFromParams(params, true, nil)
 		if err != nil {
+			handler.countRejection(rejectionReason(err))
 			if eris.Is(err, eris.Cause(ErrInvalidSignature)) || eris.Is(err, eris.Cause(ErrSystemTransactionRequired)) {
 				return middleware.Error(http.StatusUnauthorized, eris.ToString(err, true)), nil
 			}
@@ -104,6 +147,7 @@ func (handler *Handler) registerTxHandlerSwagger(api *untyped.API) error {
 
 		txReply, err := handler.generateCreatePersonaResponseFromPayload(payload, sp, ecs.CreatePersonaMsg)
 		if err != nil {
+			handler.countRejection(rejectionReason(err))
 			return nil, err
 		}
 		return &txReply, nil
@@ -118,11 +162,27 @@ func (handler *Handler) registerTxHandlerSwagger(api *untyped.API) error {
 // submitTransaction submits a transaction to the game world, as well as the blockchain.
 func (handler *Handler) submitTransaction(txVal any, tx message.Message, sp *sign.Transaction,
 ) (*TransactionReply, error) {
-	log.Debug().Msgf("submitting transaction %d: %v", tx.ID(), txVal)
-	tick, txHash := handler.w.AddTransaction(tx.ID(), txVal, sp)
+	log.Debug().Str("trace_id", sp.TraceID).Msgf("submitting transaction %d: %v", tx.ID(), txVal)
+	if handler.adapterRequired {
+		return handler.submitTransactionToAdapterFirst(txVal, tx, sp)
+	}
+	var retry *TransactionReply
+	tick, txHash, err := handler.w.AddTransactionAfter(tx.ID(), txVal, sp, func(_ context.Context, tick uint64) (
+		err error,
+	) {
+		retry, err = handler.acceptTx(sp, tick)
+		return err
+	})
+	if retry != nil {
+		return retry, nil
+	}
+	if err != nil {
+		return nil, handler.revokeTx(sp, eris.Wrap(err, "error queuing transaction"))
+	}
 	txReply := &TransactionReply{
-		TxHash: string(txHash),
-		Tick:   tick,
+		TxHash:  string(txHash),
+		Tick:    tick,
+		TraceID: sp.TraceID,
 	}
 	// check if we have an adapter
 	if handler.adapter != nil {
@@ -130,8 +190,9 @@ func (handler *Handler) submitTransaction(txVal any, tx message.Message, sp *sig
 		if handler.w.IsRecovering() {
 			return nil, eris.New("unable to submit transactions: game world is recovering state")
 		}
-		log.Debug().Msgf("TX %d: tick %d: hash %s: submitted to base shard", tx.ID(), txReply.Tick, txReply.TxHash)
-		err := handler.adapter.Submit(context.Background(), sp, uint64(tx.ID()), txReply.Tick)
+		log.Debug().Str("trace_id", sp.TraceID).
+			Msgf("TX %d: tick %d: hash %s: submitted to base shard", tx.ID(), txReply.Tick, txReply.TxHash)
+		err = handler.adapter.Submit(context.Background(), sp, uint64(tx.ID()), txReply.Tick)
 		if err != nil {
 			return nil, eris.Wrap(err, "error submitting transaction to base shard")
 		}
@@ -140,3 +201,66 @@ func (handler *Handler) submitTransaction(txVal any, tx message.Message, sp *sig
 	}
 	return txReply, nil
 }
+
+// submitTransactionToAdapterFirst submits the transaction to the blockchain and only adds it to the game world once
+// the submission succeeded. This guarantees that every transaction processed by the game world is persisted, with the
+// tick it is processed in.
+func (handler *Handler) submitTransactionToAdapterFirst(txVal any, tx message.Message, sp *sign.Transaction,
+) (*TransactionReply, error) {
+	if handler.w.IsRecovering() {
+		return nil, eris.New("unable to submit transactions: game world is recovering state")
+	}
+	var retry *TransactionReply
+	tick, txHash, err := handler.w.AddTransactionAfter(tx.ID(), txVal, sp, func(ctx context.Context, tick uint64) error {
+		var err error
+		if retry, err = handler.acceptTx(sp, tick); err != nil {
+			return err
+		}
+		if err = handler.adapter.Submit(ctx, sp, uint64(tx.ID()), tick); err != nil {
+			return eris.Wrap(err, "error submitting transaction to base shard")
+		}
+		log.Debug().Str("trace_id", sp.TraceID).Msgf("TX %d: tick %d: submitted to base shard", tx.ID(), tick)
+		return nil
+	})
+	if retry != nil {
+		return retry, nil
+	}
+	if err != nil {
+		return nil, handler.revokeTx(sp, err)
+	}
+	return &TransactionReply{
+		TxHash:  string(txHash),
+		Tick:    tick,
+		TraceID: sp.TraceID,
+	}, nil
+}
+
+// errTxIsRetry stops a retry of an accepted transaction from being queued again.
+var errTxIsRetry = errors.New("transaction was already accepted")
+
+// acceptTx records that the given transaction is about to be queued for the given tick. A byte-identical retry of a
+// transaction that was accepted within the nonce retry window gets the reply of the original submission, along with
+// errTxIsRetry so it is not queued again.
+func (handler *Handler) acceptTx(sp *sign.Transaction, tick uint64) (*TransactionReply, error) {
+	acceptedTick, isRetry, err := handler.w.AcceptTx(sp, tick)
+	if err != nil {
+		return nil, err
+	}
+	if !isRetry {
+		return nil, nil
+	}
+	return &TransactionReply{
+		TxHash:  sp.HashHex(),
+		Tick:    acceptedTick,
+		TraceID: sp.TraceID,
+	}, eris.Wrap(errTxIsRetry, "")
+}
+
+// revokeTx revokes the acceptance of a transaction that could not be queued, so a retry is not mistaken for a
+// transaction that was queued. The given error is returned.
+func (handler *Handler) revokeTx(sp *sign.Transaction, err error) error {
+	if revokeErr := handler.w.RevokeTx(sp); revokeErr != nil {
+		log.Error().Err(revokeErr).Str("tx_hash", sp.HashHex()).Msg("failed to revoke accepted transaction")
+	}
+	return err
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"reflect"
+	"strings"
 
 	"github.com/rotisserie/eris"
 	"pkg.world.dev/world-engine/cardinal/ecs"
@@ -15,9 +17,11 @@ var (
 	ErrSystemTransactionForbidden = errors.New("system transaction forbidden")
 )
 
-func decode[T any](buf []byte) (T, error) {
+func decode[T any](buf []byte, allowUnknownFields bool) (T, error) {
 	dec := json.NewDecoder(bytes.NewBuffer(buf))
-	dec.DisallowUnknownFields()
+	if !allowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
 	var val T
 	if err := dec.Decode(&val); err != nil {
 		return val, eris.Wrap(err, "error decoding")
@@ -25,8 +29,8 @@ func decode[T any](buf []byte) (T, error) {
 	return val, nil
 }
 
-func getSignerAddressFromPayload(sp sign.Transaction) (string, error) {
-	msg, err := decode[ecs.CreatePersona](sp.Body)
+func getSignerAddressFromPayload(sp sign.Transaction, allowUnknownFields bool) (string, error) {
+	msg, err := decode[ecs.CreatePersona](sp.Body, allowUnknownFields)
 	if err != nil {
 		return "", err
 	}
@@ -59,7 +63,7 @@ func (handler *Handler) verifySignature(sp *sign.Transaction, isSystemTransactio
 	var signerAddress string
 	if sp.IsSystemTransaction() {
 		// For system transactions, just use the signed address that is include in the signature.
-		signerAddress, err = getSignerAddressFromPayload(*sp)
+		signerAddress, err = getSignerAddressFromPayload(*sp, handler.allowUnknownFields)
 	} else {
 		// For non-system transaction, get the signer address from storage. If this PersonaTag doesn't exist,
 		// an error will be returned and the signature verification will fail.
@@ -75,7 +79,7 @@ func (handler *Handler) verifySignature(sp *sign.Transaction, isSystemTransactio
 	}
 
 	// The signature is valid. Verify and use the nonce in an atomic operation
-	if err = handler.w.UseNonce(signerAddress, sp.Nonce); err != nil {
+	if err = handler.w.UseNonceForTx(signerAddress, sp); err != nil {
 		return nil, eris.Wrap(err, "nonce verification failed")
 	}
 
@@ -91,18 +95,19 @@ func populatePlaceholderFields(request map[string]interface{}) {
 	}
 }
 
-func (handler *Handler) verifySignatureOfMapRequest(request map[string]interface{}, isSystemTransaction bool,
-) (payload []byte, sig *sign.Transaction, err error) {
+// mapRequestToTransaction converts a transaction request to a sign.Transaction, and returns it along with the payload
+// of the transaction. The signature is not verified; see verifySignature.
+func (handler *Handler) mapRequestToTransaction(request map[string]interface{},
+) (payload []byte, sp *sign.Transaction, err error) {
 	if handler.disableSigVerification {
 		populatePlaceholderFields(request)
 	}
-	sp, err := sign.MappedTransaction(request)
-	if err != nil {
-		return nil, nil, eris.Wrap(err, ErrInvalidSignature.Error())
+	if handler.allowUnknownFields {
+		removeUnknownTransactionFields(request)
 	}
-	sig, err = handler.verifySignature(sp, isSystemTransaction)
+	sp, err = sign.MappedTransaction(request)
 	if err != nil {
-		return nil, nil, eris.Wrapf(err, ErrInvalidSignature.Error())
+		return nil, nil, eris.Wrap(err, ErrInvalidSignature.Error())
 	}
 	if len(sp.Body) == 0 {
 		buf, err := json.Marshal(request)
@@ -111,6 +116,21 @@ func (handler *Handler) verifySignatureOfMapRequest(request map[string]interface
 		}
 		return buf, sp, nil
 	}
+	return sp.Body, sp, nil
+}
 
-	return sig.Body, sig, nil
+// removeUnknownTransactionFields deletes the fields of a transaction request that are not fields of sign.Transaction,
+// which sign.MappedTransaction would reject.
+func removeUnknownTransactionFields(request map[string]interface{}) {
+	txType := reflect.TypeOf(sign.Transaction{})
+	known := make(map[string]bool, txType.NumField())
+	for i := 0; i < txType.NumField(); i++ {
+		name, _, _ := strings.Cut(txType.Field(i).Tag.Get("json"), ",")
+		known[name] = true
+	}
+	for key := range request {
+		if !known[key] {
+			delete(request, key)
+		}
+	}
 }
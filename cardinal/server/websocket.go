@@ -0,0 +1,62 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"github.com/rotisserie/eris"
+	"pkg.world.dev/world-engine/cardinal/events"
+	"pkg.world.dev/world-engine/sign"
+)
+
+// withWebSocketOriginCheck rejects websocket upgrade requests whose Origin header is not one of the origins given with
+// WithWebSocketOrigins. Requests without an Origin header come from non-browser clients (e.g. the relay) and are
+// allowed, since cross-site websocket hijacking requires a browser, and browsers always send the header.
+func (handler *Handler) withWebSocketOriginCheck(next http.Handler) http.Handler {
+	if len(handler.webSocketOrigins) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !websocket.IsWebSocketUpgrade(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !handler.webSocketOrigins[normalizeOrigin(origin)] {
+			http.Error(w, "websocket origin not allowed", http.StatusForbidden)
+			return
+		}
+		// The origin has been verified above. Remove it so the websocket upgrader's default same origin check does not
+		// reject allowed cross origin requests.
+		r.Header.Del("Origin")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withEventsAuth authenticates websocket upgrade requests that carry an auth query parameter, so the connection is
+// listed with its persona tag by /debug/subscribers. The parameter is a JSON encoded signed transaction of the persona,
+// like the body of /query/persona/me, and its nonce is used up so it can not be replayed. Requests whose auth does not
+// verify are rejected with 401 Unauthorized, and requests without it connect anonymously.
+func (handler *Handler) withEventsAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.URL.Query().Get("auth")
+		if auth == "" || !websocket.IsWebSocketUpgrade(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		sp, err := sign.UnmarshalTransaction([]byte(auth))
+		if err == nil {
+			sp, err = handler.verifySignature(sp, false)
+		}
+		if err != nil {
+			http.Error(w, eris.ToString(eris.Wrap(err, ErrInvalidSignature.Error()), false), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(events.ContextWithPersonaTag(r.Context(), sp.PersonaTag)))
+	})
+}
+
+func normalizeOrigin(origin string) string {
+	return strings.ToLower(strings.TrimRight(origin, "/"))
+}
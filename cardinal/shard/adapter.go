@@ -35,7 +35,7 @@ type WriteAdapter interface {
 // QueryAdapter provides the functionality to query transactions from the EVM base shard.
 type QueryAdapter interface {
 	// QueryTransactions queries transactions stored on-chain. This is primarily used to rebuild state during world
-	// recovery.
+	// recovery. The bodies of transactions marked Compressed are gzipped, see DecompressBody.
 	QueryTransactions(
 		context.Context,
 		*shardtypes.QueryTransactionsRequest) (*shardtypes.QueryTransactionsResponse, error)
@@ -60,6 +60,9 @@ type adapterImpl struct {
 
 	// opts
 	creds credentials.TransportCredentials
+	// compressionMinSize is the size from which transaction bodies are compressed. Zero disables compression. See
+	// WithCompression.
+	compressionMinSize int
 }
 
 func loadClientCredentials(path string) (credentials.TransportCredentials, error) {
@@ -105,7 +108,13 @@ func NewAdapter(cfg AdapterConfig, opts ...Option) (Adapter, error) {
 }
 
 func (a adapterImpl) Submit(ctx context.Context, sp *sign.Transaction, txID uint64, epoch uint64) error {
-	req := &shardv1.SubmitShardTxRequest{Tx: transactionToProto(sp), Epoch: epoch, TxId: txID}
+	tx := transactionToProto(sp)
+	if a.compressionMinSize > 0 && len(tx.Body) >= a.compressionMinSize {
+		if err := compressTransaction(tx); err != nil {
+			return err
+		}
+	}
+	req := &shardv1.SubmitShardTxRequest{Tx: tx, Epoch: epoch, TxId: txID}
 	_, err := a.ShardSequencer.SubmitShardTx(ctx, req)
 	return eris.Wrap(err, "")
 }
@@ -0,0 +1,51 @@
+package shard
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"gotest.tools/v3/assert"
+
+	"pkg.world.dev/world-engine/sign"
+
+	shardv1 "pkg.world.dev/world-engine/rift/shard/v1"
+)
+
+type recordingSequencer struct {
+	reqs []*shardv1.SubmitShardTxRequest
+}
+
+func (r *recordingSequencer) SubmitShardTx(
+	_ context.Context,
+	in *shardv1.SubmitShardTxRequest,
+	_ ...grpc.CallOption,
+) (*shardv1.SubmitShardTxResponse, error) {
+	r.reqs = append(r.reqs, in)
+	return &shardv1.SubmitShardTxResponse{}, nil
+}
+
+func TestSubmitMarksCompressedTransactions(t *testing.T) {
+	sequencer := &recordingSequencer{}
+	a := adapterImpl{ShardSequencer: sequencer}
+	WithCompression(100)(&a)
+
+	small := []byte(`{"amount":1}`)
+	large := []byte(`{"data":"` + string(bytes.Repeat([]byte("a"), 1000)) + `"}`)
+	for i, body := range [][]byte{small, large} {
+		tx := &sign.Transaction{PersonaTag: "foo", Namespace: "bar", Nonce: uint64(i), Signature: "sig", Body: body}
+		assert.NilError(t, a.Submit(context.Background(), tx, 1, uint64(i)))
+	}
+	assert.Equal(t, len(sequencer.reqs), 2)
+
+	// Bodies below the minimum size are submitted as is.
+	assert.Check(t, !sequencer.reqs[0].Tx.Compressed)
+	assert.DeepEqual(t, sequencer.reqs[0].Tx.Body, small)
+
+	assert.Check(t, sequencer.reqs[1].Tx.Compressed)
+	assert.Check(t, len(sequencer.reqs[1].Tx.Body) < len(large))
+	body, err := DecompressBody(sequencer.reqs[1].Tx)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, body, large)
+}
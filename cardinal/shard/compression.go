@@ -0,0 +1,56 @@
+package shard
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	"github.com/rotisserie/eris"
+
+	shardv1 "pkg.world.dev/world-engine/rift/shard/v1"
+)
+
+// CompressBody gzips the given transaction body.
+func CompressBody(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(body); err != nil {
+		return nil, eris.Wrap(err, "failed to compress transaction body")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, eris.Wrap(err, "failed to compress transaction body")
+	}
+	return buf.Bytes(), nil
+}
+
+// compressTransaction gzips the body of the given transaction and marks it Compressed, unless compressing would not
+// make the body smaller.
+func compressTransaction(tx *shardv1.Transaction) error {
+	compressed, err := CompressBody(tx.Body)
+	if err != nil {
+		return err
+	}
+	if len(compressed) < len(tx.Body) {
+		tx.Body = compressed
+		tx.Compressed = true
+	}
+	return nil
+}
+
+// DecompressBody returns the original body of a transaction queried from the chain. The bodies of transactions that
+// are not marked Compressed are returned as is.
+func DecompressBody(tx *shardv1.Transaction) ([]byte, error) {
+	if !tx.Compressed {
+		return tx.Body, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(tx.Body))
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to decompress transaction body")
+	}
+	defer reader.Close()
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to decompress transaction body")
+	}
+	return decompressed, nil
+}
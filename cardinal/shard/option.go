@@ -4,6 +4,16 @@ import "github.com/rotisserie/eris"
 
 type Option func(adapter *adapterImpl)
 
+// WithCompression gzips the bodies of submitted transactions that are at least minSize bytes long, to reduce the cost
+// of storing them on chain. A body is only replaced if compressing makes it smaller, in which case the transaction is
+// marked Compressed. World recovery decompresses marked transactions with DecompressBody, so compression can be turned
+// on or off at any time.
+func WithCompression(minSize int) Option {
+	return func(a *adapterImpl) {
+		a.compressionMinSize = minSize
+	}
+}
+
 func WithCredentials(credPath string) Option {
 	return func(a *adapterImpl) {
 		if credPath == "" {
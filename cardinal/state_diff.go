@@ -0,0 +1,179 @@
+package cardinal
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/rotisserie/eris"
+	"pkg.world.dev/world-engine/cardinal/ecs"
+	"pkg.world.dev/world-engine/cardinal/ecs/filter"
+	"pkg.world.dev/world-engine/cardinal/types/entity"
+)
+
+// StateSnapshot is the format written by World.ExportState and read by DiffStates.
+type StateSnapshot struct {
+	Tick     uint64           `json:"tick"`
+	Entities []EntitySnapshot `json:"entities"`
+}
+
+// EntitySnapshot holds the values of all the components of one entity, keyed by component name.
+type EntitySnapshot struct {
+	ID         EntityID                   `json:"id"`
+	Components map[string]json.RawMessage `json:"components"`
+}
+
+// ComponentChange holds the values of a component before and after a change. Before is empty if the component was
+// added to the entity, and After is empty if the component was removed from the entity.
+type ComponentChange struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// Diff describes how one state snapshot differs from another. See DiffStates.
+type Diff struct {
+	// AddedEntities are only in the second snapshot.
+	AddedEntities []EntityID `json:"addedEntities"`
+	// RemovedEntities are only in the first snapshot.
+	RemovedEntities []EntityID `json:"removedEntities"`
+	// Changed holds, for each entity that is in both snapshots, the components whose values differ.
+	Changed map[EntityID]map[string]ComponentChange `json:"changed"`
+}
+
+// IsEmpty reports whether the two compared snapshots hold the same state.
+func (d Diff) IsEmpty() bool {
+	return len(d.AddedEntities) == 0 && len(d.RemovedEntities) == 0 && len(d.Changed) == 0
+}
+
+// ExportState writes the state of the last committed tick to out as a JSON encoded StateSnapshot, with entities sorted
+// by ID.
+func (w *World) ExportState(out io.Writer) error {
+	wCtx := ecs.NewReadOnlyWorldContext(w.instance)
+	defer ecs.PinTickSnapshot(wCtx)()
+	snapshot := StateSnapshot{
+		Tick:     w.instance.CurrentTick(),
+		Entities: []EntitySnapshot{},
+	}
+	var eachErr error
+	err := ecs.NewSearch(filter.All()).Each(wCtx, func(id entity.ID) bool {
+		comps, err := wCtx.StoreReader().GetComponentTypesForEntity(id)
+		if err != nil {
+			eachErr = err
+			return false
+		}
+		ent := EntitySnapshot{ID: id, Components: make(map[string]json.RawMessage, len(comps))}
+		for _, c := range comps {
+			data, err := wCtx.StoreReader().GetComponentForEntityInRawJSON(c, id)
+			if err != nil {
+				eachErr = err
+				return false
+			}
+			ent.Components[c.Name()] = data
+		}
+		snapshot.Entities = append(snapshot.Entities, ent)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if eachErr != nil {
+		return eachErr
+	}
+	sort.Slice(snapshot.Entities, func(i, j int) bool {
+		return snapshot.Entities[i].ID < snapshot.Entities[j].ID
+	})
+	return eris.Wrap(json.NewEncoder(out).Encode(snapshot), "")
+}
+
+// DiffStates compares two snapshots written by World.ExportState and reports the entities that were added or removed,
+// and the component values that changed, going from a to b. Component values are compared as JSON, so differences in
+// whitespace are ignored. This is useful to check that recovering a world produced the same state as the original.
+func DiffStates(a, b io.Reader) (Diff, error) {
+	before, err := readStateSnapshot(a)
+	if err != nil {
+		return Diff{}, err
+	}
+	after, err := readStateSnapshot(b)
+	if err != nil {
+		return Diff{}, err
+	}
+	diff := Diff{
+		AddedEntities:   []EntityID{},
+		RemovedEntities: []EntityID{},
+		Changed:         map[EntityID]map[string]ComponentChange{},
+	}
+	for id, beforeComps := range before {
+		afterComps, ok := after[id]
+		if !ok {
+			diff.RemovedEntities = append(diff.RemovedEntities, id)
+			continue
+		}
+		changes, err := diffComponents(beforeComps, afterComps)
+		if err != nil {
+			return Diff{}, err
+		}
+		if len(changes) > 0 {
+			diff.Changed[id] = changes
+		}
+	}
+	for id := range after {
+		if _, ok := before[id]; !ok {
+			diff.AddedEntities = append(diff.AddedEntities, id)
+		}
+	}
+	sort.Slice(diff.AddedEntities, func(i, j int) bool {
+		return diff.AddedEntities[i] < diff.AddedEntities[j]
+	})
+	sort.Slice(diff.RemovedEntities, func(i, j int) bool {
+		return diff.RemovedEntities[i] < diff.RemovedEntities[j]
+	})
+	return diff, nil
+}
+
+func readStateSnapshot(r io.Reader) (map[EntityID]map[string]json.RawMessage, error) {
+	var snapshot StateSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, eris.Wrap(err, "failed to decode state snapshot")
+	}
+	entities := make(map[EntityID]map[string]json.RawMessage, len(snapshot.Entities))
+	for _, ent := range snapshot.Entities {
+		entities[ent.ID] = ent.Components
+	}
+	return entities, nil
+}
+
+func diffComponents(before, after map[string]json.RawMessage) (map[string]ComponentChange, error) {
+	changes := map[string]ComponentChange{}
+	for name, beforeValue := range before {
+		afterValue, ok := after[name]
+		if !ok {
+			changes[name] = ComponentChange{Before: beforeValue}
+			continue
+		}
+		equal, err := jsonEqual(beforeValue, afterValue)
+		if err != nil {
+			return nil, err
+		}
+		if !equal {
+			changes[name] = ComponentChange{Before: beforeValue, After: afterValue}
+		}
+	}
+	for name, afterValue := range after {
+		if _, ok := before[name]; !ok {
+			changes[name] = ComponentChange{After: afterValue}
+		}
+	}
+	return changes, nil
+}
+
+func jsonEqual(a, b json.RawMessage) (bool, error) {
+	var compactA, compactB bytes.Buffer
+	if err := json.Compact(&compactA, a); err != nil {
+		return false, eris.Wrap(err, "")
+	}
+	if err := json.Compact(&compactB, b); err != nil {
+		return false, eris.Wrap(err, "")
+	}
+	return bytes.Equal(compactA.Bytes(), compactB.Bytes()), nil
+}
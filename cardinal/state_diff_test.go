@@ -0,0 +1,71 @@
+package cardinal_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"pkg.world.dev/world-engine/assert"
+	"pkg.world.dev/world-engine/cardinal"
+	"pkg.world.dev/world-engine/cardinal/testutils"
+)
+
+func TestDiffStatesReportsAddedRemovedAndChangedEntities(t *testing.T) {
+	world := testutils.NewTestWorld(t)
+	assert.NilError(t, cardinal.RegisterComponent[Height](world))
+	assert.NilError(t, cardinal.RegisterComponent[Weight](world))
+	assert.NilError(t, world.Instance().LoadGameState())
+	wCtx := testutils.WorldToWorldContext(world)
+	ctx := context.Background()
+
+	unchanged, err := cardinal.Create(wCtx, Height{Inches: 1})
+	assert.NilError(t, err)
+	changed, err := cardinal.Create(wCtx, Height{Inches: 2})
+	assert.NilError(t, err)
+	removed, err := cardinal.Create(wCtx, Height{Inches: 3})
+	assert.NilError(t, err)
+	assert.NilError(t, world.Tick(ctx))
+	var before bytes.Buffer
+	assert.NilError(t, world.ExportState(&before))
+
+	// Exporting the same state twice produces an empty diff.
+	var same bytes.Buffer
+	assert.NilError(t, world.ExportState(&same))
+	diff, err := cardinal.DiffStates(bytes.NewReader(before.Bytes()), &same)
+	assert.NilError(t, err)
+	assert.Check(t, diff.IsEmpty())
+
+	assert.NilError(t, cardinal.SetComponent[Height](wCtx, changed, &Height{Inches: 20}))
+	assert.NilError(t, cardinal.AddComponentTo[Weight](wCtx, changed))
+	assert.NilError(t, cardinal.Remove(wCtx, removed))
+	added, err := cardinal.Create(wCtx, Weight{Pounds: 4})
+	assert.NilError(t, err)
+	assert.NilError(t, world.Tick(ctx))
+	var after bytes.Buffer
+	assert.NilError(t, world.ExportState(&after))
+
+	diff, err = cardinal.DiffStates(&before, &after)
+	assert.NilError(t, err)
+	assert.Check(t, !diff.IsEmpty())
+	assert.DeepEqual(t, []cardinal.EntityID{added}, diff.AddedEntities)
+	assert.DeepEqual(t, []cardinal.EntityID{removed}, diff.RemovedEntities)
+	assert.Equal(t, 1, len(diff.Changed))
+	_, ok := diff.Changed[unchanged]
+	assert.Check(t, !ok)
+	changes := diff.Changed[changed]
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, `{"Inches":2}`, string(changes["height"].Before))
+	assert.Equal(t, `{"Inches":20}`, string(changes["height"].After))
+	assert.Equal(t, 0, len(changes["weight"].Before))
+	assert.Equal(t, `{"Pounds":0}`, string(changes["weight"].After))
+}
+
+func TestDiffStatesIgnoresWhitespace(t *testing.T) {
+	snapshot := func(value string) *strings.Reader {
+		return strings.NewReader(`{"tick":1,"entities":[{"id":1,"components":{"height":` + value + `}}]}`)
+	}
+	diff, err := cardinal.DiffStates(snapshot(`{"Inches":1}`), snapshot(`{ "Inches": 1 }`))
+	assert.NilError(t, err)
+	assert.Check(t, diff.IsEmpty())
+}
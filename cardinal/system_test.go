@@ -9,6 +9,7 @@ import (
 	"pkg.world.dev/world-engine/assert"
 
 	"pkg.world.dev/world-engine/cardinal"
+	"pkg.world.dev/world-engine/cardinal/ecs"
 )
 
 type Health struct {
@@ -88,3 +89,35 @@ func TestCanRegisterMultipleSystem(t *testing.T) {
 	assert.Check(t, firstSystemCalled)
 	assert.Check(t, secondSystemCalled)
 }
+
+type PaymentService interface {
+	Charge(personaTag string, amount int) error
+}
+
+type fakePaymentService struct {
+	charged map[string]int
+}
+
+func (f *fakePaymentService) Charge(personaTag string, amount int) error {
+	f.charged[personaTag] += amount
+	return nil
+}
+
+func TestSystemsCanUseInjectedDependencies(t *testing.T) {
+	payments := &fakePaymentService{charged: map[string]int{}}
+	world, doTick := testutils.MakeWorldAndTicker(t, cardinal.WithDependency[PaymentService](payments))
+	err := cardinal.RegisterSystems(world, func(wCtx cardinal.WorldContext) error {
+		service, err := cardinal.GetDependency[PaymentService](wCtx)
+		if err != nil {
+			return err
+		}
+		return service.Charge("alice", 10)
+	})
+	assert.NilError(t, err)
+	doTick()
+	doTick()
+	assert.Equal(t, 20, payments.charged["alice"])
+
+	_, err = cardinal.GetDependency[*Health](testutils.WorldToWorldContext(world))
+	assert.ErrorIs(t, err, ecs.ErrDependencyNotFound)
+}
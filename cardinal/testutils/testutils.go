@@ -30,10 +30,7 @@ func MakeTestTransactionHandler(
 ) *TestTransactionHandler {
 	eventHub := events.CreateWebSocketEventHub()
 	world.SetEventHub(eventHub)
-	eventBuilder := events.CreateNewWebSocketBuilder(
-		"/events",
-		events.CreateWebSocketEventHandler(eventHub),
-	)
+	eventBuilder := events.CreateEventHubWebSocketBuilder("/events", eventHub, events.SubscriptionLimits{})
 	txh, err := server.NewHandler(world, eventBuilder, opts...)
 	assert.NilError(t, err)
 
@@ -44,6 +41,8 @@ func MakeTestTransactionHandler(
 	t.Cleanup(func() {
 		assert.NilError(t, txh.Close())
 	})
+	// Bind the port before serving so the actual port is known when server.WithPort("0") is used.
+	assert.NilError(t, txh.Listen())
 
 	go func() {
 		err = txh.Serve()
@@ -58,8 +57,8 @@ func MakeTestTransactionHandler(
 		_ = gameObject.Shutdown()
 	})
 
-	host := "localhost:4040"
-	healthURL := host + healthPath
+	host := "localhost:" + txh.Port
+	healthURL := host + txh.BasePath + healthPath
 	start := time.Now()
 	for {
 		assert.Check(
@@ -83,7 +82,8 @@ func MakeTestTransactionHandler(
 	}
 }
 
-// TestTransactionHandler is a helper struct that can start an HTTP server on port 4040 with the given world.
+// TestTransactionHandler is a helper struct that can start an HTTP server with the given world. The server runs on
+// port 4040 unless a different port is set via server.WithPort.
 type TestTransactionHandler struct {
 	*server.Handler
 	T        *testing.T
@@ -92,11 +92,11 @@ type TestTransactionHandler struct {
 }
 
 func (t *TestTransactionHandler) MakeHTTPURL(path string) string {
-	return "http://" + t.Host + "/" + path
+	return "http://" + t.Host + t.BasePath + "/" + path
 }
 
 func (t *TestTransactionHandler) MakeWebSocketURL(path string) string {
-	return "ws://" + t.Host + "/" + path
+	return "ws://" + t.Host + t.BasePath + "/" + path
 }
 
 func (t *TestTransactionHandler) Post(path string, payload any) *http.Response {
@@ -1,6 +1,8 @@
 package txpool
 
 import (
+	"sort"
+	"strings"
 	"sync"
 
 	"pkg.world.dev/world-engine/cardinal/types/message"
@@ -11,6 +13,8 @@ type TxQueue struct {
 	m          txMap
 	txsInQueue int
 	mux        *sync.Mutex
+	// consumed holds the IDs of the message types whose txs were read during the tick. See MarkConsumed.
+	consumed map[message.TypeID]bool
 }
 
 func NewTxQueue() *TxQueue {
@@ -42,6 +46,39 @@ func (t *TxQueue) GetEVMTxs() []TxData {
 	return transactions
 }
 
+// GetTracedTxs gets all the txs in the queue that were tagged with a trace ID.
+// NOTE: this is called ONLY in the copied tx queue in world.Tick, so we do not need to use the mutex here.
+func (t *TxQueue) GetTracedTxs() []TxData {
+	transactions := make([]TxData, 0)
+	for _, txs := range t.m {
+		for _, tx := range txs {
+			if tx.Tx != nil && tx.Tx.TraceID != "" {
+				transactions = append(transactions, tx)
+			}
+		}
+	}
+	return transactions
+}
+
+// GetPersonaTags gets the distinct, sorted persona tags that signed at least one tx in the queue. Empty persona tags
+// and the system persona tag are excluded.
+// NOTE: this is called ONLY in the copied tx queue in world.Tick, so we do not need to use the mutex here.
+func (t *TxQueue) GetPersonaTags() []string {
+	seen := map[string]bool{}
+	tags := make([]string, 0)
+	for _, txs := range t.m {
+		for _, tx := range txs {
+			if tx.Tx == nil || tx.Tx.PersonaTag == "" || tx.Tx.IsSystemTransaction() || seen[tx.Tx.PersonaTag] {
+				continue
+			}
+			seen[tx.Tx.PersonaTag] = true
+			tags = append(tags, tx.Tx.PersonaTag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
 func (t *TxQueue) AddTransaction(id message.TypeID, v any, sig *sign.Transaction) message.TxHash {
 	return t.addTransaction(id, v, sig, "")
 }
@@ -74,9 +111,111 @@ func (t *TxQueue) CopyTransactions() *TxQueue {
 	return &cpy
 }
 
+// Clone returns a copy of the TxQueue that can be modified without affecting the original.
+func (t *TxQueue) Clone() *TxQueue {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	cpy := NewTxQueue()
+	for id, txs := range t.m {
+		cpy.m[id] = append([]TxData(nil), txs...)
+	}
+	cpy.txsInQueue = t.txsInQueue
+	return cpy
+}
+
 func (t *TxQueue) reset() {
 	t.m = txMap{}
 	t.txsInQueue = 0
+	t.consumed = nil
+}
+
+// MarkConsumed records that the txs of the given message type were read by a system.
+// NOTE: this is called ONLY in the copied tx queue in world.Tick, so we do not need to use the mutex here.
+func (t *TxQueue) MarkConsumed(id message.TypeID) {
+	if t.consumed == nil {
+		t.consumed = map[message.TypeID]bool{}
+	}
+	t.consumed[id] = true
+}
+
+// GetAmountOfConsumedTxs returns the number of txs whose message type was marked as consumed.
+// NOTE: this is called ONLY in the copied tx queue in world.Tick, so we do not need to use the mutex here.
+func (t *TxQueue) GetAmountOfConsumedTxs() int {
+	amount := 0
+	for id := range t.consumed {
+		amount += len(t.m[id])
+	}
+	return amount
+}
+
+// GetUnconsumedTxs gets all the txs in the queue whose message type was never marked as consumed.
+// NOTE: this is called ONLY in the copied tx queue in world.Tick, so we do not need to use the mutex here.
+func (t *TxQueue) GetUnconsumedTxs() []TxData {
+	transactions := make([]TxData, 0)
+	for id, txs := range t.m {
+		if t.consumed[id] {
+			continue
+		}
+		transactions = append(transactions, txs...)
+	}
+	return transactions
+}
+
+// GetTxs gets all the txs in the queue, ordered by message type ID and then by the order they were added in.
+// NOTE: this is called ONLY in the copied tx queue in world.Tick, so we do not need to use the mutex here.
+func (t *TxQueue) GetTxs() []TxData {
+	ids := make([]message.TypeID, 0, len(t.m))
+	for id := range t.m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	transactions := make([]TxData, 0, t.txsInQueue)
+	for _, id := range ids {
+		transactions = append(transactions, t.m[id]...)
+	}
+	return transactions
+}
+
+// OrderByNonce reorders the txs of each message type so that the txs signed by the same persona are in nonce order,
+// and so are applied in the order the persona submitted them. The txs of a persona keep the positions they took in
+// the queue, so the order between personas is unchanged. System transactions are left as they are.
+// Txs of different message types are not ordered against each other: each message type is handed to the systems
+// separately, so e.g. a move and an attack of the same persona are applied in the order of the systems that handle
+// them, not in nonce order.
+// NOTE: this is called ONLY in the copied tx queue in world.Tick, so we do not need to use the mutex here.
+func (t *TxQueue) OrderByNonce() {
+	for _, txs := range t.m {
+		orderByNonce(txs)
+	}
+}
+
+func orderByNonce(txs []TxData) {
+	positions := map[string][]int{}
+	for i, tx := range txs {
+		if tx.Tx == nil || tx.Tx.IsSystemTransaction() {
+			continue
+		}
+		// Persona tags are case-insensitive.
+		persona := strings.ToLower(tx.Tx.PersonaTag)
+		positions[persona] = append(positions[persona], i)
+	}
+	for _, indexes := range positions {
+		if len(indexes) < 2 {
+			continue
+		}
+		personaTxs := make([]TxData, 0, len(indexes))
+		for _, i := range indexes {
+			personaTxs = append(personaTxs, txs[i])
+		}
+		sort.SliceStable(personaTxs, func(i, j int) bool {
+			return personaTxs[i].Tx.Nonce < personaTxs[j].Tx.Nonce
+		})
+		for k, i := range indexes {
+			txs[i] = personaTxs[k]
+		}
+	}
 }
 
 func (t *TxQueue) ForID(id message.TypeID) []TxData {
@@ -3,6 +3,7 @@ package component
 import (
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/invopop/jsonschema"
 	"github.com/rotisserie/eris"
@@ -26,6 +27,12 @@ type (
 		Decode([]byte) (any, error)
 		Name() string
 		GetSchema() []byte
+		// TTL returns the number of ticks this component stays on an entity before it is removed automatically. 0
+		// means the component never expires.
+		TTL() uint64
+		// RedisTTL returns how long the component of an entity stays in redis after it was last written. 0 means the
+		// component never expires.
+		RedisTTL() time.Duration
 	}
 
 	Component interface {
@@ -57,12 +64,22 @@ type componentMetadata[T any] struct {
 	name       string
 	defaultVal interface{}
 	schema     []byte
+	ttl        uint64
+	redisTTL   time.Duration
 }
 
 func (c *componentMetadata[T]) GetSchema() []byte {
 	return c.schema
 }
 
+func (c *componentMetadata[T]) TTL() uint64 {
+	return c.ttl
+}
+
+func (c *componentMetadata[T]) RedisTTL() time.Duration {
+	return c.redisTTL
+}
+
 // SetID set's this component's ID. It must be unique across the world object.
 func (c *componentMetadata[T]) SetID(id TypeID) error {
 	if c.isIDSet {
@@ -140,11 +157,18 @@ func newComponentType[T Component](s T, name string, defaultVal interface{}) (*c
 	return componentType, nil
 }
 
+// IsOfType reports if the given component was created for the Go type T.
+func IsOfType[T Component](c ComponentMetadata) bool {
+	_, ok := c.(*componentMetadata[T])
+	return ok
+}
+
 // ComponentOption is a type that can be passed to NewComponentMetadata to augment the creation
 // of the component type.
 type ComponentOption[T any] func(c *componentMetadata[T]) //revive:disable-line:exported
 
-// WithDefault updated the created componentMetadata with a default value.
+// WithDefault updated the created componentMetadata with a default value. The default is used when the component is
+// added to an entity, and when an entity is created with the zero value of the component.
 func WithDefault[T any](defaultVal T) ComponentOption[T] {
 	return func(c *componentMetadata[T]) {
 		c.defaultVal = defaultVal
@@ -152,6 +176,26 @@ func WithDefault[T any](defaultVal T) ComponentOption[T] {
 	}
 }
 
+// WithTTL makes the component expire the given number of ticks after it was added to an entity, e.g. for a temporary
+// shield. Expired components are removed from their entity automatically.
+func WithTTL[T any](ticks uint64) ComponentOption[T] {
+	return func(c *componentMetadata[T]) {
+		c.ttl = ticks
+	}
+}
+
+// WithRedisTTL makes redis expire the component of an entity when it has not been written for the given duration, so
+// ephemeral state (e.g. of a match that was abandoned when its world crashed) cleans itself up. Unlike WithTTL, the
+// expiry is based on wall-clock time, and only happens while the world is not running: every commit of the world
+// refreshes the TTL of the components of live entities, so a component expires once the world stopped committing for
+// the given duration. The refreshes happen in the background, outside of the commits. When the world loads its state,
+// every entity that lost such a component is removed entirely.
+func WithRedisTTL[T any](ttl time.Duration) ComponentOption[T] {
+	return func(c *componentMetadata[T]) {
+		c.redisTTL = ttl
+	}
+}
+
 func SerializeComponentSchema(component Component) ([]byte, error) {
 	componentSchema := jsonschema.Reflect(component)
 	schema, err := componentSchema.MarshalJSON()
@@ -1,5 +1,7 @@
 package message
 
+import "github.com/invopop/jsonschema"
+
 type TxHash string
 
 // TypeID represents a message's ID. ID's are assigned to messages when they are registered in a World object.
@@ -19,3 +21,26 @@ type Message interface {
 	// IsEVMCompatible reports if this message can be sent from the EVM.
 	IsEVMCompatible() bool
 }
+
+// Validator is implemented by messages that can check a decoded message input before it is queued, such as the
+// messages created with ecs.NewMessageType.
+type Validator interface {
+	// Validate runs the validator the message was created with (if any) on a decoded message input.
+	Validate(any) error
+}
+
+// Validate validates the given decoded input of msg if msg implements Validator. Inputs of other messages are always
+// valid.
+func Validate(msg Message, v any) error {
+	if validator, ok := msg.(Validator); ok {
+		return validator.Validate(v)
+	}
+	return nil
+}
+
+// SchemaProvider is implemented by messages that can describe their input and output types, such as the messages
+// created with ecs.NewMessageType. Messages that do not implement it have no schema in the manifest of the world.
+type SchemaProvider interface {
+	// Schema returns the json schema of the message's input and output types.
+	Schema() (in, out *jsonschema.Schema)
+}
@@ -11,6 +11,7 @@ import (
 	"pkg.world.dev/world-engine/cardinal/txpool"
 
 	"pkg.world.dev/world-engine/cardinal/ecs"
+	"pkg.world.dev/world-engine/cardinal/ecs/receipt"
 	"pkg.world.dev/world-engine/cardinal/testutils"
 	"pkg.world.dev/world-engine/cardinal/types/entity"
 	"pkg.world.dev/world-engine/sign"
@@ -586,6 +587,39 @@ func TestSystemCanClobberTransactionResult(t *testing.T) {
 	assert.Equal(t, secondResult, gotResult)
 }
 
+func TestSystemCanAddManyTransactionResults(t *testing.T) {
+	type AttackIn struct {
+		Targets []int
+	}
+	type HitOut struct {
+		Target int
+	}
+	world := testutils.NewTestWorld(t).Instance()
+	attackTx := ecs.NewMessageType[AttackIn, HitOut]("attack")
+	assert.NilError(t, world.RegisterMessages(attackTx))
+	world.RegisterSystem(func(wCtx ecs.WorldContext) error {
+		for _, tx := range attackTx.In(wCtx) {
+			for _, target := range tx.Msg.Targets {
+				attackTx.AddResult(wCtx, tx.Hash, HitOut{Target: target})
+			}
+			results, errs, ok := attackTx.GetReceiptResults(wCtx, tx.Hash)
+			assert.Check(t, ok)
+			assert.Equal(t, 0, len(errs))
+			assert.DeepEqual(t, []HitOut{{1}, {2}, {3}}, results)
+		}
+		return nil
+	})
+	assert.NilError(t, world.LoadGameState())
+
+	_ = attackTx.AddToQueue(world, AttackIn{Targets: []int{1, 2, 3}})
+	assert.NilError(t, world.Tick(context.Background()))
+
+	receipts, err := world.GetTransactionReceiptsForTick(world.CurrentTick() - 1)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(receipts))
+	assert.DeepEqual(t, receipt.Results{HitOut{1}, HitOut{2}, HitOut{3}}, receipts[0].Result)
+}
+
 func TestCopyTransactions(t *testing.T) {
 	type FooMsg struct {
 		X int
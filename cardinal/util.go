@@ -2,6 +2,7 @@ package cardinal
 
 import (
 	"pkg.world.dev/world-engine/cardinal/ecs"
+	"pkg.world.dev/world-engine/cardinal/ecs/ecb"
 	"pkg.world.dev/world-engine/cardinal/server"
 	"pkg.world.dev/world-engine/cardinal/types/message"
 )
@@ -32,3 +33,14 @@ func separateOptions(opts []WorldOption) (ecsOptions []ecs.Option, serverOptions
 	}
 	return ecsOptions, serverOptions, cardinalOptions
 }
+
+// separateStoreOptions returns the options meant for the entity store manager.
+func separateStoreOptions(opts []WorldOption) []ecb.Option {
+	var storeOptions []ecb.Option
+	for _, opt := range opts {
+		if opt.storeOption != nil {
+			storeOptions = append(storeOptions, opt.storeOption)
+		}
+	}
+	return storeOptions
+}
@@ -0,0 +1,205 @@
+package cardinal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rotisserie/eris"
+	"github.com/rs/zerolog/log"
+)
+
+// The lifecycle events posted to the URL given with WithLifecycleWebhook.
+const (
+	LifecycleWorldStarted      = "world-started"
+	LifecycleWorldShuttingDown = "world-shutting-down"
+	LifecycleRecoveryStarted   = "recovery-started"
+	LifecycleRecoveryCompleted = "recovery-completed"
+	LifecycleTickFailed        = "tick-failed"
+)
+
+const (
+	// webhookPendingEvents is the number of lifecycle events that may wait to be delivered. Events are dropped when
+	// the webhook falls further behind, so the world never waits on it.
+	webhookPendingEvents = 64
+	webhookMaxAttempts   = 5
+	webhookFirstBackoff  = 500 * time.Millisecond
+	webhookTimeout       = 5 * time.Second
+	// webhookShutdownTimeout is how long ShutDown waits for the pending lifecycle events to be delivered.
+	webhookShutdownTimeout = 5 * time.Second
+	// webhookTickFailedTimeout is how long the game loop waits for a tick-failed event to be delivered before it
+	// panics or stops ticking.
+	webhookTickFailedTimeout = 5 * time.Second
+)
+
+// LifecycleEvent is the JSON body posted to the URL given with WithLifecycleWebhook.
+type LifecycleEvent struct {
+	Event     string    `json:"event"`
+	Namespace string    `json:"namespace"`
+	Tick      uint64    `json:"tick"`
+	Time      time.Time `json:"time"`
+	// Error is set for tick-failed events, and for recovery-completed events of a recovery that failed.
+	Error string `json:"error,omitempty"`
+}
+
+// lifecycleWebhook posts lifecycle events to a URL from a goroutine of its own. Failed deliveries are retried with
+// exponential backoff.
+type lifecycleWebhook struct {
+	url    string
+	client *http.Client
+	events chan queuedLifecycleEvent
+	done   chan struct{}
+	mutex  sync.Mutex
+	closed bool
+}
+
+// queuedLifecycleEvent is an event waiting to be delivered. delivered, if set, is closed once the webhook is done with
+// the event, whether or not the delivery succeeded.
+type queuedLifecycleEvent struct {
+	event     LifecycleEvent
+	delivered chan struct{}
+}
+
+func newLifecycleWebhook(url string) *lifecycleWebhook {
+	h := &lifecycleWebhook{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+		events: make(chan queuedLifecycleEvent, webhookPendingEvents),
+		done:   make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+// send queues the event for delivery. It never blocks.
+func (h *lifecycleWebhook) send(event LifecycleEvent) {
+	h.queue(queuedLifecycleEvent{event: event})
+}
+
+// sendAndWait queues the event for delivery, and waits up to the given timeout for the webhook to be done with it.
+func (h *lifecycleWebhook) sendAndWait(event LifecycleEvent, timeout time.Duration) {
+	delivered := make(chan struct{})
+	if !h.queue(queuedLifecycleEvent{event: event, delivered: delivered}) {
+		return
+	}
+	select {
+	case <-delivered:
+	case <-time.After(timeout):
+		log.Warn().Str("event", event.Event).Msg("lifecycle webhook did not deliver the event in time")
+	}
+}
+
+// queue adds the event to the events waiting to be delivered. False is returned if the event was dropped.
+func (h *lifecycleWebhook) queue(queued queuedLifecycleEvent) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.closed {
+		return false
+	}
+	select {
+	case h.events <- queued:
+		return true
+	default:
+		log.Warn().Str("event", queued.event.Event).Msg("lifecycle webhook is not keeping up, dropping event")
+		return false
+	}
+}
+
+func (h *lifecycleWebhook) run() {
+	defer close(h.done)
+	for queued := range h.events {
+		h.deliver(queued.event)
+		if queued.delivered != nil {
+			close(queued.delivered)
+		}
+	}
+}
+
+// deliver posts the event, retrying failed deliveries with exponential backoff.
+func (h *lifecycleWebhook) deliver(event LifecycleEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Error().Err(err).Str("event", event.Event).Msg("failed to encode lifecycle event")
+		return
+	}
+	backoff := webhookFirstBackoff
+	for attempt := 1; ; attempt++ {
+		err = h.post(body)
+		if err == nil {
+			return
+		}
+		if attempt == webhookMaxAttempts {
+			log.Warn().Err(err).Str("event", event.Event).Msg("failed to deliver lifecycle event, giving up")
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (h *lifecycleWebhook) post(body []byte) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return eris.Wrap(err, "")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return eris.Wrap(err, "")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return eris.Errorf("lifecycle webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// close stops accepting events and waits up to webhookShutdownTimeout for the queued events to be delivered.
+func (h *lifecycleWebhook) close() {
+	h.mutex.Lock()
+	if h.closed {
+		h.mutex.Unlock()
+		return
+	}
+	h.closed = true
+	close(h.events)
+	h.mutex.Unlock()
+	select {
+	case <-h.done:
+	case <-time.After(webhookShutdownTimeout):
+		log.Warn().Msg("lifecycle webhook did not deliver all events before shutdown")
+	}
+}
+
+// notifyLifecycle posts the given lifecycle event if the world was created with WithLifecycleWebhook.
+func (w *World) notifyLifecycle(event string, err error) {
+	if w.lifecycleWebhook == nil {
+		return
+	}
+	w.lifecycleWebhook.send(w.newLifecycleEvent(event, err))
+}
+
+// notifyTickFailed posts a tick-failed event if the world was created with WithLifecycleWebhook. Unlike the other
+// events, it waits up to webhookTickFailedTimeout for the delivery, because the game loop may panic right after.
+func (w *World) notifyTickFailed(err error) {
+	if w.lifecycleWebhook == nil {
+		return
+	}
+	w.lifecycleWebhook.sendAndWait(w.newLifecycleEvent(LifecycleTickFailed, err), webhookTickFailedTimeout)
+}
+
+func (w *World) newLifecycleEvent(event string, err error) LifecycleEvent {
+	e := LifecycleEvent{
+		Event:     event,
+		Namespace: w.instance.Namespace().String(),
+		Tick:      w.instance.CurrentTick(),
+		Time:      time.Now().UTC(),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return e
+}
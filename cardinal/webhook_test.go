@@ -0,0 +1,38 @@
+package cardinal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pkg.world.dev/world-engine/assert"
+)
+
+func TestSendAndWaitReturnsOnceTheEventIsDelivered(t *testing.T) {
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		received.Add(1)
+	}))
+	defer server.Close()
+	webhook := newLifecycleWebhook(server.URL)
+	defer webhook.close()
+
+	webhook.sendAndWait(LifecycleEvent{Event: LifecycleTickFailed, Error: "boom"}, time.Minute)
+	assert.Equal(t, int32(1), received.Load())
+}
+
+func TestSendAndWaitGivesUpAfterTheTimeout(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+	webhook := newLifecycleWebhook(server.URL)
+
+	start := time.Now()
+	webhook.sendAndWait(LifecycleEvent{Event: LifecycleTickFailed}, 100*time.Millisecond)
+	assert.Check(t, time.Since(start) < webhookTimeout)
+}
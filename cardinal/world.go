@@ -28,6 +28,9 @@ import (
 	"pkg.world.dev/world-engine/cardinal/types/entity"
 )
 
+// defaultTickInterval is the time between ticks of worlds that were not given a tick channel with WithTickChannel.
+const defaultTickInterval = time.Second
+
 var ErrEntitiesCreatedBeforeStartGame = errors.New("entities should not be created before start game")
 
 type World struct {
@@ -40,6 +43,12 @@ type World struct {
 	serverOptions      []server.Option
 	gameManagerOptions []server.GameManagerOptions
 	cleanup            func()
+	// mode is the CARDINAL_MODE the world was started in (ModeProd or ModeDev).
+	mode string
+	// lifecycleWebhook receives the lifecycle events of the world. See WithLifecycleWebhook.
+	lifecycleWebhook *lifecycleWebhook
+	// subscriptionLimits bound the options of /events subscribers. See WithEventSubscriptionLimits.
+	subscriptionLimits events.SubscriptionLimits
 
 	// gameSequenceStage describes what stage the game is in (e.g. starting, running, shut down, etc)
 	gameSequenceStage gamestage.Atomic
@@ -53,6 +62,29 @@ type (
 	TxHash   = message.TxHash
 	Receipt  = receipt.Receipt
 
+	// ScheduledMessage describes a message scheduled with WorldContext.ScheduleMessage.
+	ScheduledMessage = ecs.ScheduledMessage
+
+	// EVMEvent is an event emitted with WorldContext.EmitEVMEvent.
+	EVMEvent = ecs.EVMEvent
+
+	// CreatedAt is the component that holds the creation tick of entities. See WithEntityCreationTicks.
+	CreatedAt = ecs.CreatedAt
+
+	// CodedError is an error with a machine readable code that is added to the receipt of a transaction. See
+	// NewCodedError.
+	CodedError = receipt.CodedError
+
+	// TransactionLogEntry is one line of the file written with WithTransactionLog.
+	TransactionLogEntry = ecs.TransactionLogEntry
+
+	// SignedTx and TickResult are used to replay a single tick with World.ReplayTick.
+	SignedTx   = ecs.SignedTx
+	TickResult = ecs.TickResult
+
+	// SystemOption changes when a system registered with RegisterSystem runs.
+	SystemOption = ecs.SystemOption
+
 	// System is a function that process the transaction in the given transaction queue.
 	// Systems are automatically called during a world tick, and they must be registered
 	// with a world using RegisterSystems.
@@ -82,16 +114,18 @@ func NewWorld(opts ...WorldOption) (*World, error) {
 		}
 	} else {
 		log.Logger.Info().Msg("Starting a new Cardinal world in development mode")
-		ecsOptions = append(ecsOptions, ecs.WithPrettyLog())
-		serverOptions = append(serverOptions, server.WithPrettyPrint())
-		gameManagerOptions = append(gameManagerOptions, server.WithGameManagerPrettyPrint)
+		// Logs are human readable in development mode. The defaults go first, so WithPrettyLog can override them.
+		ecsOptions = append([]ecs.Option{ecs.WithPrettyLog(true)}, ecsOptions...)
+		serverOptions = append([]server.Option{server.WithPrettyPrint(true)}, serverOptions...)
+		serverOptions = append(serverOptions, server.WithDebugTick(), server.WithDebugBroadcast(),
+			server.WithDebugSubscribers())
 	}
 	redisStore := redis.NewRedisStorage(redis.Options{
 		Addr:     cfg.RedisAddress,
 		Password: cfg.RedisPassword,
 		DB:       0, // use default DB
 	}, cfg.CardinalNamespace)
-	storeManager, err := ecb.NewManager(redisStore.Client)
+	storeManager, err := ecb.NewManager(redisStore.Client, separateStoreOptions(opts)...)
 	if err != nil {
 		return nil, err
 	}
@@ -112,6 +146,7 @@ func NewWorld(opts ...WorldOption) (*World, error) {
 		gameManagerOptions: gameManagerOptions,
 		endStartGame:       make(chan bool),
 		gameSequenceStage:  gamestage.NewAtomic(),
+		mode:               cfg.CardinalMode,
 	}
 
 	// Apply options
@@ -133,17 +168,27 @@ func NewMockWorld(opts ...WorldOption) (*World, error) {
 }
 
 // CreateMany creates multiple entities in the world, and returns the slice of ids for the newly created
-// entities. At least 1 component must be provided.
+// entities. At least 1 component must be provided. Components passed as their zero value start with their registered
+// default; use SetComponent afterwards to store an explicit zero value.
 func CreateMany(wCtx WorldContext, num int, components ...component.Component) ([]EntityID, error) {
 	return ecs.CreateMany(wCtx.Instance(), num, components...)
 }
 
 // Create creates a single entity in the world, and returns the id of the newly created entity.
-// At least 1 component must be provided.
+// At least 1 component must be provided. Zero valued components start with their registered default, as in CreateMany.
 func Create(wCtx WorldContext, components ...component.Component) (EntityID, error) {
 	return ecs.Create(wCtx.Instance(), components...)
 }
 
+// CreateManyWith creates one entity per value in values, with each entity's component of type T set to the
+// corresponding value. This is useful for spawning a list of specific items. Any extra components are added to every
+// entity with the same value.
+func CreateManyWith[T component.Component](wCtx WorldContext, values []T, components ...component.Component) (
+	[]EntityID, error,
+) {
+	return ecs.CreateManyWith[T](wCtx.Instance(), values, components...)
+}
+
 // SetComponent Set sets component data to the entity.
 func SetComponent[T component.Component](wCtx WorldContext, id entity.ID, comp *T) error {
 	return ecs.SetComponent[T](wCtx.Instance(), id, comp)
@@ -159,11 +204,31 @@ func UpdateComponent[T component.Component](wCtx WorldContext, id entity.ID, fn
 	return ecs.UpdateComponent[T](wCtx.Instance(), id, fn)
 }
 
+// SwapComponent exchanges the values of a component between two entities. Neither entity is changed if either one
+// lacks the component.
+func SwapComponent[T component.Component](wCtx WorldContext, a, b EntityID) error {
+	return ecs.SwapComponent[T](wCtx.Instance(), a, b)
+}
+
 // AddComponentTo Adds a component on an entity.
 func AddComponentTo[T component.Component](wCtx WorldContext, id entity.ID) error {
 	return ecs.AddComponentTo[T](wCtx.Instance(), id)
 }
 
+// AddComponentToIfAbsent Adds a component on an entity if it is not already there. The returned bool reports
+// whether the component was added.
+func AddComponentToIfAbsent[T component.Component](wCtx WorldContext, id entity.ID) (bool, error) {
+	return ecs.AddComponentToIfAbsent[T](wCtx.Instance(), id)
+}
+
+// IncrementField adds delta to the numeric field called fieldName on the entity's component of type T. It returns an
+// error if the field does not exist or is not numeric, and for integer fields if delta is fractional or the result does
+// not fit in the field. See ecs.IncrementField.
+func IncrementField[T component.Component, N ecs.Number](wCtx WorldContext, id entity.ID, fieldName string, delta N,
+) error {
+	return ecs.IncrementField[T](wCtx.Instance(), id, fieldName, delta)
+}
+
 // RemoveComponentFrom Removes a component from an entity.
 func RemoveComponentFrom[T component.Component](wCtx WorldContext, id entity.ID) error {
 	return ecs.RemoveComponentFrom[T](wCtx.Instance(), id)
@@ -171,7 +236,70 @@ func RemoveComponentFrom[T component.Component](wCtx WorldContext, id entity.ID)
 
 // Remove removes the given entity id from the world.
 func Remove(wCtx WorldContext, id EntityID) error {
-	return wCtx.Instance().GetWorld().Remove(id)
+	return ecs.Remove(wCtx.Instance(), id)
+}
+
+// ReadOnly runs fn with a read only view of the given WorldContext, so a query handler can be sure it never changes
+// the state of the world, even through helpers it shares with systems. Creating, changing or removing entities and
+// components fails in fn, and ReadOnly returns ecs.ErrCannotModifyStateWithReadOnlyContext if fn attempted any such
+// change, even if fn ignored the error.
+func ReadOnly(wCtx WorldContext, fn func(WorldContext) error) error {
+	return ecs.ReadOnly(wCtx.Instance(), func(view ecs.WorldContext) error {
+		return fn(&worldContext{instance: view})
+	})
+}
+
+// GetEntityForPersona returns the entity that holds the signer data for the given persona tag. Games can attach
+// public components (e.g. a profile or a score) to this entity so that other personas can look them up by tag.
+func GetEntityForPersona(wCtx WorldContext, personaTag string) (EntityID, bool) {
+	return ecs.GetEntityForPersonaInContext(wCtx.Instance(), personaTag)
+}
+
+// GetDisplayName returns the display name the given persona set with the set-display-name message. Show it to other
+// players instead of the persona tag when it is set. False is returned if the persona has no display name, or if the
+// world was not created with WithPersonaDisplayNames.
+func GetDisplayName(wCtx WorldContext, personaTag string) (string, bool) {
+	return ecs.GetDisplayNameInContext(wCtx.Instance(), personaTag)
+}
+
+// GetPersonaComponent returns the component data of type T that is attached to the given persona tag's entity. This
+// is meant to be used in query handlers to read another persona's public data. ecs.ErrPersonaTagNotFound is returned
+// if the persona tag has not been registered.
+func GetPersonaComponent[T component.Component](wCtx WorldContext, personaTag string) (*T, error) {
+	id, ok := GetEntityForPersona(wCtx, personaTag)
+	if !ok {
+		return nil, eris.Wrapf(ecs.ErrPersonaTagNotFound, "persona tag %q", personaTag)
+	}
+	return GetComponent[T](wCtx, id)
+}
+
+// EntitiesOwnedBy returns the entities whose owner component (the component registered as ownerComponentName) is set
+// to the given persona tag. This is useful for the common "list my units/items" pattern. The owner component must
+// have a string PersonaTag field, e.g.:
+//
+//	type Owner struct {
+//		PersonaTag string `json:"personaTag"`
+//	}
+func EntitiesOwnedBy(wCtx WorldContext, personaTag string, ownerComponentName string) ([]EntityID, error) {
+	return ecs.EntitiesOwnedByInContext(wCtx.Instance(), personaTag, ownerComponentName)
+}
+
+// EntitiesOwnedBy is identical to the package level EntitiesOwnedBy, but reads the latest committed world state. It
+// can be used outside of systems and query handlers.
+func (w *World) EntitiesOwnedBy(personaTag string, ownerComponentName string) ([]EntityID, error) {
+	return w.instance.EntitiesOwnedBy(personaTag, ownerComponentName)
+}
+
+// EntitiesCreatedBetween returns the entities that were created from tick start up to, but not including, tick end.
+// The world must be created with WithEntityCreationTicks, otherwise ecs.ErrCreationTicksNotRecorded is returned.
+func EntitiesCreatedBetween(wCtx WorldContext, start, end uint64) ([]EntityID, error) {
+	return ecs.EntitiesCreatedBetweenInContext(wCtx.Instance(), start, end)
+}
+
+// GetDependency returns the dependency of type T that was given to the world with WithDependency, e.g. the client of
+// a payment service. ecs.ErrDependencyNotFound is returned if there is none.
+func GetDependency[T any](wCtx WorldContext) (T, error) {
+	return ecs.GetDependency[T](wCtx.Instance())
 }
 
 func (w *World) handleShutdown() {
@@ -211,35 +339,32 @@ func (w *World) StartGame() error {
 		w.instance.SetEventHub(events.CreateWebSocketEventHub())
 	}
 	eventHub := w.instance.GetEventHub()
-	eventBuilder := events.CreateNewWebSocketBuilder("/events", events.CreateWebSocketEventHandler(eventHub))
-	handler, err := server.NewHandler(w.instance, eventBuilder, w.serverOptions...)
+	eventBuilder := events.CreateEventHubWebSocketBuilder("/events", eventHub, w.subscriptionLimits)
+	serverOptions := append([]server.Option{server.WithMode(w.mode)}, w.serverOptions...)
+	if w.tickChannel == nil {
+		serverOptions = append(serverOptions, server.WithTickInterval(defaultTickInterval))
+	}
+	handler, err := server.NewHandler(w.instance, eventBuilder, serverOptions...)
 	if err != nil {
 		return err
 	}
 	w.server = handler
 
-	w.evmServer, err = evm.NewServer(w.instance)
-	if err != nil {
-		if !errors.Is(eris.Cause(err), evm.ErrNoEVMTypes) {
-			return err
-		}
-		w.instance.Logger.Debug().
-			Msgf("no EVM messages or queries specified. EVM server will not run: %s", eris.ToString(err, true))
-	} else {
-		w.instance.Logger.Debug().Msg("running world with EVM server")
-		err = w.evmServer.Serve()
-		if err != nil {
-			return err
-		}
+	if w.instance.IsReadReplica() {
+		// A read replica cannot accept EVM messages, so only the primary world runs the EVM server.
+		w.instance.Logger.Debug().Msg("world is a read replica. EVM server will not run")
+	} else if err = w.startEVMServer(); err != nil {
+		return err
 	}
 
 	if w.tickChannel == nil {
-		w.tickChannel = time.Tick(time.Second) //nolint:staticcheck // its ok.
+		w.tickChannel = time.Tick(defaultTickInterval) //nolint:staticcheck // its ok.
 	}
 	w.instance.StartGameLoop(context.Background(), w.tickChannel, w.tickDoneChannel)
 	gameManager := server.NewGameManager(w.instance, w.server, w.gameManagerOptions...)
 	w.gameManager = &gameManager
 	go func() {
+		w.notifyLifecycle(LifecycleWorldStarted, nil)
 		ok := w.gameSequenceStage.CompareAndSwap(gamestage.StageStarting, gamestage.StageRunning)
 		if !ok {
 			log.Fatal().Msg("game was started prematurely")
@@ -257,6 +382,22 @@ func (w *World) StartGame() error {
 	return err
 }
 
+// startEVMServer starts the EVM server if any EVM messages or queries were registered.
+func (w *World) startEVMServer() error {
+	var err error
+	w.evmServer, err = evm.NewServer(w.instance)
+	if err != nil {
+		if !errors.Is(eris.Cause(err), evm.ErrNoEVMTypes) {
+			return err
+		}
+		w.instance.Logger.Debug().
+			Msgf("no EVM messages or queries specified. EVM server will not run: %s", eris.ToString(err, true))
+		return nil
+	}
+	w.instance.Logger.Debug().Msg("running world with EVM server")
+	return w.evmServer.Serve()
+}
+
 func (w *World) IsGameRunning() bool {
 	return w.gameSequenceStage.Load() == gamestage.StageRunning
 }
@@ -275,6 +416,10 @@ func (w *World) ShutDown() error {
 	defer func() {
 		w.gameSequenceStage.Store(gamestage.StageShutDown)
 	}()
+	w.notifyLifecycle(LifecycleWorldShuttingDown, nil)
+	if w.lifecycleWebhook != nil {
+		defer w.lifecycleWebhook.close()
+	}
 	if w.evmServer != nil {
 		w.evmServer.Shutdown()
 	}
@@ -303,8 +448,71 @@ func RegisterSystems(w *World, systems ...System) error {
 	return nil
 }
 
-func RegisterComponent[T component.Component](world *World) error {
-	return ecs.RegisterComponent[T](world.instance)
+// RegisterSystem registers a single system. Use WithSkipDuringRecovery for systems with external side effects that must
+// not be repeated when the world recovers its state from the chain, or WithRecoveryOnly for systems that should only
+// run during such a recovery.
+func RegisterSystem(w *World, system System, opts ...SystemOption) error {
+	functionName := filepath.Base(runtime.FuncForPC(reflect.ValueOf(system).Pointer()).Name())
+	w.instance.RegisterSystemWithName(
+		func(wCtx ecs.WorldContext) error {
+			return system(&worldContext{instance: wCtx})
+		}, functionName, opts...,
+	)
+	return nil
+}
+
+// WithSkipDuringRecovery skips the system while the world is recovering from the chain, so it only runs during live
+// ticks.
+func WithSkipDuringRecovery() SystemOption {
+	return ecs.WithSkipDuringRecovery()
+}
+
+// WithRecoveryOnly only runs the system while the world is recovering from the chain.
+func WithRecoveryOnly() SystemOption {
+	return ecs.WithRecoveryOnly()
+}
+
+// WithUsedComponents declares the components a system reads or writes, e.g. WithUsedComponents(Health{}, Position{}).
+// StartGame fails if any of them is not registered, instead of the system failing in the first tick it runs.
+func WithUsedComponents(comps ...component.Component) SystemOption {
+	return ecs.WithUsedComponents(comps...)
+}
+
+// RegisteredComponents returns the names of all registered components, sorted by name.
+func (w *World) RegisteredComponents() []string {
+	return w.instance.RegisteredComponents()
+}
+
+// RegisterSystemAfter registers a system that runs after all the systems with the given names. A name can be the
+// package qualified function name of the system (e.g. "system.MoveSystem") or just the function name (e.g.
+// "MoveSystem"). Systems are sorted when the game starts; StartGame returns an error if a named system does not
+// exist or if the dependencies form a cycle.
+func RegisterSystemAfter(w *World, system System, afterNames ...string) error {
+	functionName := filepath.Base(runtime.FuncForPC(reflect.ValueOf(system).Pointer()).Name())
+	w.instance.RegisterSystemWithNameAfter(
+		func(wCtx ecs.WorldContext) error {
+			return system(&worldContext{instance: wCtx})
+		}, functionName, afterNames...,
+	)
+	return nil
+}
+
+// RegisterPerPersonaTickHook registers a function that is called once per tick for each persona tag that submitted
+// at least one transaction during that tick. Hooks run after all systems.
+func RegisterPerPersonaTickHook(w *World, hook func(wCtx WorldContext, personaTag string) error) {
+	w.instance.RegisterPerPersonaTickHook(
+		func(wCtx ecs.WorldContext, personaTag string) error {
+			return hook(&worldContext{instance: wCtx}, personaTag)
+		},
+	)
+}
+
+// RegisterComponent registers the component type T with the world. Pass component.WithDefault to give newly added
+// components a default value instead of the zero value (including components created with their zero value), and
+// component.WithTTL to make them expire after a number of ticks. Pass component.WithRedisTTL to make redis expire
+// ephemeral components once the world stopped ticking for a while.
+func RegisterComponent[T component.Component](world *World, opts ...component.ComponentOption[T]) error {
+	return ecs.RegisterComponent[T](world.instance, opts...)
 }
 
 // RegisterMessages adds the given messages to the game world. HTTP endpoints to queue up/execute these
@@ -313,19 +521,78 @@ func RegisterMessages(w *World, msgs ...AnyMessage) error {
 	return w.instance.RegisterMessages(toMessageType(msgs)...)
 }
 
+// Plugin bundles the components, messages, queries and systems of a feature, e.g. an inventory, so large games can be
+// organized into modules. See AddPlugin.
+type Plugin interface {
+	// Register registers everything the plugin needs with the world, in dependency order: components first, then the
+	// messages, queries and systems that use them.
+	Register(w *World) error
+}
+
+// AddPlugin registers the given plugin with the world. If the plugin fails to register, everything it registered is
+// removed from the world again. Plugins that register messages must be added before RegisterMessages is called, and
+// plugins that register components must be added after the components of the game are registered. See
+// ecs.World.AddPlugin.
+func AddPlugin(w *World, plugin Plugin) error {
+	return w.instance.AddPlugin(ecsPlugin{world: w, plugin: plugin})
+}
+
+// ecsPlugin registers a Plugin with the ecs.World of its cardinal.World.
+type ecsPlugin struct {
+	world  *World
+	plugin Plugin
+}
+
+func (p ecsPlugin) Register(*ecs.World) error {
+	return p.plugin.Register(p.world)
+}
+
+// QueryOption configures a query registered with RegisterQuery.
+type QueryOption[Request, Reply any] func() func(queryType *ecs.QueryType[Request, Reply])
+
+// WithQueryDeprecation marks a query as deprecated. The query keeps working, but its HTTP responses carry Deprecation
+// and Sunset headers, and it is flagged in the /query/http/endpoints listing. Pass the zero time if there is no planned
+// sunset.
+func WithQueryDeprecation[Request, Reply any](message string, sunset time.Time) QueryOption[Request, Reply] {
+	return ecs.WithQueryDeprecation[Request, Reply](message, sunset)
+}
+
+// NilReplyPolicy decides how the HTTP server answers a query whose handler returned a nil reply and a nil error. See
+// WithNilReplyPolicy.
+type NilReplyPolicy = ecs.NilReplyPolicy
+
+const (
+	NilReplyAsNull        = ecs.NilReplyAsNull
+	NilReplyAsEmptyObject = ecs.NilReplyAsEmptyObject
+	NilReplyAsNoContent   = ecs.NilReplyAsNoContent
+	NilReplyAsError       = ecs.NilReplyAsError
+)
+
+// WithNilReplyPolicy sets how a query's nil reply is returned to HTTP clients: as null (the default), as an empty
+// object, as a 204 No Content response, or as an error.
+func WithNilReplyPolicy[Request, Reply any](policy NilReplyPolicy) QueryOption[Request, Reply] {
+	return ecs.WithNilReplyPolicy[Request, Reply](policy)
+}
+
 // RegisterQuery adds the given query to the game world. HTTP endpoints to use these queries
 // will automatically be created when StartGame is called. This function does not add EVM support to the query.
 func RegisterQuery[Request any, Reply any](
 	world *World,
 	name string,
 	handler func(wCtx WorldContext, req *Request) (*Reply, error),
+	opts ...QueryOption[Request, Reply],
 ) error {
+	ecsOpts := make([]func() func(*ecs.QueryType[Request, Reply]), 0, len(opts))
+	for _, opt := range opts {
+		ecsOpts = append(ecsOpts, opt)
+	}
 	err := ecs.RegisterQuery[Request, Reply](
 		world.instance,
 		name,
 		func(wCtx ecs.WorldContext, req *Request) (*Reply, error) {
 			return handler(&worldContext{instance: wCtx}, req)
 		},
+		ecsOpts...,
 	)
 	if err != nil {
 		return err
@@ -333,6 +600,12 @@ func RegisterQuery[Request any, Reply any](
 	return nil
 }
 
+// RegisterCQLQuery registers the given CQL string under the given name. The CQL string is parsed once, when it is
+// registered, and can then be run by name via the CQL endpoint.
+func RegisterCQLQuery(w *World, name, cqlText string) error {
+	return w.instance.RegisterCQLQuery(name, cqlText)
+}
+
 // RegisterQueryWithEVMSupport adds the given query to the game world. HTTP endpoints to use these queries
 // will automatically be created when StartGame is called. This Register method must only be called once.
 // This function also adds EVM support to the query.
@@ -359,14 +632,50 @@ func (w *World) Instance() *ecs.World {
 	return w.instance
 }
 
+// MessageEndpoint returns the HTTP path that transactions of the message with the given name are sent to, e.g.
+// "/tx/game/attack".
+func (w *World) MessageEndpoint(name string) (string, error) {
+	return w.instance.MessageEndpoint(name)
+}
+
 func (w *World) CurrentTick() uint64 {
 	return w.instance.CurrentTick()
 }
 
+// IsNonceUsed reports whether the given signer has already used the given nonce. A transaction with a used nonce is
+// rejected.
+func (w *World) IsNonceUsed(signerAddress string, nonce uint64) (bool, error) {
+	return w.instance.IsNonceUsed(signerAddress, nonce)
+}
+
+// OnRecoveryComplete registers fn to be called when the world finishes recovering its state from the chain. fn
+// receives nil if the world recovered successfully and is ready to accept transactions.
+func (w *World) OnRecoveryComplete(fn func(err error)) {
+	w.instance.OnRecoveryComplete(fn)
+}
+
+// EntityCount returns the number of live entities in the world.
+func (w *World) EntityCount() (int, error) {
+	return w.instance.EntityCount()
+}
+
 func (w *World) Tick(ctx context.Context) error {
 	return w.instance.Tick(ctx)
 }
 
+// ReplayTick runs a single tick with exactly the given transactions and returns the resulting receipts. It is meant for
+// post-mortems: load the transactions of a problematic tick into a clean world and replay them to reproduce a bug.
+func (w *World) ReplayTick(ctx context.Context, txs []SignedTx) (TickResult, error) {
+	return w.instance.ReplayTick(ctx, txs)
+}
+
+// TickSystems runs a tick in which only the systems with the given names run, and returns the resulting receipts. The
+// tick changes the state of the world like any other tick, so it is only meant for debugging a single system. It is
+// refused if the world was created with WithAdapter.
+func (w *World) TickSystems(ctx context.Context, systemNames ...string) (TickResult, error) {
+	return w.instance.TickSystems(ctx, systemNames...)
+}
+
 // Init Registers a system that only runs once on a new game before tick 0.
 func (w *World) Init(system System) {
 	w.instance.AddInitSystem(
@@ -375,3 +684,9 @@ func (w *World) Init(system System) {
 		},
 	)
 }
+
+// ReportInitProgress records how far the init system has come, from 0 to 1. The progress is reported by /health until
+// the init system is done, see WithReadyAfterInit.
+func ReportInitProgress(wCtx WorldContext, progress float64) {
+	wCtx.Instance().GetWorld().SetInitProgress(progress)
+}
@@ -1,6 +1,8 @@
 package cardinal
 
 import (
+	"context"
+
 	"github.com/rs/zerolog"
 	"pkg.world.dev/world-engine/cardinal/ecs"
 	"pkg.world.dev/world-engine/cardinal/events"
@@ -32,6 +34,38 @@ type WorldContext interface {
 	// EmitEvent broadcasts an event message to all subscribed clients.
 	EmitEvent(event string)
 
+	// ScheduleMessage stores the given message body and processes it as a system transaction in the given future
+	// tick, e.g. to expire a timed effect. Scheduled messages are saved with the game state, so they survive a
+	// restart. The returned hash identifies the receipt of the message once the tick has run.
+	ScheduleMessage(msg AnyMessage, body any, atTick uint64) (TxHash, error)
+
+	// ScheduledMessages returns every scheduled message that has not been processed yet, in the order they were
+	// scheduled.
+	ScheduledMessages() ([]ScheduledMessage, error)
+
+	// CancelScheduled removes the scheduled message with the given hash (as returned by ScheduleMessage) before it is
+	// processed, e.g. because the unit a delayed effect was meant for has died.
+	CancelScheduled(txHash TxHash) error
+
+	// EmitEVMEvent queues an event that is handed to the handler given with WithEVMEventHandler once the current tick
+	// has been committed. The handler decides how the event is made observable on chain. Events of a failed tick are
+	// discarded.
+	EmitEVMEvent(topic string, data []byte) error
+
+	// SetExternalKey tags the given entity with a stable, game defined key (e.g. the catalog ID of an item), so it can
+	// be found with LookupByExternalKey even though entity IDs differ between worlds. Keys are saved with the game
+	// state. An entity has at most one key, and a key can only be used by one entity.
+	SetExternalKey(id EntityID, key string) error
+
+	// LookupByExternalKey returns the entity that was tagged with the given key by SetExternalKey. False is returned if
+	// no existing entity has the key.
+	LookupByExternalKey(key string) (EntityID, bool)
+
+	// Context returns the context of the HTTP request a query is answering. It is cancelled once the client goes
+	// away, so queries that iterate over many entities can check Context().Err() and stop early. Searches made with
+	// NewSearch already do so. Systems get a context that is never cancelled.
+	Context() context.Context
+
 	// Logger returns a zerolog.Logger. Additional metadata information is often attached to
 	// this logger (e.g. the name of the active System).
 	Logger() *zerolog.Logger
@@ -53,6 +87,34 @@ func (wCtx *worldContext) CurrentTick() uint64 {
 
 func (wCtx *worldContext) Timestamp() uint64 { return wCtx.instance.Timestamp() }
 
+func (wCtx *worldContext) ScheduleMessage(msg AnyMessage, body any, atTick uint64) (TxHash, error) {
+	return wCtx.instance.ScheduleMessage(msg.Convert(), body, atTick)
+}
+
+func (wCtx *worldContext) ScheduledMessages() ([]ScheduledMessage, error) {
+	return wCtx.instance.ScheduledMessages()
+}
+
+func (wCtx *worldContext) CancelScheduled(txHash TxHash) error {
+	return wCtx.instance.CancelScheduled(txHash)
+}
+
+func (wCtx *worldContext) EmitEVMEvent(topic string, data []byte) error {
+	return wCtx.instance.EmitEVMEvent(topic, data)
+}
+
+func (wCtx *worldContext) SetExternalKey(id EntityID, key string) error {
+	return wCtx.instance.SetExternalKey(id, key)
+}
+
+func (wCtx *worldContext) LookupByExternalKey(key string) (EntityID, bool) {
+	return wCtx.instance.LookupByExternalKey(key)
+}
+
+func (wCtx *worldContext) Context() context.Context {
+	return wCtx.instance.Context()
+}
+
 func (wCtx *worldContext) Logger() *zerolog.Logger {
 	return wCtx.instance.Logger()
 }
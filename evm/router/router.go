@@ -4,18 +4,23 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"io"
 	"os"
 	"strings"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/rotisserie/eris"
 	namespacetypes "pkg.world.dev/world-engine/evm/x/namespace/types"
 	routerv1 "pkg.world.dev/world-engine/rift/router/v1"
 
@@ -207,16 +212,77 @@ func (r *routerImpl) Query(ctx context.Context, request []byte, resource, namesp
 		r.logger.Error("failed to get client connection", "error", err.Error())
 		return nil, err
 	}
-	res, err := client.QueryShard(ctx, &routerv1.QueryShardRequest{
+	req := &routerv1.QueryShardRequest{
 		Resource: resource,
 		Request:  request,
-	})
+	}
+	// large replies are streamed back in chunks. game shards that don't support streaming fall back to the unary RPC.
+	res, err := r.queryStream(ctx, client, req)
+	if status.Code(err) == codes.Unimplemented {
+		r.logger.Debug("game shard does not support streamed queries, falling back to unary query")
+		var unaryRes *routerv1.QueryShardResponse
+		unaryRes, err = client.QueryShard(ctx, req)
+		if err == nil {
+			res = unaryRes.Response
+		}
+	}
 	if err != nil {
 		r.logger.Error("failed to query game shard", "error", err.Error())
 		return nil, err
 	}
-	r.logger.Debug("successfully queried game shard")
-	return res.Response, nil
+	r.logger.Debug("successfully queried game shard", "reply_size", len(res))
+	return res, nil
+}
+
+func (r *routerImpl) queryStream(ctx context.Context, client routerv1.MsgClient, req *routerv1.QueryShardRequest) (
+	[]byte, error,
+) {
+	stream, err := client.QueryShardStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return assembleQueryChunks(stream)
+}
+
+// maxQueryReplySize is the largest query reply, in bytes, the router accepts from a game shard. The size a shard
+// declares is not trusted to allocate more than this.
+const maxQueryReplySize = 32 * 1024 * 1024
+
+// assembleQueryChunks reads all chunks from the stream and concatenates them into the full ABI encoded reply. Every
+// chunk must declare the same total size, which must not exceed maxQueryReplySize, and the chunks must add up to it.
+func assembleQueryChunks(stream routerv1.Msg_QueryShardStreamClient) ([]byte, error) {
+	var (
+		reply     []byte
+		totalSize uint64
+	)
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to receive query reply chunk")
+		}
+		if reply == nil {
+			totalSize = chunk.TotalSize
+			if totalSize > maxQueryReplySize {
+				return nil, eris.Errorf("query reply of %d bytes exceeds the maximum of %d bytes", totalSize,
+					maxQueryReplySize)
+			}
+			reply = make([]byte, 0, totalSize)
+		} else if chunk.TotalSize != totalSize {
+			return nil, eris.Errorf("query reply chunk declared a size of %d bytes, but the first declared %d bytes",
+				chunk.TotalSize, totalSize)
+		}
+		if uint64(len(chunk.Chunk)) > totalSize-uint64(len(reply)) {
+			return nil, eris.Errorf("query reply exceeded its declared size of %d bytes", totalSize)
+		}
+		reply = append(reply, chunk.Chunk...)
+	}
+	if uint64(len(reply)) != totalSize {
+		return nil, eris.Errorf("incomplete query reply: got %d of %d bytes", len(reply), totalSize)
+	}
+	return reply, nil
 }
 
 func (r *routerImpl) getConnectionForNamespace(ns string) (routerv1.MsgClient, error) {
@@ -3,15 +3,19 @@ package router
 import (
 	"context"
 	types2 "github.com/ethereum/go-ethereum/core/types"
+	"io"
+	"math"
 	"math/big"
 	"testing"
 
+	"google.golang.org/grpc"
 	"gotest.tools/v3/assert"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/ethereum/go-ethereum/common"
 	"pkg.berachain.dev/polaris/eth/core/types"
 	namespacetypes "pkg.world.dev/world-engine/evm/x/namespace/types"
+	routerv1 "pkg.world.dev/world-engine/rift/router/v1"
 
 	"cosmossdk.io/log"
 )
@@ -52,3 +56,58 @@ func TestRouter(t *testing.T) {
 	// queue should be cleared after dispatching
 	assert.Equal(t, router.queue.IsSet(contractAddr), false)
 }
+
+type fakeQueryStream struct {
+	grpc.ClientStream
+	chunks []*routerv1.QueryShardChunk
+}
+
+func (f *fakeQueryStream) Recv() (*routerv1.QueryShardChunk, error) {
+	if len(f.chunks) == 0 {
+		return nil, io.EOF
+	}
+	chunk := f.chunks[0]
+	f.chunks = f.chunks[1:]
+	return chunk, nil
+}
+
+func TestAssembleQueryChunks(t *testing.T) {
+	reply, err := assembleQueryChunks(&fakeQueryStream{chunks: []*routerv1.QueryShardChunk{
+		{Chunk: []byte("hel"), TotalSize: 5},
+		{Chunk: []byte("lo"), TotalSize: 5},
+	}})
+	assert.NilError(t, err)
+	assert.Equal(t, string(reply), "hello")
+
+	reply, err = assembleQueryChunks(&fakeQueryStream{chunks: []*routerv1.QueryShardChunk{
+		{Chunk: []byte{}, TotalSize: 0},
+	}})
+	assert.NilError(t, err)
+	assert.Equal(t, len(reply), 0)
+
+	// a stream that ends before all bytes arrive is an error
+	_, err = assembleQueryChunks(&fakeQueryStream{chunks: []*routerv1.QueryShardChunk{
+		{Chunk: []byte("hel"), TotalSize: 5},
+	}})
+	assert.ErrorContains(t, err, "incomplete")
+
+	// chunks that add up to more than the declared size are an error
+	_, err = assembleQueryChunks(&fakeQueryStream{chunks: []*routerv1.QueryShardChunk{
+		{Chunk: []byte("hel"), TotalSize: 5},
+		{Chunk: []byte("lo!"), TotalSize: 5},
+	}})
+	assert.ErrorContains(t, err, "exceeded")
+
+	// every chunk must declare the same size
+	_, err = assembleQueryChunks(&fakeQueryStream{chunks: []*routerv1.QueryShardChunk{
+		{Chunk: []byte("hel"), TotalSize: 5},
+		{Chunk: []byte("lo!"), TotalSize: 6},
+	}})
+	assert.ErrorContains(t, err, "first declared")
+
+	// the declared size is not trusted to allocate more than the maximum reply size
+	_, err = assembleQueryChunks(&fakeQueryStream{chunks: []*routerv1.QueryShardChunk{
+		{Chunk: []byte("hel"), TotalSize: math.MaxUint64},
+	}})
+	assert.ErrorContains(t, err, "maximum")
+}
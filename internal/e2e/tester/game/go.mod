@@ -145,7 +145,7 @@ require (
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	pkg.world.dev/world-engine/evm v1.0.0-beta // indirect
-	pkg.world.dev/world-engine/rift v1.0.0-beta // indirect
-	pkg.world.dev/world-engine/sign v1.0.0-beta // indirect
+	pkg.world.dev/world-engine/rift v1.0.1-beta // indirect
+	pkg.world.dev/world-engine/sign v1.0.1-beta // indirect
 	sigs.k8s.io/yaml v1.3.0 // indirect
 )
@@ -26,9 +26,29 @@ var (
 	allowlistEnabled       = false
 	allowlistKeyCollection = "allowlist_keys_collection"
 	allowedUsers           = "allowed_users"
+
+	// allowlistExemptGroupsEnvVar is a comma separated list of Nakama group names. Members of these groups (e.g. staff
+	// or QA accounts) can claim persona tags without a beta key, even when the allowlist is enabled.
+	allowlistExemptGroupsEnvVar = "ALLOWLIST_EXEMPT_GROUPS"
+	allowlistExemptGroups       = map[string]bool{}
+
+	// devAutoVerifyEnvVar makes every user pass the beta key check, so local development does not need beta keys.
+	// It is refused when the relay looks like it runs in production. See initDevAutoVerify.
+	devAutoVerifyEnvVar = "DEV_AUTO_VERIFY"
+	devAutoVerify       = false
 )
 
-func initAllowlist(_ runtime.Logger, initializer runtime.Initializer) error {
+const (
+	// groupMembershipStateMember is the highest (i.e. least privileged) Nakama group membership state that still
+	// counts as being in the group. Higher states are pending join requests.
+	groupMembershipStateMember = 2
+	userGroupsListLimit        = 100
+)
+
+func initAllowlist(logger runtime.Logger, initializer runtime.Initializer) error {
+	if err := initDevAutoVerify(logger); err != nil {
+		return err
+	}
 	enabledStr := os.Getenv(allowlistEnabledEnvVar)
 	if enabledStr == "" {
 		return nil
@@ -42,6 +62,11 @@ func initAllowlist(_ runtime.Logger, initializer runtime.Initializer) error {
 	if !allowlistEnabled {
 		return nil
 	}
+	for _, group := range strings.Split(os.Getenv(allowlistExemptGroupsEnvVar), ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			allowlistExemptGroups[group] = true
+		}
+	}
 	err = initializer.RegisterRpc("generate-beta-keys", allowListRPC)
 	if err != nil {
 		return eris.Wrap(err, "failed to register rpc")
@@ -54,6 +79,33 @@ func initAllowlist(_ runtime.Logger, initializer runtime.Initializer) error {
 	return nil
 }
 
+// initDevAutoVerify enables DEV_AUTO_VERIFY. It fails if the flag is set alongside an indication that the relay runs
+// in production, so the beta key check is never skipped by accident outside of local development.
+func initDevAutoVerify(logger runtime.Logger) error {
+	enabledStr := os.Getenv(devAutoVerifyEnvVar)
+	if enabledStr == "" {
+		return nil
+	}
+	enabled, err := strconv.ParseBool(enabledStr)
+	if err != nil {
+		return eris.Wrapf(err, "the %s flag was set, however the variable %q was an invalid bool",
+			devAutoVerifyEnvVar, enabledStr)
+	}
+	if !enabled {
+		return nil
+	}
+	if !DebugEnabled {
+		return eris.Errorf("%s can only be enabled along with ENABLE_DEBUG", devAutoVerifyEnvVar)
+	}
+	if strings.EqualFold(os.Getenv("CARDINAL_MODE"), "production") {
+		return eris.Errorf("%s can not be enabled when CARDINAL_MODE is production", devAutoVerifyEnvVar)
+	}
+	devAutoVerify = true
+	logger.Warn("%s is enabled: every user passes the beta key check. This is insecure and only meant for local "+
+		"development", devAutoVerifyEnvVar)
+	return nil
+}
+
 type GenKeysMsg struct {
 	Amount int `json:"amount"`
 }
@@ -206,7 +258,7 @@ func writeVerified(ctx context.Context, nk runtime.NakamaModule, userID string)
 }
 
 func checkVerified(ctx context.Context, nk runtime.NakamaModule, userID string) error {
-	if !allowlistEnabled {
+	if !allowlistEnabled || devAutoVerify {
 		return nil
 	}
 	objs, err := nk.StorageRead(ctx, []*runtime.StorageRead{
@@ -225,6 +277,49 @@ func checkVerified(ctx context.Context, nk runtime.NakamaModule, userID string)
 	return nil
 }
 
+// checkVerifiedOrExempt is like checkVerified, but users that belong to one of the allowlist exempt groups pass the
+// check without a beta key. Each exemption is logged for auditing.
+func checkVerifiedOrExempt(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) error {
+	if !allowlistEnabled || devAutoVerify {
+		return nil
+	}
+	group, exempt, err := findAllowlistExemptGroup(ctx, nk, userID)
+	if err != nil {
+		return err
+	}
+	if exempt {
+		logger.Info("user %q is exempt from the allowlist via group %q; skipping beta key check", userID, group)
+		return nil
+	}
+	return checkVerified(ctx, nk, userID)
+}
+
+// findAllowlistExemptGroup returns the first allowlist exempt group the given user is a member of.
+func findAllowlistExemptGroup(ctx context.Context, nk runtime.NakamaModule, userID string) (
+	group string, exempt bool, err error,
+) {
+	if len(allowlistExemptGroups) == 0 {
+		return "", false, nil
+	}
+	cursor := ""
+	for {
+		userGroups, nextCursor, err := nk.UserGroupsList(ctx, userID, userGroupsListLimit, nil, cursor)
+		if err != nil {
+			return "", false, eris.Wrap(err, "unable to list groups for user")
+		}
+		for _, ug := range userGroups {
+			name := ug.GetGroup().GetName()
+			if allowlistExemptGroups[name] && ug.GetState().GetValue() <= groupMembershipStateMember {
+				return name, true, nil
+			}
+		}
+		if nextCursor == "" {
+			return "", false, nil
+		}
+		cursor = nextCursor
+	}
+}
+
 func readKey(ctx context.Context, nk runtime.NakamaModule, key string) (*KeyStorage, error) {
 	objs, err := nk.StorageRead(ctx, []*runtime.StorageRead{
 		{
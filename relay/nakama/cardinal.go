@@ -11,15 +11,17 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/heroiclabs/nakama-common/runtime"
 	"github.com/rotisserie/eris"
-	"pkg.world.dev/world-engine/sign"
 )
 
 var (
 	listEndpoints               = "query/http/endpoints"
 	createPersonaEndpoint       = "tx/persona/create-persona"
+	authorizeAddressEndpoint    = "tx/game/authorize-persona-address"
 	readPersonaSignerEndpoint   = "query/persona/signer"
 	transactionReceiptsEndpoint = "query/receipts/list"
 	eventEndpoint               = "events"
@@ -29,13 +31,26 @@ var (
 
 	globalCardinalAddress string
 
+	// globalMaxResponseSize is the largest response body, in bytes, the relay reads from cardinal.
+	globalMaxResponseSize int64 = defaultMaxResponseSize
+	// globalRequestTimeout bounds how long the relay waits for cardinal to answer a forwarded request. Zero means no
+	// timeout.
+	globalRequestTimeout = defaultRequestTimeout
+
 	ErrPersonaSignerAvailable = errors.New("persona signer is available")
 	ErrPersonaSignerUnknown   = errors.New("persona signer is unknown")
+	ErrResponseTooLarge       = errors.New("cardinal response is too large")
+)
+
+const (
+	defaultMaxResponseSize = 10 << 20
+	defaultRequestTimeout  = 30 * time.Second
 )
 
 type txResponse struct {
-	TxHash string `json:"txHash"`
-	Tick   uint64 `json:"tick"`
+	TxHash  string `json:"txHash"`
+	Tick    uint64 `json:"tick"`
+	TraceID string `json:"traceId,omitempty"`
 }
 
 func initCardinalAddress() error {
@@ -46,6 +61,41 @@ func initCardinalAddress() error {
 	return nil
 }
 
+// initCardinalResponseLimits reads the maximum cardinal response size (EnvCardinalMaxResponseSize, in bytes) and the
+// cardinal request timeout (EnvCardinalRequestTimeout, e.g. "30s") from the environment. These protect the relay from
+// a misbehaving cardinal that sends huge responses or never answers.
+func initCardinalResponseLimits() error {
+	if sizeStr := os.Getenv(EnvCardinalMaxResponseSize); sizeStr != "" {
+		size, err := strconv.ParseInt(sizeStr, 10, 64)
+		if err != nil || size < 1 {
+			return eris.Errorf("%s must be a positive integer, got %q", EnvCardinalMaxResponseSize, sizeStr)
+		}
+		globalMaxResponseSize = size
+	}
+	if timeoutStr := os.Getenv(EnvCardinalRequestTimeout); timeoutStr != "" {
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil || timeout < 0 {
+			return eris.Errorf("%s must be a non-negative duration, got %q", EnvCardinalRequestTimeout, timeoutStr)
+		}
+		globalRequestTimeout = timeout
+	}
+	return nil
+}
+
+// readResponseBody reads the body of a cardinal response, failing with ErrResponseTooLarge instead of reading more
+// than globalMaxResponseSize bytes.
+func readResponseBody(resp *http.Response) ([]byte, error) {
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, globalMaxResponseSize+1))
+	if err != nil {
+		return nil, eris.Wrap(err, "")
+	}
+	if int64(len(buf)) > globalMaxResponseSize {
+		return nil, eris.Wrapf(ErrResponseTooLarge, "response from %q is larger than %d bytes",
+			resp.Request.URL, globalMaxResponseSize)
+	}
+	return buf, nil
+}
+
 func makeHTTPURL(resource string) string {
 	return fmt.Sprintf("http://%s/%s", globalCardinalAddress, resource)
 }
@@ -60,23 +110,17 @@ type endpoints struct {
 }
 
 func getCardinalEndpoints() (txEndpoints []string, queryEndpoints []string, err error) {
-	var resp *http.Response
 	url := makeHTTPURL(listEndpoints)
-	//nolint:gosec,noctx // its ok. maybe revisit.
-	resp, err = http.Post(url, "", nil)
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, nil)
 	if err != nil {
-		return txEndpoints, queryEndpoints, err
+		return txEndpoints, queryEndpoints, eris.Wrap(err, "")
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		buf, _ := io.ReadAll(resp.Body)
-		err = eris.Errorf("list endpoints (at %q) failed with status code %d: %v",
-			url, resp.StatusCode, string(buf))
-		return txEndpoints, queryEndpoints, err
+	buf, err := doRequest(req)
+	if err != nil {
+		return txEndpoints, queryEndpoints, eris.Wrapf(err, "list endpoints (at %q) failed", url)
 	}
-	dec := json.NewDecoder(resp.Body)
 	var ep endpoints
-	if err = dec.Decode(&ep); err != nil {
+	if err = json.Unmarshal(buf, &ep); err != nil {
 		return txEndpoints, queryEndpoints, eris.Wrap(err, "")
 	}
 	txEndpoints = ep.TxEndpoints
@@ -84,20 +128,34 @@ func getCardinalEndpoints() (txEndpoints []string, queryEndpoints []string, err
 	return txEndpoints, queryEndpoints, err
 }
 
-func doRequest(req *http.Request) (*http.Response, error) {
+// doRequest sends the given request to cardinal and returns the body of its 200 OK response. The request is canceled
+// after globalRequestTimeout, and a body of more than globalMaxResponseSize bytes fails with ErrResponseTooLarge.
+func doRequest(req *http.Request) ([]byte, error) {
+	if globalRequestTimeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), globalRequestTimeout)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, eris.Wrapf(err, "request to %q failed", req.URL)
-	} else if resp.StatusCode != http.StatusOK {
+	}
+	defer resp.Body.Close()
+	buf, err := readResponseBody(resp)
+	if resp.StatusCode != http.StatusOK {
 		statusCode := resp.StatusCode
-		var buf []byte
-		buf, err = io.ReadAll(resp.Body)
 		if err != nil {
 			return nil, eris.Wrapf(err, "failed reading body in resp, status code: %d", statusCode)
 		}
-		reqBuf, err := io.ReadAll(req.Body)
-		if err != nil {
-			return nil, eris.Wrapf(err, "failed reading body in request, status code: %d", statusCode)
+		var reqBuf []byte
+		if req.GetBody != nil {
+			var body io.ReadCloser
+			if body, err = req.GetBody(); err == nil {
+				reqBuf, err = io.ReadAll(io.LimitReader(body, globalMaxResponseSize))
+			}
+			if err != nil {
+				return nil, eris.Wrapf(err, "failed reading body in request, status code: %d", statusCode)
+			}
 		}
 		return nil,
 			eris.Errorf(
@@ -107,7 +165,10 @@ func doRequest(req *http.Request) (*http.Response, error) {
 				statusCode,
 				string(buf))
 	}
-	return resp, nil
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
 }
 
 func cardinalCreatePersona(ctx context.Context, nk runtime.NakamaModule, personaTag string) (
@@ -132,15 +193,9 @@ func cardinalCreatePersona(ctx context.Context, nk runtime.NakamaModule, persona
 		SignerAddress: signerAddress,
 	}
 
-	key, nonce, err := getPrivateKeyAndANonce(ctx, nk)
-	if err != nil {
-		return "", 0, eris.Wrapf(err, "unable to get the private key or a nonce")
-	}
-
-	transaction, err := sign.NewSystemTransaction(key, globalNamespace, nonce, createPersonaTx)
-
+	transaction, err := newNakamaTxSigner(nk).signSystemTx(ctx, createPersonaTx)
 	if err != nil {
-		return "", 0, eris.Wrapf(err, "unable to create signed payload")
+		return "", 0, err
 	}
 
 	buf, err := transaction.Marshal()
@@ -153,21 +208,14 @@ func cardinalCreatePersona(ctx context.Context, nk runtime.NakamaModule, persona
 		return "", 0, eris.Wrapf(err, "unable to make request to %q", createPersonaEndpoint)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := doRequest(req)
+	buf, err = doRequest(req)
 	if err != nil {
 		return "", 0, err
 	}
 
-	defer resp.Body.Close()
-
-	if code := resp.StatusCode; code != http.StatusOK {
-		buf, err = io.ReadAll(resp.Body)
-		return "", 0, eris.Wrapf(err, "create persona response is not 200. code %v, body: %v", code, string(buf))
-	}
-
 	var createPersonaResponse txResponse
 
-	if err = json.NewDecoder(resp.Body).Decode(&createPersonaResponse); err != nil {
+	if err = json.Unmarshal(buf, &createPersonaResponse); err != nil {
 		return "", 0, eris.Wrap(err, "unable to decode response")
 	}
 	if createPersonaResponse.TxHash == "" {
@@ -195,17 +243,16 @@ func cardinalQueryPersonaSigner(ctx context.Context, personaTag string, tick uin
 		return "", eris.Wrap(err, "")
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpResp, err := doRequest(httpReq)
+	buf, err = doRequest(httpReq)
 	if err != nil {
 		return "", err
 	}
-	defer httpResp.Body.Close()
 
 	var resp struct {
 		Status        string `json:"status"`
 		SignerAddress string `json:"signerAddress"`
 	}
-	if err = json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+	if err = json.Unmarshal(buf, &resp); err != nil {
 		return "", eris.Wrap(err, "")
 	}
 	if resp.Status == readPersonaSignerStatusUnknown {
@@ -215,3 +262,41 @@ func cardinalQueryPersonaSigner(ctx context.Context, personaTag string, tick uin
 	}
 	return resp.SignerAddress, nil
 }
+
+// cardinalAuthorizePersonaAddress submits a transaction to cardinal that authorizes the given address to act on
+// behalf of the given persona tag. The transaction is signed with Nakama's key, so the persona tag must already be
+// registered to Nakama's signer address.
+func cardinalAuthorizePersonaAddress(ctx context.Context, nk runtime.NakamaModule, personaTag, address string,
+) (txHash string, err error) {
+	authorizeTx := struct {
+		Address string `json:"address"`
+	}{
+		Address: address,
+	}
+
+	transaction, err := newNakamaTxSigner(nk).signPersonaTx(ctx, personaTag, authorizeTx, "")
+	if err != nil {
+		return "", err
+	}
+	buf, err := transaction.Marshal()
+	if err != nil {
+		return "", eris.Wrap(err, "unable to marshal signed payload")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, makeHTTPURL(authorizeAddressEndpoint),
+		bytes.NewReader(buf))
+	if err != nil {
+		return "", eris.Wrapf(err, "unable to make request to %q", authorizeAddressEndpoint)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	buf, err = doRequest(req)
+	if err != nil {
+		return "", err
+	}
+
+	var authorizeResponse txResponse
+	if err = json.Unmarshal(buf, &authorizeResponse); err != nil {
+		return "", eris.Wrap(err, "unable to decode response")
+	}
+	return authorizeResponse.TxHash, nil
+}
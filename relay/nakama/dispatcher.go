@@ -19,9 +19,10 @@ type TransactionReceiptsReply struct {
 }
 
 type Receipt struct {
-	TxHash string         `json:"txHash"`
-	Result map[string]any `json:"result"`
-	Errors []string       `json:"errors"`
+	TxHash  string         `json:"txHash"`
+	Result  map[string]any `json:"result"`
+	Errors  []string       `json:"errors"`
+	TraceID string         `json:"traceId,omitempty"`
 }
 
 // receiptsDispatcher continually polls Cardinal for transaction receipts and dispatches them to any subscribed
@@ -29,12 +30,22 @@ type Receipt struct {
 type receiptsDispatcher struct {
 	ch chan *Receipt
 	m  *sync.Map
+	// workers is the number of goroutines that deliver each receipt to the subscribed channels.
+	workers int
 }
 
-func newReceiptsDispatcher() *receiptsDispatcher {
+// receiptDelivery is a batch of subscribed channels that a dispatch worker must send a receipt to.
+type receiptDelivery struct {
+	receipt     *Receipt
+	subscribers []receiptChan
+	done        *sync.WaitGroup
+}
+
+func newReceiptsDispatcher(workers int) *receiptsDispatcher {
 	return &receiptsDispatcher{
-		ch: make(receiptChan),
-		m:  &sync.Map{},
+		ch:      make(receiptChan),
+		m:       &sync.Map{},
+		workers: max(workers, 1),
 	}
 }
 
@@ -46,17 +57,47 @@ func (r *receiptsDispatcher) subscribe(session string, ch receiptChan) {
 
 // dispatch continually drains r.ch (receipts from cardinal) and sends copies to all subscribed channels.
 // This function is meant to be called in a goroutine. Pushed receipts will not block when sending.
+// The subscribed channels are split evenly between r.workers goroutines. Each receipt is fully delivered before the
+// next one is, so every subscriber still sees receipts in order.
 func (r *receiptsDispatcher) dispatch(_ runtime.Logger) {
+	deliveries := make(chan receiptDelivery)
+	defer close(deliveries)
+	for i := 0; i < r.workers; i++ {
+		go deliverReceipts(deliveries)
+	}
+	var subscribers []receiptChan
 	for receipt := range r.ch {
+		subscribers = subscribers[:0]
 		r.m.Range(func(key, value any) bool {
 			ch, _ := value.(receiptChan)
+			subscribers = append(subscribers, ch)
+			return true
+		})
+		batchSize := (len(subscribers) + r.workers - 1) / r.workers
+		done := &sync.WaitGroup{}
+		for start := 0; start < len(subscribers); start += batchSize {
+			done.Add(1)
+			deliveries <- receiptDelivery{
+				receipt:     receipt,
+				subscribers: subscribers[start:min(start+batchSize, len(subscribers))],
+				done:        done,
+			}
+		}
+		done.Wait()
+	}
+}
+
+// deliverReceipts sends each delivery's receipt to the delivery's subscribed channels until deliveries is closed.
+func deliverReceipts(deliveries <-chan receiptDelivery) {
+	for delivery := range deliveries {
+		for _, ch := range delivery.subscribers {
 			// avoid blocking r.ch by making a best-effort delivery here.
 			select {
-			case ch <- receipt:
+			case ch <- delivery.receipt:
 			default:
 			}
-			return true
-		})
+		}
+		delivery.done.Done()
 	}
 }
 
@@ -111,15 +152,14 @@ func (r *receiptsDispatcher) getBatchOfReceiptsFromCardinal(startTick uint64) (
 		return nil, eris.Wrap(err, "")
 	}
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := doRequest(req)
+	buf, err = doRequest(req)
 	if err != nil {
 		return nil, eris.Wrapf(err, "failed to query %q", url)
 	}
-	defer resp.Body.Close()
 
 	reply = &TransactionReceiptsReply{}
 
-	if err = json.NewDecoder(resp.Body).Decode(reply); err != nil {
+	if err = json.Unmarshal(buf, reply); err != nil {
 		return nil, eris.Wrap(err, "")
 	}
 	return reply, nil
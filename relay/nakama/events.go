@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -13,10 +14,51 @@ import (
 	"github.com/rotisserie/eris"
 )
 
+// systemTopic is the topic cardinal uses for messages broadcast by the operator of the server with /debug/broadcast.
+const systemTopic = "system"
+
+// eventQuery asks cardinal to send every event as a topicMessage, so the topic of an event is set by cardinal rather
+// than by whatever the game put in the message. Besides the events of the game, only system messages are asked for:
+// every event is forwarded to all players as a persistent notification.
+const eventQuery = "?envelope=true&topics=" + systemTopic
+
 type Event struct {
+	// topic is empty for the events of the game.
+	topic   string
 	message string
 }
 
+// topicMessage is the format cardinal uses to send events when eventQuery is given.
+type topicMessage struct {
+	Topic   string `json:"topic"`
+	Message string `json:"message"`
+}
+
+// parseEvent reads an event cardinal sent in the format asked for with eventQuery.
+func parseEvent(bz []byte) (*Event, error) {
+	var msg topicMessage
+	if err := json.Unmarshal(bz, &msg); err != nil {
+		return nil, eris.Wrap(err, "event is not a topic message")
+	}
+	if msg.Topic == "" {
+		return &Event{message: msg.Message}, nil
+	}
+	// Events with other topics keep the format cardinal sends them in without the envelope.
+	return &Event{topic: msg.Topic, message: string(bz)}, nil
+}
+
+// systemMessage returns the message of the event if it was broadcast on the system topic.
+func (e *Event) systemMessage() (string, bool) {
+	if e.topic != systemTopic {
+		return "", false
+	}
+	var msg topicMessage
+	if err := json.Unmarshal([]byte(e.message), &msg); err != nil {
+		return "", false
+	}
+	return msg.Message, true
+}
+
 type EventHub struct {
 	inputConnection *websocket.Conn
 	channels        *sync.Map // map[string]chan *Event
@@ -24,7 +66,7 @@ type EventHub struct {
 }
 
 func createEventHub(logger runtime.Logger) (*EventHub, error) {
-	url := makeWebSocketURL(eventEndpoint)
+	url := makeWebSocketURL(eventEndpoint + eventQuery)
 	webSocketConnection, _, err := websocket.DefaultDialer.Dial(url, nil) //nolint:bodyclose // no need.
 	for err != nil {
 		if errors.Is(err, &net.DNSError{}) {
@@ -85,6 +127,11 @@ func (eh *EventHub) Dispatch(log runtime.Logger) error {
 			eh.Shutdown()
 			continue
 		}
+		event, parseErr := parseEvent(message)
+		if parseErr != nil {
+			log.Error("dropped an event: %s", eris.ToString(parseErr, true))
+			continue
+		}
 		eh.channels.Range(func(key any, value any) bool {
 			channel, ok := value.(chan *Event)
 			if !ok {
@@ -92,7 +139,7 @@ func (eh *EventHub) Dispatch(log runtime.Logger) error {
 				eh.Shutdown()
 				return false
 			}
-			channel <- &Event{message: string(message)}
+			channel <- event
 			return true
 		})
 		if err != nil {
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGameEventsCannotPoseAsSystemMessages(t *testing.T) {
+	forged := `{"topic":"system","message":"restarting now"}`
+	bz, err := json.Marshal(topicMessage{Message: forged})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := parseEvent(bz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := event.systemMessage(); ok {
+		t.Fatal("a game event was treated as a system message")
+	}
+	if event.message != forged {
+		t.Fatalf("got message %q, want %q", event.message, forged)
+	}
+
+	event, err = parseEvent([]byte(`{"topic":"system","message":"restarting in 5 minutes"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, ok := event.systemMessage()
+	if !ok || msg != "restarting in 5 minutes" {
+		t.Fatalf("got system message %q, %v", msg, ok)
+	}
+}
@@ -9,13 +9,14 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/heroiclabs/nakama-common/api"
 	"github.com/heroiclabs/nakama-common/runtime"
 	"github.com/rotisserie/eris"
-	"pkg.world.dev/world-engine/sign"
 )
 
 const (
@@ -44,6 +45,11 @@ const (
 	EnvCardinalAddr      = "CARDINAL_ADDR"
 	EnvCardinalNamespace = "CARDINAL_NAMESPACE"
 
+	EnvReceiptDispatchWorkers = "RECEIPT_DISPATCH_WORKERS"
+
+	EnvCardinalMaxResponseSize = "CARDINAL_MAX_RESPONSE_SIZE"
+	EnvCardinalRequestTimeout  = "CARDINAL_REQUEST_TIMEOUT"
+
 	cardinalCollection = "cardinalCollection"
 	personaTagKey      = "personaTag"
 
@@ -87,11 +93,17 @@ func InitModule(
 		return eris.Wrap(err, "failed to init cardinal address")
 	}
 
+	if err := initCardinalResponseLimits(); err != nil {
+		return eris.Wrap(err, "failed to init cardinal response limits")
+	}
+
 	if err := initNamespace(); err != nil {
 		return eris.Wrap(err, "failed to init namespace")
 	}
 
-	initReceiptDispatcher(logger)
+	if err := initReceiptDispatcher(logger); err != nil {
+		return eris.Wrap(err, "failed to init receipt dispatcher")
+	}
 
 	if err := initEventHub(ctx, logger, nk); err != nil {
 		return eris.Wrap(err, "failed to init event hub")
@@ -111,7 +123,14 @@ func InitModule(
 		return eris.Wrap(err, "failed to init persona tag assignment map")
 	}
 
-	ptv := initPersonaTagVerifier(logger, nk, globalReceiptsDispatcher)
+	if err := initPersonaTagReservationTTL(ctx, logger); err != nil {
+		return eris.Wrap(err, "failed to init persona tag reservation ttl")
+	}
+
+	ptv, err := initPersonaTagVerifier(logger, nk, globalReceiptsDispatcher)
+	if err != nil {
+		return eris.Wrap(err, "failed to init persona tag verifier")
+	}
 
 	if err := initPersonaTagEndpoints(logger, initializer, ptv, notifier); err != nil {
 		return eris.Wrap(err, "failed to init persona tag endpoints")
@@ -144,10 +163,21 @@ func initNamespace() error {
 	return nil
 }
 
-func initReceiptDispatcher(log runtime.Logger) {
-	globalReceiptsDispatcher = newReceiptsDispatcher()
+// initReceiptDispatcher starts polling cardinal for receipts. The number of goroutines used to deliver receipts to
+// subscribers can be set with the EnvReceiptDispatchWorkers environment variable and defaults to 1.
+func initReceiptDispatcher(log runtime.Logger) error {
+	workers := 1
+	if workersStr := os.Getenv(EnvReceiptDispatchWorkers); workersStr != "" {
+		var err error
+		workers, err = strconv.Atoi(workersStr)
+		if err != nil || workers < 1 {
+			return eris.Errorf("%s must be a positive integer, got %q", EnvReceiptDispatchWorkers, workersStr)
+		}
+	}
+	globalReceiptsDispatcher = newReceiptsDispatcher(workers)
 	go globalReceiptsDispatcher.pollReceipts(log)
 	go globalReceiptsDispatcher.dispatch(log)
+	return nil
 }
 
 func initEventHub(ctx context.Context, log runtime.Logger, nk runtime.NakamaModule) error {
@@ -166,7 +196,11 @@ func initEventHub(ctx context.Context, log runtime.Logger, nk runtime.NakamaModu
 	go func() {
 		channel := eventHub.Subscribe("main")
 		for event := range channel {
-			err := eris.Wrap(nk.NotificationSendAll(ctx, "event", map[string]interface{}{"message": event.message}, 1, true), "")
+			subject, message := "event", event.message
+			if systemMessage, ok := event.systemMessage(); ok {
+				subject, message = systemTopic, systemMessage
+			}
+			err := eris.Wrap(nk.NotificationSendAll(ctx, subject, map[string]interface{}{"message": message}, 1, true), "")
 			if err != nil {
 				log.Error("error sending notifications: %s", eris.ToString(err, true))
 			}
@@ -219,7 +253,11 @@ func initPersonaTagAssignmentMap(ctx context.Context, logger runtime.Logger, nk
 			}
 			if ptr.Status == personaTagStatusAccepted || ptr.Status == personaTagStatusPending {
 				logger.Debug("%s has been assigned to %s", ptr.PersonaTag, userID)
-				globalPersonaTagAssignment.Store(ptr.PersonaTag, userID)
+				globalPersonaTagAssignment.Store(ptr.PersonaTag, personaTagAssignment{
+					userID:     userID,
+					reservedAt: time.Now(),
+					confirmed:  ptr.Status == personaTagStatusAccepted,
+				})
 			}
 		}
 		if cursor == "" {
@@ -238,7 +276,19 @@ func initPersonaTagEndpoints(
 	if err := initializer.RegisterRpc("nakama/claim-persona", handleClaimPersona(ptv, notifier)); err != nil {
 		return eris.Wrap(err, "")
 	}
-	return eris.Wrap(initializer.RegisterRpc("nakama/show-persona", handleShowPersona), "")
+	if err := initializer.RegisterRpc("nakama/wallet-claim-challenge", handleWalletClaimChallenge); err != nil {
+		return eris.Wrap(err, "")
+	}
+	if err := initializer.RegisterRpc(
+		"nakama/claim-persona-with-wallet",
+		handleClaimPersonaWithWallet(ptv, notifier),
+	); err != nil {
+		return eris.Wrap(err, "")
+	}
+	if err := initializer.RegisterRpc("nakama/show-persona", handleShowPersona); err != nil {
+		return eris.Wrap(err, "")
+	}
+	return eris.Wrap(initializer.RegisterRpc("nakama/persona-status", handlePersonaStatus), "")
 }
 
 // getUserID gets the Nakama UserID from the given context.
@@ -266,8 +316,9 @@ func handleClaimPersona(ptv *personaTagVerifier, notifier *receiptNotifier) naka
 			return logErrorMessageFailedPrecondition(logger, err, "unable to get userID")
 		}
 
-		// check if the user is verified. this requires them to input a valid beta key.
-		err = checkVerified(ctx, nk, userID)
+		// check if the user is verified. this requires them to input a valid beta key, unless they belong to an
+		// allowlist exempt group.
+		err = checkVerifiedOrExempt(ctx, logger, nk, userID)
 		if err != nil {
 			if eris.Is(eris.Cause(err), ErrNotAllowlisted) {
 				return logDebugWithMessageAndCode(logger, err, AlreadyExists, "unable to claim persona tag")
@@ -288,70 +339,86 @@ func handleClaimPersona(ptv *personaTagVerifier, notifier *receiptNotifier) naka
 			)
 		}
 
-		tag, err := loadPersonaTagStorageObj(ctx, nk)
-		if err != nil {
-			if !errors.Is(err, ErrPersonaTagStorageObjNotFound) {
-				return logErrorMessageFailedPrecondition(logger, err, "unable to get persona tag storage object")
-			}
-		} else {
-			switch tag.Status {
-			case personaTagStatusPending:
-				return logDebugWithMessageAndCode(
-					logger,
-					eris.Errorf("persona tag %q is pending for this account", tag.PersonaTag),
-					AlreadyExists,
-					"persona tag %q is pending", tag.PersonaTag,
-				)
-			case personaTagStatusAccepted:
-				return logErrorWithMessageAndCode(
-					logger,
-					eris.Errorf("persona tag %q already associated with this account", tag.PersonaTag),
-					AlreadyExists,
-					"persona tag %q already associated with this account",
-					tag.PersonaTag)
-			case personaTagStatusRejected:
-				// if the tag was rejected, don't do anything. let the user try to claim another tag.
-			}
-		}
-
-		txHash, tick, err := cardinalCreatePersona(ctx, nk, ptr.PersonaTag)
-		if err != nil {
-			return logErrorMessageFailedPrecondition(logger, err, "unable to make create persona request to cardinal")
-		}
-		notifier.AddTxHashToPendingNotifications(txHash, userID)
+		return claimPersonaTag(ctx, logger, nk, ptv, notifier, userID, ptr)
+	}
+}
 
-		ptr.Status = personaTagStatusPending
-		if err = ptr.savePersonaTagStorageObj(ctx, nk); err != nil {
-			return logErrorMessageFailedPrecondition(logger, err, "unable to set persona tag storage object")
+// claimPersonaTag attempts to associate the given user with the persona tag in ptr by submitting a create persona
+// transaction to cardinal. It is shared by all RPCs that claim a persona tag.
+//
+//nolint:gocognit
+func claimPersonaTag(
+	ctx context.Context,
+	logger runtime.Logger,
+	nk runtime.NakamaModule,
+	ptv *personaTagVerifier,
+	notifier *receiptNotifier,
+	userID string,
+	ptr *personaTagStorageObj,
+) (string, error) {
+	tag, err := loadPersonaTagStorageObj(ctx, nk)
+	if err != nil {
+		if !errors.Is(err, ErrPersonaTagStorageObjNotFound) {
+			return logErrorMessageFailedPrecondition(logger, err, "unable to get persona tag storage object")
 		}
-
-		// Try to actually assign this personaTag->UserID in the sync map. If this succeeds, Nakama is OK with this
-		// user having the persona tag.
-		if ok := setPersonaTagAssignment(ptr.PersonaTag, userID); !ok {
-			ptr.Status = personaTagStatusRejected
-			if err = ptr.savePersonaTagStorageObj(ctx, nk); err != nil {
-				return logErrorMessageFailedPrecondition(logger, err, "unable to set persona tag storage object")
-			}
+	} else {
+		switch tag.Status {
+		case personaTagStatusPending:
+			return logDebugWithMessageAndCode(
+				logger,
+				eris.Errorf("persona tag %q is pending for this account", tag.PersonaTag),
+				AlreadyExists,
+				"persona tag %q is pending", tag.PersonaTag,
+			)
+		case personaTagStatusAccepted:
 			return logErrorWithMessageAndCode(
 				logger,
-				eris.Errorf("persona tag %q is not available", ptr.PersonaTag),
+				eris.Errorf("persona tag %q already associated with this account", tag.PersonaTag),
 				AlreadyExists,
-				"persona tag %q is not available",
-				ptr.PersonaTag)
+				"persona tag %q already associated with this account",
+				tag.PersonaTag)
+		case personaTagStatusRejected:
+			// if the tag was rejected, don't do anything. let the user try to claim another tag.
 		}
+	}
+
+	txHash, tick, err := cardinalCreatePersona(ctx, nk, ptr.PersonaTag)
+	if err != nil {
+		return logErrorMessageFailedPrecondition(logger, err, "unable to make create persona request to cardinal")
+	}
+	notifier.AddTxHashToPendingNotifications(txHash, userID)
+
+	ptr.Status = personaTagStatusPending
+	if err = ptr.savePersonaTagStorageObj(ctx, nk); err != nil {
+		return logErrorMessageFailedPrecondition(logger, err, "unable to set persona tag storage object")
+	}
 
-		ptr.Tick = tick
-		ptr.TxHash = txHash
+	// Try to actually assign this personaTag->UserID in the sync map. If this succeeds, Nakama is OK with this
+	// user having the persona tag.
+	if ok := setPersonaTagAssignment(ptr.PersonaTag, userID); !ok {
+		ptr.Status = personaTagStatusRejected
 		if err = ptr.savePersonaTagStorageObj(ctx, nk); err != nil {
-			return logErrorMessageFailedPrecondition(logger, err, "unable to save persona tag storage object")
-		}
-		ptv.addPendingPersonaTag(userID, ptr.TxHash)
-		res, err := ptr.toJSON()
-		if err != nil {
-			return logErrorMessageFailedPrecondition(logger, err, "unable to marshal response")
+			return logErrorMessageFailedPrecondition(logger, err, "unable to set persona tag storage object")
 		}
-		return res, nil
+		return logErrorWithMessageAndCode(
+			logger,
+			eris.Errorf("persona tag %q is not available", ptr.PersonaTag),
+			AlreadyExists,
+			"persona tag %q is not available",
+			ptr.PersonaTag)
+	}
+
+	ptr.Tick = tick
+	ptr.TxHash = txHash
+	if err = ptr.savePersonaTagStorageObj(ctx, nk); err != nil {
+		return logErrorMessageFailedPrecondition(logger, err, "unable to save persona tag storage object")
+	}
+	ptv.addPendingPersonaTag(userID, ptr.TxHash)
+	res, err := ptr.toJSON()
+	if err != nil {
+		return logErrorMessageFailedPrecondition(logger, err, "unable to marshal response")
 	}
+	return res, nil
 }
 
 func handleShowPersona(ctx context.Context, logger runtime.Logger, _ *sql.DB, nk runtime.NakamaModule, _ string,
@@ -374,6 +441,54 @@ func handleShowPersona(ctx context.Context, logger runtime.Logger, _ *sql.DB, nk
 	return res, nil
 }
 
+// personaStatusResponse is returned by the persona-status RPC. It intentionally leaves out the user ID and the signer
+// address of the owner of the persona tag.
+type personaStatusResponse struct {
+	PersonaTag string `json:"personaTag"`
+	// Claimed is true if the persona tag has been claimed by anyone, even if cardinal has not yet accepted the claim.
+	Claimed bool `json:"claimed"`
+	// Accepted is true if cardinal has assigned the persona tag to a signer.
+	Accepted bool `json:"accepted"`
+}
+
+// handlePersonaStatus reports whether the persona tag in the payload has been claimed and accepted. This allows
+// clients to check whether a persona tag is available before attempting to claim it.
+func handlePersonaStatus(ctx context.Context, logger runtime.Logger, _ *sql.DB, _ runtime.NakamaModule,
+	payload string,
+) (string, error) {
+	ptr := &personaTagStorageObj{}
+	if err := json.Unmarshal([]byte(payload), ptr); err != nil {
+		return logErrorMessageFailedPrecondition(logger, eris.Wrap(err, ""), "unable to marshal payload")
+	}
+	if ptr.PersonaTag == "" {
+		return logErrorWithMessageAndCode(
+			logger,
+			eris.New("personaTag field was empty"),
+			InvalidArgument,
+			"personaTag field must not be empty",
+		)
+	}
+
+	res := personaStatusResponse{PersonaTag: ptr.PersonaTag}
+	_, err := cardinalQueryPersonaSigner(ctx, ptr.PersonaTag, 0)
+	switch {
+	case err == nil:
+		res.Claimed = true
+		res.Accepted = true
+	case eris.Is(eris.Cause(err), ErrPersonaSignerAvailable), eris.Is(eris.Cause(err), ErrPersonaSignerUnknown):
+		// Cardinal has not assigned the tag (yet), but a claim for it may already be in flight.
+		_, res.Claimed = globalPersonaTagAssignment.Load(ptr.PersonaTag)
+	default:
+		return logErrorMessageFailedPrecondition(logger, err, "unable to query persona signer")
+	}
+
+	buf, err := json.Marshal(res)
+	if err != nil {
+		return logErrorMessageFailedPrecondition(logger, eris.Wrap(err, ""), "unable to marshal response")
+	}
+	return string(buf), nil
+}
+
 // initCardinalEndpoints queries the cardinal server to find the list of existing endpoints, and attempts to
 // set up RPC wrappers around each one.
 //
@@ -386,9 +501,10 @@ func initCardinalEndpoints(logger runtime.Logger, initializer runtime.Initialize
 
 	createTransaction := func(payload string, endpoint string, nk runtime.NakamaModule, ctx context.Context,
 	) (io.Reader, error) {
-		logger.Debug("The %s endpoint requires a signed payload", endpoint)
+		traceID := getTraceID(ctx)
+		logger.Debug("The %s endpoint requires a signed payload (trace ID %q)", endpoint, traceID)
 		var transaction io.Reader
-		transaction, err = makeTransaction(ctx, nk, payload)
+		transaction, err = makeTransaction(ctx, nk, payload, traceID)
 		if err != nil {
 			return nil, err
 		}
@@ -426,6 +542,11 @@ func initCardinalEndpoints(logger runtime.Logger, initializer runtime.Initialize
 					return logErrorMessageFailedPrecondition(logger, err, "unable to make payload")
 				}
 
+				if globalRequestTimeout > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, globalRequestTimeout)
+					defer cancel()
+				}
 				req, err := http.NewRequestWithContext(ctx, http.MethodPost, makeHTTPURL(currEndpoint), resultPayload)
 				req.Header.Set("Content-Type", "application/json")
 				if err != nil {
@@ -436,8 +557,9 @@ func initCardinalEndpoints(logger runtime.Logger, initializer runtime.Initialize
 					return logErrorMessageFailedPrecondition(logger, err, "request failed for endpoint %q", currEndpoint)
 				}
 				defer resp.Body.Close()
-				if resp.StatusCode != http.StatusOK {
-					body, err := io.ReadAll(resp.Body)
+				// Cardinal answers transactions with 202 Accepted when it runs with WithAsyncTxResponses.
+				if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+					body, err := readResponseBody(resp)
 					if err != nil {
 						return logErrorMessageFailedPrecondition(
 							logger,
@@ -451,7 +573,7 @@ func initCardinalEndpoints(logger runtime.Logger, initializer runtime.Initialize
 						"bad status code: %s: %s", resp.Status, body,
 					)
 				}
-				bz, err := io.ReadAll(resp.Body)
+				bz, err := readResponseBody(resp)
 				if err != nil {
 					return logErrorMessageFailedPrecondition(logger, err, "can't read body")
 				}
@@ -465,6 +587,7 @@ func initCardinalEndpoints(logger runtime.Logger, initializer runtime.Initialize
 					if err != nil {
 						return logErrorMessageFailedPrecondition(logger, err, "unable to get user id")
 					}
+					logger.Debug("tx %q submitted to cardinal (trace ID %q)", asTx.TxHash, asTx.TraceID)
 					notify.AddTxHashToPendingNotifications(asTx.TxHash, userID)
 				}
 
@@ -553,17 +676,21 @@ func errToNakamaError(err error, code int) error {
 }
 
 // setPersonaTagAssignment attempts to associate a given persona tag with the given user ID, and returns
-// true if the attempt was successful or false if it failed. This method is safe for concurrent access.
+// true if the attempt was successful or false if it failed. The association is an unconfirmed reservation until
+// confirmPersonaTagAssignment is called. This method is safe for concurrent access.
 func setPersonaTagAssignment(personaTag, userID string) (ok bool) {
-	val, loaded := globalPersonaTagAssignment.LoadOrStore(personaTag, userID)
+	val, loaded := globalPersonaTagAssignment.LoadOrStore(personaTag, personaTagAssignment{
+		userID:     userID,
+		reservedAt: time.Now(),
+	})
 	if !loaded {
 		return true
 	}
-	gotUserID, _ := val.(string)
-	return gotUserID == userID
+	assignment, _ := val.(personaTagAssignment)
+	return assignment.userID == userID
 }
 
-func makeTransaction(ctx context.Context, nk runtime.NakamaModule, payload string) (io.Reader, error) {
+func makeTransaction(ctx context.Context, nk runtime.NakamaModule, payload, traceID string) (io.Reader, error) {
 	ptr, err := loadPersonaTagStorageObj(ctx, nk)
 	if err != nil {
 		return nil, err
@@ -576,12 +703,7 @@ func makeTransaction(ctx context.Context, nk runtime.NakamaModule, payload strin
 	if ptr.Status != personaTagStatusAccepted {
 		return nil, eris.Wrap(ErrNoPersonaTagForUser, "")
 	}
-	personaTag := ptr.PersonaTag
-	pk, nonce, err := getPrivateKeyAndANonce(ctx, nk)
-	if err != nil {
-		return nil, err
-	}
-	sp, err := sign.NewTransaction(pk, personaTag, globalNamespace, nonce, payload)
+	sp, err := newNakamaTxSigner(nk).signPersonaTx(ctx, ptr.PersonaTag, payload, traceID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/rotisserie/eris"
+)
+
+var (
+	// personaTagReservationTTLEnvVar is how long (e.g. "10m") a claimed persona tag stays reserved while cardinal has
+	// not yet confirmed the claim. Once the TTL passes, the tag is released so other users can claim it. Unset or zero
+	// means reservations never expire.
+	personaTagReservationTTLEnvVar = "PERSONA_TAG_RESERVATION_TTL"
+)
+
+const maxPersonaTagReservationSweepInterval = time.Minute
+
+// personaTagAssignment is the value stored in globalPersonaTagAssignment. Values are never mutated in place so they
+// can be safely swapped and deleted with the sync.Map compare methods.
+type personaTagAssignment struct {
+	userID     string
+	reservedAt time.Time
+	// confirmed is true once cardinal has accepted the persona tag for this user. Confirmed assignments never expire.
+	confirmed bool
+}
+
+// initPersonaTagReservationTTL reads the persona tag reservation TTL from the environment and, if set, starts a
+// background sweep that releases stale reservations.
+func initPersonaTagReservationTTL(ctx context.Context, logger runtime.Logger) error {
+	ttlStr := os.Getenv(personaTagReservationTTLEnvVar)
+	if ttlStr == "" {
+		return nil
+	}
+	ttl, err := time.ParseDuration(ttlStr)
+	if err != nil {
+		return eris.Wrapf(err, "the %s variable %q is not a valid duration", personaTagReservationTTLEnvVar, ttlStr)
+	}
+	if ttl <= 0 {
+		return nil
+	}
+	go sweepPersonaTagReservations(ctx, logger, ttl)
+	return nil
+}
+
+func sweepPersonaTagReservations(ctx context.Context, logger runtime.Logger, ttl time.Duration) {
+	interval := ttl
+	if interval > maxPersonaTagReservationSweepInterval {
+		interval = maxPersonaTagReservationSweepInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			releaseExpiredPersonaTagReservations(logger, now, ttl)
+		}
+	}
+}
+
+// releaseExpiredPersonaTagReservations removes all unconfirmed persona tag reservations that are older than ttl.
+func releaseExpiredPersonaTagReservations(logger runtime.Logger, now time.Time, ttl time.Duration) {
+	globalPersonaTagAssignment.Range(func(key, value any) bool {
+		assignment, ok := value.(personaTagAssignment)
+		if !ok || assignment.confirmed || now.Sub(assignment.reservedAt) < ttl {
+			return true
+		}
+		// The compare guards against releasing a reservation that was confirmed since Range loaded it.
+		if globalPersonaTagAssignment.CompareAndDelete(key, value) {
+			logger.Info("released unconfirmed reservation of persona tag %q by user %q", key, assignment.userID)
+		}
+		return true
+	})
+}
+
+// confirmPersonaTagAssignment marks the reservation of the given persona tag by the given user as confirmed by
+// cardinal, so it is never released.
+func confirmPersonaTagAssignment(personaTag, userID string) {
+	for {
+		val, loaded := globalPersonaTagAssignment.Load(personaTag)
+		if !loaded {
+			// The reservation expired before cardinal confirmed it. Cardinal is the source of truth, so reassign it.
+			val, loaded = globalPersonaTagAssignment.LoadOrStore(personaTag, personaTagAssignment{
+				userID:     userID,
+				reservedAt: time.Now(),
+				confirmed:  true,
+			})
+			if !loaded {
+				return
+			}
+		}
+		assignment, _ := val.(personaTagAssignment)
+		if assignment.userID != userID || assignment.confirmed {
+			return
+		}
+		assignment.confirmed = true
+		if globalPersonaTagAssignment.CompareAndSwap(personaTag, val, assignment) {
+			return
+		}
+	}
+}
+
+// releasePersonaTagReservation removes the unconfirmed reservation of the given persona tag by the given user, so that
+// the persona tag can be claimed again.
+func releasePersonaTagReservation(personaTag, userID string) {
+	val, ok := globalPersonaTagAssignment.Load(personaTag)
+	if !ok {
+		return
+	}
+	if assignment, _ := val.(personaTagAssignment); assignment.userID == userID && !assignment.confirmed {
+		globalPersonaTagAssignment.CompareAndDelete(personaTag, val)
+	}
+}
@@ -16,6 +16,9 @@ type personaTagStorageObj struct {
 	Status     personaTagStatus `json:"status"`
 	Tick       uint64           `json:"tick"`
 	TxHash     string           `json:"txHash"`
+	// WalletAddress is an optional EVM address that will be authorized for this persona tag once the persona tag
+	// has been accepted by cardinal.
+	WalletAddress string `json:"walletAddress,omitempty"`
 	// version is used with Nakama storage layer to allow for optimistic locking. Saving this storage
 	// object succeeds only if the passed in version matches the version in the storage layer.
 	// see https://heroiclabs.com/docs/nakama/concepts/storage/collections/#conditional-writes for more info.
@@ -96,6 +99,11 @@ func (p *personaTagStorageObj) attemptToUpdatePending(ctx context.Context, nk ru
 			p.Status = personaTagStatusRejected
 		}
 	}
+	if p.Status == personaTagStatusAccepted {
+		if userID, err := getUserID(ctx); err == nil {
+			confirmPersonaTagAssignment(p.PersonaTag, userID)
+		}
+	}
 	// Attempt to save the updated Status to Nakama. One reason this can fail is that the underlying record was
 	// updated while this processing was going on. Whatever the reason, re-fetch this record from Nakama's storage.
 	if err = p.savePersonaTagStorageObj(ctx, nk); err != nil {
@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"os"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/heroiclabs/nakama-common/runtime"
@@ -21,12 +24,32 @@ type personaTagVerifier struct {
 	pendingCh       chan txHashAndUserID
 	nk              runtime.NakamaModule
 	logger          runtime.Logger
+	// pollInterval is how often cardinal is asked about claims whose receipt has not arrived.
+	pollInterval time.Duration
+	// maxPollAttempts is how many times cardinal is asked about a claim before the claim is rejected. Zero means the
+	// claim is polled until cardinal confirms or rejects it.
+	maxPollAttempts int
+	// polledCh receives the claims polled by startPoll once cardinal answered about all of them.
+	polledCh chan []polledClaim
+	// polling is set while a poll started by startPoll is running.
+	polling bool
+	// authorizer authorizes the wallets of accepted claims made with nakama/claim-persona-with-wallet.
+	authorizer *walletAuthorizer
 }
 
 type pendingPersonaTagRequest struct {
 	lastUpdate time.Time
 	userID     string
 	status     personaTagStatus
+	attempts   int
+}
+
+// polledClaim is a pending claim that is polled, along with the status cardinal reported for it.
+type polledClaim struct {
+	txHash     string
+	userID     string
+	lastUpdate time.Time
+	status     personaTagStatus
 }
 
 type txHashAndUserID struct {
@@ -37,6 +60,24 @@ type txHashAndUserID struct {
 //nolint:gosec // its ok
 const personaVerifierSessionName = "persona_verifier_session"
 
+var (
+	// personaTagPollIntervalEnvVar is how often (e.g. "10s") the relay asks cardinal whether a pending persona tag
+	// claim was accepted, in case the receipt of the claim was missed.
+	personaTagPollIntervalEnvVar = "PERSONA_TAG_CONFIRMATION_POLL_INTERVAL"
+	// personaTagMaxPollAttemptsEnvVar is how many times the relay asks cardinal about a pending claim before giving up,
+	// rejecting the claim and notifying the user. Zero means the relay never gives up.
+	personaTagMaxPollAttemptsEnvVar = "PERSONA_TAG_CONFIRMATION_MAX_ATTEMPTS"
+)
+
+const (
+	defaultPersonaTagPollInterval    = 10 * time.Second
+	defaultPersonaTagMaxPollAttempts = 30
+	// personaTagPollBatchSize is the maximum number of claims cardinal is asked about per poll interval.
+	personaTagPollBatchSize = 20
+	// staleReceiptDuration is how long a receipt for an unknown claim is kept in case the claim shows up.
+	staleReceiptDuration = time.Minute
+)
+
 func (p *personaTagVerifier) addPendingPersonaTag(userID, txHash string) {
 	p.pendingCh <- txHashAndUserID{
 		userID: userID,
@@ -45,7 +86,7 @@ func (p *personaTagVerifier) addPendingPersonaTag(userID, txHash string) {
 }
 
 func initPersonaTagVerifier(logger runtime.Logger, nk runtime.NakamaModule, rd *receiptsDispatcher,
-) *personaTagVerifier {
+) (*personaTagVerifier, error) {
 	channelLimit := 100
 	ptv := &personaTagVerifier{
 		txHashToPending: map[string]pendingPersonaTagRequest{},
@@ -53,19 +94,41 @@ func initPersonaTagVerifier(logger runtime.Logger, nk runtime.NakamaModule, rd *
 		pendingCh:       make(chan txHashAndUserID),
 		nk:              nk,
 		logger:          logger,
+		pollInterval:    defaultPersonaTagPollInterval,
+		maxPollAttempts: defaultPersonaTagMaxPollAttempts,
+		polledCh:        make(chan []polledClaim, 1),
+		authorizer:      newWalletAuthorizer(logger, nk),
+	}
+	if intervalStr := os.Getenv(personaTagPollIntervalEnvVar); intervalStr != "" {
+		interval, err := time.ParseDuration(intervalStr)
+		if err != nil || interval <= 0 {
+			return nil, eris.Errorf("%s must be a positive duration, got %q", personaTagPollIntervalEnvVar, intervalStr)
+		}
+		ptv.pollInterval = interval
+	}
+	if attemptsStr := os.Getenv(personaTagMaxPollAttemptsEnvVar); attemptsStr != "" {
+		attempts, err := strconv.Atoi(attemptsStr)
+		if err != nil || attempts < 0 {
+			return nil, eris.Errorf("%s must be a non-negative integer, got %q", personaTagMaxPollAttemptsEnvVar,
+				attemptsStr)
+		}
+		ptv.maxPollAttempts = attempts
 	}
 	rd.subscribe(personaVerifierSessionName, ptv.receiptCh)
 	go ptv.consume()
-	return ptv
+	return ptv, nil
 }
 
 func (p *personaTagVerifier) consume() {
-	cleanupTick := time.Tick(time.Minute)
+	pollTick := time.Tick(p.pollInterval)
 	for {
 		var currTxHash string
 		select {
-		case now := <-cleanupTick:
+		case now := <-pollTick:
 			p.cleanupStaleEntries(now)
+			p.startPoll()
+		case polled := <-p.polledCh:
+			p.handlePolled(polled)
 		case receipt := <-p.receiptCh:
 			currTxHash = p.handleReceipt(receipt)
 		case pending := <-p.pendingCh:
@@ -80,14 +143,142 @@ func (p *personaTagVerifier) consume() {
 	}
 }
 
+// cleanupStaleEntries removes entries that are not waiting on cardinal: receipts for claims this relay never saw, and
+// claims whose verification failed. Claims waiting on cardinal are handled by startPoll.
 func (p *personaTagVerifier) cleanupStaleEntries(now time.Time) {
 	for key, val := range p.txHashToPending {
-		if diff := now.Sub(val.lastUpdate); diff > time.Minute {
+		if val.userID != "" && val.status == "" {
+			continue
+		}
+		if diff := now.Sub(val.lastUpdate); diff > staleReceiptDuration {
 			delete(p.txHashToPending, key)
 		}
 	}
 }
 
+// startPoll asks cardinal about the claims whose receipt has not arrived yet. Cardinal is queried from a goroutine of
+// its own, so claims and receipts keep being handled in the meantime, and the answers are handled by handlePolled. At
+// most personaTagPollBatchSize claims are polled at a time, starting with the ones that were polled least recently.
+func (p *personaTagVerifier) startPoll() {
+	if p.polling {
+		return
+	}
+	batch := make([]polledClaim, 0)
+	for txHash, pending := range p.txHashToPending {
+		if pending.userID == "" || pending.status != "" {
+			continue
+		}
+		batch = append(batch, polledClaim{txHash: txHash, userID: pending.userID, lastUpdate: pending.lastUpdate})
+	}
+	if len(batch) == 0 {
+		return
+	}
+	sort.Slice(batch, func(i, j int) bool {
+		return batch[i].lastUpdate.Before(batch[j].lastUpdate)
+	})
+	if len(batch) > personaTagPollBatchSize {
+		batch = batch[:personaTagPollBatchSize]
+	}
+	p.polling = true
+	go func() {
+		for i := range batch {
+			status, err := p.queryClaimStatus(batch[i].userID, batch[i].txHash)
+			if err != nil {
+				p.logger.Error("failed to poll persona tag claim %q: %s", batch[i].txHash, eris.ToString(err, true))
+			}
+			batch[i].status = status
+		}
+		p.polledCh <- batch
+	}()
+}
+
+// handlePolled applies the statuses cardinal reported for the claims polled by startPoll. Claims that are still
+// unconfirmed after maxPollAttempts are rejected, so a claim never stays pending forever.
+func (p *personaTagVerifier) handlePolled(batch []polledClaim) {
+	p.polling = false
+	for _, polled := range batch {
+		pending, ok := p.txHashToPending[polled.txHash]
+		if !ok || pending.userID != polled.userID || pending.status != "" {
+			// The claim was resolved while cardinal was polled, e.g. because its receipt arrived.
+			continue
+		}
+		pending.attempts++
+		pending.lastUpdate = time.Now()
+		status := polled.status
+		giveUp := status == personaTagStatusPending && p.maxPollAttempts > 0 && pending.attempts >= p.maxPollAttempts
+		switch {
+		case status == "":
+			// The claim was already resolved elsewhere, e.g. by the persona status RPC.
+			delete(p.txHashToPending, polled.txHash)
+			continue
+		case giveUp:
+			pending.status = personaTagStatusRejected
+		case status != personaTagStatusPending:
+			pending.status = status
+		}
+		p.txHashToPending[polled.txHash] = pending
+		if pending.status == "" {
+			continue
+		}
+		if err := p.attemptVerification(polled.txHash); err != nil {
+			p.logger.Error("failed to verify persona tag: %s", eris.ToString(err, true))
+			continue
+		}
+		if giveUp {
+			p.abandonClaim(pending.userID, polled.txHash)
+		}
+	}
+}
+
+// queryClaimStatus asks cardinal whether the pending claim of the given user was accepted. An empty status is
+// returned if the user no longer has a pending claim with the given tx hash.
+func (p *personaTagVerifier) queryClaimStatus(userID, txHash string) (personaTagStatus, error) {
+	ctx := context.WithValue(context.Background(), runtime.RUNTIME_CTX_USER_ID, userID) //nolint:staticcheck // its fine.
+	ptr, err := loadPersonaTagStorageObj(ctx, p.nk)
+	if err != nil {
+		return personaTagStatusPending, err
+	}
+	if ptr.Status != personaTagStatusPending || ptr.TxHash != txHash {
+		return "", nil
+	}
+	verified, err := ptr.verifyPersonaTag(ctx)
+	switch {
+	case eris.Is(eris.Cause(err), ErrPersonaSignerUnknown):
+		return personaTagStatusPending, nil
+	case eris.Is(eris.Cause(err), ErrPersonaSignerAvailable):
+		return personaTagStatusRejected, nil
+	case err != nil:
+		return personaTagStatusPending, err
+	case verified:
+		return personaTagStatusAccepted, nil
+	default:
+		return personaTagStatusRejected, nil
+	}
+}
+
+// abandonClaim releases the reservation of a claim that cardinal never confirmed and tells the user the claim failed,
+// so they can claim a persona tag again.
+func (p *personaTagVerifier) abandonClaim(userID, txHash string) {
+	ctx := context.WithValue(context.Background(), runtime.RUNTIME_CTX_USER_ID, userID) //nolint:staticcheck // its fine.
+	ptr, err := loadPersonaTagStorageObj(ctx, p.nk)
+	if err != nil {
+		p.logger.Error("failed to load abandoned persona tag claim %q: %s", txHash, eris.ToString(err, true))
+		return
+	}
+	releasePersonaTagReservation(ptr.PersonaTag, userID)
+	p.logger.Info("persona tag %q claimed by user %q was not confirmed by cardinal after %d attempts",
+		ptr.PersonaTag, userID, p.maxPollAttempts)
+	content := map[string]any{
+		"personaTag": ptr.PersonaTag,
+		"txHash":     txHash,
+		"status":     personaTagStatusRejected,
+		"reason":     "the claim was not confirmed by cardinal",
+	}
+	if err = p.nk.NotificationSend(ctx, userID, "persona tag claim failed", content, 1, "", true); err != nil {
+		p.logger.Error("failed to notify user %q of failed persona tag claim: %s", userID, eris.ToString(err, true))
+	}
+}
+
 func (p *personaTagVerifier) handleReceipt(receipt *Receipt) string {
 	result, ok := receipt.Result["Success"]
 	if !ok {
@@ -138,6 +329,12 @@ func (p *personaTagVerifier) attemptVerification(txHash string) error {
 		return eris.Wrap(err, "unable to set persona tag storage object")
 	}
 	delete(p.txHashToPending, txHash)
+	if ptr.Status == personaTagStatusAccepted {
+		confirmPersonaTagAssignment(ptr.PersonaTag, pending.userID)
+	}
 	p.logger.Debug("result of associating user %q with persona tag %q: %v", pending.userID, ptr.PersonaTag, pending.status)
+	if ptr.Status == personaTagStatusAccepted && ptr.WalletAddress != "" {
+		p.authorizer.add(pending.userID, ptr.PersonaTag, ptr.WalletAddress)
+	}
 	return nil
 }
@@ -156,14 +156,3 @@ func incrementNonce(ctx context.Context, nk runtime.NakamaModule) (nonce uint64,
 	}
 	return nonce, nil
 }
-
-// getPrivateKeyAndANonce returns the global Nakama private key, as well as a unique nonce that can
-// be used to sign a transaction. The nonce is guaranteed to be unique, and this method is safe
-// for concurrent access.
-func getPrivateKeyAndANonce(ctx context.Context, nk runtime.NakamaModule) (*ecdsa.PrivateKey, uint64, error) {
-	nonce, err := incrementNonce(ctx, nk)
-	if err != nil {
-		return nil, 0, err
-	}
-	return globalPrivateKey, nonce, nil
-}
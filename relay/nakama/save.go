@@ -128,9 +128,10 @@ func handleGetSaveGame(ctx context.Context, logger runtime.Logger, _ *sql.DB, nk
 		}
 	}
 
-	// check if the user is allowlisted. NOTE: checkVerified will return nil in two cases:
+	// check if the user is allowlisted. NOTE: checkVerified will return nil in three cases:
 	// case 1: if the allowlist is disabled (via ENABLE_ALLOWLIST env var).
-	// case 2: the user is actually allowlisted.
+	// case 2: if verification is skipped for local development (via DEV_AUTO_VERIFY env var).
+	// case 3: the user is actually allowlisted.
 	var verified bool
 	err = checkVerified(ctx, nk, userID)
 	if err != nil {
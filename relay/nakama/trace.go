@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+const (
+	traceIDQueryParam = "traceId"
+	traceIDByteLength = 16
+)
+
+// getTraceID returns the trace ID the client supplied via the traceId query parameter. If no trace ID was supplied,
+// a new random one is generated so the transaction can still be followed through the relay and cardinal logs.
+func getTraceID(ctx context.Context) string {
+	if params, ok := ctx.Value(runtime.RUNTIME_CTX_QUERY_PARAMS).(map[string][]string); ok {
+		if ids := params[traceIDQueryParam]; len(ids) > 0 && ids[0] != "" {
+			return ids[0]
+		}
+	}
+	buf := make([]byte, traceIDByteLength)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
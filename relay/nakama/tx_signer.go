@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/rotisserie/eris"
+	"pkg.world.dev/world-engine/sign"
+)
+
+// nonceSource hands out the nonces of the transactions signed by a txSigner. A nonce must never be handed out twice,
+// or cardinal rejects the second transaction.
+type nonceSource interface {
+	nextNonce(ctx context.Context) (uint64, error)
+}
+
+// nakamaNonceSource hands out the nonces stored in Nakama's storage, which survive a restart of Nakama.
+type nakamaNonceSource struct {
+	nk runtime.NakamaModule
+}
+
+func (s nakamaNonceSource) nextNonce(ctx context.Context) (uint64, error) {
+	return incrementNonce(ctx, s.nk)
+}
+
+// txSigner signs the transactions Nakama sends to cardinal. It holds everything it needs, so the signing flow can be
+// used, and tested, without the package level state of Nakama.
+type txSigner struct {
+	key       *ecdsa.PrivateKey
+	namespace string
+	nonces    nonceSource
+}
+
+func newTxSigner(key *ecdsa.PrivateKey, namespace string, nonces nonceSource) *txSigner {
+	return &txSigner{key: key, namespace: namespace, nonces: nonces}
+}
+
+// newNakamaTxSigner returns a txSigner that signs with Nakama's private key for the cardinal namespace, using the
+// nonces stored in Nakama's storage.
+func newNakamaTxSigner(nk runtime.NakamaModule) *txSigner {
+	return newTxSigner(globalPrivateKey, globalNamespace, nakamaNonceSource{nk: nk})
+}
+
+// signPersonaTx signs the given payload as a transaction of the given persona tag. A string or []byte payload must
+// already be JSON; any other payload is encoded to JSON.
+func (s *txSigner) signPersonaTx(ctx context.Context, personaTag string, payload any, traceID string,
+) (*sign.Transaction, error) {
+	nonce, err := s.nonces.nextNonce(ctx)
+	if err != nil {
+		return nil, eris.Wrap(err, "unable to get a nonce")
+	}
+	tx, err := sign.NewTransaction(s.key, personaTag, s.namespace, nonce, payload)
+	if err != nil {
+		return nil, eris.Wrap(err, "unable to create signed payload")
+	}
+	tx.TraceID = traceID
+	return tx, nil
+}
+
+// signSystemTx signs the given payload as a system transaction, e.g. to create a persona.
+func (s *txSigner) signSystemTx(ctx context.Context, payload any) (*sign.Transaction, error) {
+	nonce, err := s.nonces.nextNonce(ctx)
+	if err != nil {
+		return nil, eris.Wrap(err, "unable to get a nonce")
+	}
+	tx, err := sign.NewSystemTransaction(s.key, s.namespace, nonce, payload)
+	if err != nil {
+		return nil, eris.Wrap(err, "unable to create signed payload")
+	}
+	return tx, nil
+}
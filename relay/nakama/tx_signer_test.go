@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"pkg.world.dev/world-engine/sign"
+)
+
+type fakeNonceSource struct {
+	next uint64
+	err  error
+}
+
+func (f *fakeNonceSource) nextNonce(context.Context) (uint64, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.next++
+	return f.next, nil
+}
+
+func newTestTxSigner(t *testing.T, nonces nonceSource) (*txSigner, string) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newTxSigner(key, "test-namespace", nonces), crypto.PubkeyToAddress(key.PublicKey).Hex()
+}
+
+func TestSignPersonaTx(t *testing.T) {
+	signer, address := newTestTxSigner(t, &fakeNonceSource{next: 41})
+
+	tx, err := signer.signPersonaTx(context.Background(), "CoolMage", `{"x":1}`, "trace-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Namespace != "test-namespace" {
+		t.Errorf("namespace is %q, want %q", tx.Namespace, "test-namespace")
+	}
+	if tx.PersonaTag != "CoolMage" {
+		t.Errorf("persona tag is %q, want %q", tx.PersonaTag, "CoolMage")
+	}
+	if tx.Nonce != 42 {
+		t.Errorf("nonce is %d, want 42", tx.Nonce)
+	}
+	if tx.TraceID != "trace-1" {
+		t.Errorf("trace ID is %q, want %q", tx.TraceID, "trace-1")
+	}
+	if err = tx.Verify(address); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+
+	// Every transaction gets a nonce of its own.
+	tx, err = signer.signPersonaTx(context.Background(), "CoolMage", `{"x":1}`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Nonce != 43 {
+		t.Errorf("nonce is %d, want 43", tx.Nonce)
+	}
+}
+
+func TestSignSystemTx(t *testing.T) {
+	signer, address := newTestTxSigner(t, &fakeNonceSource{})
+
+	payload := struct {
+		PersonaTag string `json:"personaTag"`
+	}{PersonaTag: "CoolMage"}
+	tx, err := signer.signSystemTx(context.Background(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tx.IsSystemTransaction() {
+		t.Errorf("persona tag is %q, want %q", tx.PersonaTag, sign.SystemPersonaTag)
+	}
+	if tx.Namespace != "test-namespace" || tx.Nonce != 1 {
+		t.Errorf("got namespace %q and nonce %d, want %q and 1", tx.Namespace, tx.Nonce, "test-namespace")
+	}
+	if err = tx.Verify(address); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+}
+
+func TestSignTxFailsWithoutNonce(t *testing.T) {
+	errNoNonce := errors.New("no nonce")
+	signer, _ := newTestTxSigner(t, &fakeNonceSource{err: errNoNonce})
+
+	if _, err := signer.signPersonaTx(context.Background(), "CoolMage", `{"x":1}`, ""); !errors.Is(err, errNoNonce) {
+		t.Errorf("got error %v, want %v", err, errNoNonce)
+	}
+	if _, err := signer.signSystemTx(context.Background(), `{"x":1}`); !errors.Is(err, errNoNonce) {
+		t.Errorf("got error %v, want %v", err, errNoNonce)
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/rotisserie/eris"
+)
+
+var (
+	ErrInvalidWalletSignature      = errors.New("invalid wallet signature")
+	ErrWalletClaimChallengeInvalid = errors.New("wallet claim challenge is missing, expired or for another persona tag")
+)
+
+const (
+	// walletClaimChallengeTTL is how long the message returned by the nakama/wallet-claim-challenge RPC can be used to
+	// claim a persona tag with a wallet.
+	walletClaimChallengeTTL = 5 * time.Minute
+	// walletAuthorizationRetryInterval is how often authorizations of wallets that cardinal did not accept are retried.
+	walletAuthorizationRetryInterval = 10 * time.Second
+	// maxWalletAuthorizationAttempts is how many times the relay submits the authorization of a wallet before it gives
+	// up on it.
+	maxWalletAuthorizationAttempts = 30
+)
+
+// globalWalletClaimChallenges maps a user ID to the walletClaimChallenge most recently issued to the user.
+var globalWalletClaimChallenges sync.Map
+
+// walletClaimChallenge is a single use nonce issued to a user who wants to claim a persona tag with a wallet.
+type walletClaimChallenge struct {
+	personaTag string
+	nonce      string
+	expiresAt  time.Time
+}
+
+// message returns the message the wallet must sign to use the challenge.
+func (c walletClaimChallenge) message(namespace string) string {
+	return walletClaimMessage(c.personaTag, namespace, c.nonce, c.expiresAt)
+}
+
+// walletClaimChallengeRequest is the payload for the nakama/wallet-claim-challenge RPC.
+type walletClaimChallengeRequest struct {
+	PersonaTag string `json:"personaTag"`
+}
+
+// walletClaimChallengeResponse is returned by the nakama/wallet-claim-challenge RPC. Message must be signed by the
+// wallet and passed to nakama/claim-persona-with-wallet before ExpiresAt (unix seconds).
+type walletClaimChallengeResponse struct {
+	Message   string `json:"message"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+// walletClaimPersonaRequest is the payload for the nakama/claim-persona-with-wallet RPC. Signature must be an
+// EIP-191 personal_sign signature (hex encoded) of the message returned by the nakama/wallet-claim-challenge RPC.
+type walletClaimPersonaRequest struct {
+	PersonaTag    string `json:"personaTag"`
+	WalletAddress string `json:"walletAddress"`
+	Signature     string `json:"signature"`
+}
+
+// walletClaimMessage is the message a wallet must sign to prove it controls the given address at persona claim time.
+// The namespace is included so a signature can not be replayed against a different cardinal shard, and the nonce and
+// expiry so it can not be replayed at all.
+func walletClaimMessage(personaTag, namespace, nonce string, expiresAt time.Time) string {
+	return fmt.Sprintf("claim persona %s in %s with nonce %s until %s", personaTag, namespace, nonce,
+		expiresAt.UTC().Format(time.RFC3339))
+}
+
+// issueWalletClaimChallenge issues a new challenge for the given user and persona tag. It replaces any challenge
+// previously issued to the user.
+func issueWalletClaimChallenge(userID, personaTag string, now time.Time) (walletClaimChallenge, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return walletClaimChallenge{}, eris.Wrap(err, "unable to generate nonce")
+	}
+	challenge := walletClaimChallenge{
+		personaTag: personaTag,
+		nonce:      hex.EncodeToString(buf),
+		expiresAt:  now.Add(walletClaimChallengeTTL),
+	}
+	globalWalletClaimChallenges.Store(userID, challenge)
+	return challenge, nil
+}
+
+// takeWalletClaimChallenge removes the challenge issued to the given user and returns it if it was issued for the
+// given persona tag and has not expired. A challenge can only be taken once, whether or not the claim succeeds.
+func takeWalletClaimChallenge(userID, personaTag string, now time.Time) (walletClaimChallenge, error) {
+	val, ok := globalWalletClaimChallenges.LoadAndDelete(userID)
+	if !ok {
+		return walletClaimChallenge{}, eris.Wrap(ErrWalletClaimChallengeInvalid, "")
+	}
+	challenge, _ := val.(walletClaimChallenge)
+	if challenge.personaTag != personaTag {
+		return walletClaimChallenge{}, eris.Wrapf(ErrWalletClaimChallengeInvalid, "challenge was issued for %q",
+			challenge.personaTag)
+	}
+	if now.After(challenge.expiresAt) {
+		return walletClaimChallenge{}, eris.Wrap(ErrWalletClaimChallengeInvalid, "challenge expired")
+	}
+	return challenge, nil
+}
+
+// verifyWalletSignature checks that the given hex encoded signature of msg was produced by hexAddress.
+func verifyWalletSignature(hexAddress, msg, hexSignature string) error {
+	if !common.IsHexAddress(hexAddress) {
+		return eris.Wrapf(ErrInvalidWalletSignature, "%q is not a valid address", hexAddress)
+	}
+	sig := common.FromHex(hexSignature)
+	if len(sig) != crypto.SignatureLength {
+		return eris.Wrapf(ErrInvalidWalletSignature, "signature must be %d bytes", crypto.SignatureLength)
+	}
+	if sig[crypto.RecoveryIDOffset] == 27 || sig[crypto.RecoveryIDOffset] == 28 {
+		sig[crypto.RecoveryIDOffset] -= 27 // Transform yellow paper V from 27/28 to 0/1
+	}
+	hash := crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(msg), msg)))
+	pubKey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return eris.Wrap(ErrInvalidWalletSignature, err.Error())
+	}
+	if crypto.PubkeyToAddress(*pubKey) != common.HexToAddress(hexAddress) {
+		return eris.Wrap(ErrInvalidWalletSignature, "signature does not match wallet address")
+	}
+	return nil
+}
+
+// handleWalletClaimChallenge issues the message a wallet must sign to claim the persona tag in the payload with
+// nakama/claim-persona-with-wallet.
+func handleWalletClaimChallenge(ctx context.Context, logger runtime.Logger, _ *sql.DB, _ runtime.NakamaModule,
+	payload string,
+) (string, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return logErrorMessageFailedPrecondition(logger, err, "unable to get userID")
+	}
+	req := walletClaimChallengeRequest{}
+	if err = json.Unmarshal([]byte(payload), &req); err != nil {
+		return logErrorMessageFailedPrecondition(logger, eris.Wrap(err, ""), "unable to marshal payload")
+	}
+	if req.PersonaTag == "" {
+		return logErrorWithMessageAndCode(
+			logger,
+			eris.New("personaTag field was empty"),
+			InvalidArgument,
+			"personaTag field must not be empty",
+		)
+	}
+	challenge, err := issueWalletClaimChallenge(userID, req.PersonaTag, time.Now())
+	if err != nil {
+		return logErrorMessageFailedPrecondition(logger, err, "unable to issue wallet claim challenge")
+	}
+	buf, err := json.Marshal(walletClaimChallengeResponse{
+		Message:   challenge.message(globalNamespace),
+		ExpiresAt: challenge.expiresAt.Unix(),
+	})
+	if err != nil {
+		return logErrorMessageFailedPrecondition(logger, eris.Wrap(err, ""), "unable to marshal response")
+	}
+	return string(buf), nil
+}
+
+// handleClaimPersonaWithWallet handles a request to claim a persona tag where the caller also proves control of an
+// EVM wallet. Once cardinal accepts the persona tag, the wallet address is authorized for the persona so the client
+// can immediately sign game transactions with its wallet.
+func handleClaimPersonaWithWallet(ptv *personaTagVerifier, notifier *receiptNotifier) nakamaRPCHandler {
+	return func(ctx context.Context, logger runtime.Logger, _ *sql.DB, nk runtime.NakamaModule, payload string) (
+		string, error) {
+		userID, err := getUserID(ctx)
+		if err != nil {
+			return logErrorMessageFailedPrecondition(logger, err, "unable to get userID")
+		}
+
+		err = checkVerifiedOrExempt(ctx, logger, nk, userID)
+		if err != nil {
+			if eris.Is(eris.Cause(err), ErrNotAllowlisted) {
+				return logDebugWithMessageAndCode(logger, err, AlreadyExists, "unable to claim persona tag")
+			}
+			return logErrorMessageFailedPrecondition(logger, err, "unable to claim persona tag")
+		}
+
+		req := walletClaimPersonaRequest{}
+		if err = json.Unmarshal([]byte(payload), &req); err != nil {
+			return logErrorMessageFailedPrecondition(logger, eris.Wrap(err, ""), "unable to marshal payload")
+		}
+		if req.PersonaTag == "" {
+			return logErrorWithMessageAndCode(
+				logger,
+				eris.New("personaTag field was empty"),
+				InvalidArgument,
+				"personaTag field must not be empty",
+			)
+		}
+		challenge, err := takeWalletClaimChallenge(userID, req.PersonaTag, time.Now())
+		if err != nil {
+			return logErrorWithMessageAndCode(logger, err, Unauthenticated, "unable to verify wallet signature")
+		}
+		if err = verifyWalletSignature(req.WalletAddress, challenge.message(globalNamespace), req.Signature); err != nil {
+			return logErrorWithMessageAndCode(logger, err, Unauthenticated, "unable to verify wallet signature")
+		}
+
+		ptr := &personaTagStorageObj{
+			PersonaTag:    req.PersonaTag,
+			WalletAddress: strings.ToLower(req.WalletAddress),
+		}
+		return claimPersonaTag(ctx, logger, nk, ptv, notifier, userID, ptr)
+	}
+}
+
+// walletAuthorizer submits the transactions that authorize wallets for the persona tags they were claimed with. It runs
+// outside the personaTagVerifier loop, so a slow cardinal does not hold up other claims, and authorizations that fail
+// are retried every walletAuthorizationRetryInterval.
+type walletAuthorizer struct {
+	nk     runtime.NakamaModule
+	logger runtime.Logger
+	mu     sync.Mutex
+	queue  []walletAuthorization
+	// wake is signalled when authorizations are added to queue.
+	wake chan struct{}
+}
+
+type walletAuthorization struct {
+	userID     string
+	personaTag string
+	address    string
+	attempts   int
+}
+
+func newWalletAuthorizer(logger runtime.Logger, nk runtime.NakamaModule) *walletAuthorizer {
+	a := &walletAuthorizer{
+		nk:     nk,
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+	}
+	go a.run()
+	return a
+}
+
+// add queues the authorization of the given wallet address for the persona tag of the given user. It never blocks.
+func (a *walletAuthorizer) add(userID, personaTag, address string) {
+	a.mu.Lock()
+	a.queue = append(a.queue, walletAuthorization{userID: userID, personaTag: personaTag, address: address})
+	a.mu.Unlock()
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (a *walletAuthorizer) run() {
+	retryTick := time.Tick(walletAuthorizationRetryInterval)
+	var retries []walletAuthorization
+	for {
+		select {
+		case <-a.wake:
+			a.mu.Lock()
+			queued := a.queue
+			a.queue = nil
+			a.mu.Unlock()
+			retries = append(retries, a.authorize(queued)...)
+		case <-retryTick:
+			retries = a.authorize(retries)
+		}
+	}
+}
+
+// authorize submits the given authorizations to cardinal and returns the ones that failed and should be retried.
+func (a *walletAuthorizer) authorize(auths []walletAuthorization) []walletAuthorization {
+	var failed []walletAuthorization
+	for _, auth := range auths {
+		//nolint:staticcheck // its fine.
+		ctx := context.WithValue(context.Background(), runtime.RUNTIME_CTX_USER_ID, auth.userID)
+		txHash, err := cardinalAuthorizePersonaAddress(ctx, a.nk, auth.personaTag, auth.address)
+		if err == nil {
+			a.logger.Debug("authorizing wallet %q for persona tag %q in tx %q", auth.address, auth.personaTag, txHash)
+			continue
+		}
+		auth.attempts++
+		if auth.attempts >= maxWalletAuthorizationAttempts {
+			a.logger.Error("giving up authorizing wallet %q for persona tag %q after %d attempts: %s", auth.address,
+				auth.personaTag, auth.attempts, eris.ToString(err, true))
+			continue
+		}
+		a.logger.Warn("unable to authorize wallet %q for persona tag %q, will retry: %s", auth.address,
+			auth.personaTag, eris.ToString(err, true))
+		failed = append(failed, auth)
+	}
+	return failed
+}
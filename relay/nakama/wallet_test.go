@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rotisserie/eris"
+)
+
+func TestWalletClaimChallengesCanOnlyBeUsedOnce(t *testing.T) {
+	now := time.Now()
+	challenge, err := issueWalletClaimChallenge("user-1", "foo", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = takeWalletClaimChallenge("user-1", "foo", now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = takeWalletClaimChallenge("user-1", "foo", now); !eris.Is(eris.Cause(err), ErrWalletClaimChallengeInvalid) {
+		t.Fatalf("reusing a challenge got %v, want %v", err, ErrWalletClaimChallengeInvalid)
+	}
+
+	next, err := issueWalletClaimChallenge("user-1", "foo", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.message("ns") == challenge.message("ns") {
+		t.Fatal("two challenges have the same message")
+	}
+}
+
+func TestWalletClaimChallengesExpire(t *testing.T) {
+	now := time.Now()
+	if _, err := issueWalletClaimChallenge("user-2", "foo", now); err != nil {
+		t.Fatal(err)
+	}
+	_, err := takeWalletClaimChallenge("user-2", "foo", now.Add(walletClaimChallengeTTL+time.Second))
+	if !eris.Is(eris.Cause(err), ErrWalletClaimChallengeInvalid) {
+		t.Fatalf("taking an expired challenge got %v, want %v", err, ErrWalletClaimChallengeInvalid)
+	}
+
+	if _, err = issueWalletClaimChallenge("user-2", "foo", now); err != nil {
+		t.Fatal(err)
+	}
+	_, err = takeWalletClaimChallenge("user-2", "bar", now)
+	if !eris.Is(eris.Cause(err), ErrWalletClaimChallengeInvalid) {
+		t.Fatalf("taking a challenge for another persona tag got %v, want %v", err, ErrWalletClaimChallengeInvalid)
+	}
+}
+
+func TestVerifyWalletSignatureOfChallenge(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	challenge, err := issueWalletClaimChallenge("user-3", "foo", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := challenge.message("ns")
+	hash := crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(msg), msg)))
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = verifyWalletSignature(address, msg, hexutil.Encode(sig)); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := issueWalletClaimChallenge("user-3", "foo", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = verifyWalletSignature(address, other.message("ns"), hexutil.Encode(sig))
+	if !eris.Is(eris.Cause(err), ErrInvalidWalletSignature) {
+		t.Fatalf("a signature of an old challenge got %v, want %v", err, ErrInvalidWalletSignature)
+	}
+}
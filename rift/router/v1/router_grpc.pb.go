@@ -24,6 +24,7 @@ const _ = grpc.SupportPackageIsVersion7
 type MsgClient interface {
 	SendMessage(ctx context.Context, in *SendMessageRequest, opts ...grpc.CallOption) (*SendMessageResponse, error)
 	QueryShard(ctx context.Context, in *QueryShardRequest, opts ...grpc.CallOption) (*QueryShardResponse, error)
+	QueryShardStream(ctx context.Context, in *QueryShardRequest, opts ...grpc.CallOption) (Msg_QueryShardStreamClient, error)
 }
 
 type msgClient struct {
@@ -52,12 +53,45 @@ func (c *msgClient) QueryShard(ctx context.Context, in *QueryShardRequest, opts
 	return out, nil
 }
 
+func (c *msgClient) QueryShardStream(ctx context.Context, in *QueryShardRequest, opts ...grpc.CallOption) (Msg_QueryShardStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Msg_ServiceDesc.Streams[0], "/world.engine.router.v1.Msg/QueryShardStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &msgQueryShardStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Msg_QueryShardStreamClient interface {
+	Recv() (*QueryShardChunk, error)
+	grpc.ClientStream
+}
+
+type msgQueryShardStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *msgQueryShardStreamClient) Recv() (*QueryShardChunk, error) {
+	m := new(QueryShardChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // MsgServer is the server API for Msg service.
 // All implementations must embed UnimplementedMsgServer
 // for forward compatibility
 type MsgServer interface {
 	SendMessage(context.Context, *SendMessageRequest) (*SendMessageResponse, error)
 	QueryShard(context.Context, *QueryShardRequest) (*QueryShardResponse, error)
+	QueryShardStream(*QueryShardRequest, Msg_QueryShardStreamServer) error
 	mustEmbedUnimplementedMsgServer()
 }
 
@@ -71,6 +105,9 @@ func (UnimplementedMsgServer) SendMessage(context.Context, *SendMessageRequest)
 func (UnimplementedMsgServer) QueryShard(context.Context, *QueryShardRequest) (*QueryShardResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method QueryShard not implemented")
 }
+func (UnimplementedMsgServer) QueryShardStream(*QueryShardRequest, Msg_QueryShardStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method QueryShardStream not implemented")
+}
 func (UnimplementedMsgServer) mustEmbedUnimplementedMsgServer() {}
 
 // UnsafeMsgServer may be embedded to opt out of forward compatibility for this service.
@@ -120,6 +157,27 @@ func _Msg_QueryShard_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Msg_QueryShardStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(QueryShardRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MsgServer).QueryShardStream(m, &msgQueryShardStreamServer{stream})
+}
+
+type Msg_QueryShardStreamServer interface {
+	Send(*QueryShardChunk) error
+	grpc.ServerStream
+}
+
+type msgQueryShardStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *msgQueryShardStreamServer) Send(m *QueryShardChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Msg_ServiceDesc is the grpc.ServiceDesc for Msg service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -136,6 +194,12 @@ var Msg_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _Msg_QueryShard_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "QueryShardStream",
+			Handler:       _Msg_QueryShardStream_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "router/v1/router.proto",
 }
@@ -131,6 +131,8 @@ type Transaction struct {
 	Nonce      uint64 `protobuf:"varint,3,opt,name=Nonce,proto3" json:"Nonce,omitempty"`
 	Signature  string `protobuf:"bytes,4,opt,name=Signature,proto3" json:"Signature,omitempty"`
 	Body       []byte `protobuf:"bytes,5,opt,name=Body,proto3" json:"Body,omitempty"`
+	// Compressed is set when Body is gzipped. The signature is over the uncompressed body.
+	Compressed bool `protobuf:"varint,6,opt,name=Compressed,proto3" json:"Compressed,omitempty"`
 }
 
 func (x *Transaction) Reset() {
@@ -200,6 +202,13 @@ func (x *Transaction) GetBody() []byte {
 	return nil
 }
 
+func (x *Transaction) GetCompressed() bool {
+	if x != nil {
+		return x.Compressed
+	}
+	return false
+}
+
 var File_shard_v1_shard_proto protoreflect.FileDescriptor
 
 var file_shard_v1_shard_proto_rawDesc = []byte{
@@ -214,7 +223,7 @@ var file_shard_v1_shard_proto_rawDesc = []byte{
 	0x6f, 0x72, 0x6c, 0x64, 0x2e, 0x65, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x2e, 0x73, 0x68, 0x61, 0x72,
 	0x64, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
 	0x52, 0x02, 0x74, 0x78, 0x22, 0x17, 0x0a, 0x15, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x53, 0x68,
-	0x61, 0x72, 0x64, 0x54, 0x78, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x93, 0x01,
+	0x61, 0x72, 0x64, 0x54, 0x78, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0xb3, 0x01,
 	0x0a, 0x0b, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x0a,
 	0x0a, 0x50, 0x65, 0x72, 0x73, 0x6f, 0x6e, 0x61, 0x54, 0x61, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28,
 	0x09, 0x52, 0x0a, 0x50, 0x65, 0x72, 0x73, 0x6f, 0x6e, 0x61, 0x54, 0x61, 0x67, 0x12, 0x1c, 0x0a,
@@ -224,7 +233,9 @@ var file_shard_v1_shard_proto_rawDesc = []byte{
 	0x65, 0x12, 0x1c, 0x0a, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x04,
 	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12,
 	0x12, 0x0a, 0x04, 0x42, 0x6f, 0x64, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x42,
-	0x6f, 0x64, 0x79, 0x32, 0x7a, 0x0a, 0x0c, 0x53, 0x68, 0x61, 0x72, 0x64, 0x48, 0x61, 0x6e, 0x64,
+	0x6f, 0x64, 0x79, 0x12, 0x1e, 0x0a, 0x0a, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65,
+	0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73,
+	0x73, 0x65, 0x64, 0x32, 0x7a, 0x0a, 0x0c, 0x53, 0x68, 0x61, 0x72, 0x64, 0x48, 0x61, 0x6e, 0x64,
 	0x6c, 0x65, 0x72, 0x12, 0x6a, 0x0a, 0x0d, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x53, 0x68, 0x61,
 	0x72, 0x64, 0x54, 0x78, 0x12, 0x2b, 0x2e, 0x77, 0x6f, 0x72, 0x6c, 0x64, 0x2e, 0x65, 0x6e, 0x67,
 	0x69, 0x6e, 0x65, 0x2e, 0x73, 0x68, 0x61, 0x72, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62,
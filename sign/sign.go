@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -21,6 +22,7 @@ var (
 	ErrCannotSignEmptyBody       = errors.New("cannot sign empty body")
 	ErrInvalidPersonaTag         = errors.New("invalid persona tag")
 	ErrInvalidNamespace          = errors.New("invalid namespace")
+	ErrInvalidSignature          = errors.New("invalid signature")
 
 	ErrNoPersonaTagField = errors.New("transaction must contain personaTag field")
 	ErrNoNamespaceField  = errors.New("transaction must contain namespace field")
@@ -32,6 +34,11 @@ var (
 // does not actually exist (e.g. during the PersonaTag creation process).
 const SystemPersonaTag = "SystemPersonaTag"
 
+// Transaction is a signed message of a persona.
+//
+// Its Hash is the Keccak256 hash of the persona tag, namespace, nonce and body, i.e. of everything that is signed. The
+// hash does not include the signature, but it does depend on how the body is encoded. See ContentHash for an identity
+// of the transaction that does not.
 type Transaction struct {
 	PersonaTag string          `json:"personaTag"`
 	Namespace  string          `json:"namespace"`
@@ -39,6 +46,9 @@ type Transaction struct {
 	Signature  string          `json:"signature"` // hex encoded string
 	Hash       common.Hash     `json:"hash,omitempty"`
 	Body       json.RawMessage `json:"body"` // json string
+	// TraceID is an optional client supplied identifier used to follow a transaction through logs and receipts. It is
+	// not part of the signed hash.
+	TraceID string `json:"traceId,omitempty"`
 }
 
 func UnmarshalTransaction(bz []byte) (*Transaction, error) {
@@ -85,6 +95,7 @@ func MappedTransaction(tx map[string]interface{}) (*Transaction, error) {
 		"nonce":      true,
 		"body":       true,
 		"hash":       true,
+		"traceId":    true,
 	}
 	for key := range tx {
 		if !transactionKeys[key] {
@@ -146,8 +157,9 @@ func normalizeJSON(data any) ([]byte, error) {
 	return normalizedBz, nil
 }
 
-// sign uses the given private key to sign the personaTag, namespace, nonce, and data.
-func sign(pk *ecdsa.PrivateKey, personaTag, namespace string, nonce uint64, data any) (*Transaction, error) {
+// buildUnsigned validates and normalizes the given fields and returns a Transaction with its hash populated but
+// without a signature.
+func buildUnsigned(personaTag, namespace string, nonce uint64, data any) (*Transaction, error) {
 	if data == nil || reflect.ValueOf(data).IsZero() {
 		return nil, ErrCannotSignEmptyBody
 	}
@@ -168,11 +180,22 @@ func sign(pk *ecdsa.PrivateKey, personaTag, namespace string, nonce uint64, data
 		Body:       bz,
 	}
 	sp.populateHash()
-	buf, err := crypto.Sign(sp.Hash.Bytes(), pk)
+	return sp, nil
+}
+
+// sign uses the given private key to sign the personaTag, namespace, nonce, and data.
+func sign(pk *ecdsa.PrivateKey, personaTag, namespace string, nonce uint64, data any) (*Transaction, error) {
+	sp, err := buildUnsigned(personaTag, namespace, nonce, data)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := crypto.Sign(sp.SigningBytes(), pk)
 	if err != nil {
 		return nil, eris.Wrap(err, "error signing hash")
 	}
-	sp.Signature = common.Bytes2Hex(buf)
+	if err = sp.AttachSignature(buf); err != nil {
+		return nil, err
+	}
 	return sp, nil
 }
 
@@ -194,6 +217,44 @@ func NewTransaction(pk *ecdsa.PrivateKey,
 	return sign(pk, personaTag, namespace, nonce, data)
 }
 
+// BuildUnsigned creates a Transaction for the given body, tag, and nonce without signing it. This allows the
+// transaction to be signed by a hardware wallet, KMS, or any other signer that does not expose its private key: sign
+// the bytes returned by SigningBytes and pass the resulting signature to AttachSignature.
+func BuildUnsigned(personaTag, namespace string, nonce uint64, data any) (*Transaction, error) {
+	if len(personaTag) == 0 || personaTag == SystemPersonaTag {
+		return nil, ErrInvalidPersonaTag
+	}
+	return buildUnsigned(personaTag, namespace, nonce, data)
+}
+
+// BuildUnsignedSystem is like BuildUnsigned, but uses the SystemPersonaTag.
+func BuildUnsignedSystem(namespace string, nonce uint64, data any) (*Transaction, error) {
+	return buildUnsigned(SystemPersonaTag, namespace, nonce, data)
+}
+
+// SigningBytes returns the canonical 32 byte Keccak256 hash of this Transaction. This is the exact payload that must
+// be signed (without any additional prefix or hashing) to produce a valid signature.
+func (s *Transaction) SigningBytes() []byte {
+	if isZeroHash(s.Hash) {
+		s.populateHash()
+	}
+	return s.Hash.Bytes()
+}
+
+// AttachSignature sets the signature of this Transaction to the given 65 byte [R || S || V] secp256k1 signature of
+// SigningBytes. V may either be 0/1 or 27/28.
+func (s *Transaction) AttachSignature(sig []byte) error {
+	if len(sig) != crypto.SignatureLength {
+		return eris.Wrapf(ErrInvalidSignature, "signature must be %d bytes, got %d", crypto.SignatureLength, len(sig))
+	}
+	v := sig[crypto.RecoveryIDOffset]
+	if v != 0 && v != 1 && v != 27 && v != 28 {
+		return eris.Wrapf(ErrInvalidSignature, "invalid recovery id %d", v)
+	}
+	s.Signature = common.Bytes2Hex(sig)
+	return nil
+}
+
 func (s *Transaction) IsSystemTransaction() bool {
 	return s.PersonaTag == SystemPersonaTag
 }
@@ -209,7 +270,7 @@ func isZeroHash(hash common.Hash) bool {
 	return hash == common.Hash{}
 }
 
-// HashHex return a hex encoded hash of the signature.
+// HashHex returns the hex encoded Hash of this Transaction. Cardinal uses it as the TxHash of receipts.
 func (s *Transaction) HashHex() string {
 	if isZeroHash(s.Hash) {
 		s.populateHash()
@@ -248,6 +309,44 @@ func (s *Transaction) Verify(hexAddress string) error {
 	return nil
 }
 
+// ContentHash returns a hash that identifies the logical action of this Transaction: the same persona tag, namespace,
+// nonce, message name and body always have the same content hash, no matter the signature, the order of the keys of
+// the body, or its whitespace. Persona tags are compared case-insensitively. Use it to deduplicate transactions; use
+// Hash to check what was signed.
+func (s *Transaction) ContentHash(messageName string) common.Hash {
+	bz, err := json.Marshal(struct {
+		PersonaTag  string `json:"personaTag"`
+		Namespace   string `json:"namespace"`
+		Nonce       uint64 `json:"nonce"`
+		MessageName string `json:"messageName"`
+		Body        []byte `json:"body"`
+	}{
+		PersonaTag:  strings.ToLower(s.PersonaTag),
+		Namespace:   s.Namespace,
+		Nonce:       s.Nonce,
+		MessageName: messageName,
+		Body:        canonicalBody(s.Body),
+	})
+	if err != nil {
+		// Marshalling strings, numbers and bytes can not fail.
+		panic(err)
+	}
+	return crypto.Keccak256Hash(bz)
+}
+
+// canonicalBody returns the given JSON body with sorted keys and without whitespace. Bodies that are not valid JSON
+// are returned as is.
+func canonicalBody(body json.RawMessage) []byte {
+	if normalized, err := normalizeJSON([]byte(body)); err == nil {
+		return normalized
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, body); err == nil {
+		return compacted.Bytes()
+	}
+	return body
+}
+
 func (s *Transaction) populateHash() {
 	s.Hash = crypto.Keccak256Hash(
 		[]byte(s.PersonaTag),
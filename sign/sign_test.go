@@ -68,6 +68,26 @@ func TestCanParseAMappedTransaction(t *testing.T) {
 	assert.DeepEqual(t, sp, gotSP)
 }
 
+func TestTraceIDIsNotPartOfSignature(t *testing.T) {
+	goodKey, err := crypto.GenerateKey()
+	assert.NilError(t, err)
+	sp, err := NewTransaction(goodKey, "my-tag", "my-namespace", 100, `{"msg": "this is a request body"}`)
+	assert.NilError(t, err)
+	wantHash := sp.HashHex()
+
+	sp.TraceID = "some-trace-id"
+	bz, err := json.Marshal(sp)
+	assert.NilError(t, err)
+	asMap := map[string]any{}
+	assert.NilError(t, json.Unmarshal(bz, &asMap))
+
+	tx, err := MappedTransaction(asMap)
+	assert.NilError(t, err)
+	assert.Equal(t, tx.TraceID, "some-trace-id")
+	assert.Equal(t, tx.HashHex(), wantHash)
+	assert.NilError(t, tx.Verify(crypto.PubkeyToAddress(goodKey.PublicKey).Hex()))
+}
+
 func TestCanGetHashHex(t *testing.T) {
 	goodKey, err := crypto.GenerateKey()
 	assert.NilError(t, err)
@@ -325,3 +345,55 @@ func TestUnsortedJSONBlobsCanBeSignedAndVerified(t *testing.T) {
 
 	assert.NilError(t, gotTx.Verify(addr))
 }
+
+func TestCanAttachAnExternallyProducedSignature(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NilError(t, err)
+	addressHex := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	unsigned, err := BuildUnsigned("my-tag", "my-namespace", 100, `{"msg": "hello"}`)
+	assert.NilError(t, err)
+	assert.Equal(t, "", unsigned.Signature)
+
+	// Pretend the signing bytes were handed to a hardware wallet that returns a yellow paper style V value.
+	sig, err := crypto.Sign(unsigned.SigningBytes(), key)
+	assert.NilError(t, err)
+	sig[crypto.RecoveryIDOffset] += 27
+	assert.NilError(t, unsigned.AttachSignature(sig))
+
+	buf, err := unsigned.Marshal()
+	assert.NilError(t, err)
+	tx, err := UnmarshalTransaction(buf)
+	assert.NilError(t, err)
+	assert.NilError(t, tx.Verify(addressHex))
+
+	// The result must be the same as signing the transaction in-process.
+	want, err := NewTransaction(key, "my-tag", "my-namespace", 100, `{"msg": "hello"}`)
+	assert.NilError(t, err)
+	assert.Equal(t, want.HashHex(), tx.HashHex())
+
+	err = unsigned.AttachSignature(sig[:10])
+	assert.ErrorIs(t, eris.Cause(err), ErrInvalidSignature)
+}
+
+func TestContentHashDoesNotDependOnSignatureOrBodyEncoding(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NilError(t, err)
+	otherKey, err := crypto.GenerateKey()
+	assert.NilError(t, err)
+	tx, err := NewTransaction(key, "my-tag", "my-namespace", 100, `{"a": 1, "b": 2}`)
+	assert.NilError(t, err)
+	sameAction, err := NewTransaction(otherKey, "MY-TAG", "my-namespace", 100, `{"a": 1, "b": 2}`)
+	assert.NilError(t, err)
+	sameAction.Body = json.RawMessage(`{ "b": 2,  "a": 1 }`)
+	assert.Check(t, tx.Signature != sameAction.Signature)
+	assert.Equal(t, tx.ContentHash("move"), sameAction.ContentHash("move"))
+
+	assert.Check(t, tx.ContentHash("move") != tx.ContentHash("attack"))
+	otherNonce, err := NewTransaction(key, "my-tag", "my-namespace", 101, `{"a": 1, "b": 2}`)
+	assert.NilError(t, err)
+	assert.Check(t, tx.ContentHash("move") != otherNonce.ContentHash("move"))
+	otherBody, err := NewTransaction(key, "my-tag", "my-namespace", 100, `{"a": 1, "b": 3}`)
+	assert.NilError(t, err)
+	assert.Check(t, tx.ContentHash("move") != otherBody.ContentHash("move"))
+}